@@ -6,12 +6,21 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rickchristie/govner/gowt/bench"
+	coverage "github.com/rickchristie/govner/gowt/coverage"
+	"github.com/rickchristie/govner/gowt/editor"
+	gotest "github.com/rickchristie/govner/gowt/gotest"
+	"github.com/rickchristie/govner/gowt/history"
 	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/rickchristie/govner/gowt/source"
+	"github.com/rickchristie/govner/gowt/stack"
 	view "github.com/rickchristie/govner/gowt/view"
+	watch "github.com/rickchristie/govner/gowt/watch"
 )
 
 // Screen represents which screen is currently active
@@ -21,6 +30,11 @@ const (
 	ScreenTree Screen = iota
 	ScreenLog
 	ScreenHelp
+	ScreenCoverage
+	ScreenBench
+	ScreenFuzz
+	ScreenStacks
+	ScreenRaces
 )
 
 // --- Messages for async test event streaming ---
@@ -40,7 +54,9 @@ type TestDoneMsg struct {
 
 // TestStartedMsg is sent when the test command has started
 type TestStartedMsg struct {
-	Stream EventStream
+	Stream   EventStream
+	Cover    bool              // Whether the run writes a coverage profile
+	Recorder *history.Recorder // Records the run's events, nil if it isn't kept
 }
 
 // TickMsg is used for elapsed time updates
@@ -64,6 +80,75 @@ type LogCacheCleanedMsg struct {
 	Test    string // Test name to run (for -run flag)
 }
 
+// FilesChangedMsg is sent by the watcher after a burst of saves
+type FilesChangedMsg struct {
+	Files []string
+}
+
+// WatchRunMsg is sent when the packages affected by changed files are known
+type WatchRunMsg struct {
+	Files    []string // The changed files
+	Packages []string // Affected packages to rerun
+	Err      error    // Set if the package graph could not be loaded
+}
+
+// CoverageLoadedMsg is sent when the coverage profile of a run has been read
+type CoverageLoadedMsg struct {
+	Profile *coverage.Profile
+	Err     error
+	RunGen  int // Generation counter to distinguish between runs
+}
+
+// CoverageSourceMsg is sent when the source of a file has been read for the coverage view
+type CoverageSourceMsg struct {
+	File   string
+	Source string
+	Err    error
+}
+
+// PreviousRunLoadedMsg is sent when the run before the one that just
+// completed has been read from the history
+type PreviousRunLoadedMsg struct {
+	Tree   *model.TestTree // nil if there is no previous run
+	Err    error
+	RunGen int // Generation counter to distinguish between runs
+}
+
+// EditorCommandMsg is sent when the command to open a file:line reference
+// is ready to run
+type EditorCommandMsg struct {
+	Cmd *exec.Cmd
+	Err error
+}
+
+// EditorClosedMsg is sent when the editor exits
+type EditorClosedMsg struct {
+	Err error
+}
+
+// SourceLoadedMsg is sent when the file of a file:line reference has been
+// read for the log view's source preview
+type SourceLoadedMsg struct {
+	Package string
+	File    string // As written in the log
+	Source  *source.File
+	Err     error
+}
+
+// ModuleLoadedMsg is sent when the module of a package whose stacks are
+// shown has been found
+type ModuleLoadedMsg struct {
+	Package string
+	Module  string
+	Err     error
+}
+
+// BaselineSavedMsg is sent when benchmark results have been saved as the baseline
+type BaselineSavedMsg struct {
+	Baseline bench.Set
+	Err      error
+}
+
 // App is the main TUI application model
 type App struct {
 	screen     Screen
@@ -105,6 +190,44 @@ type App struct {
 
 	// Run generation counter to distinguish between test runs
 	runGen int
+
+	// Watch mode: rerun the packages affected by saved files
+	watcher        *watch.Watcher
+	watchArgs      gotest.Args // testArgs split into flags and package patterns
+	pendingChanges []string    // Files changed while a run was in progress
+
+	// Coverage: runs add -coverprofile while enabled
+	coverageView view.CoverageView
+	coverage     bool              // Whether the next runs collect coverage
+	coverPkg     string            // -coverpkg value, empty to cover only the tested packages
+	coverProfile string            // Where go test writes the profile, empty if coverage is unavailable
+	coverRun     bool              // Whether the current run writes a profile
+	coverData    *coverage.Profile // Coverage merged from the runs since the tree was reset
+
+	// Benchmarks: compared against a baseline saved from an earlier run
+	benchView         view.BenchView
+	benchBaseline     bench.Set // nil if there is no baseline
+	benchBaselinePath string    // Where the baseline is loaded from and saved to
+
+	fuzzView view.FuzzView
+
+	stackView view.StackView
+	raceView  view.RaceView
+	logBack   Screen // Screen the log view goes back to
+
+	// History: full runs are kept and compared with the previous run
+	historyDir       string            // Empty if runs aren't kept
+	recorder         *history.Recorder // Records the current run
+	runChanges       *history.Diff     // Changes since the previous run
+	showChangesModal bool
+
+	// Retries: failed tests are rerun to tell flaky tests from broken ones
+	retryFailed int               // Reruns per failed test, 0 to not rerun
+	retryQueue  []*model.TestNode // Reruns still to start, one entry per rerun
+	retryNode   *model.TestNode   // Test being rerun, nil if not rerunning
+	retryTree   *model.TestTree   // Events of the current rerun
+
+	editorTemplate string // Command template to open file:line references, empty for $EDITOR
 }
 
 // NewApp creates a new app for viewing pre-loaded results
@@ -119,6 +242,12 @@ func NewApp(tree *model.TestTree) App {
 		helpView: view.NewHelpView(),
 		tree:     tree,
 		running:  false,
+
+		coverageView: view.NewCoverageView(),
+		benchView:    view.NewBenchView(),
+		fuzzView:     view.NewFuzzView(),
+		stackView:    view.NewStackView(),
+		raceView:     view.NewRaceView(),
 	}
 }
 
@@ -139,9 +268,69 @@ func NewLiveApp(args []string, runner TestRunner) App {
 		testArgs:  args,
 		startTime: time.Now(),
 		runner:    runner,
+
+		coverageView: view.NewCoverageView(),
+		benchView:    view.NewBenchView(),
+		fuzzView:     view.NewFuzzView(),
+		stackView:    view.NewStackView(),
+		raceView:     view.NewRaceView(),
 	}
 }
 
+// WithBenchBaseline sets the benchmark results to compare against and where
+// to save new ones. baseline is nil if none was saved yet.
+func (a App) WithBenchBaseline(path string, baseline bench.Set) App {
+	a.benchBaselinePath = path
+	a.benchBaseline = baseline
+	return a
+}
+
+// WithCoverage sets where runs write their coverage profile, and whether
+// coverage starts enabled. coverPkg is passed as -coverpkg when set.
+func (a App) WithCoverage(enabled bool, coverPkg, profilePath string) App {
+	a.coverage = enabled
+	a.coverPkg = coverPkg
+	a.coverProfile = profilePath
+	a.treeView = a.treeView.SetCoverageEnabled(enabled)
+	return a
+}
+
+// WithSkippedPackages records how many packages --changed left out, for the header
+func (a App) WithSkippedPackages(n int) App {
+	a.treeView = a.treeView.SetSkippedPackages(n)
+	return a
+}
+
+// WithWatcher enables watch mode: after each run, the packages affected by
+// files changed since are run again and merged into the tree.
+func (a App) WithWatcher(w *watch.Watcher) App {
+	a.watcher = w
+	a.watchArgs = gotest.ParseArgs(a.testArgs)
+	a.treeView = a.treeView.SetWatching(true)
+	return a
+}
+
+// WithHistory keeps the events of full runs in dir, and marks what changed
+// since the previous run of the same arguments
+func (a App) WithHistory(dir string) App {
+	a.historyDir = dir
+	return a
+}
+
+// WithRetries reruns each failed test n times after a run, to tell flaky
+// tests from ones that fail consistently
+func (a App) WithRetries(n int) App {
+	a.retryFailed = n
+	return a
+}
+
+// WithEditor sets the command template that opens file:line references,
+// e.g. "code --goto {file}:{line}:{col}". Empty uses $GOWT_EDITOR or $EDITOR.
+func (a App) WithEditor(template string) App {
+	a.editorTemplate = template
+	return a
+}
+
 func (a App) Init() tea.Cmd {
 	if !a.running {
 		return nil
@@ -151,24 +340,181 @@ func (a App) Init() tea.Cmd {
 	return tea.Batch(
 		a.startTests(),
 		a.tickCmd(),
+		a.waitForChanges(),
 	)
 }
 
-// startTests starts the go test command
+// startTests starts the go test command, recording the run in the history
 func (a *App) startTests() tea.Cmd {
+	return a.startTestsWithArgs(a.testArgs, a.historyDir)
+}
+
+// startTestsWithArgs starts the go test command with the given arguments.
+// The run is recorded in historyDir unless it is empty.
+func (a *App) startTestsWithArgs(args []string, historyDir string) tea.Cmd {
+	historyArgs := args
+	cover := a.coverage && a.coverProfile != ""
+	if cover {
+		args = a.coverageArgs(args)
+	}
 	return func() tea.Msg {
-		stream, err := a.runner.Start(a.testArgs)
+		stream, err := a.runner.Start(args)
 		if err != nil {
 			return TestDoneMsg{Err: err, ExitCode: 1, RunGen: a.runGen}
 		}
-		return TestStartedMsg{Stream: stream}
+		// A run that can't be recorded still runs, it just isn't compared
+		var recorder *history.Recorder
+		if historyDir != "" {
+			recorder, _ = history.NewRecorder(historyDir, historyArgs)
+		}
+		return TestStartedMsg{Stream: stream, Cover: cover, Recorder: recorder}
+	}
+}
+
+// completeRun returns a command that adds the recorded run to the history
+// and loads the previous run of the same arguments to compare with
+func (a *App) completeRun() tea.Cmd {
+	recorder := a.recorder
+	a.recorder = nil
+	dir := a.historyDir
+	args := a.testArgs
+	runGen := a.runGen
+	return func() tea.Msg {
+		path, err := recorder.Close()
+		if err != nil {
+			return PreviousRunLoadedMsg{Err: err, RunGen: runGen}
+		}
+		defer history.Prune(dir, args)
+		previous, err := history.Previous(dir, args, path)
+		if err != nil || previous == "" {
+			return PreviousRunLoadedMsg{Err: err, RunGen: runGen}
+		}
+		tree, err := loadTestResults(previous)
+		return PreviousRunLoadedMsg{Tree: tree, Err: err, RunGen: runGen}
+	}
+}
+
+// resetHistory drops the recording of a run that won't complete, and the
+// changes shown for the last one, before another run starts
+func (a *App) resetHistory() {
+	if a.recorder != nil {
+		a.recorder.Discard()
+		a.recorder = nil
 	}
+	a.runChanges = nil
+	a.treeView = a.treeView.SetChanges(nil)
+}
+
+// coverageArgs adds the coverage flags to go test arguments, ahead of any
+// -args section so they reach go test rather than the test binary
+func (a *App) coverageArgs(args []string) []string {
+	parsed := gotest.ParseArgs(args)
+	parsed.Flags = append(parsed.Flags, "-coverprofile="+a.coverProfile)
+	if a.coverPkg != "" {
+		parsed.Flags = append(parsed.Flags, "-coverpkg="+a.coverPkg)
+	}
+	return parsed.WithPackages(parsed.Patterns)
+}
+
+// loadCoverage returns a command that reads the profile written by the run
+func (a *App) loadCoverage() tea.Cmd {
+	path := a.coverProfile
+	runGen := a.runGen
+	return func() tea.Msg {
+		profile, err := coverage.ReadProfile(path)
+		return CoverageLoadedMsg{Profile: profile, Err: err, RunGen: runGen}
+	}
+}
+
+// saveBaseline returns a command that saves the tree's benchmark results as the baseline
+func (a *App) saveBaseline() tea.Cmd {
+	path := a.benchBaselinePath
+	set := a.tree.BenchmarkSet()
+	return func() tea.Msg {
+		return BaselineSavedMsg{Baseline: set, Err: bench.WriteFile(path, set)}
+	}
+}
+
+// loadCoverageSource returns a command that reads a covered file's source
+func (a *App) loadCoverageSource(file string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := coverage.SourcePath(file)
+		if err != nil {
+			return CoverageSourceMsg{File: file, Err: err}
+		}
+		source, err := os.ReadFile(path)
+		return CoverageSourceMsg{File: file, Source: string(source), Err: err}
+	}
+}
+
+// openEditor returns a command that resolves a file:line reference from a
+// package's log against the package directory and builds the editor command
+func (a *App) openEditor(pkg string, req view.OpenEditorRequest) tea.Cmd {
+	template := a.editorTemplate
+	return func() tea.Msg {
+		if template == "" {
+			template = editor.DefaultTemplate()
+		}
+		path, err := resolveReference(pkg, req.File)
+		if err != nil {
+			return EditorCommandMsg{Err: err}
+		}
+		cmd, err := editor.Command(template, path, req.Line, req.Col)
+		return EditorCommandMsg{Cmd: cmd, Err: err}
+	}
+}
+
+// loadSource returns a command that reads the file of a file:line reference
+// from a package's log for the source preview
+func (a *App) loadSource(pkg, file string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := resolveReference(pkg, file)
+		if err != nil {
+			return SourceLoadedMsg{Package: pkg, File: file, Err: err}
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return SourceLoadedMsg{Package: pkg, File: file, Err: err}
+		}
+		return SourceLoadedMsg{Package: pkg, File: file, Source: source.Parse(path, src)}
+	}
+}
+
+// loadModule returns a command that finds the module of a package, to
+// highlight its frames in stacks
+func (a *App) loadModule(pkg string) tea.Cmd {
+	return func() tea.Msg {
+		module, err := gotest.ModulePath(pkg)
+		return ModuleLoadedMsg{Package: pkg, Module: module, Err: err}
+	}
+}
+
+// setEditorStatus shows a message about the editor on the screen it was opened from
+func (a *App) setEditorStatus(status string) {
+	if a.screen == ScreenStacks {
+		a.stackView = a.stackView.SetStatus(status)
+	} else {
+		a.logView = a.logView.SetStatus(status)
+	}
+}
+
+// resolveReference returns the path of a file from a package's log, which
+// is usually relative to the package directory
+func resolveReference(pkg, file string) (string, error) {
+	if filepath.IsAbs(file) {
+		return file, nil
+	}
+	dir, err := editor.PackageDir(pkg)
+	if err != nil {
+		return "", err
+	}
+	return editor.Resolve(dir, file), nil
 }
 
 // startSingleTest starts go test for a specific package and test
 func (a *App) startSingleTest(pkg, testName string) tea.Cmd {
 	return func() tea.Msg {
-		stream, err := a.runner.StartSingle(pkg, testName)
+		stream, err := a.runner.StartSingle(pkg, testName, gotest.ParseArgs(a.testArgs))
 		if err != nil {
 			return TestDoneMsg{Err: err, ExitCode: 1, RunGen: a.runGen}
 		}
@@ -224,6 +570,121 @@ func (a *App) waitForEvents() tea.Cmd {
 	}
 }
 
+// waitForChanges returns a command that waits for the watcher's next burst of changes
+func (a *App) waitForChanges() tea.Cmd {
+	if a.watcher == nil {
+		return nil
+	}
+	changes := a.watcher.Changes()
+	return func() tea.Msg {
+		return FilesChangedMsg{Files: <-changes}
+	}
+}
+
+// findAffected returns a command that loads the package graph and works out
+// which tested packages the changed files affect
+func (a *App) findAffected(files []string) tea.Cmd {
+	args := a.watchArgs
+	return func() tea.Msg {
+		graph, err := gotest.LoadGraph("", args)
+		if err != nil {
+			return WatchRunMsg{Files: files, Err: err}
+		}
+		return WatchRunMsg{Files: files, Packages: graph.Affected(files)}
+	}
+}
+
+// startWatchRun resets the affected packages in the tree and runs them. If the
+// package graph could not be loaded (e.g. a broken go.mod), everything is run
+// so go test reports why.
+func (a *App) startWatchRun(msg WatchRunMsg) tea.Cmd {
+	args := a.testArgs
+	if msg.Err == nil {
+		args = a.watchArgs.WithPackages(msg.Packages)
+		for _, pkg := range msg.Packages {
+			a.tree.ResetPackage(pkg)
+		}
+	} else {
+		for pkg := range a.tree.Packages {
+			a.tree.ResetPackage(pkg)
+		}
+	}
+
+	// Increment run generation to ignore stale messages from previous run
+	a.runGen++
+	a.resetHistory()
+	a.treeView = a.treeView.SetData(a.tree)
+	a.treeView = a.treeView.SetRunning(true)
+	a.treeView = a.treeView.SetStopped(false)
+	a.startTime = time.Now()
+	a.running = true
+	a.stderrPkg = ""
+	// Only some packages run, so the run isn't compared with the history
+	return tea.Batch(a.startTestsWithArgs(args, ""), a.tickCmd())
+}
+
+// queueRetries queues the reruns of the tests that failed in the run
+func (a *App) queueRetries() {
+	if a.retryFailed == 0 || a.runner == nil {
+		return
+	}
+	for _, node := range a.tree.FailedTests() {
+		node.Retries = &model.Retries{Pending: a.retryFailed}
+		node.SuffixCacheValid = false
+		for i := 0; i < a.retryFailed; i++ {
+			a.retryQueue = append(a.retryQueue, node)
+		}
+	}
+}
+
+// startRetry starts the next rerun of a failed test. Its events are
+// collected in a tree of their own, so the run's results stay as they were.
+func (a *App) startRetry() tea.Cmd {
+	node := a.retryQueue[0]
+	a.retryQueue = a.retryQueue[1:]
+	a.retryNode = node
+	a.retryTree = model.NewTestTree()
+
+	runner := a.runner
+	runGen := a.runGen
+	args := gotest.ParseArgs(a.testArgs)
+	testName := strings.TrimPrefix(node.FullPath, node.Package+"/")
+	return func() tea.Msg {
+		// Failures aren't cached, but a rerun that passed would be
+		runner.CleanCache()
+		stream, err := runner.StartSingle(node.Package, testName, args)
+		if err != nil {
+			return TestDoneMsg{Err: err, ExitCode: 1, RunGen: runGen}
+		}
+		return TestStartedMsg{Stream: stream}
+	}
+}
+
+// recordRetry tallies the result of the finished rerun on the test's node.
+// A rerun that didn't run the test, e.g. because the package no longer
+// builds, counts as failed.
+func (a *App) recordRetry() {
+	result := a.retryTree.GetNode(a.retryNode.FullPath)
+	a.retryNode.Retries.Record(result != nil && result.Status == model.StatusPassed)
+	a.retryNode.SuffixCacheValid = false
+	a.retryNode = nil
+	a.retryTree = nil
+}
+
+// cancelRetries drops the reruns still to go, keeping the tallies so far
+func (a *App) cancelRetries() {
+	if a.retryNode != nil {
+		a.retryQueue = append(a.retryQueue, a.retryNode)
+	}
+	for _, node := range a.retryQueue {
+		node.Retries.Pending = 0
+		node.SuffixCacheValid = false
+	}
+	a.retryQueue = nil
+	a.retryNode = nil
+	a.retryTree = nil
+}
+
 // tickCmd returns a command for updating elapsed time
 func (a *App) tickCmd() tea.Cmd {
 	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
@@ -263,6 +724,12 @@ func (a *App) startLogRerun() tea.Cmd {
 	}
 	// If FullPath == Package, testName stays empty -> run all tests in package
 
+	return a.rerunSingle(pkg, testName)
+}
+
+// rerunSingle stops current tests, cleans cache, and restarts with a single
+// test, or the whole package if testName is empty
+func (a *App) rerunSingle(pkg, testName string) tea.Cmd {
 	return func() tea.Msg {
 		// Kill current test process if running
 		if a.stream != nil {
@@ -397,6 +864,8 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						a.stream.Kill()
 					}
 					a.running = false
+					a.resetHistory()
+					a.cancelRetries()
 					a.tree.Elapsed = time.Since(a.startTime).Seconds()
 					a.treeView = a.treeView.SetData(a.tree)
 					a.treeView = a.treeView.SetRunning(false)
@@ -412,6 +881,8 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					a.stream.Kill()
 				}
 				a.running = false
+				a.resetHistory()
+				a.cancelRetries()
 				a.tree.Elapsed = time.Since(a.startTime).Seconds()
 				a.treeView = a.treeView.SetData(a.tree)
 				a.treeView = a.treeView.SetRunning(false)
@@ -427,6 +898,19 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Continue processing non-keyboard messages (events, ticks, etc.)
 	}
 
+	// Handle changes modal keyboard input (but don't block other message types)
+	if a.showChangesModal {
+		if keyMsg, ok := msg.(tea.KeyMsg); ok {
+			switch keyMsg.String() {
+			case "enter", "esc", "q", " ":
+				a.showChangesModal = false
+			}
+			// Ignore other keys while modal is open
+			return a, nil
+		}
+		// Continue processing non-keyboard messages (events, ticks, etc.)
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		a.width = msg.Width
@@ -435,6 +919,11 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case TestStartedMsg:
 		// Test command started, store stream and begin waiting for events
 		a.stream = msg.Stream
+		a.coverRun = msg.Cover
+		if a.recorder != nil {
+			a.recorder.Discard()
+		}
+		a.recorder = msg.Recorder
 		cmds = append(cmds, a.waitForEvents())
 
 	case TestEventMsg:
@@ -442,6 +931,15 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.RunGen != a.runGen {
 			break
 		}
+		// Reruns of failed tests go to a tree of their own
+		if a.retryNode != nil {
+			a.retryTree.ProcessEvent(msg.Event)
+			cmds = append(cmds, a.waitForEvents())
+			break
+		}
+		if a.recorder != nil {
+			a.recorder.Record(msg.Event)
+		}
 		// ProcessEvent returns true if tree visibility changed (status, counts, icons).
 		// Skip expensive cache invalidation for log-only "output" events.
 		if a.tree.ProcessEvent(msg.Event) {
@@ -460,6 +958,22 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Show new benchmark results if viewing the table
+		if a.screen == ScreenBench && msg.Event.Action == "output" {
+			a.benchView = a.benchView.SetData(a.tree, a.benchBaseline, a.benchBaselinePath)
+		}
+
+		// Pick up new fuzz targets if viewing the dashboard; progress of the
+		// shown ones is read from their nodes as it comes in
+		if a.screen == ScreenFuzz {
+			a.fuzzView = a.fuzzView.SetData(a.tree)
+		}
+
+		// Pick up races as their reports complete
+		if a.screen == ScreenRaces {
+			a.raceView = a.raceView.SetData(a.tree)
+		}
+
 		// Continue waiting for more events
 		if a.running {
 			cmds = append(cmds, a.waitForEvents())
@@ -470,8 +984,34 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.RunGen != a.runGen {
 			break
 		}
+		if a.retryNode != nil {
+			// A rerun of a failed test is tallied on its node, not merged into the tree
+			a.recordRetry()
+		} else {
+			a.exitCode = msg.ExitCode
+
+			// Read the coverage profile the run wrote
+			if a.coverRun {
+				a.coverRun = false
+				cmds = append(cmds, a.loadCoverage())
+			}
+
+			// Compare with the previous run of the same arguments
+			if a.recorder != nil {
+				cmds = append(cmds, a.completeRun())
+			}
+
+			a.queueRetries()
+		}
+
+		// Rerun the failed tests one at a time before calling the run done
+		if len(a.retryQueue) > 0 {
+			a.treeView = a.treeView.SetData(a.tree)
+			cmds = append(cmds, a.startRetry())
+			break
+		}
+
 		a.running = false
-		a.exitCode = msg.ExitCode
 		// Update elapsed time one final time
 		a.tree.Elapsed = time.Since(a.startTime).Seconds()
 		a.treeView = a.treeView.SetData(a.tree)
@@ -489,6 +1029,98 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Files saved during the run are picked up now
+		if len(a.pendingChanges) > 0 {
+			cmds = append(cmds, a.findAffected(a.pendingChanges))
+			a.pendingChanges = nil
+		}
+
+	case PreviousRunLoadedMsg:
+		// The run is kept either way; without a previous run there's nothing to mark
+		if msg.RunGen != a.runGen || msg.Tree == nil {
+			break
+		}
+		diff := history.Compare(msg.Tree, a.tree)
+		a.runChanges = &diff
+		a.treeView = a.treeView.SetChanges(&diff)
+		a.showChangesModal = !diff.Empty()
+
+	case CoverageLoadedMsg:
+		// Ignore profiles of previous runs, and keep the last good coverage on error
+		if msg.RunGen != a.runGen || msg.Err != nil {
+			break
+		}
+		// Watch mode reruns only some packages, so merge into what we have
+		if a.coverData == nil {
+			a.coverData = msg.Profile
+		} else {
+			a.coverData.Merge(msg.Profile)
+		}
+		a.treeView = a.treeView.SetCoverage(a.coverData)
+
+	case BaselineSavedMsg:
+		if msg.Err != nil {
+			a.benchView = a.benchView.SetStatus(fmt.Sprintf("Failed to save baseline: %v", msg.Err))
+			break
+		}
+		a.benchBaseline = msg.Baseline
+		a.benchView = a.benchView.SetData(a.tree, a.benchBaseline, a.benchBaselinePath)
+		a.benchView = a.benchView.SetStatus(fmt.Sprintf("Saved %d benchmarks to %s", len(msg.Baseline), a.benchBaselinePath))
+
+	case EditorCommandMsg:
+		if msg.Err != nil {
+			a.setEditorStatus(fmt.Sprintf("Cannot open editor: %v", msg.Err))
+			break
+		}
+		// Terminal editors take over the screen until they exit
+		cmds = append(cmds, tea.ExecProcess(msg.Cmd, func(err error) tea.Msg {
+			return EditorClosedMsg{Err: err}
+		}))
+
+	case EditorClosedMsg:
+		if msg.Err != nil {
+			a.setEditorStatus(fmt.Sprintf("Editor failed: %v", msg.Err))
+		}
+		// The file may have been edited, or not loaded yet if the editor
+		// was opened without a selected reference
+		if node := a.logView.GetNode(); node != nil && a.logView.PreviewFile() != "" {
+			cmds = append(cmds, a.loadSource(node.Package, a.logView.PreviewFile()))
+		}
+
+	case ModuleLoadedMsg:
+		// Without the module, frames just aren't highlighted
+		if node := a.stackView.GetNode(); node != nil && node.Package == msg.Package && msg.Err == nil {
+			a.stackView = a.stackView.SetModule(msg.Module)
+		}
+
+	case SourceLoadedMsg:
+		if node := a.logView.GetNode(); node != nil && node.Package == msg.Package {
+			a.logView = a.logView.SetSource(msg.File, msg.Source, msg.Err)
+		}
+
+	case CoverageSourceMsg:
+		a.coverageView = a.coverageView.SetSource(msg.File, msg.Source, msg.Err)
+
+	case FilesChangedMsg:
+		cmds = append(cmds, a.waitForChanges())
+		if a.running {
+			// Let the current run finish, then rerun what changed
+			a.pendingChanges = append(a.pendingChanges, msg.Files...)
+			break
+		}
+		cmds = append(cmds, a.findAffected(msg.Files))
+
+	case WatchRunMsg:
+		if a.running {
+			// A manual rerun started while the graph was loading
+			a.pendingChanges = append(a.pendingChanges, msg.Files...)
+			break
+		}
+		if msg.Err == nil && len(msg.Packages) == 0 {
+			break // No tested package depends on the changed files
+		}
+		cmds = append(cmds, a.startWatchRun(msg))
+
 	case TickMsg:
 		// Always tick the log view for copy animation
 		a.logView = a.logView.Tick()
@@ -520,8 +1152,9 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.stderrPkg = strings.TrimSpace(strings.TrimPrefix(line, "# "))
 		}
 
-		// Add stderr output to the current package as output event
-		if a.stderrPkg != "" {
+		// Add stderr output to the current package as output event,
+		// unless it comes from a rerun of a failed test
+		if a.stderrPkg != "" && a.retryNode == nil {
 			event := model.TestEvent{
 				Time:    time.Now(),
 				Action:  "output",
@@ -544,9 +1177,13 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// Increment run generation to ignore stale messages from previous run
 		a.runGen++
+		a.resetHistory()
+		a.cancelRetries()
 		// Reset and start tests
 		a.tree = model.NewTestTree()
 		a.treeView = a.treeView.SetData(a.tree)
+		a.coverData = nil
+		a.treeView = a.treeView.SetCoverage(nil)
 		a.treeView = a.treeView.SetRunning(true)
 		a.treeView = a.treeView.SetStopped(false)
 		a.startTime = time.Now()
@@ -560,9 +1197,13 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// Increment run generation to ignore stale messages from previous run
 		a.runGen++
+		a.resetHistory()
+		a.cancelRetries()
 		// Reset and start tests for single test
 		a.tree = model.NewTestTree()
 		a.treeView = a.treeView.SetData(a.tree)
+		a.coverData = nil
+		a.treeView = a.treeView.SetCoverage(nil)
 		a.treeView = a.treeView.SetRunning(true)
 		a.treeView = a.treeView.SetStopped(false)
 		a.startTime = time.Now()
@@ -592,6 +1233,7 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					Height: a.height,
 				})
 				a.screen = ScreenLog
+				a.logBack = ScreenTree
 
 			case view.ShowHelpRequest:
 				a.prevScreen = ScreenTree
@@ -620,6 +1262,129 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Show stop confirmation modal
 				a.showStopModal = true
 				a.stopModalChoice = 1 // Default to "No"
+
+			case view.ToggleCoverageRequest:
+				// Takes effect on the next run
+				if a.coverProfile != "" {
+					a.coverage = !a.coverage
+					a.treeView = a.treeView.SetCoverageEnabled(a.coverage)
+				}
+
+			case view.ShowBenchRequest:
+				a.benchView = a.benchView.SetData(a.tree, a.benchBaseline, a.benchBaselinePath)
+				a.benchView, _, _ = a.benchView.Update(tea.WindowSizeMsg{
+					Width:  a.width,
+					Height: a.height,
+				})
+				a.screen = ScreenBench
+
+			case view.ShowChangesRequest:
+				if a.runChanges != nil {
+					a.showChangesModal = true
+				}
+
+			case view.ShowFuzzRequest:
+				a.fuzzView = a.fuzzView.SetData(a.tree)
+				a.fuzzView, _, _ = a.fuzzView.Update(tea.WindowSizeMsg{
+					Width:  a.width,
+					Height: a.height,
+				})
+				a.screen = ScreenFuzz
+
+			case view.ShowRacesRequest:
+				a.raceView = a.raceView.SetData(a.tree)
+				a.raceView, _, _ = a.raceView.Update(tea.WindowSizeMsg{
+					Width:  a.width,
+					Height: a.height,
+				})
+				a.screen = ScreenRaces
+
+			case view.ShowCoverageRequest:
+				a.coverageView = a.coverageView.SetPackage(req.Package, a.coverData)
+				a.coverageView, _, _ = a.coverageView.Update(tea.WindowSizeMsg{
+					Width:  a.width,
+					Height: a.height,
+				})
+				a.screen = ScreenCoverage
+			}
+		}
+
+	case ScreenBench:
+		var request view.BenchViewRequest
+		a.benchView, cmd, request = a.benchView.Update(msg)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+
+		if request != nil {
+			switch request.(type) {
+			case view.CloseBenchRequest:
+				a.screen = ScreenTree
+
+			case view.SaveBaselineRequest:
+				if a.benchBaselinePath != "" {
+					cmds = append(cmds, a.saveBaseline())
+				}
+			}
+		}
+
+	case ScreenFuzz:
+		var request view.FuzzViewRequest
+		a.fuzzView, cmd, request = a.fuzzView.Update(msg)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+
+		if request != nil {
+			switch req := request.(type) {
+			case view.CloseFuzzRequest:
+				a.screen = ScreenTree
+
+			case view.RerunCrasherRequest:
+				// Runs the failing input from testdata/fuzz as a regular test
+				if a.runner != nil {
+					cmds = append(cmds, a.rerunSingle(req.Node.Package, req.Node.Fuzz.RerunTest()))
+				}
+			}
+		}
+
+	case ScreenRaces:
+		var request view.RaceViewRequest
+		a.raceView, cmd, request = a.raceView.Update(msg)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+
+		if request != nil {
+			switch req := request.(type) {
+			case view.CloseRaceRequest:
+				a.screen = ScreenTree
+
+			case view.OpenRaceTestRequest:
+				a.logView = a.logView.SetData(req.Node, a.tree.ProcessedLogBuffer, a.tree.RawLogBuffer)
+				a.logView, _, _ = a.logView.Update(tea.WindowSizeMsg{
+					Width:  a.width,
+					Height: a.height,
+				})
+				a.screen = ScreenLog
+				a.logBack = ScreenRaces
+			}
+		}
+
+	case ScreenCoverage:
+		var request view.CoverageViewRequest
+		a.coverageView, cmd, request = a.coverageView.Update(msg)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+
+		if request != nil {
+			switch req := request.(type) {
+			case view.CloseCoverageRequest:
+				a.screen = ScreenTree
+
+			case view.OpenCoverageFileRequest:
+				cmds = append(cmds, a.loadCoverageSource(req.File))
 			}
 		}
 
@@ -633,7 +1398,14 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if request != nil {
 			switch req := request.(type) {
 			case view.BackRequest:
-				a.screen = ScreenTree
+				a.screen = a.logBack
+				if a.logBack == ScreenRaces {
+					a.raceView = a.raceView.SetData(a.tree)
+					a.raceView, _, _ = a.raceView.Update(tea.WindowSizeMsg{
+						Width:  a.width,
+						Height: a.height,
+					})
+				}
 
 			case view.ShowLogHelpRequest:
 				a.prevScreen = ScreenLog
@@ -650,6 +1422,32 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				a.logRerunModalChoice = 1 // Default to "No"
 				a.logRerunNode = req.Node
 
+			case view.OpenEditorRequest:
+				if node := a.logView.GetNode(); node != nil {
+					cmds = append(cmds, a.openEditor(node.Package, req))
+				}
+
+			case view.LoadSourceRequest:
+				if node := a.logView.GetNode(); node != nil {
+					cmds = append(cmds, a.loadSource(node.Package, req.File))
+				}
+
+			case view.ShowStacksRequest:
+				if node := a.logView.GetNode(); node != nil {
+					dump := stack.Parse(node.GetFullOutput(a.tree.RawLogBuffer))
+					if dump == nil {
+						a.logView = a.logView.SetStatus("No goroutine stacks in the raw log")
+						break
+					}
+					a.stackView = a.stackView.SetData(node, dump)
+					a.stackView, _, _ = a.stackView.Update(tea.WindowSizeMsg{
+						Width:  a.width,
+						Height: a.height,
+					})
+					a.screen = ScreenStacks
+					cmds = append(cmds, a.loadModule(node.Package))
+				}
+
 			case view.CopyLogsRequest:
 				// Copy to clipboard and trigger animation
 				if err := copyToClipboard(req.Logs); err == nil {
@@ -664,6 +1462,35 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case ScreenStacks:
+		var request view.StackViewRequest
+		a.stackView, cmd, request = a.stackView.Update(msg)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+
+		if request != nil {
+			switch req := request.(type) {
+			case view.CloseStackRequest:
+				a.screen = ScreenLog
+				// Catch up on output that came in meanwhile
+				if node := a.logView.GetNode(); node != nil {
+					if updated := a.tree.GetNode(node.FullPath); updated != nil {
+						a.logView = a.logView.UpdateContent(updated)
+					}
+				}
+				a.logView, _, _ = a.logView.Update(tea.WindowSizeMsg{
+					Width:  a.width,
+					Height: a.height,
+				})
+
+			case view.OpenFrameRequest:
+				if node := a.stackView.GetNode(); node != nil {
+					cmds = append(cmds, a.openEditor(node.Package, view.OpenEditorRequest{File: req.File, Line: req.Line}))
+				}
+			}
+		}
+
 	case ScreenHelp:
 		var request view.HelpViewRequest
 		a.helpView, cmd, request = a.helpView.Update(msg)
@@ -703,6 +1530,16 @@ func (a App) View() string {
 		content = a.logView.View()
 	case ScreenHelp:
 		content = a.helpView.View()
+	case ScreenCoverage:
+		content = a.coverageView.View()
+	case ScreenBench:
+		content = a.benchView.View()
+	case ScreenFuzz:
+		content = a.fuzzView.View()
+	case ScreenStacks:
+		content = a.stackView.View()
+	case ScreenRaces:
+		content = a.raceView.View()
 	default:
 		content = "Unknown screen"
 	}
@@ -740,6 +1577,11 @@ func (a App) View() string {
 		)
 	}
 
+	// Overlay changes since the previous run if shown
+	if a.showChangesModal && a.runChanges != nil {
+		content = view.RenderChangesModal(content, *a.runChanges, a.width, a.height)
+	}
+
 	// Overlay stop confirmation modal if shown
 	if a.showStopModal {
 		content = view.RenderConfirmModal(
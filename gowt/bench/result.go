@@ -0,0 +1,203 @@
+// Package bench parses Go benchmark results and compares two sets of them
+// the way benchstat does: medians, spread, and a Mann-Whitney U test to tell
+// real changes from noise.
+package bench
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Standard units reported by the testing package
+const (
+	UnitTime   = "ns/op"
+	UnitBytes  = "B/op"
+	UnitAllocs = "allocs/op"
+)
+
+// Metric is one value of a benchmark result, e.g. 123.4 ns/op
+type Metric struct {
+	Value float64
+	Unit  string
+}
+
+// Result is one benchmark result line, e.g.
+// "BenchmarkFoo-8   1000   1234 ns/op   64 B/op   2 allocs/op"
+type Result struct {
+	Package    string // Import path, from the "pkg:" line or the -json event
+	Name       string // Benchmark name without the -GOMAXPROCS suffix
+	Iterations int
+	Metrics    []Metric
+}
+
+// Key identifies the benchmark across runs: its package and name
+func (r Result) Key() string {
+	if r.Package == "" {
+		return r.Name
+	}
+	return r.Package + "." + r.Name
+}
+
+// Value returns the value of a unit, if the result has it
+func (r Result) Value(unit string) (float64, bool) {
+	for _, m := range r.Metrics {
+		if m.Unit == unit {
+			return m.Value, true
+		}
+	}
+	return 0, false
+}
+
+// String formats the result as a benchmark result line, without the package
+func (r Result) String() string {
+	var sb strings.Builder
+	sb.WriteString(r.Name)
+	sb.WriteString("\t")
+	sb.WriteString(strconv.Itoa(r.Iterations))
+	for _, m := range r.Metrics {
+		sb.WriteString("\t")
+		sb.WriteString(strconv.FormatFloat(m.Value, 'f', -1, 64))
+		sb.WriteString(" ")
+		sb.WriteString(m.Unit)
+	}
+	return sb.String()
+}
+
+// ParseLine parses a benchmark result line. ok is false for any other line,
+// including the bare "BenchmarkFoo" line printed when a benchmark starts.
+func ParseLine(line string) (Result, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || len(fields)%2 != 0 || !strings.HasPrefix(fields[0], "Benchmark") {
+		return Result{}, false
+	}
+	iterations, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Result{}, false
+	}
+
+	r := Result{Name: trimProcs(fields[0]), Iterations: iterations}
+	for i := 2; i < len(fields); i += 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return Result{}, false
+		}
+		r.Metrics = append(r.Metrics, Metric{Value: value, Unit: fields[i+1]})
+	}
+	return r, true
+}
+
+// trimProcs removes the "-8" GOMAXPROCS suffix the testing package adds
+func trimProcs(name string) string {
+	i := strings.LastIndex(name, "-")
+	if i == -1 {
+		return name
+	}
+	if _, err := strconv.Atoi(name[i+1:]); err != nil {
+		return name
+	}
+	return name[:i]
+}
+
+// Set holds the results of several benchmarks, each run one or more times
+// (-count), keyed by Result.Key
+type Set map[string][]Result
+
+// Add records a result
+func (s Set) Add(r Result) {
+	s[r.Key()] = append(s[r.Key()], r)
+}
+
+// Names returns the benchmark keys, sorted
+func (s Set) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Values returns the values of a unit across the runs of a benchmark
+func (s Set) Values(key, unit string) []float64 {
+	return Values(s[key], unit)
+}
+
+// Values returns the values of a unit across results
+func Values(results []Result, unit string) []float64 {
+	var values []float64
+	for _, r := range results {
+		if v, ok := r.Value(unit); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Parse reads benchmark results from plain `go test -bench` output or from
+// `go test -json` output
+func Parse(r io.Reader) (Set, error) {
+	set := make(Set)
+	pkg := ""
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "{") {
+			var event struct{ Package, Output string }
+			if json.Unmarshal([]byte(line), &event) == nil {
+				line = event.Output
+				pkg = event.Package
+			}
+		}
+		if name, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(name)
+			continue
+		}
+		if result, ok := ParseLine(line); ok {
+			result.Package = pkg
+			set.Add(result)
+		}
+	}
+	return set, scanner.Err()
+}
+
+// ReadFile reads benchmark results from a file, see Parse
+func ReadFile(path string) (Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// WriteFile saves results as benchmark result lines, which Parse and
+// benchstat both read
+func WriteFile(path string, set Set) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	pkg := ""
+	for _, key := range set.Names() {
+		for _, r := range set[key] {
+			if r.Package != pkg {
+				pkg = r.Package
+				sb.WriteString("pkg: " + pkg + "\n")
+			}
+			sb.WriteString(r.String())
+			sb.WriteString("\n")
+		}
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("failed to save benchmarks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package bench
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected Result
+		ok       bool
+	}{
+		{
+			"full result",
+			"BenchmarkJoin-8 \t    1000\t       123.7 ns/op\t     112 B/op\t       1 allocs/op\n",
+			Result{Name: "BenchmarkJoin", Iterations: 1000, Metrics: []Metric{{123.7, UnitTime}, {112, UnitBytes}, {1, UnitAllocs}}},
+			true,
+		},
+		{
+			"sub-benchmark without procs suffix",
+			"BenchmarkSub/big           \t    1000\t         1.576 ns/op\n",
+			Result{Name: "BenchmarkSub/big", Iterations: 1000, Metrics: []Metric{{1.576, UnitTime}}},
+			true,
+		},
+		{
+			"custom metric",
+			"BenchmarkRead-4   500   2000 ns/op   512.00 MB/s\n",
+			Result{Name: "BenchmarkRead", Iterations: 500, Metrics: []Metric{{2000, UnitTime}, {512, "MB/s"}}},
+			true,
+		},
+		{"start line", "BenchmarkJoin\n", Result{}, false},
+		{"log line", "BenchmarkJoin failed: 3 ns/op\n", Result{}, false},
+		{"test output", "--- PASS: TestFoo (0.00s)\n", Result{}, false},
+		{"odd fields", "BenchmarkJoin 1000 123 ns/op 5\n", Result{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := ParseLine(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestTrimProcs(t *testing.T) {
+	assert.Equal(t, "BenchmarkFoo", trimProcs("BenchmarkFoo-16"))
+	assert.Equal(t, "BenchmarkFoo/size-large", trimProcs("BenchmarkFoo/size-large"))
+	assert.Equal(t, "BenchmarkFoo", trimProcs("BenchmarkFoo"))
+}
+
+func TestParse_TextAndJSON(t *testing.T) {
+	input := `goos: linux
+pkg: example.com/p
+BenchmarkA-8   100   10 ns/op
+{"Action":"output","Package":"example.com/p","Test":"BenchmarkA","Output":"BenchmarkA-8 \t 100\t 12 ns/op\n"}
+{"Action":"output","Package":"example.com/q","Output":"BenchmarkB-8 \t 100\t 30 ns/op\n"}
+PASS
+`
+	set, err := Parse(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com/p.BenchmarkA", "example.com/q.BenchmarkB"}, set.Names())
+	assert.Equal(t, []float64{10, 12}, set.Values("example.com/p.BenchmarkA", UnitTime))
+	assert.Equal(t, []float64{30}, set.Values("example.com/q.BenchmarkB", UnitTime))
+	assert.Empty(t, set.Values("example.com/q.BenchmarkB", UnitAllocs))
+}
+
+func TestWriteFile_RoundTrip(t *testing.T) {
+	set := make(Set)
+	set.Add(Result{Package: "example.com/p", Name: "BenchmarkA", Iterations: 100, Metrics: []Metric{{10.5, UnitTime}, {64, UnitBytes}}})
+	set.Add(Result{Package: "example.com/p", Name: "BenchmarkA", Iterations: 100, Metrics: []Metric{{11, UnitTime}, {64, UnitBytes}}})
+	set.Add(Result{Package: "example.com/q", Name: "BenchmarkB", Iterations: 10, Metrics: []Metric{{3, UnitTime}}})
+
+	path := filepath.Join(t.TempDir(), "nested", "bench.txt")
+	require.NoError(t, WriteFile(path, set))
+
+	read, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, set, read)
+}
@@ -0,0 +1,177 @@
+package bench
+
+import (
+	"math"
+	"sort"
+)
+
+// Alpha is the significance level below which a difference is reported, as
+// in benchstat
+const Alpha = 0.05
+
+// Summary describes the values of one metric across runs
+type Summary struct {
+	N      int
+	Median float64
+	Spread float64 // Largest distance from the median, as a fraction of it
+}
+
+// Summarize computes the median and spread of values
+func Summarize(values []float64) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	s := Summary{N: len(sorted), Median: median(sorted)}
+	if s.Median != 0 {
+		lo := (s.Median - sorted[0]) / s.Median
+		hi := (sorted[len(sorted)-1] - s.Median) / s.Median
+		s.Spread = math.Max(math.Abs(lo), math.Abs(hi))
+	}
+	return s
+}
+
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// Comparison is the change of a metric between two sets of runs
+type Comparison struct {
+	Old, New    Summary
+	Delta       float64 // Relative change of the median, e.g. -0.12 for 12% less
+	P           float64 // Two-sided Mann-Whitney U test p-value
+	Significant bool    // P < Alpha; otherwise benchstat prints "~"
+}
+
+// Compare compares the old and new values of a metric
+func Compare(old, new []float64) Comparison {
+	c := Comparison{Old: Summarize(old), New: Summarize(new), P: 1}
+	if c.Old.N == 0 || c.New.N == 0 {
+		return c
+	}
+	if c.Old.Median != 0 {
+		c.Delta = (c.New.Median - c.Old.Median) / c.Old.Median
+	}
+	c.P = MannWhitneyU(old, new)
+	c.Significant = c.P < Alpha
+	return c
+}
+
+// MannWhitneyU returns the two-sided p-value of the Mann-Whitney U test that
+// a and b come from the same distribution. Small samples without ties use
+// the exact distribution of U; otherwise the normal approximation with tie
+// correction is used.
+func MannWhitneyU(a, b []float64) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	// Rank the pooled values, averaging ranks of ties
+	type value struct {
+		v     float64
+		fromA bool
+	}
+	pooled := make([]value, 0, n1+n2)
+	for _, v := range a {
+		pooled = append(pooled, value{v, true})
+	}
+	for _, v := range b {
+		pooled = append(pooled, value{v, false})
+	}
+	sort.Slice(pooled, func(i, j int) bool { return pooled[i].v < pooled[j].v })
+
+	var rankSumA, tieTerm float64
+	ties := false
+	for i := 0; i < len(pooled); {
+		j := i
+		for j < len(pooled) && pooled[j].v == pooled[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // Average of ranks i+1..j
+		for k := i; k < j; k++ {
+			if pooled[k].fromA {
+				rankSumA += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+
+	u := rankSumA - float64(n1*(n1+1))/2
+	if !ties && n1*n2 <= 400 {
+		return exactP(u, n1, n2)
+	}
+
+	// Normal approximation with continuity and tie correction
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// exactP returns the two-sided p-value of U for samples of size n1 and n2,
+// counting the orderings of the pooled sample that give each U
+func exactP(u float64, n1, n2 int) float64 {
+	// count[j][k] is the number of orderings of i values from a and j from b
+	// with U = k. The largest value is either from a, beating all j values of
+	// b, or from b: N(i, j, k) = N(i-1, j, k-j) + N(i, j-1, k).
+	maxU := n1 * n2
+	newTable := func() [][]float64 {
+		t := make([][]float64, n2+1)
+		for j := range t {
+			t[j] = make([]float64, maxU+1)
+		}
+		return t
+	}
+	prev := newTable()
+	for j := range prev {
+		prev[j][0] = 1 // No values from a: U is 0
+	}
+	for i := 1; i <= n1; i++ {
+		cur := newTable()
+		cur[0][0] = 1 // No values from b: U is 0
+		for j := 1; j <= n2; j++ {
+			for k := 0; k <= maxU; k++ {
+				if k >= j {
+					cur[j][k] += prev[j][k-j]
+				}
+				cur[j][k] += cur[j-1][k]
+			}
+		}
+		prev = cur
+	}
+
+	counts := prev[n2]
+	var total float64
+	for _, c := range counts {
+		total += c
+	}
+
+	// Two-sided: probability of a U at least as far from the mean
+	mean := float64(maxU) / 2
+	dist := math.Abs(u - mean)
+	var tail float64
+	for k, c := range counts {
+		if math.Abs(float64(k)-mean) >= dist-1e-9 {
+			tail += c
+		}
+	}
+	return math.Min(1, tail/total)
+}
@@ -0,0 +1,54 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]float64{90, 100, 110, 100, 120})
+	assert.Equal(t, 5, s.N)
+	assert.Equal(t, 100.0, s.Median)
+	assert.InDelta(t, 0.2, s.Spread, 1e-9)
+
+	assert.Equal(t, 105.0, Summarize([]float64{100, 110}).Median)
+	assert.Equal(t, Summary{}, Summarize(nil))
+}
+
+func TestMannWhitneyU_Exact(t *testing.T) {
+	// Completely separated samples of 5: the most extreme of C(10,5)=252
+	// orderings on either side
+	p := MannWhitneyU([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10})
+	assert.InDelta(t, 2.0/252, p, 1e-9)
+
+	// Interleaved samples are not different
+	p = MannWhitneyU([]float64{1, 3, 5, 7, 9}, []float64{2, 4, 6, 8, 10})
+	assert.Greater(t, p, 0.5)
+
+	// A single run each can never be significant
+	assert.Equal(t, 1.0, MannWhitneyU([]float64{1}, []float64{2}))
+}
+
+func TestMannWhitneyU_Ties(t *testing.T) {
+	// Identical samples
+	assert.Equal(t, 1.0, MannWhitneyU([]float64{5, 5, 5}, []float64{5, 5, 5}))
+
+	// Separated samples with ties use the normal approximation
+	p := MannWhitneyU([]float64{1, 1, 2, 2, 3, 3}, []float64{7, 7, 8, 8, 9, 9})
+	assert.Less(t, p, Alpha)
+}
+
+func TestCompare(t *testing.T) {
+	c := Compare([]float64{100, 101, 99, 100, 102}, []float64{80, 81, 79, 80, 82})
+	assert.InDelta(t, -0.2, c.Delta, 1e-9)
+	assert.True(t, c.Significant)
+
+	c = Compare([]float64{100}, []float64{80})
+	assert.InDelta(t, -0.2, c.Delta, 1e-9)
+	assert.False(t, c.Significant)
+
+	c = Compare(nil, []float64{80})
+	assert.Equal(t, 1.0, c.P)
+	assert.False(t, c.Significant)
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rickchristie/govner/gowt/gotest"
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/rickchristie/govner/gowt/report"
+	"github.com/rickchristie/govner/gowt/util"
+	view "github.com/rickchristie/govner/gowt/view"
+)
+
+// ciSlowest is how many of the slowest tests the CI summary lists
+const ciSlowest = 10
+
+// ciStyles color the CI output. lipgloss drops the colors when stdout isn't
+// a terminal, as on most CI runners.
+type ciStyles struct {
+	passed  lipgloss.Style
+	failed  lipgloss.Style
+	skipped lipgloss.Style
+	cached  lipgloss.Style
+	flaky   lipgloss.Style
+	dim     lipgloss.Style
+	bold    lipgloss.Style
+}
+
+func newCIStyles() ciStyles {
+	return ciStyles{
+		passed:  lipgloss.NewStyle().Foreground(view.ColorPassed),
+		failed:  lipgloss.NewStyle().Foreground(view.ColorFailed),
+		skipped: lipgloss.NewStyle().Foreground(view.ColorSkipped),
+		cached:  lipgloss.NewStyle().Foreground(view.ColorCached),
+		flaky:   lipgloss.NewStyle().Foreground(view.ColorFlaky),
+		dim:     lipgloss.NewStyle().Foreground(view.ColorPending),
+		bold:    lipgloss.NewStyle().Bold(true),
+	}
+}
+
+// ciRun runs go test without the TUI: a line per package as it finishes,
+// then the failures grouped by package, the slowest tests and the totals
+type ciRun struct {
+	runner    TestRunner
+	args      gotest.Args // The run's go test arguments, whose flags reruns keep
+	out       io.Writer
+	tree      *model.TestTree
+	styles    ciStyles
+	stderrPkg string // Current package for stderr output
+	skipped   int    // Packages left out by --changed
+}
+
+// newCIRun creates a headless run of go test with args, printing to out
+func newCIRun(runner TestRunner, args []string, out io.Writer) *ciRun {
+	return &ciRun{
+		runner: runner,
+		args:   gotest.ParseArgs(args),
+		out:    out,
+		tree:   model.NewTestTree(),
+		styles: newCIStyles(),
+	}
+}
+
+// runCIMode runs tests headless and returns go test's exit code
+func runCIMode(args []string, opts liveOptions) int {
+	return newCIRun(NewRealTestRunner(), args, os.Stdout).run(args, opts)
+}
+
+// run runs go test with args and the reruns of opts, prints the summary and
+// writes the reports. Returns go test's exit code.
+func (r *ciRun) run(args []string, opts liveOptions) int {
+	r.skipped = opts.skippedPkgs
+
+	start := time.Now()
+	stream, err := r.runner.Start(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to start go test: %v\n", err)
+		return 1
+	}
+	result := r.consume(stream, r.tree, true)
+	r.tree.Elapsed = time.Since(start).Seconds()
+
+	if opts.retryFailed > 0 {
+		r.retry(opts.retryFailed)
+	}
+
+	r.printFailures()
+	r.printSlowest()
+	r.printTotals()
+
+	if len(opts.reports) > 0 {
+		if err := report.Write(report.New(r.tree), opts.reports); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	return result.ExitCode
+}
+
+// consume reads the stream into tree until go test exits. Stderr is added
+// to the package it belongs to, as in the TUI. With progress, a line is
+// printed for each package that finishes.
+func (r *ciRun) consume(stream EventStream, tree *model.TestTree, progress bool) TestResult {
+	events := stream.Events()
+	stderr := stream.Stderr()
+	handle := func(event model.TestEvent) {
+		tree.ProcessEvent(event)
+		if progress && event.Test == "" && event.Package != "" &&
+			(event.Action == "pass" || event.Action == "fail" || event.Action == "skip") {
+			r.printPackage(tree.Packages[event.Package])
+		}
+	}
+	handleStderr := func(line string) {
+		if strings.HasPrefix(line, "# ") {
+			r.stderrPkg = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		}
+		if r.stderrPkg != "" {
+			tree.ProcessEvent(model.TestEvent{
+				Time:    time.Now(),
+				Action:  "output",
+				Package: r.stderrPkg,
+				Output:  line,
+			})
+		}
+	}
+
+	for {
+		select {
+		case event := <-events:
+			handle(event)
+		case line := <-stderr:
+			handleStderr(line)
+		case result := <-stream.Done():
+			// Events are all sent before done, stderr may still trail
+			for {
+				select {
+				case event := <-events:
+					handle(event)
+				case line := <-stderr:
+					handleStderr(line)
+				default:
+					return result
+				}
+			}
+		}
+	}
+}
+
+// printPackage prints the progress line of a finished package
+func (r *ciRun) printPackage(pkg *model.TestNode) {
+	if pkg == nil {
+		return
+	}
+	s := r.styles
+	var icon, detail string
+	switch {
+	case pkg.Status == model.StatusFailed && pkg.TotalCount == 0:
+		icon, detail = s.failed.Render(view.IconCharFailed), s.failed.Render("failed")
+	case pkg.Status == model.StatusSkipped || pkg.TotalCount == 0:
+		icon, detail = s.skipped.Render(view.IconCharSkipped), s.dim.Render("no tests")
+	default:
+		switch {
+		case pkg.Status == model.StatusFailed:
+			icon = s.failed.Render(view.IconCharFailed)
+		case pkg.Cached:
+			icon = s.cached.Render(view.IconCharCached)
+		default:
+			icon = s.passed.Render(view.IconCharPassed)
+		}
+		detail = r.counts(pkg.PassedCount, pkg.FailedCount, pkg.SkippedCount)
+	}
+
+	elapsed := util.FormatDuration(pkg.Elapsed)
+	if pkg.Cached {
+		elapsed += " (cached)"
+	}
+	fmt.Fprintf(r.out, "%s %s  %s  %s\n", icon, pkg.Package, detail, s.dim.Render(elapsed))
+}
+
+// counts formats test counts, leaving out the zero ones except passed
+func (r *ciRun) counts(passed, failed, skipped int) string {
+	parts := []string{r.styles.passed.Render(fmt.Sprintf("%d passed", passed))}
+	if failed > 0 {
+		parts = append(parts, r.styles.failed.Render(fmt.Sprintf("%d failed", failed)))
+	}
+	if skipped > 0 {
+		parts = append(parts, r.styles.skipped.Render(fmt.Sprintf("%d skipped", skipped)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// retry reruns each failed test n times and tallies the results on its
+// node, telling flaky tests from ones that fail consistently
+func (r *ciRun) retry(n int) {
+	failed := r.tree.FailedTests()
+	if len(failed) == 0 {
+		return
+	}
+	r.printHeading(fmt.Sprintf("Rerunning %d failed tests %d times", len(failed), n))
+	for _, node := range failed {
+		node.Retries = &model.Retries{Pending: n}
+		testName := strings.TrimPrefix(node.FullPath, node.Package+"/")
+		for i := 0; i < n; i++ {
+			// Failures aren't cached, but a rerun that passed would be
+			r.runner.CleanCache()
+			passed := false
+			if stream, err := r.runner.StartSingle(node.Package, testName, r.args); err == nil {
+				rerun := model.NewTestTree()
+				r.consume(stream, rerun, false)
+				result := rerun.GetNode(node.FullPath)
+				passed = result != nil && result.Status == model.StatusPassed
+			}
+			node.Retries.Record(passed)
+		}
+
+		icon, tally := r.styles.failed.Render(view.IconCharFailed), fmt.Sprintf("failed %d/%d", node.Retries.Failed, n)
+		if node.Retries.Flaky() {
+			icon, tally = r.styles.flaky.Render(view.IconCharFlaky), fmt.Sprintf("flaky: passed %d/%d", node.Retries.Passed, n)
+		}
+		fmt.Fprintf(r.out, "%s %s %s  %s\n", icon, node.Package, testName, r.styles.dim.Render(tally))
+	}
+}
+
+// printFailures prints the processed log of each failed test, grouped by
+// package. Packages that failed outside their tests, e.g. because they don't
+// build, show the package output instead.
+func (r *ciRun) printFailures() {
+	failed := r.tree.FailedTests()
+	byPackage := make(map[string][]*model.TestNode)
+	for _, node := range failed {
+		byPackage[node.Package] = append(byPackage[node.Package], node)
+	}
+
+	heading := false
+	for _, pkg := range r.tree.GetSortedPackages() {
+		tests := byPackage[pkg.Package]
+		if len(tests) == 0 && pkg.Status != model.StatusFailed {
+			continue
+		}
+		if !heading {
+			r.printHeading("Failures")
+			heading = true
+		}
+		fmt.Fprintf(r.out, "%s %s\n", r.styles.failed.Render(view.IconCharFailed), r.styles.bold.Render(pkg.Package))
+		if len(tests) == 0 {
+			r.printLog(pkg.GetProcessedOutput(r.tree.ProcessedLogBuffer))
+			continue
+		}
+		for _, node := range tests {
+			name := strings.TrimPrefix(node.FullPath, node.Package+"/")
+			fmt.Fprintf(r.out, "\n  %s %s %s\n", r.styles.failed.Render(view.IconCharFailed), r.styles.bold.Render(name),
+				r.styles.dim.Render(util.FormatDuration(node.Elapsed)))
+			r.printLog(node.GetProcessedOutput(r.tree.ProcessedLogBuffer))
+		}
+		fmt.Fprintln(r.out)
+	}
+}
+
+// printLog prints a processed log indented under its test, without the
+// blank lines around it
+func (r *ciRun) printLog(log string) {
+	log = strings.Trim(log, "\n")
+	if log == "" {
+		return
+	}
+	for _, line := range strings.Split(log, "\n") {
+		fmt.Fprintf(r.out, "    %s\n", line)
+	}
+}
+
+// printSlowest lists the slowest top-level tests. Subtests are left out, as
+// their time is already part of their parent's.
+func (r *ciRun) printSlowest() {
+	var tests []*model.TestNode
+	for _, pkg := range r.tree.Packages {
+		for _, node := range pkg.Children {
+			if node.Elapsed > 0 {
+				tests = append(tests, node)
+			}
+		}
+	}
+	if len(tests) == 0 {
+		return
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].Elapsed != tests[j].Elapsed {
+			return tests[i].Elapsed > tests[j].Elapsed
+		}
+		return tests[i].FullPath < tests[j].FullPath
+	})
+	if len(tests) > ciSlowest {
+		tests = tests[:ciSlowest]
+	}
+
+	r.printHeading("Slowest tests")
+	for _, node := range tests {
+		fmt.Fprintf(r.out, "%8s  %s %s\n", util.FormatDuration(node.Elapsed), node.Package, node.Name)
+	}
+}
+
+// printTotals prints the test counts of the run
+func (r *ciRun) printTotals() {
+	passed, failed, skipped, _, _ := r.tree.ComputeAllStats()
+	flaky := 0
+	for _, node := range r.tree.FailedTests() {
+		if node.Retries != nil && node.Retries.Flaky() {
+			flaky++
+		}
+	}
+
+	r.printHeading("Totals")
+	totals := r.counts(passed, failed, skipped)
+	if flaky > 0 {
+		totals += ", " + r.styles.flaky.Render(fmt.Sprintf("%d flaky", flaky))
+	}
+	packages := fmt.Sprintf("%d packages", len(r.tree.Packages))
+	if r.skipped > 0 {
+		packages += r.styles.dim.Render(fmt.Sprintf(", %d unaffected packages skipped", r.skipped))
+	}
+	fmt.Fprintf(r.out, "%s in %s (%s)\n", totals, packages, util.FormatDuration(r.tree.Elapsed))
+}
+
+func (r *ciRun) printHeading(title string) {
+	fmt.Fprintf(r.out, "\n%s\n\n", r.styles.bold.Render("── "+title+" ──"))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rickchristie/govner/gowt/gotest"
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ciPassOutput = `{"Action":"start","Package":"example.com/ci"}
+{"Action":"run","Package":"example.com/ci","Test":"TestA"}
+{"Action":"output","Package":"example.com/ci","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"example.com/ci","Test":"TestA","Output":"--- PASS: TestA (0.00s)\n"}
+{"Action":"pass","Package":"example.com/ci","Test":"TestA","Elapsed":0}
+{"Action":"run","Package":"example.com/ci","Test":"TestB"}
+{"Action":"output","Package":"example.com/ci","Test":"TestB","Output":"=== RUN   TestB\n"}
+{"Action":"output","Package":"example.com/ci","Test":"TestB","Output":"--- PASS: TestB (0.00s)\n"}
+{"Action":"pass","Package":"example.com/ci","Test":"TestB","Elapsed":0}
+{"Action":"output","Package":"example.com/ci","Output":"PASS\n"}
+{"Action":"output","Package":"example.com/ci","Output":"ok  \texample.com/ci\t0.002s\n"}
+{"Action":"pass","Package":"example.com/ci","Elapsed":0.002}`
+
+const ciFailOutput = `{"Action":"start","Package":"example.com/ci"}
+{"Action":"run","Package":"example.com/ci","Test":"TestA"}
+{"Action":"output","Package":"example.com/ci","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"example.com/ci","Test":"TestA","Output":"--- PASS: TestA (0.00s)\n"}
+{"Action":"pass","Package":"example.com/ci","Test":"TestA","Elapsed":0}
+{"Action":"run","Package":"example.com/ci","Test":"TestB"}
+{"Action":"output","Package":"example.com/ci","Test":"TestB","Output":"=== RUN   TestB\n"}
+{"Action":"output","Package":"example.com/ci","Test":"TestB","Output":"    ci_test.go:12: boom\n"}
+{"Action":"output","Package":"example.com/ci","Test":"TestB","Output":"--- FAIL: TestB (0.00s)\n"}
+{"Action":"fail","Package":"example.com/ci","Test":"TestB","Elapsed":0}
+{"Action":"output","Package":"example.com/ci","Output":"FAIL\n"}
+{"Action":"output","Package":"example.com/ci","Output":"FAIL\texample.com/ci\t0.002s\n"}
+{"Action":"fail","Package":"example.com/ci","Elapsed":0.002}`
+
+// ciRerunPass and ciRerunFail are go test -run '^TestB$' output
+const ciRerunPass = `{"Action":"start","Package":"example.com/ci"}
+{"Action":"run","Package":"example.com/ci","Test":"TestB"}
+{"Action":"output","Package":"example.com/ci","Test":"TestB","Output":"--- PASS: TestB (0.00s)\n"}
+{"Action":"pass","Package":"example.com/ci","Test":"TestB","Elapsed":0}
+{"Action":"pass","Package":"example.com/ci","Elapsed":0.001}`
+
+const ciRerunFail = `{"Action":"start","Package":"example.com/ci"}
+{"Action":"run","Package":"example.com/ci","Test":"TestB"}
+{"Action":"output","Package":"example.com/ci","Test":"TestB","Output":"--- FAIL: TestB (0.00s)\n"}
+{"Action":"fail","Package":"example.com/ci","Test":"TestB","Elapsed":0}
+{"Action":"fail","Package":"example.com/ci","Elapsed":0.001}`
+
+// fakeStream replays recorded go test -json output
+type fakeStream struct {
+	events chan model.TestEvent
+	stderr chan string
+	done   chan TestResult
+}
+
+func newFakeStream(t *testing.T, output string, exitCode int) *fakeStream {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	s := &fakeStream{
+		events: make(chan model.TestEvent, len(lines)),
+		stderr: make(chan string),
+		done:   make(chan TestResult, 1),
+	}
+	for _, line := range lines {
+		var event model.TestEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event), line)
+		s.events <- event
+	}
+	s.done <- TestResult{ExitCode: exitCode}
+	return s
+}
+
+func (s *fakeStream) Events() <-chan model.TestEvent { return s.events }
+func (s *fakeStream) Stderr() <-chan string          { return s.stderr }
+func (s *fakeStream) Done() <-chan TestResult        { return s.done }
+func (s *fakeStream) Kill() error                    { return nil }
+
+// fakeRunner replays the output of a run, then of each rerun in turn
+type fakeRunner struct {
+	t      *testing.T
+	output string
+	reruns []string
+	starts [][]string // Command lines of the reruns, as the real runner builds them
+}
+
+func (r *fakeRunner) Start(args []string) (EventStream, error) {
+	return newFakeStream(r.t, r.output, exitCode(r.output)), nil
+}
+
+func (r *fakeRunner) StartSingle(pkg, testName string, args gotest.Args) (EventStream, error) {
+	require.NotEmpty(r.t, r.reruns, "unexpected rerun of %s", testName)
+	output := r.reruns[0]
+	r.reruns = r.reruns[1:]
+	r.starts = append(r.starts, singleArgs(pkg, testName, args))
+	return newFakeStream(r.t, output, exitCode(output)), nil
+}
+
+func (r *fakeRunner) CleanCache() error { return nil }
+
+// exitCode returns go test's exit code for output: 1 if the package failed
+func exitCode(output string) int {
+	if strings.Contains(output, `{"Action":"fail","Package":"example.com/ci","Elapsed"`) {
+		return 1
+	}
+	return 0
+}
+
+// runCI runs the CI pipeline against runner, returning its exit code and output
+func runCI(runner *fakeRunner, args []string, opts liveOptions) (int, string) {
+	var out bytes.Buffer
+	code := newCIRun(runner, args, &out).run(args, opts)
+	return code, out.String()
+}
+
+func TestCI_Pass(t *testing.T) {
+	code, out := runCI(&fakeRunner{t: t, output: ciPassOutput}, []string{"./..."}, liveOptions{retryFailed: 2})
+
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "example.com/ci  2 passed")
+	assert.NotContains(t, out, "Failures")
+	assert.NotContains(t, out, "Rerunning")
+	assert.Contains(t, out, "── Totals ──\n\n2 passed in 1 packages (")
+}
+
+func TestCI_Fail(t *testing.T) {
+	code, out := runCI(&fakeRunner{t: t, output: ciFailOutput}, []string{"./..."}, liveOptions{})
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "── Failures ──")
+	assert.Contains(t, out, "TestB")
+	assert.Contains(t, out, "boom")
+	assert.Contains(t, out, "1 passed, 1 failed in 1 packages (")
+	assert.NotContains(t, out, "flaky")
+}
+
+func TestCI_RetryFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		reruns []string
+		tally  string
+		totals string
+	}{
+		{"consistent failure", []string{ciRerunFail, ciRerunFail}, "example.com/ci TestB  failed 2/2", "1 passed, 1 failed in 1 packages ("},
+		{"flaky", []string{ciRerunFail, ciRerunPass}, "example.com/ci TestB  flaky: passed 1/2", "1 passed, 1 failed, 1 flaky in 1 packages ("},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{t: t, output: ciFailOutput, reruns: tt.reruns}
+			code, out := runCI(runner, []string{"-race", "-tags", "integration", "./..."}, liveOptions{retryFailed: 2})
+
+			// The exit code is the run's, whatever the reruns did
+			assert.Equal(t, 1, code)
+			assert.Contains(t, out, "── Rerunning 1 failed tests 2 times ──")
+			assert.Contains(t, out, tt.tally)
+			assert.Contains(t, out, tt.totals)
+			assert.Empty(t, runner.reruns)
+
+			// Only the failed test is rerun, with the run's flags
+			want := []string{"test", "-json", "-race", "-tags", "integration", "example.com/ci", "-run", "^TestB$"}
+			assert.Equal(t, [][]string{want, want}, runner.starts)
+		})
+	}
+}
+
+func TestCI_SkippedPackages(t *testing.T) {
+	_, out := runCI(&fakeRunner{t: t, output: ciPassOutput}, []string{"example.com/ci"}, liveOptions{skippedPkgs: 3})
+	assert.Contains(t, out, "2 passed in 1 packages, 3 unaffected packages skipped (")
+
+	_, out = runCI(&fakeRunner{t: t, output: ciPassOutput}, []string{"example.com/ci"}, liveOptions{})
+	assert.NotContains(t, out, "skipped")
+}
@@ -0,0 +1,196 @@
+// Package coverage reads the profiles written by go test -coverprofile and
+// answers the questions gowt asks of them: how much of a package is covered,
+// and which lines of a file ran.
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Block is a run of statements that execute together
+type Block struct {
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	NumStmt   int
+	Count     int
+}
+
+// Stats counts covered and total statements
+type Stats struct {
+	Covered int
+	Total   int
+}
+
+// Percent returns the covered share of statements, 0-100
+func (s Stats) Percent() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Covered) / float64(s.Total) * 100
+}
+
+// Profile is a parsed coverage profile. Files are keyed by the name go test
+// writes, which is the package import path joined with the file's base name,
+// e.g. "example.com/m/pkg/file.go".
+type Profile struct {
+	Mode  string
+	Files map[string][]Block
+}
+
+// ReadProfile parses the coverage profile at path
+func ReadProfile(path string) (*Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseProfile(f)
+}
+
+// ParseProfile parses a coverage profile. When several test binaries cover
+// the same block (e.g. with -coverpkg), their counts are added up.
+func ParseProfile(r io.Reader) (*Profile, error) {
+	p := &Profile{Files: make(map[string][]Block)}
+	seen := make(map[string]map[[4]int]int) // File -> block position -> index in Files[file]
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if mode, ok := strings.CutPrefix(line, "mode: "); ok {
+			p.Mode = mode
+			continue
+		}
+
+		file, block, err := parseBlock(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		pos := [4]int{block.StartLine, block.StartCol, block.EndLine, block.EndCol}
+		if seen[file] == nil {
+			seen[file] = make(map[[4]int]int)
+		}
+		if i, ok := seen[file][pos]; ok {
+			p.Files[file][i].Count += block.Count
+			continue
+		}
+		seen[file][pos] = len(p.Files[file])
+		p.Files[file] = append(p.Files[file], block)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseBlock parses "file:startLine.startCol,endLine.endCol numStmt count"
+func parseBlock(line string) (string, Block, error) {
+	colon := strings.LastIndex(line, ":")
+	if colon == -1 {
+		return "", Block{}, fmt.Errorf("invalid block %q", line)
+	}
+	file := line[:colon]
+
+	var b Block
+	_, err := fmt.Sscanf(line[colon+1:], "%d.%d,%d.%d %d %d",
+		&b.StartLine, &b.StartCol, &b.EndLine, &b.EndCol, &b.NumStmt, &b.Count)
+	if err != nil {
+		return "", Block{}, fmt.Errorf("invalid block %q: %w", line, err)
+	}
+	return file, b, nil
+}
+
+// Merge replaces the blocks of every file in other, keeping files other
+// does not cover. Used when only some packages were run again.
+func (p *Profile) Merge(other *Profile) {
+	if other.Mode != "" {
+		p.Mode = other.Mode
+	}
+	for file, blocks := range other.Files {
+		p.Files[file] = blocks
+	}
+}
+
+// FileStats returns the statement counts of one file
+func (p *Profile) FileStats(file string) Stats {
+	var s Stats
+	for _, b := range p.Files[file] {
+		s.Total += b.NumStmt
+		if b.Count > 0 {
+			s.Covered += b.NumStmt
+		}
+	}
+	return s
+}
+
+// PackageFiles returns the files of a package, sorted
+func (p *Profile) PackageFiles(pkg string) []string {
+	var files []string
+	for file := range p.Files {
+		if path.Dir(file) == pkg {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// PackageStats returns the statement counts of a package's own files. The
+// second result is false if the profile holds no file of the package.
+func (p *Profile) PackageStats(pkg string) (Stats, bool) {
+	var s Stats
+	files := p.PackageFiles(pkg)
+	for _, file := range files {
+		fs := p.FileStats(file)
+		s.Covered += fs.Covered
+		s.Total += fs.Total
+	}
+	return s, len(files) > 0
+}
+
+// LineCoverage maps each line of a file that holds statements to whether it
+// ran. A line shared by several blocks counts as covered if any of them ran.
+func (p *Profile) LineCoverage(file string) map[int]bool {
+	lines := make(map[int]bool)
+	for _, b := range p.Files[file] {
+		if b.NumStmt == 0 {
+			continue
+		}
+		for line := b.StartLine; line <= b.EndLine; line++ {
+			lines[line] = lines[line] || b.Count > 0
+		}
+	}
+	return lines
+}
+
+// SourcePath resolves a profile file name to a path on disk, asking go list
+// where the package lives
+func SourcePath(file string) (string, error) {
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}", path.Dir(file)).Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate package %s: %w", path.Dir(file), err)
+	}
+	dir := strings.TrimSpace(string(out))
+	return filepath.Join(dir, path.Base(file)), nil
+}
+
+// FormatPercent formats a coverage percentage the way go test prints it
+func FormatPercent(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', 1, 64) + "%"
+}
@@ -0,0 +1,86 @@
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profile = `mode: set
+example.com/m/pkg/a.go:3.20,5.2 2 1
+example.com/m/pkg/a.go:7.20,9.2 1 0
+example.com/m/pkg/b.go:3.14,4.10 3 0
+example.com/m/pkg/b.go:4.10,6.2 1 1
+example.com/m/other/c.go:3.14,5.2 4 0
+`
+
+func parse(t *testing.T, s string) *Profile {
+	t.Helper()
+	p, err := ParseProfile(strings.NewReader(s))
+	require.NoError(t, err)
+	return p
+}
+
+func TestParseProfile(t *testing.T) {
+	p := parse(t, profile)
+	assert.Equal(t, "set", p.Mode)
+	assert.Len(t, p.Files, 3)
+	assert.Equal(t, []Block{
+		{StartLine: 3, StartCol: 20, EndLine: 5, EndCol: 2, NumStmt: 2, Count: 1},
+		{StartLine: 7, StartCol: 20, EndLine: 9, EndCol: 2, NumStmt: 1, Count: 0},
+	}, p.Files["example.com/m/pkg/a.go"])
+}
+
+func TestParseProfile_MergesDuplicateBlocks(t *testing.T) {
+	// With -coverpkg every test binary reports the same blocks
+	p := parse(t, `mode: count
+example.com/m/pkg/a.go:3.20,5.2 2 0
+example.com/m/pkg/a.go:3.20,5.2 2 3
+`)
+	require.Len(t, p.Files["example.com/m/pkg/a.go"], 1)
+	assert.Equal(t, 3, p.Files["example.com/m/pkg/a.go"][0].Count)
+}
+
+func TestParseProfile_Invalid(t *testing.T) {
+	_, err := ParseProfile(strings.NewReader("mode: set\nexample.com/m/a.go:garbage\n"))
+	assert.Error(t, err)
+}
+
+func TestProfile_PackageStats(t *testing.T) {
+	p := parse(t, profile)
+
+	stats, ok := p.PackageStats("example.com/m/pkg")
+	assert.True(t, ok)
+	assert.Equal(t, Stats{Covered: 3, Total: 7}, stats)
+	assert.InDelta(t, 42.86, stats.Percent(), 0.01)
+
+	_, ok = p.PackageStats("example.com/m/missing")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"example.com/m/pkg/a.go", "example.com/m/pkg/b.go"}, p.PackageFiles("example.com/m/pkg"))
+}
+
+func TestProfile_LineCoverage(t *testing.T) {
+	p := parse(t, profile)
+	assert.Equal(t, map[int]bool{3: true, 4: true, 5: true, 7: false, 8: false, 9: false}, p.LineCoverage("example.com/m/pkg/a.go"))
+
+	// Line 4 is shared by an uncovered and a covered block
+	assert.Equal(t, map[int]bool{3: false, 4: true, 5: true, 6: true}, p.LineCoverage("example.com/m/pkg/b.go"))
+}
+
+func TestProfile_Merge(t *testing.T) {
+	p := parse(t, profile)
+	p.Merge(parse(t, "mode: set\nexample.com/m/other/c.go:3.14,5.2 4 1\n"))
+
+	stats, _ := p.PackageStats("example.com/m/other")
+	assert.Equal(t, Stats{Covered: 4, Total: 4}, stats)
+	stats, _ = p.PackageStats("example.com/m/pkg")
+	assert.Equal(t, Stats{Covered: 3, Total: 7}, stats)
+}
+
+func TestFormatPercent(t *testing.T) {
+	assert.Equal(t, "42.9%", FormatPercent(42.857))
+	assert.Equal(t, "100.0%", FormatPercent(100))
+	assert.Equal(t, "0.0%", FormatPercent(0))
+}
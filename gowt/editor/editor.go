@@ -0,0 +1,108 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Template placeholders, replaced in each argument of a command template
+const (
+	PlaceholderFile = "{file}"
+	PlaceholderLine = "{line}"
+	PlaceholderCol  = "{col}"
+)
+
+// TemplateEnv is the environment variable holding a command template, e.g.
+// "code --goto {file}:{line}:{col}"
+const TemplateEnv = "GOWT_EDITOR"
+
+// DefaultTemplate returns the command template from $GOWT_EDITOR, or one
+// for $VISUAL or $EDITOR, falling back to vi
+func DefaultTemplate() string {
+	if t := os.Getenv(TemplateEnv); t != "" {
+		return t
+	}
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := os.Getenv(env); e != "" {
+			return Template(e)
+		}
+	}
+	return Template("vi")
+}
+
+// Template returns the command template that opens a file at a line in
+// editor. Editors it doesn't know just get the file.
+func Template(editor string) string {
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		return ""
+	}
+	switch name := strings.TrimSuffix(filepath.Base(fields[0]), ".exe"); name {
+	case "code", "code-insiders", "codium", "cursor", "windsurf":
+		return editor + " --goto {file}:{line}:{col}"
+	case "goland", "idea", "pycharm", "webstorm", "clion", "rider", "rustrover":
+		return editor + " --line {line} --column {col} {file}"
+	case "subl", "zed", "hx", "helix":
+		return editor + " {file}:{line}:{col}"
+	case "vi", "vim", "nvim", "gvim", "mvim", "emacs", "emacsclient", "nano", "micro", "kak", "joe", "mg":
+		return editor + " +{line} {file}"
+	default:
+		return editor + " {file}"
+	}
+}
+
+// Command builds the command that opens path at line and col from a
+// template. The file is appended if the template has no {file}.
+func Command(template, path string, line, col int) (*exec.Cmd, error) {
+	fields := strings.Fields(template)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no editor configured, set $EDITOR or $%s", TemplateEnv)
+	}
+	col = max(col, 1)
+	replacer := strings.NewReplacer(
+		PlaceholderFile, path,
+		PlaceholderLine, strconv.Itoa(line),
+		PlaceholderCol, strconv.Itoa(col),
+	)
+	args := make([]string, len(fields))
+	for i, field := range fields {
+		args[i] = replacer.Replace(field)
+	}
+	if !strings.Contains(template, PlaceholderFile) {
+		args = append(args, path)
+	}
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+// Resolve turns a file reference from a package's test output into a path.
+// Test failures name files relative to the package directory, build errors
+// relative to where go test ran, and stack traces use absolute paths.
+func Resolve(pkgDir, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	path := filepath.Join(pkgDir, file)
+	if _, err := os.Stat(path); err != nil {
+		if abs, err := filepath.Abs(file); err == nil {
+			if _, err := os.Stat(abs); err == nil {
+				return abs
+			}
+		}
+	}
+	return path
+}
+
+// PackageDir asks go list where a package lives. Build failures are reported
+// under the test variant, e.g. "pkg [pkg.test]", which is trimmed first.
+func PackageDir(pkg string) (string, error) {
+	pkg, _, _ = strings.Cut(pkg, " ")
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}", pkg).Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate package %s: %w", pkg, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
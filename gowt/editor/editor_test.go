@@ -0,0 +1,73 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		editor string
+		want   string
+	}{
+		{"code", "code --goto {file}:{line}:{col}"},
+		{"/usr/local/bin/code --wait", "/usr/local/bin/code --wait --goto {file}:{line}:{col}"},
+		{"goland", "goland --line {line} --column {col} {file}"},
+		{"nvim", "nvim +{line} {file}"},
+		{"subl", "subl {file}:{line}:{col}"},
+		{"ed", "ed {file}"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Template(tt.editor), tt.editor)
+	}
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	t.Setenv(TemplateEnv, "")
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "vim")
+	assert.Equal(t, "vim +{line} {file}", DefaultTemplate())
+
+	t.Setenv("VISUAL", "code")
+	assert.Equal(t, "code --goto {file}:{line}:{col}", DefaultTemplate())
+
+	t.Setenv(TemplateEnv, "myeditor -l {line} {file}")
+	assert.Equal(t, "myeditor -l {line} {file}", DefaultTemplate())
+}
+
+func TestCommand(t *testing.T) {
+	cmd, err := Command("code --goto {file}:{line}:{col}", "/src/foo.go", 42, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"code", "--goto", "/src/foo.go:42:1"}, cmd.Args)
+
+	cmd, err = Command("ed", "/src/foo.go", 42, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ed", "/src/foo.go"}, cmd.Args)
+
+	_, err = Command("  ", "/src/foo.go", 1, 1)
+	assert.Error(t, err)
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	pkgDir := filepath.Join(dir, "pkg")
+	require.NoError(t, os.MkdirAll(pkgDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(pkgDir, "foo_test.go"), nil, 0o644))
+	t.Chdir(dir)
+
+	assert.Equal(t, filepath.Join(pkgDir, "foo_test.go"), Resolve(pkgDir, "foo_test.go"))
+	assert.Equal(t, "/abs/foo.go", Resolve(pkgDir, "/abs/foo.go"))
+
+	// Build errors are relative to where go test ran
+	cwdPath, err := filepath.Abs(filepath.Join("pkg", "foo_test.go"))
+	require.NoError(t, err)
+	assert.Equal(t, cwdPath, Resolve(filepath.Join(dir, "other"), "pkg/foo_test.go"))
+
+	// Missing files stay relative to the package
+	assert.Equal(t, filepath.Join(pkgDir, "gone.go"), Resolve(pkgDir, "gone.go"))
+}
@@ -0,0 +1,59 @@
+// Package editor finds file:line references in test output and opens them
+// in the user's editor.
+package editor
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Location is a file:line reference found in test output
+type Location struct {
+	File    string // As written in the output: a base name, relative or absolute path
+	Line    int
+	Col     int    // 0 if the reference has no column
+	Text    string // The reference as it appears in the output, e.g. "foo_test.go:42"
+	LogLine int    // Line of the output it was found on, 0-indexed
+}
+
+// String returns the reference as file:line
+func (l Location) String() string {
+	return l.File + ":" + strconv.Itoa(l.Line)
+}
+
+// locationPattern matches Go file references: "foo_test.go:42:",
+// "./pkg/foo.go:3:14" and "/abs/path/foo.go:12 +0x1d" in stack traces
+var locationPattern = regexp.MustCompile(`(?:^|[^\w.\-/\\])((?:[A-Za-z]:)?[\w.\-/\\@+~]*\w\.go):(\d+)(?::(\d+))?`)
+
+// Find returns the file:line references in output, in order. A reference
+// repeated on the same line is returned once.
+func Find(output string) []Location {
+	var locations []Location
+	for i, line := range strings.Split(output, "\n") {
+		if !strings.Contains(line, ".go:") {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, m := range locationPattern.FindAllStringSubmatchIndex(line, -1) {
+			file := line[m[2]:m[3]]
+			lineNum, err := strconv.Atoi(line[m[4]:m[5]])
+			if err != nil || lineNum == 0 {
+				continue
+			}
+			col := 0
+			end := m[5]
+			if m[6] >= 0 {
+				col, _ = strconv.Atoi(line[m[6]:m[7]])
+				end = m[7]
+			}
+			text := line[m[2]:end]
+			if seen[text] {
+				continue
+			}
+			seen[text] = true
+			locations = append(locations, Location{File: file, Line: lineNum, Col: col, Text: text, LogLine: i})
+		}
+	}
+	return locations
+}
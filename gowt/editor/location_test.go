@@ -0,0 +1,31 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFind(t *testing.T) {
+	output := "=== RUN   TestFoo\n" +
+		"    foo_test.go:42: got 1, want 2\n" +
+		"panic: boom\n" +
+		"\t/home/me/proj/pkg/foo.go:12 +0x1d\n" +
+		"./pkg/bar.go:3:14: undefined: x (see ./pkg/bar.go:3:14)\n" +
+		"C:\\proj\\baz.go:7 and notgo.txt:3 and foo.go:0\n"
+
+	assert.Equal(t, []Location{
+		{File: "foo_test.go", Line: 42, Text: "foo_test.go:42", LogLine: 1},
+		{File: "/home/me/proj/pkg/foo.go", Line: 12, Text: "/home/me/proj/pkg/foo.go:12", LogLine: 3},
+		{File: "./pkg/bar.go", Line: 3, Col: 14, Text: "./pkg/bar.go:3:14", LogLine: 4},
+		{File: "C:\\proj\\baz.go", Line: 7, Text: "C:\\proj\\baz.go:7", LogLine: 5},
+	}, Find(output))
+}
+
+func TestFind_None(t *testing.T) {
+	assert.Empty(t, Find("--- PASS: TestFoo (0.00s)\nok  \texample.com/foo\t0.1s\n"))
+}
+
+func TestLocationString(t *testing.T) {
+	assert.Equal(t, "foo_test.go:42", Location{File: "foo_test.go", Line: 42, Col: 3}.String())
+}
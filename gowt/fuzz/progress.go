@@ -0,0 +1,133 @@
+// Package fuzz follows the progress lines go test -fuzz prints while it
+// fuzzes, e.g. "fuzz: elapsed: 3s, execs: 325017 (108336/sec), new
+// interesting: 11 (total: 202)", and the crasher file it writes on failure.
+package fuzz
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Phase is what the fuzzing engine is doing
+type Phase int
+
+const (
+	PhaseBaseline   Phase = iota // Running the seed corpus to gather baseline coverage
+	PhaseFuzzing                 // Generating new inputs
+	PhaseMinimizing              // Shrinking a failing or interesting input
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseFuzzing:
+		return "fuzzing"
+	case PhaseMinimizing:
+		return "minimizing"
+	default:
+		return "baseline"
+	}
+}
+
+// Progress is the latest state of one fuzz target
+type Progress struct {
+	Phase          Phase
+	Elapsed        time.Duration
+	Execs          int64 // Inputs run so far
+	ExecsPerSec    int64 // Over the last reporting interval
+	NewInteresting int   // Inputs added to the cache this run
+	Corpus         int   // Total corpus size, seed corpus included
+	Baseline       int   // Seed inputs run while gathering baseline coverage
+	BaselineTotal  int
+	Workers        int
+	Crasher        string // Failing input, e.g. "testdata/fuzz/FuzzFoo/af69258a12129d6c"
+}
+
+const (
+	linePrefix    = "fuzz: "
+	crasherPrefix = "Failing input written to "
+)
+
+// IsOutput reports whether line is printed by the fuzzing engine
+func IsOutput(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, linePrefix) || strings.HasPrefix(line, crasherPrefix)
+}
+
+// Update applies a line of fuzz output to the progress. Returns false if the
+// line is not fuzz output.
+func (p *Progress) Update(line string) bool {
+	line = strings.TrimSpace(line)
+	if file, ok := strings.CutPrefix(line, crasherPrefix); ok {
+		p.Crasher = file
+		return true
+	}
+	rest, ok := strings.CutPrefix(line, linePrefix)
+	if !ok {
+		return false
+	}
+
+	// "minimizing 37-byte failing input file" comes without elapsed time
+	if strings.HasPrefix(rest, "minimizing") {
+		p.Phase = PhaseMinimizing
+		return true
+	}
+
+	for _, part := range strings.Split(rest, ", ") {
+		var a, b int64
+		switch {
+		case strings.HasPrefix(part, "elapsed: "):
+			if d, err := time.ParseDuration(strings.TrimPrefix(part, "elapsed: ")); err == nil {
+				p.Elapsed = d
+			}
+		case strings.HasPrefix(part, "gathering baseline coverage: "):
+			p.Phase = PhaseBaseline
+			if _, err := fmt.Sscanf(part, "gathering baseline coverage: %d/%d", &a, &b); err == nil {
+				p.Baseline, p.BaselineTotal = int(a), int(b)
+			}
+		case strings.HasPrefix(part, "now fuzzing with "):
+			p.Phase = PhaseFuzzing
+			if _, err := fmt.Sscanf(part, "now fuzzing with %d", &a); err == nil {
+				p.Workers = int(a)
+			}
+		case strings.HasPrefix(part, "execs: "):
+			p.Phase = PhaseFuzzing
+			if _, err := fmt.Sscanf(part, "execs: %d (%d/sec)", &a, &b); err == nil {
+				p.Execs, p.ExecsPerSec = a, b
+			}
+		case strings.HasPrefix(part, "new interesting: "):
+			if _, err := fmt.Sscanf(part, "new interesting: %d (total: %d)", &a, &b); err == nil {
+				p.NewInteresting, p.Corpus = int(a), int(b)
+			}
+		case part == "minimizing":
+			p.Phase = PhaseMinimizing
+		}
+	}
+	return true
+}
+
+// RerunTest returns the test name that reruns just the crasher, e.g.
+// "FuzzFoo/af69258a12129d6c", or "" if there is none. go test runs each
+// file in testdata/fuzz/FuzzFoo as a subtest named after the file.
+func (p Progress) RerunTest() string {
+	if p.Crasher == "" {
+		return ""
+	}
+	dir, file := filepath.Split(filepath.Clean(p.Crasher))
+	return filepath.Base(dir) + "/" + file
+}
+
+// FormatCount formats a count compactly, e.g. 108336 as "108.3k"
+func FormatCount(n int64) string {
+	switch {
+	case n >= 1e9:
+		return fmt.Sprintf("%.1fG", float64(n)/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1e4:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
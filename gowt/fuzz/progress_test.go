@@ -0,0 +1,86 @@
+package fuzz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected Progress
+		ok       bool
+	}{
+		{
+			"gathering baseline",
+			[]string{"fuzz: elapsed: 0s, gathering baseline coverage: 3/192 completed\n"},
+			Progress{Phase: PhaseBaseline, Baseline: 3, BaselineTotal: 192},
+			true,
+		},
+		{
+			"baseline done",
+			[]string{"fuzz: elapsed: 0s, gathering baseline coverage: 192/192 completed, now fuzzing with 8 workers\n"},
+			Progress{Phase: PhaseFuzzing, Baseline: 192, BaselineTotal: 192, Workers: 8},
+			true,
+		},
+		{
+			"fuzzing",
+			[]string{
+				"fuzz: elapsed: 0s, gathering baseline coverage: 1/1 completed, now fuzzing with 1 workers\n",
+				"fuzz: elapsed: 1m3s, execs: 325017 (108336/sec), new interesting: 11 (total: 202)\n",
+			},
+			Progress{
+				Phase: PhaseFuzzing, Elapsed: 63 * time.Second, Execs: 325017, ExecsPerSec: 108336,
+				NewInteresting: 11, Corpus: 202, Baseline: 1, BaselineTotal: 1, Workers: 1,
+			},
+			true,
+		},
+		{
+			"minimizing crasher",
+			[]string{
+				"fuzz: minimizing 37-byte failing input file\n",
+				"fuzz: elapsed: 2s, minimizing\n",
+				"    Failing input written to testdata/fuzz/FuzzReverse/a30ef0d92b9eb2d5\n",
+			},
+			Progress{Phase: PhaseMinimizing, Elapsed: 2 * time.Second, Crasher: "testdata/fuzz/FuzzReverse/a30ef0d92b9eb2d5"},
+			true,
+		},
+		{"log line", []string{"    fuzz_test.go:21: fuzz: not progress\n"}, Progress{}, false},
+		{"rerun hint", []string{"    go test -run=FuzzReverse/a30ef0d92b9eb2d5\n"}, Progress{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Progress
+			ok := true
+			for _, line := range tt.lines {
+				ok = p.Update(line) && ok
+			}
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, p)
+		})
+	}
+}
+
+func TestRerunTest(t *testing.T) {
+	assert.Equal(t, "", Progress{}.RerunTest())
+	assert.Equal(t, "FuzzReverse/a30ef0d92b9eb2d5",
+		Progress{Crasher: "testdata/fuzz/FuzzReverse/a30ef0d92b9eb2d5"}.RerunTest())
+}
+
+func TestFormatCount(t *testing.T) {
+	assert.Equal(t, "0", FormatCount(0))
+	assert.Equal(t, "9999", FormatCount(9999))
+	assert.Equal(t, "108.3k", FormatCount(108336))
+	assert.Equal(t, "2.5M", FormatCount(2500000))
+	assert.Equal(t, "1.2G", FormatCount(1200000000))
+}
+
+func TestIsOutput(t *testing.T) {
+	assert.True(t, IsOutput("fuzz: elapsed: 0s, minimizing\n"))
+	assert.True(t, IsOutput("    Failing input written to testdata/fuzz/FuzzFoo/abc\n"))
+	assert.False(t, IsOutput("    fuzz_test.go:21: fuzz: not progress\n"))
+}
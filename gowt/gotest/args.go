@@ -0,0 +1,130 @@
+// Package gotest knows about the go test command line and the package graph
+// behind it: which flags take values, which arguments are package patterns,
+// and which test binaries a changed file ends up in.
+package gotest
+
+import "strings"
+
+// valueFlags are go test and build flags that take a separate value
+// argument, e.g. "-run TestFoo". Boolean flags and "-flag=value" forms need
+// no entry.
+var valueFlags = map[string]bool{
+	"run": true, "skip": true, "bench": true, "benchtime": true, "count": true,
+	"cpu": true, "parallel": true, "timeout": true, "shuffle": true, "list": true,
+	"fuzz": true, "fuzztime": true, "fuzzminimizetime": true,
+	"coverprofile": true, "covermode": true, "coverpkg": true,
+	"cpuprofile": true, "memprofile": true, "memprofilerate": true,
+	"blockprofile": true, "blockprofilerate": true, "mutexprofile": true,
+	"mutexprofilefraction": true, "outputdir": true, "trace": true,
+	"o": true, "p": true, "tags": true, "exec": true, "vet": true,
+	"ldflags": true, "gcflags": true, "asmflags": true, "gccgoflags": true,
+	"mod": true, "modfile": true, "overlay": true, "pkgdir": true,
+	"toolexec": true, "C": true, "pgo": true,
+}
+
+// rerunOmitFlags are the flags left out of a rerun: those choosing what runs,
+// which the rerun's -run replaces, and the profiles, which the rerun would
+// overwrite
+var rerunOmitFlags = map[string]bool{
+	"run": true, "skip": true, "bench": true, "fuzz": true, "list": true,
+	"coverprofile": true, "cpuprofile": true, "memprofile": true,
+	"blockprofile": true, "mutexprofile": true, "trace": true,
+}
+
+// graphFlags are the build flags that change which files and packages a build
+// is made of, so go list must be given them too
+var graphFlags = map[string]bool{
+	"C": true, "tags": true, "mod": true, "modfile": true, "overlay": true,
+	"race": true, "msan": true, "asan": true, "cover": true, "coverpkg": true,
+}
+
+// Args is a go test command line split into its parts
+type Args struct {
+	Flags      []string // go test flags, each followed by its value if separate
+	Patterns   []string // Package patterns, empty means the current directory
+	BinaryArgs []string // "-args" and everything after it, passed to the test binary
+}
+
+// ParseArgs splits go test arguments into flags, package patterns and test
+// binary arguments
+func ParseArgs(args []string) Args {
+	var parsed Args
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name := strings.TrimLeft(arg, "-")
+		switch {
+		case arg == "-args" || arg == "--args":
+			parsed.BinaryArgs = append([]string(nil), args[i:]...)
+			return parsed
+		case !strings.HasPrefix(arg, "-") || arg == "-":
+			parsed.Patterns = append(parsed.Patterns, arg)
+		case !strings.Contains(name, "=") && valueFlags[name] && i+1 < len(args):
+			parsed.Flags = append(parsed.Flags, arg, args[i+1])
+			i++
+		default:
+			parsed.Flags = append(parsed.Flags, arg)
+		}
+	}
+	return parsed
+}
+
+// PatternsOrDefault returns the package patterns, or "." when there are none
+func (a Args) PatternsOrDefault() []string {
+	if len(a.Patterns) == 0 {
+		return []string{"."}
+	}
+	return a.Patterns
+}
+
+// GraphFlags returns the flags among a.Flags that change the package graph,
+// e.g. -tags, for go list to see the packages go test builds. -C comes
+// first, as the go command requires.
+func (a Args) GraphFlags() []string {
+	var dir, flags []string
+	for i := 0; i < len(a.Flags); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(a.Flags[i], "-"), "=")
+		flag := a.Flags[i : i+1]
+		if !hasValue && valueFlags[name] && i+1 < len(a.Flags) {
+			flag = a.Flags[i : i+2]
+			i++
+		}
+		switch {
+		case name == "C":
+			dir = flag
+		case graphFlags[name]:
+			flags = append(flags, flag...)
+		}
+	}
+	return append(dir, flags...)
+}
+
+// Rerun returns the command line that runs the tests matching runPattern in
+// pkg, or all of its tests if runPattern is empty, with the flags of a such as
+// -race, -tags or -count, so the tests run as they did
+func (a Args) Rerun(pkg, runPattern string) []string {
+	var result []string
+	for i := 0; i < len(a.Flags); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(a.Flags[i], "-"), "=")
+		flag := a.Flags[i : i+1]
+		if !hasValue && valueFlags[name] && i+1 < len(a.Flags) {
+			flag = a.Flags[i : i+2]
+			i++
+		}
+		if !rerunOmitFlags[name] {
+			result = append(result, flag...)
+		}
+	}
+	result = append(result, pkg)
+	if runPattern != "" {
+		result = append(result, "-run", runPattern)
+	}
+	return append(result, a.BinaryArgs...)
+}
+
+// WithPackages returns the command line with the package patterns replaced by pkgs
+func (a Args) WithPackages(pkgs []string) []string {
+	result := make([]string, 0, len(a.Flags)+len(pkgs)+len(a.BinaryArgs))
+	result = append(result, a.Flags...)
+	result = append(result, pkgs...)
+	return append(result, a.BinaryArgs...)
+}
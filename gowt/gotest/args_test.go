@@ -0,0 +1,94 @@
+package gotest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected Args
+	}{
+		{"empty", nil, Args{}},
+		{"patterns only", []string{"./...", "./cmd"}, Args{Patterns: []string{"./...", "./cmd"}}},
+		{"boolean flag", []string{"-race", "./..."}, Args{Flags: []string{"-race"}, Patterns: []string{"./..."}}},
+		{"value flag", []string{"-run", "TestFoo", "./pkg"}, Args{Flags: []string{"-run", "TestFoo"}, Patterns: []string{"./pkg"}}},
+		{"flag with equals", []string{"-count=1", "./pkg"}, Args{Flags: []string{"-count=1"}, Patterns: []string{"./pkg"}}},
+		{"double dash flag", []string{"--timeout", "30s"}, Args{Flags: []string{"--timeout", "30s"}}},
+		{
+			"binary args",
+			[]string{"./pkg", "-args", "-update", "./golden"},
+			Args{Patterns: []string{"./pkg"}, BinaryArgs: []string{"-args", "-update", "./golden"}},
+		},
+		{"value flag at end", []string{"-run"}, Args{Flags: []string{"-run"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseArgs(tt.args))
+		})
+	}
+}
+
+func TestArgs_PatternsOrDefault(t *testing.T) {
+	assert.Equal(t, []string{"."}, ParseArgs([]string{"-v"}).PatternsOrDefault())
+	assert.Equal(t, []string{"./..."}, ParseArgs([]string{"-v", "./..."}).PatternsOrDefault())
+}
+
+func TestArgs_WithPackages(t *testing.T) {
+	args := ParseArgs([]string{"-run", "TestFoo", "./...", "-args", "-update"})
+	result := args.WithPackages([]string{"example.com/a", "example.com/b"})
+	assert.Equal(t, []string{"-run", "TestFoo", "example.com/a", "example.com/b", "-args", "-update"}, result)
+}
+
+func TestArgs_GraphFlags(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"-v", "-run", "TestFoo", "./..."}, nil},
+		{[]string{"-tags", "integration", "-count=1", "./..."}, []string{"-tags", "integration"}},
+		{[]string{"-race", "--tags=a,b", "-mod=vendor"}, []string{"-race", "--tags=a,b", "-mod=vendor"}},
+		{[]string{"-tags", "x", "-C", "sub", "-cover"}, []string{"-C", "sub", "-tags", "x", "-cover"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseArgs(tt.args).GraphFlags())
+		})
+	}
+}
+
+func TestArgs_Rerun(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"./..."}, []string{"example.com/m/a", "-run", "^TestFoo$"}},
+		{
+			[]string{"-race", "-tags", "integration", "-count=1", "-timeout", "30s", "-cover", "./..."},
+			[]string{"-race", "-tags", "integration", "-count=1", "-timeout", "30s", "-cover", "example.com/m/a", "-run", "^TestFoo$"},
+		},
+		// The rerun selects the test itself and doesn't overwrite profiles
+		{
+			[]string{"-run", "TestF", "-skip=TestBar", "-bench", ".", "-coverprofile", "c.out", "-v", "./..."},
+			[]string{"-v", "example.com/m/a", "-run", "^TestFoo$"},
+		},
+		{
+			[]string{"-count", "3", "./...", "-args", "-update"},
+			[]string{"-count", "3", "example.com/m/a", "-run", "^TestFoo$", "-args", "-update"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseArgs(tt.args).Rerun("example.com/m/a", "^TestFoo$"))
+		})
+	}
+
+	assert.Equal(t, []string{"-race", "example.com/m/a"}, ParseArgs([]string{"-race"}).Rerun("example.com/m/a", ""))
+}
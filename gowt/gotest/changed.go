@@ -0,0 +1,68 @@
+package gotest
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultBaseRef is compared against when no base ref is given, so only
+// uncommitted changes count
+const DefaultBaseRef = "HEAD"
+
+// IsGitRef reports whether arg names a commit in the repository at dir
+func IsGitRef(dir, arg string) bool {
+	if arg == "" || strings.HasPrefix(arg, "-") {
+		return false
+	}
+	_, err := git(dir, "rev-parse", "--verify", "--quiet", arg+"^{commit}")
+	return err == nil
+}
+
+// ChangedFiles returns the absolute paths of files that differ between the
+// working tree and the merge base of baseRef and HEAD, including untracked
+// files. Like the diff of a pull request, changes made on baseRef after the
+// current branch forked from it do not count.
+func ChangedFiles(dir, baseRef string) ([]string, error) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(top)
+
+	base, err := git(dir, "merge-base", baseRef, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	diff, err := git(dir, "diff", "--name-only", strings.TrimSpace(base), "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(dir, "ls-files", "--others", "--exclude-standard", "--full-name", ":/")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(diff+untracked, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, filepath.Join(root, filepath.FromSlash(line)))
+		}
+	}
+	return files, nil
+}
+
+// git runs a git command in dir and returns its stdout
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(output), nil
+}
@@ -0,0 +1,88 @@
+package gotest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initRepo creates a git repository with one commit holding a.go and b.go
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package m\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.go"), []byte("package m\n"), 0644))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "-c", "user.name=gowt", "-c", "user.email=gowt@example.com", "commit", "-q", "-m", "init")
+	return dir
+}
+
+// runGit runs a git command in dir, failing the test if it fails
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := initRepo(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package m\n\nvar X = 1\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "c.go"), []byte("package sub\n"), 0644))
+
+	// Run from a subdirectory: paths are still resolved from the repository root
+	files, err := ChangedFiles(filepath.Join(dir, "sub"), DefaultBaseRef)
+	require.NoError(t, err)
+
+	root, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{filepath.Join(root, "a.go"), filepath.Join(root, "sub", "c.go")}, files)
+}
+
+func TestChangedFiles_SinceMergeBase(t *testing.T) {
+	dir := initRepo(t)
+	commit := func(msg string) {
+		runGit(t, dir, "-c", "user.name=gowt", "-c", "user.email=gowt@example.com", "commit", "-q", "-am", msg)
+	}
+
+	// main-line moves on after feature forked from it
+	runGit(t, dir, "branch", "-q", "main-line")
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package m\n\nvar X = 1\n"), 0644))
+	commit("feature")
+	runGit(t, dir, "checkout", "-q", "main-line")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.go"), []byte("package m\n\nvar Y = 1\n"), 0644))
+	commit("main")
+	runGit(t, dir, "checkout", "-q", "feature")
+
+	files, err := ChangedFiles(dir, "main-line")
+	require.NoError(t, err)
+
+	root, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "a.go")}, files)
+}
+
+func TestChangedFiles_UnknownRef(t *testing.T) {
+	dir := initRepo(t)
+	_, err := ChangedFiles(dir, "no-such-ref")
+	assert.Error(t, err)
+}
+
+func TestIsGitRef(t *testing.T) {
+	dir := initRepo(t)
+	assert.True(t, IsGitRef(dir, "HEAD"))
+	assert.False(t, IsGitRef(dir, "./..."))
+	assert.False(t, IsGitRef(dir, "-race"))
+	assert.False(t, IsGitRef(dir, "no-such-ref"))
+}
@@ -0,0 +1,147 @@
+package gotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// listedPackage is the part of `go list -json` output the graph needs
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	ForTest    string
+	Deps       []string
+	DepOnly    bool
+}
+
+// Graph records, for each package matched by the patterns, every package its
+// test binary is built from, and for each of those the directory it lives in.
+type Graph struct {
+	dirs     map[string]string          // Package directory -> import path
+	testDeps map[string]map[string]bool // Tested package -> packages in its test binary, itself included
+}
+
+// LoadGraph runs `go list -deps -test -json` in dir for the package patterns
+// of a go test command line, with its build flags that change the graph
+func LoadGraph(dir string, testArgs Args) (*Graph, error) {
+	args := append([]string{"list"}, testArgs.GraphFlags()...)
+	args = append(args, "-e", "-deps", "-test", "-json=ImportPath,Dir,ForTest,Deps,DepOnly")
+	args = append(args, testArgs.PatternsOrDefault()...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseGraph(bytes.NewReader(output))
+}
+
+// ModulePath asks go list for the path of the module a package is in
+func ModulePath(pkg string) (string, error) {
+	out, err := exec.Command("go", "list", "-f", "{{with .Module}}{{.Path}}{{end}}", stripVariant(pkg)).Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot find module of %s: %w", pkg, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// parseGraph reads the JSON stream written by go list. Test variants of a
+// package ("p [p.test]", "p_test [p.test]") carry the dependencies of the test
+// binary of p in Deps, so the graph is built from them.
+func parseGraph(r io.Reader) (*Graph, error) {
+	g := &Graph{
+		dirs:     make(map[string]string),
+		testDeps: make(map[string]map[string]bool),
+	}
+	dec := json.NewDecoder(r)
+	for {
+		var pkg listedPackage
+		if err := dec.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid go list output: %w", err)
+		}
+
+		path := stripVariant(pkg.ImportPath)
+		if pkg.Dir != "" && pkg.ForTest == "" && !strings.HasSuffix(path, ".test") {
+			g.dirs[pkg.Dir] = path
+		}
+		if pkg.ForTest == "" || pkg.DepOnly {
+			continue
+		}
+
+		deps := g.testDeps[pkg.ForTest]
+		if deps == nil {
+			deps = map[string]bool{pkg.ForTest: true}
+			g.testDeps[pkg.ForTest] = deps
+		}
+		deps[path] = true
+		for _, dep := range pkg.Deps {
+			deps[stripVariant(dep)] = true
+		}
+	}
+	return g, nil
+}
+
+// stripVariant removes the " [p.test]" suffix of a test variant import path
+func stripVariant(importPath string) string {
+	if i := strings.Index(importPath, " ["); i != -1 {
+		return importPath[:i]
+	}
+	return importPath
+}
+
+// Tested returns the packages with tests, sorted
+func (g *Graph) Tested() []string {
+	pkgs := make([]string, 0, len(g.testDeps))
+	for pkg := range g.testDeps {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// PackageOf returns the package a file belongs to. Files under a testdata
+// directory belong to the package that holds it.
+func (g *Graph) PackageOf(file string) (string, bool) {
+	dir := filepath.Dir(file)
+	if i := strings.Index(filepath.ToSlash(dir)+"/", "/testdata/"); i != -1 {
+		dir = filepath.FromSlash(filepath.ToSlash(dir)[:i])
+	}
+	pkg, ok := g.dirs[dir]
+	return pkg, ok
+}
+
+// Affected returns the tested packages whose test binary includes a package
+// with a changed file, sorted. A changed go.mod or go.sum affects every
+// tested package.
+func (g *Graph) Affected(files []string) []string {
+	changed := make(map[string]bool)
+	for _, file := range files {
+		if base := filepath.Base(file); base == "go.mod" || base == "go.sum" {
+			return g.Tested()
+		}
+		if pkg, ok := g.PackageOf(file); ok {
+			changed[pkg] = true
+		}
+	}
+
+	var affected []string
+	for _, pkg := range g.Tested() {
+		for dep := range g.testDeps[pkg] {
+			if changed[dep] {
+				affected = append(affected, pkg)
+				break
+			}
+		}
+	}
+	return affected
+}
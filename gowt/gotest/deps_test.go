@@ -0,0 +1,103 @@
+package gotest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listOutput mimics `go list -deps -test -json` for a module where
+// app imports lib, lib has tests and app has external tests.
+const listOutput = `
+{"ImportPath": "fmt", "Dir": "/go/src/fmt", "DepOnly": true}
+{"ImportPath": "example.com/m/lib", "Dir": "/m/lib"}
+{"ImportPath": "example.com/m/app", "Dir": "/m/app", "Deps": ["example.com/m/lib", "fmt"]}
+{"ImportPath": "example.com/m/lib [example.com/m/lib.test]", "Dir": "/m/lib", "ForTest": "example.com/m/lib", "Deps": ["fmt", "testing"]}
+{"ImportPath": "example.com/m/lib.test", "Dir": "/m/lib", "Deps": ["example.com/m/lib [example.com/m/lib.test]"]}
+{"ImportPath": "example.com/m/app_test [example.com/m/app.test]", "Dir": "/m/app", "ForTest": "example.com/m/app", "Deps": ["example.com/m/app", "example.com/m/lib", "fmt"]}
+{"ImportPath": "example.com/m/app.test", "Dir": "/m/app"}
+{"ImportPath": "example.com/m/util", "Dir": "/m/util"}
+`
+
+func loadTestGraph(t *testing.T) *Graph {
+	t.Helper()
+	g, err := parseGraph(strings.NewReader(listOutput))
+	require.NoError(t, err)
+	return g
+}
+
+func TestParseGraph_Tested(t *testing.T) {
+	g := loadTestGraph(t)
+	assert.Equal(t, []string{"example.com/m/app", "example.com/m/lib"}, g.Tested())
+}
+
+func TestParseGraph_InvalidOutput(t *testing.T) {
+	_, err := parseGraph(strings.NewReader(`{"ImportPath": `))
+	assert.Error(t, err)
+}
+
+func TestGraph_PackageOf(t *testing.T) {
+	g := loadTestGraph(t)
+
+	pkg, ok := g.PackageOf("/m/lib/lib.go")
+	assert.True(t, ok)
+	assert.Equal(t, "example.com/m/lib", pkg)
+
+	pkg, ok = g.PackageOf("/m/app/testdata/golden/out.txt")
+	assert.True(t, ok)
+	assert.Equal(t, "example.com/m/app", pkg)
+
+	_, ok = g.PackageOf("/m/other/other.go")
+	assert.False(t, ok)
+}
+
+func TestGraph_Affected(t *testing.T) {
+	g := loadTestGraph(t)
+
+	tests := []struct {
+		name     string
+		files    []string
+		expected []string
+	}{
+		{"dependency changes rerun importers", []string{"/m/lib/lib.go"}, []string{"example.com/m/app", "example.com/m/lib"}},
+		{"leaf package", []string{"/m/app/app.go"}, []string{"example.com/m/app"}},
+		{"testdata", []string{"/m/app/testdata/in.json"}, []string{"example.com/m/app"}},
+		{"untested package", []string{"/m/util/util.go"}, nil},
+		{"unknown directory", []string{"/m/new/new.go"}, nil},
+		{"go.mod reruns everything", []string{"/m/go.mod"}, []string{"example.com/m/app", "example.com/m/lib"}},
+		{"go.sum reruns everything", []string{"/m/go.sum"}, []string{"example.com/m/app", "example.com/m/lib"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, g.Affected(tt.files))
+		})
+	}
+}
+
+func TestLoadGraph_BuildTags(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write("go.mod", "module example.com/m\n\ngo 1.21\n")
+	write("lib/lib.go", "package lib\n")
+	write("app/app_test.go", "//go:build integration\n\npackage app\n\nimport (\n\t\"testing\"\n\n\t_ \"example.com/m/lib\"\n)\n\nfunc TestApp(t *testing.T) {}\n")
+	write("app/app.go", "package app\n")
+	libFile := filepath.Join(dir, "lib", "lib.go")
+
+	// Without the tag, app's test file and its import of lib are not built
+	g, err := LoadGraph(dir, ParseArgs([]string{"./..."}))
+	require.NoError(t, err)
+	assert.NotContains(t, g.Affected([]string{libFile}), "example.com/m/app")
+
+	g, err = LoadGraph(dir, ParseArgs([]string{"-tags", "integration", "./..."}))
+	require.NoError(t, err)
+	assert.Contains(t, g.Affected([]string{libFile}), "example.com/m/app")
+}
@@ -0,0 +1,142 @@
+package history
+
+import (
+	"sort"
+
+	model "github.com/rickchristie/govner/gowt/model"
+)
+
+// Change is how a test differs from the previous run
+type Change int
+
+const (
+	ChangeNone      Change = iota
+	ChangeRegressed        // Passed before, fails now
+	ChangeFixed            // Failed before, passes now
+	ChangeSkipped          // Ran before, skipped now
+	ChangeAdded            // Not in the previous run
+	ChangeRemoved          // Only in the previous run
+	ChangeSlower           // Passed both times, but took much longer
+)
+
+func (c Change) String() string {
+	switch c {
+	case ChangeRegressed:
+		return "newly failing"
+	case ChangeFixed:
+		return "newly passing"
+	case ChangeSkipped:
+		return "newly skipped"
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeSlower:
+		return "slower"
+	default:
+		return "unchanged"
+	}
+}
+
+// A test is much slower if it took SlowerFactor times as long as before,
+// and at least SlowerMinDelta seconds longer, so fast tests don't trip it
+const (
+	SlowerFactor   = 2.0
+	SlowerMinDelta = 0.5
+)
+
+// Entry is a test that changed
+type Entry struct {
+	Path   string // FullPath of the test node
+	Change Change
+	Before float64 // Elapsed seconds in the previous run
+	After  float64 // Elapsed seconds in this run
+}
+
+// Diff is what changed between two runs
+type Diff struct {
+	Entries []Entry          // Sorted by change, then path
+	Changes map[string]Entry // By FullPath, for tests in this run
+}
+
+// Empty reports whether nothing changed
+func (d Diff) Empty() bool {
+	return len(d.Entries) == 0
+}
+
+// Count returns how many tests had the change
+func (d Diff) Count(change Change) int {
+	n := 0
+	for _, e := range d.Entries {
+		if e.Change == change {
+			n++
+		}
+	}
+	return n
+}
+
+// Compare compares the tests of a run with the previous run. Only packages in
+// both runs are compared, so runs that were cut short or left out packages
+// don't report them as added or removed.
+func Compare(prev, cur *model.TestTree) Diff {
+	diff := Diff{Changes: make(map[string]Entry)}
+	add := func(e Entry) {
+		diff.Entries = append(diff.Entries, e)
+		if e.Change != ChangeRemoved {
+			diff.Changes[e.Path] = e
+		}
+	}
+
+	for pkgPath, pkg := range cur.Packages {
+		prevPkg := prev.Packages[pkgPath]
+		if prevPkg == nil {
+			continue
+		}
+		for _, node := range tests(pkg) {
+			before := prev.NodeIndex[node.FullPath]
+			if before == nil {
+				add(Entry{Path: node.FullPath, Change: ChangeAdded, After: node.Elapsed})
+				continue
+			}
+			e := Entry{Path: node.FullPath, Before: before.Elapsed, After: node.Elapsed}
+			switch {
+			case before.Status == model.StatusPassed && node.Status == model.StatusFailed:
+				e.Change = ChangeRegressed
+			case before.Status == model.StatusFailed && node.Status == model.StatusPassed:
+				e.Change = ChangeFixed
+			case before.Status != model.StatusSkipped && node.Status == model.StatusSkipped:
+				e.Change = ChangeSkipped
+			case before.Status == model.StatusPassed && node.Status == model.StatusPassed &&
+				node.Elapsed >= before.Elapsed*SlowerFactor && node.Elapsed-before.Elapsed >= SlowerMinDelta:
+				e.Change = ChangeSlower
+			default:
+				continue
+			}
+			add(e)
+		}
+		for _, node := range tests(prevPkg) {
+			if cur.NodeIndex[node.FullPath] == nil {
+				add(Entry{Path: node.FullPath, Change: ChangeRemoved, Before: node.Elapsed})
+			}
+		}
+	}
+
+	sort.Slice(diff.Entries, func(i, j int) bool {
+		a, b := diff.Entries[i], diff.Entries[j]
+		if a.Change != b.Change {
+			return a.Change < b.Change
+		}
+		return a.Path < b.Path
+	})
+	return diff
+}
+
+// tests returns the tests and subtests under node
+func tests(node *model.TestNode) []*model.TestNode {
+	var nodes []*model.TestNode
+	for _, child := range node.Children {
+		nodes = append(nodes, child)
+		nodes = append(nodes, tests(child)...)
+	}
+	return nodes
+}
@@ -0,0 +1,68 @@
+package history
+
+import (
+	"testing"
+
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tree builds a tree from test results: test name to action and elapsed
+func tree(pkg string, results map[string]model.TestEvent) *model.TestTree {
+	tree := model.NewTestTree()
+	for name, e := range results {
+		tree.ProcessEvent(model.TestEvent{Action: "run", Package: pkg, Test: name})
+		e.Package = pkg
+		e.Test = name
+		tree.ProcessEvent(e)
+	}
+	tree.ProcessEvent(model.TestEvent{Action: "pass", Package: pkg})
+	return tree
+}
+
+func TestCompare(t *testing.T) {
+	prev := tree("example.com/a", map[string]model.TestEvent{
+		"TestBreaks":   {Action: "pass"},
+		"TestFixed":    {Action: "fail"},
+		"TestSkipped":  {Action: "pass"},
+		"TestRemoved":  {Action: "pass"},
+		"TestSlower":   {Action: "pass", Elapsed: 1},
+		"TestJitter":   {Action: "pass", Elapsed: 0.1},
+		"TestSame":     {Action: "pass"},
+		"TestStillBad": {Action: "fail"},
+	})
+	cur := tree("example.com/a", map[string]model.TestEvent{
+		"TestBreaks":   {Action: "fail"},
+		"TestFixed":    {Action: "pass"},
+		"TestSkipped":  {Action: "skip"},
+		"TestAdded":    {Action: "pass"},
+		"TestSlower":   {Action: "pass", Elapsed: 2.5},
+		"TestJitter":   {Action: "pass", Elapsed: 0.3},
+		"TestSame":     {Action: "pass"},
+		"TestStillBad": {Action: "fail"},
+	})
+
+	diff := Compare(prev, cur)
+	assert.Equal(t, []Entry{
+		{Path: "example.com/a/TestBreaks", Change: ChangeRegressed},
+		{Path: "example.com/a/TestFixed", Change: ChangeFixed},
+		{Path: "example.com/a/TestSkipped", Change: ChangeSkipped},
+		{Path: "example.com/a/TestAdded", Change: ChangeAdded},
+		{Path: "example.com/a/TestRemoved", Change: ChangeRemoved},
+		{Path: "example.com/a/TestSlower", Change: ChangeSlower, Before: 1, After: 2.5},
+	}, diff.Entries)
+
+	assert.Equal(t, ChangeRegressed, diff.Changes["example.com/a/TestBreaks"].Change)
+	assert.NotContains(t, diff.Changes, "example.com/a/TestRemoved", "removed tests are not in the tree")
+	assert.Equal(t, 1, diff.Count(ChangeRegressed))
+	assert.False(t, diff.Empty())
+}
+
+func TestCompare_OnlySharedPackages(t *testing.T) {
+	prev := tree("example.com/a", map[string]model.TestEvent{"TestA": {Action: "pass"}})
+	cur := tree("example.com/b", map[string]model.TestEvent{"TestB": {Action: "pass"}})
+
+	diff := Compare(prev, cur)
+	require.True(t, diff.Empty())
+}
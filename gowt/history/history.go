@@ -0,0 +1,132 @@
+// Package history keeps the go test -json events of past runs under
+// .gowt/runs, so a run can be compared with the previous run of the same
+// arguments: which tests broke, got fixed, were skipped, added, removed or
+// got much slower.
+package history
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	model "github.com/rickchristie/govner/gowt/model"
+)
+
+// DefaultDir is where runs are kept, relative to the working directory
+var DefaultDir = filepath.Join(".gowt", "runs")
+
+// Keep is how many runs of the same arguments are kept
+const Keep = 20
+
+const (
+	runExt     = ".json"
+	partialExt = ".partial"
+)
+
+// Key identifies runs of the same go test arguments
+func Key(args []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(args, "\x00")))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Recorder writes the events of a run to a file in the history directory.
+// The file only becomes part of the history when the run completes, so
+// stopped runs don't show up as the previous run.
+type Recorder struct {
+	file *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+	path string // Final path, the file is written next to it until Close
+	err  error  // First write error, reported by Close
+}
+
+// NewRecorder creates a recorder for a run of args, named by the start time
+func NewRecorder(dir string, args []string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	name := Key(args) + "-" + time.Now().UTC().Format("20060102-150405.000") + runExt
+	path := filepath.Join(dir, name)
+	file, err := os.Create(path + partialExt)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(file)
+	return &Recorder{file: file, w: w, enc: json.NewEncoder(w), path: path}, nil
+}
+
+// Record appends an event to the run
+func (r *Recorder) Record(event model.TestEvent) {
+	if r.err == nil {
+		r.err = r.enc.Encode(event)
+	}
+}
+
+// Close completes the run, adding it to the history. Returns its path.
+func (r *Recorder) Close() (string, error) {
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	if err := r.file.Close(); r.err == nil {
+		r.err = err
+	}
+	if r.err != nil {
+		os.Remove(r.file.Name())
+		return "", r.err
+	}
+	return r.path, os.Rename(r.file.Name(), r.path)
+}
+
+// Discard drops an incomplete run
+func (r *Recorder) Discard() {
+	r.file.Close()
+	os.Remove(r.file.Name())
+}
+
+// runs returns the completed runs of args in dir, oldest first
+func runs(dir string, args []string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, Key(args)+"-*"+runExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths) // Names sort by start time
+	return paths, nil
+}
+
+// Previous returns the latest run of args that started before the run at
+// path, or "" if there is none
+func Previous(dir string, args []string, path string) (string, error) {
+	paths, err := runs(dir, args)
+	if err != nil {
+		return "", err
+	}
+	previous := ""
+	for _, p := range paths {
+		if filepath.Base(p) >= filepath.Base(path) {
+			break
+		}
+		previous = p
+	}
+	return previous, nil
+}
+
+// Prune removes all but the latest Keep runs of args
+func Prune(dir string, args []string) error {
+	paths, err := runs(dir, args)
+	if err != nil {
+		return err
+	}
+	for len(paths) > Keep {
+		if err := os.Remove(paths[0]); err != nil {
+			return err
+		}
+		paths = paths[1:]
+	}
+	return nil
+}
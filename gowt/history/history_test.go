@@ -0,0 +1,110 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key([]string{"-race", "./..."}), Key([]string{"-race", "./..."}))
+	assert.NotEqual(t, Key([]string{"-race", "./..."}), Key([]string{"-race ./..."}))
+	assert.Len(t, Key(nil), 12)
+}
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	args := []string{"./..."}
+
+	rec, err := NewRecorder(dir, args)
+	require.NoError(t, err)
+	rec.Record(model.TestEvent{Action: "run", Package: "example.com/a", Test: "TestA"})
+	rec.Record(model.TestEvent{Action: "pass", Package: "example.com/a", Test: "TestA", Elapsed: 0.5})
+
+	// Not part of the history until the run completes
+	previous, err := Previous(dir, args, "zzz")
+	require.NoError(t, err)
+	assert.Empty(t, previous)
+
+	path, err := rec.Close()
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var events []model.TestEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e model.TestEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		events = append(events, e)
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, 0.5, events[1].Elapsed)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "partial file is renamed")
+}
+
+func TestRecorder_Discard(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, nil)
+	require.NoError(t, err)
+	rec.Record(model.TestEvent{Action: "run", Package: "example.com/a", Test: "TestA"})
+	rec.Discard()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// completeRun records an empty run of args, returning its path
+func completeRun(t *testing.T, dir string, args []string) string {
+	rec, err := NewRecorder(dir, args)
+	require.NoError(t, err)
+	path, err := rec.Close()
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond) // Runs are named by start time
+	return path
+}
+
+func TestPrevious(t *testing.T) {
+	dir := t.TempDir()
+	args := []string{"./..."}
+	first := completeRun(t, dir, args)
+	completeRun(t, dir, []string{"./other"})
+	second := completeRun(t, dir, args)
+
+	previous, err := Previous(dir, args, second)
+	require.NoError(t, err)
+	assert.Equal(t, first, previous)
+
+	previous, err = Previous(dir, args, first)
+	require.NoError(t, err)
+	assert.Empty(t, previous)
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	args := []string{"./..."}
+	var paths []string
+	for i := 0; i < Keep+2; i++ {
+		paths = append(paths, completeRun(t, dir, args))
+	}
+	other := completeRun(t, dir, []string{"./other"})
+
+	require.NoError(t, Prune(dir, args))
+	left, err := runs(dir, args)
+	require.NoError(t, err)
+	assert.Equal(t, paths[2:], left)
+	assert.FileExists(t, other)
+}
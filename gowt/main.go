@@ -3,11 +3,22 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rickchristie/govner/gowt/bench"
+	"github.com/rickchristie/govner/gowt/gotest"
+	"github.com/rickchristie/govner/gowt/history"
 	"github.com/rickchristie/govner/gowt/meta"
+	"github.com/rickchristie/govner/gowt/report"
+	"github.com/rickchristie/govner/gowt/watch"
 )
 
+// defaultBenchBaseline is where benchmark results are saved for comparison
+var defaultBenchBaseline = filepath.Join(".gowt", "bench.txt")
+
 func main() {
 	args := os.Args[1:]
 
@@ -23,6 +34,48 @@ func main() {
 		}
 	}
 
+	// Check for --bench-baseline and --report flags, used in both live and load mode
+	baselinePath := defaultBenchBaseline
+	var reports []report.Target
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--report" || strings.HasPrefix(args[i], "--report="):
+			spec, ok := strings.CutPrefix(args[i], "--report=")
+			if !ok {
+				if i+1 >= len(args) {
+					fmt.Fprintf(os.Stderr, "Error: --report requires format=file pairs\n")
+					os.Exit(1)
+				}
+				spec = args[i+1]
+				i++
+			}
+			targets, err := report.ParseSpec(spec)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			reports = append(reports, targets...)
+		case args[i] == "--bench-baseline":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: --bench-baseline requires a file path\n")
+				os.Exit(1)
+			}
+			baselinePath = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--bench-baseline="):
+			baselinePath = strings.TrimPrefix(args[i], "--bench-baseline=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	args = rest
+	baseline, err := loadBenchBaseline(baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Check for --load or -l flag
 	for i, arg := range args {
 		if arg == "--load" || arg == "-l" {
@@ -30,7 +83,7 @@ func main() {
 				fmt.Fprintf(os.Stderr, "Error: --load requires a file path\n")
 				os.Exit(1)
 			}
-			if err := runLoadMode(args[i+1]); err != nil {
+			if err := runLoadMode(args[i+1], baselinePath, baseline, reports); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
@@ -38,19 +91,166 @@ func main() {
 		}
 	}
 
+	// Check for --watch, --changed, coverage and history flags (not passed on to go test)
+	watchMode := false
+	ciMode := false
+	changedMode := false
+	coverMode := false
+	coverPkg := ""
+	keepHistory := true
+	retryFailed := 0
+	editorTemplate := ""
+	baseRef := gotest.DefaultBaseRef
+	var testArgs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--watch":
+			watchMode = true
+		case arg == "--ci":
+			ciMode = true
+		case arg == "--changed":
+			changedMode = true
+			// The base ref is optional, so only take the next argument if git knows it
+			if i+1 < len(args) && gotest.IsGitRef("", args[i+1]) {
+				baseRef = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(arg, "--changed="):
+			changedMode = true
+			baseRef = strings.TrimPrefix(arg, "--changed=")
+		case arg == "--cover":
+			coverMode = true
+		case arg == "--coverpkg":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: --coverpkg requires a package list\n")
+				os.Exit(1)
+			}
+			coverPkg = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--coverpkg="):
+			coverPkg = strings.TrimPrefix(arg, "--coverpkg=")
+		case arg == "--editor":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: --editor requires a command template\n")
+				os.Exit(1)
+			}
+			editorTemplate = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--editor="):
+			editorTemplate = strings.TrimPrefix(arg, "--editor=")
+		case arg == "--no-history":
+			keepHistory = false
+		case arg == "--retry-failed", strings.HasPrefix(arg, "--retry-failed="):
+			value, ok := strings.CutPrefix(arg, "--retry-failed=")
+			if !ok {
+				if i+1 >= len(args) {
+					fmt.Fprintf(os.Stderr, "Error: --retry-failed requires a number of reruns\n")
+					os.Exit(1)
+				}
+				value = args[i+1]
+				i++
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "Error: --retry-failed must be a positive number, got %q\n", value)
+				os.Exit(1)
+			}
+			retryFailed = n
+		default:
+			testArgs = append(testArgs, arg)
+		}
+	}
+
+	if ciMode && watchMode {
+		fmt.Fprintf(os.Stderr, "Error: --watch can't be used with --ci\n")
+		os.Exit(1)
+	}
+
+	skippedPkgs := 0
+	if changedMode {
+		var err error
+		testArgs, skippedPkgs, err = changedPackageArgs(testArgs, baseRef)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if testArgs == nil {
+			fmt.Printf("No packages affected by changes since %s\n", baseRef)
+			return
+		}
+	}
+
+	opts := liveOptions{
+		watch:         watchMode,
+		skippedPkgs:   skippedPkgs,
+		cover:         coverMode,
+		coverPkg:      coverPkg,
+		history:       keepHistory,
+		retryFailed:   retryFailed,
+		editor:        editorTemplate,
+		baselinePath:  baselinePath,
+		benchBaseline: baseline,
+		reports:       reports,
+	}
+
+	// CI mode: run go test headless, for logs
+	if ciMode {
+		os.Exit(runCIMode(testArgs, opts))
+	}
+
 	// Live mode: run go test with TUI
-	exitCode := runLiveMode(args)
-	os.Exit(exitCode)
+	os.Exit(runLiveMode(testArgs, opts))
+}
+
+// changedPackageArgs narrows the packages in args down to those affected by
+// files changed since baseRef. It returns the new arguments and how many
+// packages were left out, or nil arguments if no package is affected.
+func changedPackageArgs(args []string, baseRef string) ([]string, int, error) {
+	files, err := gotest.ChangedFiles("", baseRef)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	parsed := gotest.ParseArgs(args)
+	graph, err := gotest.LoadGraph("", parsed)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	affected := graph.Affected(files)
+	skipped := len(graph.Tested()) - len(affected)
+	if len(affected) == 0 {
+		return nil, skipped, nil
+	}
+	return parsed.WithPackages(affected), skipped, nil
+}
+
+// loadBenchBaseline reads saved benchmark results. A missing file is not an
+// error: there is simply nothing to compare against yet.
+func loadBenchBaseline(path string) (bench.Set, error) {
+	baseline, err := bench.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark baseline: %w", err)
+	}
+	return baseline, nil
 }
 
-// runLoadMode runs the TUI with pre-loaded test results
-func runLoadMode(path string) error {
+// runLoadMode runs the TUI with pre-loaded test results. With reports to
+// write, it writes them instead of starting the TUI.
+func runLoadMode(path, baselinePath string, baseline bench.Set, reports []report.Target) error {
 	tree, err := loadTestResults(path)
 	if err != nil {
 		return err
 	}
+	if len(reports) > 0 {
+		return report.Write(report.New(tree), reports)
+	}
 
-	app := NewApp(tree)
+	app := NewApp(tree).WithBenchBaseline(baselinePath, baseline)
 	p := tea.NewProgram(app, tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
@@ -60,10 +260,54 @@ func runLoadMode(path string) error {
 	return nil
 }
 
+// liveOptions are the gowt flags that shape a live run
+type liveOptions struct {
+	watch       bool   // Rerun affected packages on file changes
+	skippedPkgs int    // Packages left out by --changed
+	cover       bool   // Start with coverage enabled
+	coverPkg    string // -coverpkg value for coverage runs
+	history     bool   // Keep runs under .gowt/runs and mark changes since the previous one
+	retryFailed int    // Reruns of each failed test, to find flaky tests
+	editor      string // Command template to open file:line references
+
+	baselinePath  string    // Where benchmark baselines are saved
+	benchBaseline bench.Set // Saved benchmark results to compare against
+
+	reports []report.Target // Reports to write from the final results
+}
+
 // runLiveMode runs tests with the live TUI
-func runLiveMode(args []string) int {
+func runLiveMode(args []string, opts liveOptions) int {
 	runner := NewRealTestRunner()
-	app := NewLiveApp(args, runner)
+	app := NewLiveApp(args, runner).WithBenchBaseline(opts.baselinePath, opts.benchBaseline)
+	if opts.skippedPkgs > 0 {
+		app = app.WithSkippedPackages(opts.skippedPkgs)
+	}
+
+	// Coverage can be toggled at any time, so always have a profile path ready
+	profilePath := filepath.Join(os.TempDir(), fmt.Sprintf("gowt-%d.cover", os.Getpid()))
+	defer os.Remove(profilePath)
+	app = app.WithCoverage(opts.cover, opts.coverPkg, profilePath)
+
+	if opts.history {
+		app = app.WithHistory(history.DefaultDir)
+	}
+	if opts.retryFailed > 0 {
+		app = app.WithRetries(opts.retryFailed)
+	}
+	app = app.WithEditor(opts.editor)
+
+	if opts.watch {
+		root, err := watch.FindModuleRoot(".")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --watch must be run inside a Go module\n")
+			return 1
+		}
+		w := watch.NewWatcher(root, watch.DefaultInterval, watch.DefaultDebounce)
+		w.Start()
+		defer w.Stop()
+		app = app.WithWatcher(w)
+	}
 	p := tea.NewProgram(app, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -74,6 +318,16 @@ func runLiveMode(args []string) int {
 
 	// Return the exit code from go test
 	if finalApp, ok := finalModel.(App); ok {
+		// Quitting mid-run leaves an incomplete recording
+		if finalApp.recorder != nil {
+			finalApp.recorder.Discard()
+		}
+		if len(opts.reports) > 0 {
+			if err := report.Write(report.New(finalApp.tree), opts.reports); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
 		return finalApp.exitCode
 	}
 	return 0
@@ -84,16 +338,43 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("Usage:")
 	fmt.Println("  gowt [packages]              Run go test with live TUI")
+	fmt.Println("  gowt --watch [packages]      Run tests, then rerun affected packages on save")
+	fmt.Println("  gowt --changed [ref] [pkgs]  Run only packages affected by changes since ref")
+	fmt.Println("  gowt --ci [packages]         Run go test headless, for CI logs")
 	fmt.Println("  gowt --load <file>           Load and view test results from JSON file")
 	fmt.Println()
 	fmt.Println("Flags:")
 	fmt.Println("  --load, -l <file>   Load test results from a JSON file (go test -json output)")
+	fmt.Println("  --watch             Rerun packages affected by changed files")
+	fmt.Println("  --ci                Run without the TUI: package progress, then failures,")
+	fmt.Println("                      slowest tests and totals; exits with go test's code")
+	fmt.Println("  --changed [ref]     Only run packages affected by files changed since ref")
+	fmt.Println("                      (default HEAD, i.e. uncommitted changes)")
+	fmt.Println("  --cover             Collect coverage (toggle with c in the TUI)")
+	fmt.Println("  --coverpkg <pkgs>   Packages to cover, passed to go test -coverpkg")
+	fmt.Println("  --report <format=file,...>")
+	fmt.Println("                      Write the results as junit, html or md reports on exit;")
+	fmt.Println("                      with --load, write them without starting the TUI")
+	fmt.Println("  --retry-failed <n>  Rerun each failed test n times to tell flaky tests apart")
+	fmt.Println("  --editor <template> Command that opens file:line references, e.g.")
+	fmt.Println("                      'code --goto {file}:{line}:{col}' (default $GOWT_EDITOR,")
+	fmt.Println("                      then $VISUAL or $EDITOR)")
+	fmt.Println("  --no-history        Don't keep runs in .gowt/runs or mark changes since the last run")
+	fmt.Println("  --bench-baseline <file>")
+	fmt.Println("                      Benchmark results to compare against (default .gowt/bench.txt)")
 	fmt.Println("  --version, -v       Show version")
 	fmt.Println("  --help, -h          Show this help message")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  gowt ./...                   Run all tests with TUI")
 	fmt.Println("  gowt -v ./pkg/...            Run tests with verbose flag")
+	fmt.Println("  gowt --watch ./...           Watch the module and rerun affected tests")
+	fmt.Println("  gowt --changed main ./...    Test what changed on this branch")
+	fmt.Println("  gowt --cover ./...           Run tests and show coverage per package")
+	fmt.Println("  gowt -run=^$ -bench=. -count=6 ./...")
+	fmt.Println("                               Run benchmarks, press b to compare with the baseline")
 	fmt.Println("  gowt --load results.json     View saved test results")
+	fmt.Println("  gowt --load results.json --report junit=report.xml")
+	fmt.Println("                               Convert saved results to JUnit XML")
 	fmt.Println("  go test -json ./... > results.json && gowt -l results.json")
 }
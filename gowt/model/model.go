@@ -1,12 +1,16 @@
 package model
 
 import (
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
+	"github.com/rickchristie/govner/gowt/bench"
+	"github.com/rickchristie/govner/gowt/fuzz"
+	"github.com/rickchristie/govner/gowt/stack"
 	util "github.com/rickchristie/govner/gowt/util"
 )
 
@@ -34,19 +38,22 @@ type TestEvent struct {
 
 // TestNode represents a node in the test tree (package, subtest, or test)
 type TestNode struct {
-	Name         string      // Short name (e.g., "TestFoo" or "subtest1")
-	FullPath     string      // Full path (e.g., "pkg/foo/TestFoo/subtest1")
-	Package      string      // Package path
-	Status       TestStatus  // Current status
-	Elapsed      float64     // Duration in seconds
-	RawLog       *NodeLog    // Raw log output refs (points to shared RawLogBuffer)
-	ProcessedLog *NodeLog    // Processed log refs (filtered & styled, points to ProcessedLogBuffer)
-	Children     []*TestNode // Child tests/subtests
-	Parent       *TestNode   // Parent node (nil for root packages)
-	Expanded     bool        // UI state: is this node expanded
-	Cached       bool        // Whether this result is from cache
-	Depth        int         // Cached depth in tree (0 for packages, 1+ for tests/subtests)
-	NameWidth    int         // Cached runewidth of Name (0 = not computed yet)
+	Name         string         // Short name (e.g., "TestFoo" or "subtest1")
+	FullPath     string         // Full path (e.g., "pkg/foo/TestFoo/subtest1")
+	Package      string         // Package path
+	Status       TestStatus     // Current status
+	Elapsed      float64        // Duration in seconds
+	RawLog       *NodeLog       // Raw log output refs (points to shared RawLogBuffer)
+	ProcessedLog *NodeLog       // Processed log refs (filtered & styled, points to ProcessedLogBuffer)
+	Children     []*TestNode    // Child tests/subtests
+	Parent       *TestNode      // Parent node (nil for root packages)
+	Expanded     bool           // UI state: is this node expanded
+	Cached       bool           // Whether this result is from cache
+	Depth        int            // Cached depth in tree (0 for packages, 1+ for tests/subtests)
+	NameWidth    int            // Cached runewidth of Name (0 = not computed yet)
+	Bench        []bench.Result // Benchmark results, one per -count run
+	Fuzz         *fuzz.Progress // Fuzzing progress, nil unless run with -fuzz
+	Retries      *Retries       // Reruns after failing, nil unless run with --retry-failed
 
 	// Render cache
 	RenderedName     string // Styled package name (permanent, never changes)
@@ -61,6 +68,50 @@ type TestNode struct {
 	RunningCount int // Count of running tests
 	CachedCount  int // Count of cached tests
 	TotalCount   int // Total test count (excludes packages)
+
+	// Assertion failure block the output is in, which spans several lines
+	assertFormat util.AssertFormatter
+	// Lines of the race report being printed, nil outside of one
+	raceLines []string
+	// Whether the node itself is in RunningCount. Its Status can't tell, as a
+	// failing subtest turns a running parent's Status to failed.
+	running bool
+}
+
+// Retries tallies the reruns of a failed test, telling flaky tests apart
+// from ones that fail consistently
+type Retries struct {
+	Passed  int // Reruns that passed
+	Failed  int // Reruns that failed, or didn't run the test at all
+	Pending int // Reruns still to go
+}
+
+// Record tallies a rerun
+func (r *Retries) Record(passed bool) {
+	if passed {
+		r.Passed++
+	} else {
+		r.Failed++
+	}
+	r.Pending = max(0, r.Pending-1)
+}
+
+// Runs returns how many reruns are done
+func (r *Retries) Runs() int {
+	return r.Passed + r.Failed
+}
+
+// Flaky reports whether the test passed on a rerun
+func (r *Retries) Flaky() bool {
+	return r.Passed > 0
+}
+
+// RaceReport is a data race found by the race detector, with the tests it
+// was reported in. go test attributes a report to whichever test was running
+// when it was printed, so the same race can show up in several tests.
+type RaceReport struct {
+	Race  stack.Race // As first reported
+	Tests []string   // FullPaths of the nodes whose output had it, in order of first report
 }
 
 // TestTree holds the entire test hierarchy
@@ -76,6 +127,14 @@ type TestTree struct {
 	// Key is node FullPath, value is the partial line being accumulated.
 	OutputLineBuffer map[string]string
 
+	// Expansion state of test nodes removed by ResetPackage, by FullPath.
+	// Restored when a later run creates the node again.
+	savedExpanded map[string]bool
+
+	// Data races found in the output, deduplicated, in order of first report
+	Races     []*RaceReport
+	raceIndex map[string]*RaceReport // By stack.Race.Key
+
 	// Global aggregated counts (sum of all packages)
 	PassedCount  int // Count of passed tests
 	FailedCount  int // Count of failed tests
@@ -93,9 +152,58 @@ func NewTestTree() *TestTree {
 		RawLogBuffer:       NewLogBuffer(),
 		ProcessedLogBuffer: NewLogBuffer(),
 		OutputLineBuffer:   make(map[string]string),
+		savedExpanded:      make(map[string]bool),
+		raceIndex:          make(map[string]*RaceReport),
 	}
 }
 
+// ResetPackage clears the results of a package before it is run again, so the
+// new run's events merge into the tree. The package node stays in place with
+// its expansion state; its tests are removed and recreated by the new events,
+// getting back the expansion state they had.
+func (t *TestTree) ResetPackage(pkgPath string) {
+	pkg := t.Packages[pkgPath]
+	if pkg == nil {
+		return
+	}
+
+	// Remove the package's results from the global counts
+	t.PassedCount -= pkg.PassedCount
+	t.FailedCount -= pkg.FailedCount
+	t.SkippedCount -= pkg.SkippedCount
+	t.RunningCount -= pkg.RunningCount
+	t.CachedCount -= pkg.CachedCount
+	t.TotalCount -= pkg.TotalCount
+	t.forgetRaces(pkgPath)
+
+	var forget func(node *TestNode)
+	forget = func(node *TestNode) {
+		for _, child := range node.Children {
+			t.savedExpanded[child.FullPath] = child.Expanded
+			delete(t.NodeIndex, child.FullPath)
+			delete(t.OutputLineBuffer, child.FullPath)
+			forget(child)
+		}
+	}
+	forget(pkg)
+	delete(t.OutputLineBuffer, pkg.FullPath)
+
+	pkg.Status = StatusPending
+	pkg.Elapsed = 0
+	pkg.Cached = false
+	pkg.RawLog = nil
+	pkg.ProcessedLog = nil
+	pkg.raceLines = nil
+	pkg.Children = make([]*TestNode, 0)
+	pkg.PassedCount = 0
+	pkg.FailedCount = 0
+	pkg.SkippedCount = 0
+	pkg.RunningCount = 0
+	pkg.CachedCount = 0
+	pkg.TotalCount = 0
+	pkg.SuffixCacheValid = false
+}
+
 // GetNode returns a node by its full path in O(1) time
 func (t *TestTree) GetNode(fullPath string) *TestNode {
 	return t.NodeIndex[fullPath]
@@ -175,8 +283,8 @@ func (t *TestTree) getOrCreatePackage(pkgPath string) *TestNode {
 }
 
 func (t *TestTree) getOrCreateTest(pkgNode *TestNode, testName string) *TestNode {
-	// Skip invalid test names (must start with "Test")
-	if testName == "" || !strings.HasPrefix(testName, "Test") {
+	// Skip invalid test names (must start with "Test", "Benchmark" or "Fuzz")
+	if testName == "" || !(strings.HasPrefix(testName, "Test") || isBenchmark(testName) || strings.HasPrefix(testName, "Fuzz")) {
 		return nil
 	}
 
@@ -196,8 +304,8 @@ func (t *TestTree) getOrCreateTest(pkgNode *TestNode, testName string) *TestNode
 				Status:    StatusPending,
 				Parent:    current,
 				Children:  make([]*TestNode, 0),
-				Expanded:  false,
-				Depth:     i + 1, // Depth relative to package (TestFoo=1, TestFoo/sub=2, etc.)
+				Expanded:  t.savedExpanded[fullPath], // Kept across reruns, see ResetPackage
+				Depth:     i + 1,                     // Depth relative to package (TestFoo=1, TestFoo/sub=2, etc.)
 			}
 			current.Children = append(current.Children, child)
 			t.NodeIndex[fullPath] = child // Add to index for O(1) lookup
@@ -228,18 +336,26 @@ func (t *TestTree) handlePackageEvent(node *TestNode, event TestEvent) bool {
 		node.Status = StatusPassed
 		node.Elapsed = event.Elapsed
 		node.SuffixCacheValid = false
+		t.finishBenchmarks(node, "pass")
 		return true
 	case "fail":
 		node.Status = StatusFailed
 		node.Elapsed = event.Elapsed
 		node.SuffixCacheValid = false
+		t.finishBenchmarks(node, "fail")
 		return true
 	case "skip":
 		node.Status = StatusSkipped
 		node.SuffixCacheValid = false
 		return true
 	case "output":
-		t.appendOutput(node, event.Output)
+		lines := t.appendOutput(node, event.Output)
+		// Races found after the tests ran, e.g. in TestMain
+		raced := t.recordRaces(node, lines)
+		// With -count > 1, results after the first are package output
+		if t.recordBenchmark(node, lines) || raced {
+			return true
+		}
 		// Detect cached package: format is "ok  \tpackage/path\t(cached)\n"
 		// Use strict matching to avoid false positives from log output
 		if isCachedOutput(event.Output) {
@@ -300,41 +416,31 @@ func (t *TestTree) ComputeAllStats() (passed, failed, skipped, running, cached i
 }
 
 func (t *TestTree) handleTestEvent(node *TestNode, event TestEvent) bool {
-	prevStatus := node.Status
-
 	switch event.Action {
 	case "run":
 		node.Status = StatusRunning
 		node.SuffixCacheValid = false
 		// Pending -> Running: increment running count
-		if prevStatus != StatusRunning {
-			t.propagateCountDelta(node, 1, "running")
-		}
+		t.setRunning(node, true)
 		return true
 	case "pause":
 		node.Status = StatusPending
 		node.SuffixCacheValid = false
 		// Running -> Pending: decrement running count
-		if prevStatus == StatusRunning {
-			t.propagateCountDelta(node, -1, "running")
-		}
+		t.setRunning(node, false)
 		return true
 	case "cont":
 		node.Status = StatusRunning
 		node.SuffixCacheValid = false
 		// Pending -> Running: increment running count
-		if prevStatus != StatusRunning {
-			t.propagateCountDelta(node, 1, "running")
-		}
+		t.setRunning(node, true)
 		return true
 	case "pass":
 		node.Status = StatusPassed
 		node.Elapsed = event.Elapsed
 		node.SuffixCacheValid = false
 		// Decrement running if was running, increment passed
-		if prevStatus == StatusRunning {
-			t.propagateCountDelta(node, -1, "running")
-		}
+		t.setRunning(node, false)
 		t.propagateCountDelta(node, 1, "passed")
 		t.propagateStatus(node)
 		return true
@@ -343,9 +449,7 @@ func (t *TestTree) handleTestEvent(node *TestNode, event TestEvent) bool {
 		node.Elapsed = event.Elapsed
 		node.SuffixCacheValid = false
 		// Decrement running if was running, increment failed
-		if prevStatus == StatusRunning {
-			t.propagateCountDelta(node, -1, "running")
-		}
+		t.setRunning(node, false)
 		t.propagateCountDelta(node, 1, "failed")
 		t.propagateStatus(node)
 		return true
@@ -354,19 +458,212 @@ func (t *TestTree) handleTestEvent(node *TestNode, event TestEvent) bool {
 		node.Elapsed = event.Elapsed
 		node.SuffixCacheValid = false
 		// Decrement running if was running, increment skipped
-		if prevStatus == StatusRunning {
-			t.propagateCountDelta(node, -1, "running")
-		}
+		t.setRunning(node, false)
 		t.propagateCountDelta(node, 1, "skipped")
 		t.propagateStatus(node)
 		return true
 	case "output":
-		t.appendOutput(node, event.Output)
-		return false // Log-only, no visual change
+		lines := t.appendOutput(node, event.Output)
+		fuzzed := t.recordFuzz(node, lines)
+		raced := t.recordRaces(node, lines)
+		return t.recordBenchmark(t.Packages[node.Package], lines) || fuzzed || raced
 	}
 	return false
 }
 
+// setRunning adds the node to the running counts or removes it from them
+func (t *TestTree) setRunning(node *TestNode, running bool) {
+	if node.running == running {
+		return
+	}
+	node.running = running
+	if running {
+		t.propagateCountDelta(node, 1, "running")
+	} else {
+		t.propagateCountDelta(node, -1, "running")
+	}
+}
+
+// isBenchmark reports whether a test name is a benchmark
+func isBenchmark(testName string) bool {
+	return strings.HasPrefix(testName, "Benchmark")
+}
+
+// recordBenchmark stores benchmark result lines on their nodes. go test -json
+// sends no pass event for benchmarks, so a result also marks it passed.
+// Lines must be complete, as a result line is often split across events.
+// Returns true if any line was a result line.
+func (t *TestTree) recordBenchmark(pkgNode *TestNode, lines []string) bool {
+	if pkgNode == nil {
+		return false
+	}
+	recorded := false
+	for _, line := range lines {
+		result, ok := bench.ParseLine(line)
+		if !ok {
+			continue
+		}
+		node := t.getOrCreateTest(pkgNode, result.Name)
+		if node == nil {
+			continue
+		}
+		result.Package = pkgNode.Package
+		node.Bench = append(node.Bench, result)
+		if node.Status == StatusPending || node.Status == StatusRunning {
+			t.handleTestEvent(node, TestEvent{Action: "pass"})
+		}
+		recorded = true
+	}
+	return recorded
+}
+
+// recordFuzz applies fuzzing engine output to the progress of the fuzz
+// target. Returns true if any line was fuzz output.
+func (t *TestTree) recordFuzz(node *TestNode, lines []string) bool {
+	recorded := false
+	for _, line := range lines {
+		if !fuzz.IsOutput(line) {
+			continue
+		}
+		if node.Fuzz == nil {
+			node.Fuzz = &fuzz.Progress{}
+		}
+		node.Fuzz.Update(line)
+		node.SuffixCacheValid = false
+		recorded = true
+	}
+	return recorded
+}
+
+// recordRaces collects the race reports in a node's output as their lines
+// come in, adding each complete report to the tree's races. Returns true if
+// any report was complete.
+func (t *TestTree) recordRaces(node *TestNode, lines []string) bool {
+	recorded := false
+	for _, line := range lines {
+		switch {
+		case line == stack.RaceWarning:
+			node.raceLines = []string{line}
+		case node.raceLines == nil:
+		case line == stack.RaceSeparator:
+			if dump := stack.Parse(strings.Join(node.raceLines, "\n")); dump != nil {
+				for _, race := range dump.Races {
+					t.addRace(node, race)
+				}
+				recorded = true
+			}
+			node.raceLines = nil
+		default:
+			node.raceLines = append(node.raceLines, line)
+		}
+	}
+	return recorded
+}
+
+// addRace adds a race reported in a node's output, or the node to the tests
+// of the same race reported before
+func (t *TestTree) addRace(node *TestNode, race stack.Race) {
+	key := race.Key()
+	report := t.raceIndex[key]
+	if report == nil {
+		report = &RaceReport{Race: race}
+		t.raceIndex[key] = report
+		t.Races = append(t.Races, report)
+	}
+	if !slices.Contains(report.Tests, node.FullPath) {
+		report.Tests = append(report.Tests, node.FullPath)
+	}
+}
+
+// forgetRaces removes a package's nodes from the tests of the races, and the
+// races reported only by them, before the package runs again
+func (t *TestTree) forgetRaces(pkgPath string) {
+	races := t.Races[:0]
+	for _, report := range t.Races {
+		report.Tests = slices.DeleteFunc(report.Tests, func(path string) bool {
+			node := t.NodeIndex[path]
+			return node == nil || node.Package == pkgPath
+		})
+		if len(report.Tests) == 0 {
+			delete(t.raceIndex, report.Race.Key())
+			continue
+		}
+		races = append(races, report)
+	}
+	t.Races = races
+}
+
+// finishBenchmarks settles benchmarks still running when their package ends,
+// e.g. parents of sub-benchmarks, which print no result of their own
+func (t *TestTree) finishBenchmarks(node *TestNode, action string) {
+	for _, child := range node.Children {
+		t.finishBenchmarks(child, action)
+		if child.running && isBenchmark(strings.TrimPrefix(child.FullPath, child.Package+"/")) {
+			t.handleTestEvent(child, TestEvent{Action: action})
+		}
+	}
+}
+
+// Benchmarks returns the nodes with benchmark results, sorted by FullPath
+func (t *TestTree) Benchmarks() []*TestNode {
+	var nodes []*TestNode
+	for _, node := range t.NodeIndex {
+		if len(node.Bench) > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].FullPath < nodes[j].FullPath })
+	return nodes
+}
+
+// FuzzTargets returns the nodes with fuzzing progress, sorted by FullPath
+func (t *TestTree) FuzzTargets() []*TestNode {
+	var nodes []*TestNode
+	for _, node := range t.NodeIndex {
+		if node.Fuzz != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].FullPath < nodes[j].FullPath })
+	return nodes
+}
+
+// FailedTests returns the failed tests to rerun, in tree order: the deepest
+// failing subtests, rather than the tests that failed because of them.
+// Benchmarks are left out, as they can't be rerun with -run.
+func (t *TestTree) FailedTests() []*TestNode {
+	var nodes []*TestNode
+	var walk func(node *TestNode) bool
+	walk = func(node *TestNode) bool {
+		failedChild := false
+		for _, child := range node.Children {
+			if walk(child) {
+				failedChild = true
+			}
+		}
+		failed := node.Status == StatusFailed
+		if failed && !failedChild && node.Parent != nil && !strings.HasPrefix(node.FullPath, node.Package+"/Benchmark") {
+			nodes = append(nodes, node)
+		}
+		return failed
+	}
+	for _, pkg := range t.GetSortedPackages() {
+		walk(pkg)
+	}
+	return nodes
+}
+
+// BenchmarkSet collects the benchmark results of the tree, as saved for comparisons
+func (t *TestTree) BenchmarkSet() bench.Set {
+	set := make(bench.Set)
+	for _, node := range t.Benchmarks() {
+		for _, r := range node.Bench {
+			set.Add(r)
+		}
+	}
+	return set
+}
+
 // Styles for processed log output
 var (
 	logStylePassed  = lipgloss.NewStyle().Foreground(lipgloss.Color("82"))
@@ -404,15 +701,20 @@ func stripAnsi(s string) string {
 	return result.String()
 }
 
-// processOutput transforms raw test output for display:
+// processOutput transforms raw test output of a node for display:
 // - Strips ANSI codes from raw output (prevents bleeding from test frameworks)
 // - Skips === RUN/PAUSE/CONT markers
 // - Styles --- PASS/FAIL/SKIP lines with colored icon, bold name, dim duration
+// - Formats testify failures and go-cmp diffs as fields and colored diffs
 // - Formats JSON lines with syntax highlighting
-func processOutput(output string) string {
+func processOutput(node *TestNode, output string) string {
 	cleaned := stripAnsi(output)
 	trimmed := strings.TrimSpace(cleaned)
 
+	if formatted, ok := node.assertFormat.Format(cleaned); ok {
+		return formatted
+	}
+
 	if strings.HasPrefix(trimmed, "=== RUN") ||
 		strings.HasPrefix(trimmed, "=== PAUSE") ||
 		strings.HasPrefix(trimmed, "=== CONT") {
@@ -499,7 +801,8 @@ func formatTestResult(line, prefix string, iconStyle lipgloss.Style, icon string
 
 // appendOutput appends output to a node and all its ancestors.
 // Handles line reassembly: go test -json can split long lines across multiple Output events.
-func (t *TestTree) appendOutput(node *TestNode, output string) {
+// Returns the complete lines appended, without trailing newlines.
+func (t *TestTree) appendOutput(node *TestNode, output string) []string {
 	// Prepend any buffered partial line from previous chunks
 	if buffered, ok := t.OutputLineBuffer[node.FullPath]; ok {
 		output = buffered + output
@@ -542,7 +845,7 @@ func (t *TestTree) appendOutput(node *TestNode, output string) {
 		node.RawLog.Append(rawRef)
 
 		// Process output for display (filter and style)
-		processed := processOutput(lineWithNewline)
+		processed := processOutput(node, lineWithNewline)
 		var processedRef BufferRef
 		if processed != "" {
 			processedRef = t.ProcessedLogBuffer.Append(processed)
@@ -603,6 +906,7 @@ func (t *TestTree) appendOutput(node *TestNode, output string) {
 			}
 		}
 	}
+	return lines
 }
 
 // propagateCountDelta adds delta to a count field on node and all ancestors, plus tree global
@@ -756,6 +1060,20 @@ func (n *TestNode) GetFullOutput(buffer *LogBuffer) string {
 	return sb.String()
 }
 
+// GetProcessedOutput returns the processed (filtered & styled) output
+// concatenated from the shared buffer
+func (n *TestNode) GetProcessedOutput(buffer *LogBuffer) string {
+	if n.ProcessedLog == nil || n.ProcessedLog.IsEmpty() {
+		return ""
+	}
+	var sb strings.Builder
+	sb.Grow(n.ProcessedLog.TotalSize())
+	for _, ref := range n.ProcessedLog.Refs {
+		sb.Write(buffer.SliceBytes(ref))
+	}
+	return sb.String()
+}
+
 // CountByStatus returns pre-computed counts for this node's subtree (O(1) operation)
 // Counts are updated incrementally as events are processed
 func (n *TestNode) CountByStatus() (passed, failed, skipped, total int) {
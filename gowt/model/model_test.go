@@ -0,0 +1,486 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rickchristie/govner/gowt/fuzz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replay feeds go test -json output to the tree, one event per line
+func replay(t *testing.T, tree *TestTree, output string) {
+	t.Helper()
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var event TestEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event), line)
+		tree.ProcessEvent(event)
+	}
+}
+
+// totals returns the global counts of the tree
+func totals(tree *TestTree) [6]int {
+	return [6]int{tree.PassedCount, tree.FailedCount, tree.SkippedCount, tree.RunningCount, tree.CachedCount, tree.TotalCount}
+}
+
+const mixedOutput = `{"Action":"start","Package":"example.com/mt"}
+{"Action":"run","Package":"example.com/mt","Test":"TestPass"}
+{"Action":"output","Package":"example.com/mt","Test":"TestPass","Output":"=== RUN   TestPass\n"}
+{"Action":"output","Package":"example.com/mt","Test":"TestPass","Output":"--- PASS: TestPass (0.00s)\n"}
+{"Action":"pass","Package":"example.com/mt","Test":"TestPass","Elapsed":0}
+{"Action":"run","Package":"example.com/mt","Test":"TestFail"}
+{"Action":"output","Package":"example.com/mt","Test":"TestFail","Output":"=== RUN   TestFail\n"}
+{"Action":"run","Package":"example.com/mt","Test":"TestFail/ok"}
+{"Action":"output","Package":"example.com/mt","Test":"TestFail/ok","Output":"=== RUN   TestFail/ok\n"}
+{"Action":"output","Package":"example.com/mt","Test":"TestFail/ok","Output":"--- PASS: TestFail/ok (0.00s)\n"}
+{"Action":"pass","Package":"example.com/mt","Test":"TestFail/ok","Elapsed":0}
+{"Action":"run","Package":"example.com/mt","Test":"TestFail/bad"}
+{"Action":"output","Package":"example.com/mt","Test":"TestFail/bad","Output":"=== RUN   TestFail/bad\n"}
+{"Action":"output","Package":"example.com/mt","Test":"TestFail/bad","Output":"    a_test.go:9: boom\n"}
+{"Action":"output","Package":"example.com/mt","Test":"TestFail/bad","Output":"--- FAIL: TestFail/bad (0.00s)\n"}
+{"Action":"fail","Package":"example.com/mt","Test":"TestFail/bad","Elapsed":0}
+{"Action":"output","Package":"example.com/mt","Test":"TestFail","Output":"--- FAIL: TestFail (0.00s)\n"}
+{"Action":"fail","Package":"example.com/mt","Test":"TestFail","Elapsed":0}
+{"Action":"run","Package":"example.com/mt","Test":"TestSkip"}
+{"Action":"output","Package":"example.com/mt","Test":"TestSkip","Output":"=== RUN   TestSkip\n"}
+{"Action":"output","Package":"example.com/mt","Test":"TestSkip","Output":"    a_test.go:12: later\n"}
+{"Action":"output","Package":"example.com/mt","Test":"TestSkip","Output":"--- SKIP: TestSkip (0.00s)\n"}
+{"Action":"skip","Package":"example.com/mt","Test":"TestSkip","Elapsed":0}
+{"Action":"output","Package":"example.com/mt","Output":"FAIL\n"}
+{"Action":"output","Package":"example.com/mt","Output":"FAIL\texample.com/mt\t0.002s\n"}
+{"Action":"fail","Package":"example.com/mt","Elapsed":0.002}`
+
+const otherOutput = `{"Action":"start","Package":"example.com/other"}
+{"Action":"run","Package":"example.com/other","Test":"TestOther"}
+{"Action":"output","Package":"example.com/other","Test":"TestOther","Output":"=== RUN   TestOther\n"}
+{"Action":"output","Package":"example.com/other","Test":"TestOther","Output":"--- PASS: TestOther (0.00s)\n"}
+{"Action":"pass","Package":"example.com/other","Test":"TestOther","Elapsed":0}
+{"Action":"output","Package":"example.com/other","Output":"PASS\n"}
+{"Action":"output","Package":"example.com/other","Output":"ok  \texample.com/other\t0.002s\n"}
+{"Action":"pass","Package":"example.com/other","Elapsed":0.002}`
+
+func TestProcessEvent_Counts(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, mixedOutput)
+
+	// Passed: TestPass, TestFail/ok. Failed: TestFail, TestFail/bad
+	assert.Equal(t, [6]int{2, 2, 1, 0, 0, 5}, totals(tree))
+	pkg := tree.Packages["example.com/mt"]
+	assert.Equal(t, StatusFailed, pkg.Status)
+	assert.Equal(t, StatusFailed, tree.GetNode("example.com/mt/TestFail").Status)
+	assert.Equal(t, StatusPassed, tree.GetNode("example.com/mt/TestFail/ok").Status)
+}
+
+func TestResetPackage_RerunKeepsTotalsAndExpansion(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, mixedOutput)
+	replay(t, tree, otherOutput)
+	before := totals(tree)
+
+	pkg := tree.Packages["example.com/mt"]
+	pkg.Expanded = true
+	tree.GetNode("example.com/mt/TestFail").Expanded = true
+
+	tree.ResetPackage("example.com/mt")
+
+	// Only the other package's results are left
+	assert.Equal(t, [6]int{1, 0, 0, 0, 0, 1}, totals(tree))
+	assert.Same(t, pkg, tree.Packages["example.com/mt"])
+	assert.Equal(t, StatusPending, pkg.Status)
+	assert.Empty(t, pkg.Children)
+	assert.Nil(t, tree.GetNode("example.com/mt/TestFail"))
+	assert.NotNil(t, tree.GetNode("example.com/other/TestOther"))
+
+	replay(t, tree, mixedOutput)
+
+	assert.Equal(t, before, totals(tree))
+	assert.Equal(t, [6]int{2, 2, 1, 0, 0, 5},
+		[6]int{pkg.PassedCount, pkg.FailedCount, pkg.SkippedCount, pkg.RunningCount, pkg.CachedCount, pkg.TotalCount})
+	assert.True(t, pkg.Expanded)
+	assert.True(t, tree.GetNode("example.com/mt/TestFail").Expanded)
+	assert.False(t, tree.GetNode("example.com/mt/TestPass").Expanded)
+	assert.Equal(t, StatusFailed, pkg.Status)
+}
+
+func TestResetPackage_Unknown(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, otherOutput)
+
+	tree.ResetPackage("example.com/missing")
+
+	assert.Equal(t, [6]int{1, 0, 0, 0, 0, 1}, totals(tree))
+	assert.Len(t, tree.Packages, 1)
+}
+
+// benchOutput is go test -json -bench . -benchmem -count 2 output: the
+// results after the first run are package output, and a result line can be
+// split across events
+const benchOutput = `{"Action":"start","Package":"example.com/bt"}
+{"Action":"output","Package":"example.com/bt","Output":"goos: linux\n"}
+{"Action":"output","Package":"example.com/bt","Output":"goarch: amd64\n"}
+{"Action":"output","Package":"example.com/bt","Output":"pkg: example.com/bt\n"}
+{"Action":"output","Package":"example.com/bt","Output":"cpu: Intel(R) Xeon(R) Processor\n"}
+{"Action":"run","Package":"example.com/bt","Test":"BenchmarkJoin"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkJoin","Output":"=== RUN   BenchmarkJoin\n"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkJoin","Output":"BenchmarkJoin\n"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkJoin","Output":"BenchmarkJoin  \t     100\t       188.0 ns/op\t       8 B/op\t       1 allocs/op\n"}
+{"Action":"output","Package":"example.com/bt","Output":"BenchmarkJoin  \t     100\t       123.2 ns/op\t       8 B/op\t       1 allocs/op\n"}
+{"Action":"run","Package":"example.com/bt","Test":"BenchmarkSizes"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkSizes","Output":"=== RUN   BenchmarkSizes\n"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkSizes","Output":"BenchmarkSizes\n"}
+{"Action":"run","Package":"example.com/bt","Test":"BenchmarkSizes/n=10"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkSizes/n=10","Output":"=== RUN   BenchmarkSizes/n=10\n"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkSizes/n=10","Output":"BenchmarkSizes/n=10\n"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkSizes/n=10","Output":"BenchmarkSizes/n=10         \t     100\t        67.84 ns/op\t      16 B/op\t       1 allocs/op\n"}
+{"Action":"output","Package":"example.com/bt","Output":"BenchmarkSizes/n=10         \t     100\t        64.56 ns/op\t      16 B/op\t       1 allocs/op\n"}
+{"Action":"run","Package":"example.com/bt","Test":"BenchmarkSizes/n=100"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkSizes/n=100","Output":"=== RUN   BenchmarkSizes/n=100\n"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkSizes/n=100","Output":"BenchmarkSizes/n=100\n"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkSizes/n=100","Output":"BenchmarkSizes/n=100        \t"}
+{"Action":"output","Package":"example.com/bt","Test":"BenchmarkSizes/n=100","Output":"     100\t       422.7 ns/op\t     112 B/op\t       1 allocs/op\n"}
+{"Action":"output","Package":"example.com/bt","Output":"BenchmarkSizes/n=100        \t"}
+{"Action":"output","Package":"example.com/bt","Output":"     100\t       115.5 ns/op\t     112 B/op\t       1 allocs/op\n"}
+{"Action":"output","Package":"example.com/bt","Output":"PASS\n"}
+{"Action":"output","Package":"example.com/bt","Output":"ok  \texample.com/bt\t0.007s\n"}
+{"Action":"pass","Package":"example.com/bt","Elapsed":0.007}`
+
+func TestProcessEvent_Benchmarks(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, benchOutput)
+
+	var names []string
+	for _, node := range tree.Benchmarks() {
+		names = append(names, node.FullPath)
+		assert.Equal(t, StatusPassed, node.Status, node.FullPath)
+		require.Len(t, node.Bench, 2, node.FullPath)
+		for _, r := range node.Bench {
+			assert.Equal(t, "example.com/bt", r.Package)
+			assert.Equal(t, 100, r.Iterations)
+		}
+	}
+	assert.Equal(t, []string{
+		"example.com/bt/BenchmarkJoin",
+		"example.com/bt/BenchmarkSizes/n=10",
+		"example.com/bt/BenchmarkSizes/n=100",
+	}, names)
+
+	joined := tree.GetNode("example.com/bt/BenchmarkSizes/n=100").Bench
+	assert.Equal(t, "BenchmarkSizes/n=100", joined[1].Name)
+	ns, ok := joined[1].Value("ns/op")
+	assert.True(t, ok)
+	assert.Equal(t, 115.5, ns)
+
+	// The parent prints no result and is settled when the package passes
+	parent := tree.GetNode("example.com/bt/BenchmarkSizes")
+	assert.Empty(t, parent.Bench)
+	assert.Equal(t, StatusPassed, parent.Status)
+	assert.Equal(t, [6]int{4, 0, 0, 0, 0, 4}, totals(tree))
+
+	set := tree.BenchmarkSet()
+	assert.Len(t, set["example.com/bt.BenchmarkJoin"], 2)
+	assert.Len(t, set["example.com/bt.BenchmarkSizes/n=10"], 2)
+}
+
+// benchCrashOutput is a -bench run whose sub-benchmark panics, trimmed of the
+// goroutine dump. The benchmarks get no fail events.
+const benchCrashOutput = `{"Action":"start","Package":"example.com/bf"}
+{"Action":"output","Package":"example.com/bf","Output":"pkg: example.com/bf\n"}
+{"Action":"run","Package":"example.com/bf","Test":"BenchmarkCrash"}
+{"Action":"output","Package":"example.com/bf","Test":"BenchmarkCrash","Output":"=== RUN   BenchmarkCrash\n"}
+{"Action":"output","Package":"example.com/bf","Test":"BenchmarkCrash","Output":"BenchmarkCrash\n"}
+{"Action":"run","Package":"example.com/bf","Test":"BenchmarkCrash/case"}
+{"Action":"output","Package":"example.com/bf","Test":"BenchmarkCrash/case","Output":"=== RUN   BenchmarkCrash/case\n"}
+{"Action":"output","Package":"example.com/bf","Test":"BenchmarkCrash/case","Output":"BenchmarkCrash/case\n"}
+{"Action":"output","Package":"example.com/bf","Test":"BenchmarkCrash/case","Output":"panic: crash\n"}
+{"Action":"output","Package":"example.com/bf","Test":"BenchmarkCrash/case","Output":"exit status 2\n"}
+{"Action":"output","Package":"example.com/bf","Output":"FAIL\texample.com/bf\t0.005s\n"}
+{"Action":"fail","Package":"example.com/bf","Elapsed":0.006}`
+
+func TestProcessEvent_BenchmarksFailWithPackage(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, benchCrashOutput)
+
+	assert.Equal(t, StatusFailed, tree.GetNode("example.com/bf/BenchmarkCrash").Status)
+	assert.Equal(t, StatusFailed, tree.GetNode("example.com/bf/BenchmarkCrash/case").Status)
+	assert.Empty(t, tree.Benchmarks())
+	assert.Equal(t, [6]int{0, 2, 0, 0, 0, 2}, totals(tree))
+}
+
+// fuzzOutput is go test -json -fuzz FuzzOK -fuzztime 4s -parallel 2 output
+const fuzzOutput = `{"Action":"start","Package":"example.com/fz"}
+{"Action":"run","Package":"example.com/fz","Test":"FuzzOK"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzOK","Output":"=== RUN   FuzzOK\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzOK","Output":"fuzz: elapsed: 0s, gathering baseline coverage: 0/1 completed\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzOK","Output":"fuzz: elapsed: 0s, gathering baseline coverage: 1/1 completed, now fuzzing with 2 workers\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzOK","Output":"fuzz: elapsed: 3s, execs: 95821 (31898/sec), new interesting: 0 (total: 1)\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzOK","Output":"fuzz: elapsed: 4s, execs: 128438 (29756/sec), new interesting: 0 (total: 1)\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzOK","Output":"--- PASS: FuzzOK (4.10s)\n"}
+{"Action":"pass","Package":"example.com/fz","Test":"FuzzOK","Elapsed":4.1}
+{"Action":"output","Package":"example.com/fz","Output":"PASS\n"}
+{"Action":"output","Package":"example.com/fz","Output":"ok  \texample.com/fz\t4.104s\n"}
+{"Action":"pass","Package":"example.com/fz","Elapsed":4.105}`
+
+func TestProcessEvent_Fuzz(t *testing.T) {
+	tree := NewTestTree()
+	lines := strings.Split(fuzzOutput, "\n")
+
+	// While gathering baseline coverage
+	replay(t, tree, strings.Join(lines[:4], "\n"))
+	node := tree.GetNode("example.com/fz/FuzzOK")
+	require.NotNil(t, node.Fuzz)
+	assert.Equal(t, fuzz.PhaseBaseline, node.Fuzz.Phase)
+	assert.Equal(t, 1, node.Fuzz.BaselineTotal)
+
+	replay(t, tree, strings.Join(lines[4:], "\n"))
+	assert.Equal(t, fuzz.Progress{
+		Phase:         fuzz.PhaseFuzzing,
+		Elapsed:       4 * time.Second,
+		Execs:         128438,
+		ExecsPerSec:   29756,
+		Corpus:        1,
+		Baseline:      1,
+		BaselineTotal: 1,
+		Workers:       2,
+	}, *node.Fuzz)
+	assert.Equal(t, StatusPassed, node.Status)
+	assert.Equal(t, []*TestNode{node}, tree.FuzzTargets())
+}
+
+// fuzzFailOutput is a -fuzz run that finds a failing input
+const fuzzFailOutput = `{"Action":"start","Package":"example.com/fz"}
+{"Action":"run","Package":"example.com/fz","Test":"FuzzParse"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"=== RUN   FuzzParse\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"fuzz: elapsed: 0s, gathering baseline coverage: 0/1 completed\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"fuzz: elapsed: 0s, gathering baseline coverage: 1/1 completed, now fuzzing with 2 workers\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"fuzz: minimizing 31-byte failing input file\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"fuzz: elapsed: 1s, minimizing\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"--- FAIL: FuzzParse (0.53s)\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"    --- FAIL: FuzzParse (0.00s)\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"        f_test.go:9: bad input \"xy0\"\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"    \n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"    Failing input written to testdata/fuzz/FuzzParse/21ef68f14f653d73\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"    To re-run:\n"}
+{"Action":"output","Package":"example.com/fz","Test":"FuzzParse","Output":"    go test -run=FuzzParse/21ef68f14f653d73\n"}
+{"Action":"fail","Package":"example.com/fz","Test":"FuzzParse","Elapsed":0.53}
+{"Action":"output","Package":"example.com/fz","Output":"FAIL\n"}
+{"Action":"output","Package":"example.com/fz","Output":"exit status 1\n"}
+{"Action":"output","Package":"example.com/fz","Output":"FAIL\texample.com/fz\t0.535s\n"}
+{"Action":"fail","Package":"example.com/fz","Elapsed":0.536}`
+
+func TestProcessEvent_FuzzFailure(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, fuzzFailOutput)
+
+	node := tree.GetNode("example.com/fz/FuzzParse")
+	require.NotNil(t, node.Fuzz)
+	assert.Equal(t, fuzz.PhaseMinimizing, node.Fuzz.Phase)
+	assert.Equal(t, "testdata/fuzz/FuzzParse/21ef68f14f653d73", node.Fuzz.Crasher)
+	assert.Equal(t, "FuzzParse/21ef68f14f653d73", node.Fuzz.RerunTest())
+	assert.Equal(t, StatusFailed, node.Status)
+	assert.Empty(t, node.Children)
+	assert.Equal(t, []*TestNode{node}, tree.FailedTests())
+}
+
+func TestFailedTests_DeepestFailures(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, mixedOutput)
+	replay(t, tree, otherOutput)
+	replay(t, tree, benchCrashOutput)
+
+	// TestFail failed only because of TestFail/bad, and benchmarks can't be
+	// rerun with -run
+	assert.Equal(t, []*TestNode{tree.GetNode("example.com/mt/TestFail/bad")}, tree.FailedTests())
+}
+
+func TestFailedTests_NoneAfterPassingRerun(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, mixedOutput)
+	require.Len(t, tree.FailedTests(), 1)
+
+	tree.ResetPackage("example.com/mt")
+	replay(t, tree, strings.ReplaceAll(strings.ReplaceAll(mixedOutput, `"fail"`, `"pass"`), "FAIL", "PASS"))
+	assert.Empty(t, tree.FailedTests())
+}
+
+func TestRetries(t *testing.T) {
+	r := &Retries{Pending: 3}
+	assert.Equal(t, 0, r.Runs())
+	assert.False(t, r.Flaky())
+
+	r.Record(false)
+	assert.Equal(t, Retries{Failed: 1, Pending: 2}, *r)
+	assert.False(t, r.Flaky())
+
+	r.Record(true)
+	r.Record(false)
+	assert.Equal(t, Retries{Passed: 1, Failed: 2}, *r)
+	assert.Equal(t, 3, r.Runs())
+	assert.True(t, r.Flaky())
+
+	// A rerun recorded after the queue was cancelled doesn't go negative
+	r.Record(true)
+	assert.Equal(t, 0, r.Pending)
+	assert.Equal(t, 4, r.Runs())
+}
+
+// raceOutput is a -race run trimmed of where the goroutines were created.
+// TestA and TestB report the same race in shared code, the other way round;
+// TestC reports a race of its own.
+const raceOutput = `{"Action":"start","Package":"example.com/rc"}
+{"Action":"run","Package":"example.com/rc","Test":"TestA"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"==================\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"WARNING: DATA RACE\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"Read at 0x00c000012345 by goroutine 8:\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"  example.com/shared.Get()\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"      /tmp/shared/shared.go:12 +0x3c\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"Previous write at 0x00c000012345 by goroutine 7:\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"  runtime.mapassign_fast64()\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"      /usr/local/go/src/internal/runtime/maps/runtime_fast64.go:182 +0x0\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"  example.com/shared.Set()\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"      /tmp/shared/shared.go:16 +0x44\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"==================\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"    testing.go:1865: race detected during execution of test\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"--- FAIL: TestA (0.00s)\n"}
+{"Action":"fail","Package":"example.com/rc","Test":"TestA","Elapsed":0}
+{"Action":"run","Package":"example.com/rc","Test":"TestB"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"=== RUN   TestB\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"==================\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"WARNING: DATA RACE\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"Write at 0x00c000012345 by goroutine 9:\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"  runtime.mapassign_fast64()\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"      /usr/local/go/src/internal/runtime/maps/runtime_fast64.go:182 +0x0\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"  example.com/shared.Set()\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"      /tmp/shared/shared.go:16 +0x44\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"Previous read at 0x00c000012345 by goroutine 7:\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"  example.com/shared.Get()\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"      /tmp/shared/shared.go:12 +0x3c\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"==================\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"    testing.go:1865: race detected during execution of test\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"--- FAIL: TestB (0.00s)\n"}
+{"Action":"fail","Package":"example.com/rc","Test":"TestB","Elapsed":0}
+{"Action":"run","Package":"example.com/rc","Test":"TestC"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"=== RUN   TestC\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"==================\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"WARNING: DATA RACE\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"Write at 0x000000834528 by goroutine 8:\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"  example.com/rc.bump()\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"      /tmp/rc/rc_test.go:20 +0x74\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"Previous write at 0x000000834528 by goroutine 9:\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"  example.com/rc.bump()\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"      /tmp/rc/rc_test.go:20 +0x8c\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"==================\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"    testing.go:1865: race detected during execution of test\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"--- FAIL: TestC (0.00s)\n"}
+{"Action":"fail","Package":"example.com/rc","Test":"TestC","Elapsed":0}
+{"Action":"output","Package":"example.com/rc","Output":"FAIL\n"}
+{"Action":"output","Package":"example.com/rc","Output":"FAIL\texample.com/rc\t0.010s\n"}
+{"Action":"fail","Package":"example.com/rc","Elapsed":0.01}`
+
+// raceFreeOutput is the package run again after the races were fixed
+const raceFreeOutput = `{"Action":"start","Package":"example.com/rc"}
+{"Action":"run","Package":"example.com/rc","Test":"TestA"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestA","Output":"--- PASS: TestA (0.00s)\n"}
+{"Action":"pass","Package":"example.com/rc","Test":"TestA","Elapsed":0}
+{"Action":"run","Package":"example.com/rc","Test":"TestB"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"=== RUN   TestB\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestB","Output":"--- PASS: TestB (0.00s)\n"}
+{"Action":"pass","Package":"example.com/rc","Test":"TestB","Elapsed":0}
+{"Action":"run","Package":"example.com/rc","Test":"TestC"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"=== RUN   TestC\n"}
+{"Action":"output","Package":"example.com/rc","Test":"TestC","Output":"--- PASS: TestC (0.00s)\n"}
+{"Action":"pass","Package":"example.com/rc","Test":"TestC","Elapsed":0}
+{"Action":"output","Package":"example.com/rc","Output":"PASS\n"}
+{"Action":"output","Package":"example.com/rc","Output":"ok  \texample.com/rc\t0.010s\n"}
+{"Action":"pass","Package":"example.com/rc","Elapsed":0.01}`
+
+// sharedRaceOutput is another package reporting TestA's race
+const sharedRaceOutput = `{"Action":"start","Package":"example.com/rc2"}
+{"Action":"run","Package":"example.com/rc2","Test":"TestD"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"=== RUN   TestD\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"==================\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"WARNING: DATA RACE\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"Read at 0x00c000012345 by goroutine 8:\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"  example.com/shared.Get()\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"      /tmp/shared/shared.go:12 +0x3c\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"Previous write at 0x00c000012345 by goroutine 7:\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"  runtime.mapassign_fast64()\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"      /usr/local/go/src/internal/runtime/maps/runtime_fast64.go:182 +0x0\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"  example.com/shared.Set()\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"      /tmp/shared/shared.go:16 +0x44\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"==================\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"    testing.go:1865: race detected during execution of test\n"}
+{"Action":"output","Package":"example.com/rc2","Test":"TestD","Output":"--- FAIL: TestD (0.00s)\n"}
+{"Action":"fail","Package":"example.com/rc2","Test":"TestD","Elapsed":0}
+{"Action":"output","Package":"example.com/rc2","Output":"FAIL\n"}
+{"Action":"output","Package":"example.com/rc2","Output":"FAIL\texample.com/rc2\t0.010s\n"}
+{"Action":"fail","Package":"example.com/rc2","Elapsed":0.01}`
+
+// raceTests returns the tests of each race in the tree
+func raceTests(tree *TestTree) [][]string {
+	var tests [][]string
+	for _, report := range tree.Races {
+		tests = append(tests, report.Tests)
+	}
+	return tests
+}
+
+func TestProcessEvent_Races(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, raceOutput)
+
+	assert.Equal(t, [][]string{
+		{"example.com/rc/TestA", "example.com/rc/TestB"},
+		{"example.com/rc/TestC"},
+	}, raceTests(tree))
+	// The race is kept as first reported
+	assert.Equal(t, "Read", tree.Races[0].Race.Stacks[0].Access())
+	assert.Equal(t, [6]int{0, 3, 0, 0, 0, 3}, totals(tree))
+}
+
+func TestProcessEvent_RacesRerun(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, raceOutput)
+
+	// The same races again don't add reports or tests
+	tree.ResetPackage("example.com/rc")
+	replay(t, tree, raceOutput)
+	assert.Equal(t, [][]string{
+		{"example.com/rc/TestA", "example.com/rc/TestB"},
+		{"example.com/rc/TestC"},
+	}, raceTests(tree))
+
+	tree.ResetPackage("example.com/rc")
+	replay(t, tree, raceFreeOutput)
+	assert.Empty(t, tree.Races)
+	assert.Equal(t, [6]int{3, 0, 0, 0, 0, 3}, totals(tree))
+}
+
+func TestResetPackage_ForgetsRaces(t *testing.T) {
+	tree := NewTestTree()
+	replay(t, tree, raceOutput)
+	replay(t, tree, sharedRaceOutput)
+	assert.Equal(t, [][]string{
+		{"example.com/rc/TestA", "example.com/rc/TestB", "example.com/rc2/TestD"},
+		{"example.com/rc/TestC"},
+	}, raceTests(tree))
+
+	// The shared race stays for the other package, TestC's goes
+	tree.ResetPackage("example.com/rc")
+	assert.Equal(t, [][]string{{"example.com/rc2/TestD"}}, raceTests(tree))
+
+	// Reported again, the races get the package's tests back
+	replay(t, tree, raceOutput)
+	assert.Equal(t, [][]string{
+		{"example.com/rc2/TestD", "example.com/rc/TestA", "example.com/rc/TestB"},
+		{"example.com/rc/TestC"},
+	}, raceTests(tree))
+}
@@ -0,0 +1,78 @@
+package report
+
+import (
+	"html/template"
+	"io"
+
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/rickchristie/govner/gowt/util"
+)
+
+// htmlTemplate is a single self-contained page: failed packages and tests
+// start expanded, everything else collapsed
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": util.FormatDuration,
+	"icon":     statusIcon,
+	"failed":   func(s model.TestStatus) bool { return s == model.StatusFailed },
+	"skipped":  func(s model.TestStatus) bool { return s == model.StatusSkipped },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Test results</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+h1 { font-size: 1.5em; }
+.totals span { margin-right: 1.5em; font-weight: bold; }
+.pass { color: #1a7f37; } .fail { color: #cf222e; } .skip { color: #6e7781; } .flaky { color: #bc4c00; }
+details { margin: 0.25em 0; }
+details details { margin-left: 1.5em; }
+summary { cursor: pointer; }
+.meta { color: #6e7781; font-size: 0.9em; margin-left: 0.5em; }
+pre { background: #f6f8fa; padding: 0.75em; overflow-x: auto; font-size: 0.85em; margin: 0.25em 0 0.5em 1.5em; }
+.case { margin-left: 1.5em; }
+</style>
+</head>
+<body>
+<h1>Test results</h1>
+<p class="totals">
+<span class="pass">✓ {{.Passed}} passed</span>
+<span class="fail">✗ {{.Failed}} failed</span>
+<span class="skip">⊘ {{.Skipped}} skipped</span>
+{{if .Flaky}}<span class="flaky">◐ {{len .Flaky}} flaky</span>{{end}}
+<span>{{.Total}} tests in {{len .Packages}} packages, {{duration .Elapsed}}</span>
+</p>
+<p class="meta">Generated {{.Generated.Format "2006-01-02 15:04:05 MST"}}</p>
+{{if .Flaky}}
+<h2>Flaky tests</h2>
+<ul>
+{{range .Flaky}}<li><b>{{.Package}}</b> {{.Name}} <span class="meta">passed {{.Passed}} of {{.Runs}} reruns</span></li>
+{{end}}</ul>
+{{end}}
+{{range .Packages}}
+<details{{if or (failed .Status) .Failed}} open{{end}}>
+<summary><span class="{{.Status}}">{{icon .Status}}</span> <b>{{.Name}}</b>
+<span class="meta">{{.Passed}} passed, {{.Failed}} failed, {{.Skipped}} skipped, {{duration .Elapsed}}{{if .Cached}} (cached){{end}}</span></summary>
+{{if .Output}}<pre>{{.Output}}</pre>{{end}}
+{{range .Cases}}
+{{if .Output}}
+<details class="case"{{if failed .Status}} open{{end}}>
+<summary><span class="{{.Status}}">{{icon .Status}}</span> {{.Name}}
+<span class="meta">{{duration .Elapsed}}{{if .Cached}} (cached){{end}}{{if and (skipped .Status) .SkipReason}} · {{.SkipReason}}{{end}}{{with .RetrySummary}} · {{.}}{{end}}</span></summary>
+<pre>{{.Output}}</pre>
+</details>
+{{else}}
+<div class="case"><span class="{{.Status}}">{{icon .Status}}</span> {{.Name}}
+<span class="meta">{{duration .Elapsed}}{{if .Cached}} (cached){{end}}{{if .SkipReason}} · {{.SkipReason}}{{end}}</span></div>
+{{end}}
+{{end}}
+</details>
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as a standalone HTML page
+func WriteHTML(w io.Writer, r Report) error {
+	return htmlTemplate.Execute(w, r)
+}
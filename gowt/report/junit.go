@@ -0,0 +1,140 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	model "github.com/rickchristie/govner/gowt/model"
+)
+
+// JUnit XML, in the dialect Jenkins, GitLab and GitHub reporters all read
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",cdata"`
+}
+
+// WriteJUnit writes the report as JUnit XML: packages as suites, tests and
+// subtests as cases
+func WriteJUnit(w io.Writer, r Report) error {
+	suites := junitSuites{
+		Tests:    r.Total,
+		Failures: r.Failed,
+		Skipped:  r.Skipped,
+		Time:     junitTime(r.Elapsed),
+	}
+	for _, pkg := range r.Packages {
+		suite := junitSuite{
+			Name:      pkg.Name,
+			Tests:     len(pkg.Cases),
+			Failures:  pkg.Failed,
+			Skipped:   pkg.Skipped,
+			Time:      junitTime(pkg.Elapsed),
+			Timestamp: r.Generated.UTC().Format("2006-01-02T15:04:05"),
+			Properties: []junitProperty{
+				{Name: "go.cached", Value: strconv.FormatBool(pkg.Cached)},
+			},
+		}
+		for _, c := range pkg.Cases {
+			jc := junitCase{Classname: pkg.Name, Name: c.Name, Time: junitTime(c.Elapsed)}
+			switch c.Status {
+			case model.StatusFailed:
+				jc.Failure = &junitMessage{Message: "Failed", Body: xmlText(c.Output)}
+				if retries := c.RetrySummary(); retries != "" {
+					jc.Failure.Message += " (" + retries + ")"
+				}
+			case model.StatusSkipped:
+				jc.Skipped = &junitMessage{Message: firstLine(c.SkipReason), Body: xmlText(c.Output)}
+			}
+			suite.Cases = append(suite.Cases, jc)
+		}
+		// A package can fail without a failing test, e.g. when it doesn't build
+		if pkg.Output != "" {
+			suite.Tests++
+			suite.Errors++
+			suites.Tests++
+			suites.Errors++
+			suite.Cases = append(suite.Cases, junitCase{
+				Classname: pkg.Name,
+				Name:      "[package failed]",
+				Time:      junitTime(0),
+				Error:     &junitMessage{Message: firstLine(pkg.Output), Body: xmlText(pkg.Output)},
+			})
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitTime formats seconds the way JUnit readers expect
+func junitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+// xmlText replaces characters XML can't hold, e.g. control characters in
+// test output, which CDATA sections don't escape
+func xmlText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			return r
+		case r < 0x20, r >= 0xD800 && r <= 0xDFFF, r == 0xFFFE, r == 0xFFFF:
+			return '\uFFFD'
+		}
+		return r
+	}, s)
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
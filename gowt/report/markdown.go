@@ -0,0 +1,121 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/rickchristie/govner/gowt/util"
+)
+
+// WriteMarkdown writes the report as a Markdown summary, e.g. for a pull
+// request comment: totals, a row per package, then failure output in
+// collapsible blocks and skip reasons
+func WriteMarkdown(w io.Writer, r Report) error {
+	var sb strings.Builder
+
+	icon := "✅"
+	if r.Failed > 0 || hasPackageErrors(r) {
+		icon = "❌"
+	}
+	fmt.Fprintf(&sb, "## %s Test results\n\n", icon)
+	fmt.Fprintf(&sb, "**%d passed**, **%d failed**, **%d skipped** of %d tests in %d packages (%s)\n\n",
+		r.Passed, r.Failed, r.Skipped, r.Total, len(r.Packages), util.FormatDuration(r.Elapsed))
+
+	sb.WriteString("| | Package | Passed | Failed | Skipped | Time |\n")
+	sb.WriteString("|---|---|---:|---:|---:|---:|\n")
+	for _, pkg := range r.Packages {
+		elapsed := util.FormatDuration(pkg.Elapsed)
+		if pkg.Cached {
+			elapsed += " (cached)"
+		}
+		fmt.Fprintf(&sb, "| %s | `%s` | %d | %d | %d | %s |\n",
+			statusIcon(pkg.Status), pkg.Name, pkg.Passed, pkg.Failed, pkg.Skipped, elapsed)
+	}
+
+	// Flaky tests, which failed but passed on a rerun
+	if len(r.Flaky) > 0 {
+		sb.WriteString("\n### Flaky\n\n")
+		for _, f := range r.Flaky {
+			fmt.Fprintf(&sb, "- `%s` %s: passed %d of %d reruns\n", f.Package, f.Name, f.Passed, f.Runs)
+		}
+	}
+
+	// Failures, with output
+	var failures strings.Builder
+	for _, pkg := range r.Packages {
+		if pkg.Output != "" {
+			writeDetails(&failures, fmt.Sprintf("`%s`", pkg.Name), pkg.Output)
+		}
+		for _, c := range pkg.Cases {
+			if c.Status == model.StatusFailed {
+				summary := fmt.Sprintf("`%s` %s (%s)", pkg.Name, c.Name, util.FormatDuration(c.Elapsed))
+				if retries := c.RetrySummary(); retries != "" {
+					summary = fmt.Sprintf("`%s` %s (%s, %s)", pkg.Name, c.Name, util.FormatDuration(c.Elapsed), retries)
+				}
+				writeDetails(&failures, summary, c.Output)
+			}
+		}
+	}
+	if failures.Len() > 0 {
+		sb.WriteString("\n### Failures\n\n")
+		sb.WriteString(failures.String())
+	}
+
+	// Skipped tests, with reasons
+	var skipped strings.Builder
+	for _, pkg := range r.Packages {
+		for _, c := range pkg.Cases {
+			if c.Status != model.StatusSkipped {
+				continue
+			}
+			fmt.Fprintf(&skipped, "- `%s` %s", pkg.Name, c.Name)
+			if c.SkipReason != "" {
+				fmt.Fprintf(&skipped, ": %s", strings.ReplaceAll(c.SkipReason, "\n", " "))
+			}
+			skipped.WriteString("\n")
+		}
+	}
+	if skipped.Len() > 0 {
+		sb.WriteString("\n### Skipped\n\n")
+		sb.WriteString(skipped.String())
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeDetails writes output in a collapsed block, fenced so Markdown in test
+// output isn't rendered
+func writeDetails(sb *strings.Builder, summary, output string) {
+	fence := "```"
+	for strings.Contains(output, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(sb, "<details><summary>%s</summary>\n\n%s\n%s\n%s\n\n</details>\n\n",
+		summary, fence, strings.TrimRight(output, "\n"), fence)
+}
+
+func statusIcon(status model.TestStatus) string {
+	switch status {
+	case model.StatusPassed:
+		return "✓"
+	case model.StatusFailed:
+		return "✗"
+	case model.StatusSkipped:
+		return "⊘"
+	default:
+		return "○"
+	}
+}
+
+// hasPackageErrors reports whether a package failed outside its tests
+func hasPackageErrors(r Report) bool {
+	for _, pkg := range r.Packages {
+		if pkg.Output != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+// Package report exports the final state of a test run as JUnit XML for CI
+// systems, or as HTML and Markdown summaries for people.
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	model "github.com/rickchristie/govner/gowt/model"
+)
+
+// Format is a report file format
+type Format string
+
+const (
+	FormatJUnit    Format = "junit"
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "md"
+)
+
+// Target is a report to write: a format and the file to write it to
+type Target struct {
+	Format Format
+	Path   string
+}
+
+// ParseSpec parses a --report value, e.g. "junit=out.xml,html=out.html,md=summary.md"
+func ParseSpec(spec string) ([]Target, error) {
+	var targets []Target
+	for _, part := range strings.Split(spec, ",") {
+		format, path, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid report %q, expected format=file", part)
+		}
+		switch f := Format(format); f {
+		case FormatJUnit, FormatHTML, FormatMarkdown:
+			targets = append(targets, Target{Format: f, Path: path})
+		default:
+			return nil, fmt.Errorf("unknown report format %q, expected junit, html or md", format)
+		}
+	}
+	return targets, nil
+}
+
+// Report is the final state of a run, flattened for rendering
+type Report struct {
+	Packages  []Package
+	Passed    int
+	Failed    int
+	Skipped   int
+	Total     int
+	Elapsed   float64 // Seconds
+	Generated time.Time
+	Flaky     []Flake // Failed tests that passed on a rerun
+}
+
+// Flake is a test that failed, then passed on a rerun
+type Flake struct {
+	Package string
+	Name    string
+	Passed  int // Reruns that passed
+	Runs    int // Reruns in all
+}
+
+// Package is a tested package: a suite in JUnit terms
+type Package struct {
+	Name    string
+	Status  model.TestStatus
+	Elapsed float64
+	Cached  bool
+	Output  string // Package output, set when the package failed outside its tests
+	Cases   []Case
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+// Case is a test or subtest
+type Case struct {
+	Name       string // Without the package, e.g. "TestFoo/sub"
+	Status     model.TestStatus
+	Elapsed    float64
+	Cached     bool
+	Output     string // Raw output of failed and skipped tests, subtests included
+	SkipReason string
+	Retries    *model.Retries // Reruns after failing, nil if not rerun
+}
+
+// New flattens the tree into a report. Tests still running or pending when
+// the run ended count as skipped.
+func New(tree *model.TestTree) Report {
+	r := Report{Elapsed: tree.Elapsed, Generated: time.Now()}
+	sumElapsed := 0.0
+	for _, pkgNode := range tree.GetSortedPackages() {
+		if pkgNode.Name == "" {
+			continue
+		}
+		pkg := Package{
+			Name:    pkgNode.Package,
+			Status:  pkgNode.Status,
+			Elapsed: pkgNode.Elapsed,
+			Cached:  pkgNode.Cached,
+		}
+		for _, node := range descendants(pkgNode) {
+			c := Case{
+				Name:    strings.TrimPrefix(node.FullPath, node.Package+"/"),
+				Status:  node.Status,
+				Elapsed: node.Elapsed,
+				Cached:  node.Cached,
+				Retries: node.Retries,
+			}
+			if node.Retries != nil && node.Retries.Flaky() {
+				r.Flaky = append(r.Flaky, Flake{
+					Package: pkg.Name,
+					Name:    c.Name,
+					Passed:  node.Retries.Passed,
+					Runs:    node.Retries.Runs(),
+				})
+			}
+			switch node.Status {
+			case model.StatusPassed:
+				pkg.Passed++
+			case model.StatusFailed:
+				pkg.Failed++
+				c.Output = output(tree, node)
+			case model.StatusSkipped:
+				pkg.Skipped++
+				c.Output = output(tree, node)
+				c.SkipReason = skipReason(c.Output)
+			default:
+				pkg.Skipped++
+				c.Status = model.StatusSkipped
+				c.SkipReason = "did not finish"
+			}
+			pkg.Cases = append(pkg.Cases, c)
+		}
+		// Build failures and panics outside tests only show in package output
+		if pkg.Status == model.StatusFailed && pkg.Failed == 0 {
+			pkg.Output = output(tree, pkgNode)
+		}
+		r.Packages = append(r.Packages, pkg)
+		r.Passed += pkg.Passed
+		r.Failed += pkg.Failed
+		r.Skipped += pkg.Skipped
+		r.Total += len(pkg.Cases)
+		sumElapsed += pkg.Elapsed
+	}
+	// Loaded results have no wall time, so use the time spent in packages
+	if r.Elapsed == 0 {
+		r.Elapsed = sumElapsed
+	}
+	return r
+}
+
+// RetrySummary describes the reruns of a failed case, or returns "" if it
+// wasn't rerun
+func (c Case) RetrySummary() string {
+	switch {
+	case c.Retries == nil || c.Retries.Runs() == 0:
+		return ""
+	case c.Retries.Flaky():
+		return fmt.Sprintf("flaky, passed %d of %d reruns", c.Retries.Passed, c.Retries.Runs())
+	default:
+		return fmt.Sprintf("failed %d of %d reruns", c.Retries.Failed, c.Retries.Runs())
+	}
+}
+
+// Write renders the report in every target's format
+func Write(r Report, targets []Target) error {
+	for _, target := range targets {
+		if err := writeFile(target.Path, func(w io.Writer) error {
+			switch target.Format {
+			case FormatJUnit:
+				return WriteJUnit(w, r)
+			case FormatHTML:
+				return WriteHTML(w, r)
+			default:
+				return WriteMarkdown(w, r)
+			}
+		}); err != nil {
+			return fmt.Errorf("failed to write %s report: %w", target.Format, err)
+		}
+	}
+	return nil
+}
+
+// writeFile creates path and its directory and writes to it with render
+func writeFile(path string, render func(io.Writer) error) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := render(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// descendants returns the tests and subtests under node, depth first in run order
+func descendants(node *model.TestNode) []*model.TestNode {
+	var nodes []*model.TestNode
+	for _, child := range node.Children {
+		nodes = append(nodes, child)
+		nodes = append(nodes, descendants(child)...)
+	}
+	return nodes
+}
+
+// output returns the node's raw output without the === RUN/PAUSE/CONT markers
+// and ANSI escapes
+func output(tree *model.TestTree, node *model.TestNode) string {
+	var lines []string
+	for _, line := range strings.SplitAfter(node.GetFullOutput(tree.RawLogBuffer), "\n") {
+		if line == "" {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "=== RUN") || strings.HasPrefix(trimmed, "=== PAUSE") ||
+			strings.HasPrefix(trimmed, "=== CONT") || strings.HasPrefix(trimmed, "=== NAME") {
+			continue
+		}
+		lines = append(lines, stripAnsi(line))
+	}
+	return strings.Join(lines, "")
+}
+
+// skipReason picks the t.Skip message out of a skipped test's output: the
+// lines before its "--- SKIP" line
+func skipReason(output string) string {
+	var reason []string
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--- SKIP") {
+			break
+		}
+		if trimmed != "" {
+			reason = append(reason, trimmed)
+		}
+	}
+	return strings.Join(reason, "\n")
+}
+
+// stripAnsi removes ANSI escape sequences from a string
+func stripAnsi(s string) string {
+	var result strings.Builder
+	inEscape := false
+
+	for _, r := range s {
+		if r == '\x1b' {
+			inEscape = true
+			continue
+		}
+		if inEscape {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				inEscape = false
+			}
+			continue
+		}
+		result.WriteRune(r)
+	}
+
+	return result.String()
+}
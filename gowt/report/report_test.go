@@ -0,0 +1,208 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTree builds a tree with a failing and a cached package, and one that
+// doesn't build. go test reports the build failure under the test binary's
+// import path, so that shows as a package of its own.
+func testTree() *model.TestTree {
+	events := []model.TestEvent{
+		{Action: "run", Package: "example.com/a", Test: "TestOK"},
+		{Action: "output", Package: "example.com/a", Test: "TestOK", Output: "=== RUN   TestOK\n"},
+		{Action: "output", Package: "example.com/a", Test: "TestOK", Output: "--- PASS: TestOK (0.01s)\n"},
+		{Action: "pass", Package: "example.com/a", Test: "TestOK", Elapsed: 0.01},
+		{Action: "run", Package: "example.com/a", Test: "TestBad"},
+		{Action: "run", Package: "example.com/a", Test: "TestBad/sub"},
+		{Action: "output", Package: "example.com/a", Test: "TestBad/sub", Output: "=== RUN   TestBad/sub\n"},
+		{Action: "output", Package: "example.com/a", Test: "TestBad/sub", Output: "    a_test.go:12: got 1, want \x1b[1m2\x1b[0m <b>\n"},
+		{Action: "output", Package: "example.com/a", Test: "TestBad/sub", Output: "--- FAIL: TestBad/sub (0.20s)\n"},
+		{Action: "fail", Package: "example.com/a", Test: "TestBad/sub", Elapsed: 0.2},
+		{Action: "fail", Package: "example.com/a", Test: "TestBad", Elapsed: 0.2},
+		{Action: "run", Package: "example.com/a", Test: "TestLater"},
+		{Action: "output", Package: "example.com/a", Test: "TestLater", Output: "=== RUN   TestLater\n"},
+		{Action: "output", Package: "example.com/a", Test: "TestLater", Output: "    a_test.go:20: needs a database\n"},
+		{Action: "output", Package: "example.com/a", Test: "TestLater", Output: "--- SKIP: TestLater (0.00s)\n"},
+		{Action: "skip", Package: "example.com/a", Test: "TestLater"},
+		{Action: "fail", Package: "example.com/a", Elapsed: 0.5},
+		{Action: "run", Package: "example.com/b", Test: "TestCached"},
+		{Action: "pass", Package: "example.com/b", Test: "TestCached"},
+		{Action: "output", Package: "example.com/b", Output: "ok  \texample.com/b\t(cached)\n"},
+		{Action: "pass", Package: "example.com/b"},
+		{Action: "build-output", ImportPath: "example.com/c [example.com/c.test]", Output: "c/c.go:3:1: syntax error\n"},
+		{Action: "build-fail", ImportPath: "example.com/c [example.com/c.test]"},
+		{Action: "output", Package: "example.com/c", Output: "FAIL\texample.com/c [build failed]\n"},
+		{Action: "fail", Package: "example.com/c"},
+	}
+	tree := model.NewTestTree()
+	for _, e := range events {
+		tree.ProcessEvent(e)
+	}
+	return tree
+}
+
+func TestParseSpec(t *testing.T) {
+	targets, err := ParseSpec("junit=out.xml,html=out.html, md=summary.md")
+	require.NoError(t, err)
+	assert.Equal(t, []Target{
+		{FormatJUnit, "out.xml"},
+		{FormatHTML, "out.html"},
+		{FormatMarkdown, "summary.md"},
+	}, targets)
+
+	_, err = ParseSpec("junit")
+	assert.Error(t, err)
+	_, err = ParseSpec("junit=")
+	assert.Error(t, err)
+	_, err = ParseSpec("pdf=out.pdf")
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	r := New(testTree())
+
+	assert.Equal(t, 2, r.Passed)
+	assert.Equal(t, 2, r.Failed)
+	assert.Equal(t, 1, r.Skipped)
+	assert.Equal(t, 5, r.Total)
+	require.Len(t, r.Packages, 4)
+
+	a := r.Packages[0]
+	assert.Equal(t, "example.com/a", a.Name)
+	assert.Equal(t, model.StatusFailed, a.Status)
+	assert.Empty(t, a.Output, "failures are reported on the tests")
+	var names []string
+	for _, c := range a.Cases {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"TestOK", "TestBad", "TestBad/sub", "TestLater"}, names)
+	assert.Equal(t, "    a_test.go:12: got 1, want 2 <b>\n--- FAIL: TestBad/sub (0.20s)\n", a.Cases[2].Output)
+	assert.Equal(t, "a_test.go:20: needs a database", a.Cases[3].SkipReason)
+
+	b := r.Packages[1]
+	assert.True(t, b.Cached)
+	assert.True(t, b.Cases[0].Cached)
+
+	c := r.Packages[2]
+	assert.Equal(t, model.StatusFailed, c.Status)
+	assert.Empty(t, c.Cases)
+	assert.Contains(t, c.Output, "[build failed]")
+	assert.Contains(t, r.Packages[3].Output, "syntax error")
+}
+
+func TestNew_Unfinished(t *testing.T) {
+	tree := model.NewTestTree()
+	tree.ProcessEvent(model.TestEvent{Action: "run", Package: "example.com/a", Test: "TestSlow"})
+
+	r := New(tree)
+	require.Len(t, r.Packages, 1)
+	assert.Equal(t, model.StatusSkipped, r.Packages[0].Cases[0].Status)
+	assert.Equal(t, "did not finish", r.Packages[0].Cases[0].SkipReason)
+	assert.Equal(t, 1, r.Skipped)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, New(testTree())))
+
+	var suites junitSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, 7, suites.Tests)
+	assert.Equal(t, 2, suites.Failures)
+	assert.Equal(t, 2, suites.Errors)
+	assert.Equal(t, 1, suites.Skipped)
+	require.Len(t, suites.Suites, 4)
+
+	a := suites.Suites[0]
+	assert.Equal(t, "example.com/a", a.Name)
+	assert.Equal(t, "0.500", a.Time)
+	require.Len(t, a.Cases, 4)
+	assert.Nil(t, a.Cases[0].Failure)
+	assert.Equal(t, "0.200", a.Cases[2].Time)
+	require.NotNil(t, a.Cases[2].Failure)
+	assert.Contains(t, a.Cases[2].Failure.Body, "got 1, want 2 <b>")
+	require.NotNil(t, a.Cases[3].Skipped)
+	assert.Equal(t, "a_test.go:20: needs a database", a.Cases[3].Skipped.Message)
+
+	assert.Equal(t, []junitProperty{{"go.cached", "true"}}, suites.Suites[1].Properties)
+
+	c := suites.Suites[3]
+	require.Len(t, c.Cases, 1)
+	assert.Equal(t, "[package failed]", c.Cases[0].Name)
+	require.NotNil(t, c.Cases[0].Error)
+	assert.Equal(t, "c/c.go:3:1: syntax error", c.Cases[0].Error.Message)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteMarkdown(&buf, New(testTree())))
+	md := buf.String()
+
+	assert.Contains(t, md, "## ❌ Test results")
+	assert.Contains(t, md, "**2 passed**, **2 failed**, **1 skipped** of 5 tests in 4 packages")
+	assert.Contains(t, md, "| ✗ | `example.com/a` | 1 | 2 | 1 | 0.5s |")
+	assert.Contains(t, md, "| ✓ | `example.com/b` | 1 | 0 | 0 | 0.0s (cached) |")
+	assert.Contains(t, md, "<details><summary>`example.com/a` TestBad/sub (0.2s)</summary>\n\n```\n    a_test.go:12: got 1, want 2 <b>\n--- FAIL: TestBad/sub (0.20s)\n```\n")
+	assert.Contains(t, md, "- `example.com/a` TestLater: a_test.go:20: needs a database\n")
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, New(testTree())))
+	page := buf.String()
+
+	assert.Contains(t, page, "✗ 2 failed")
+	assert.Contains(t, page, "got 1, want 2 &lt;b&gt;")
+	assert.Contains(t, page, "needs a database")
+	assert.Contains(t, page, "(cached)")
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	targets := []Target{
+		{FormatJUnit, filepath.Join(dir, "reports", "junit.xml")},
+		{FormatMarkdown, filepath.Join(dir, "summary.md")},
+	}
+	require.NoError(t, Write(New(testTree()), targets))
+
+	for _, target := range targets {
+		data, err := os.ReadFile(target.Path)
+		require.NoError(t, err)
+		assert.NotEmpty(t, data)
+	}
+}
+
+func TestXMLText(t *testing.T) {
+	assert.Equal(t, "a\tb\n�c", xmlText("a\tb\n\x07c"))
+}
+
+func TestFlaky(t *testing.T) {
+	tree := testTree()
+	tree.GetNode("example.com/a/TestBad/sub").Retries = &model.Retries{Passed: 2, Failed: 1}
+	r := New(tree)
+
+	assert.Equal(t, []Flake{{Package: "example.com/a", Name: "TestBad/sub", Passed: 2, Runs: 3}}, r.Flaky)
+	assert.Equal(t, "flaky, passed 2 of 3 reruns", r.Packages[0].Cases[2].RetrySummary())
+	assert.Empty(t, r.Packages[0].Cases[1].RetrySummary())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, r))
+	assert.Contains(t, buf.String(), `message="Failed (flaky, passed 2 of 3 reruns)"`)
+
+	buf.Reset()
+	require.NoError(t, WriteMarkdown(&buf, r))
+	assert.Contains(t, buf.String(), "### Flaky\n\n- `example.com/a` TestBad/sub: passed 2 of 3 reruns\n")
+
+	buf.Reset()
+	require.NoError(t, WriteHTML(&buf, r))
+	assert.Contains(t, buf.String(), "◐ 1 flaky")
+}
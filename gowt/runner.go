@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"io"
 	"os/exec"
+	"regexp"
 	"strings"
 	"syscall"
 
+	gotest "github.com/rickchristie/govner/gowt/gotest"
 	model "github.com/rickchristie/govner/gowt/model"
 )
 
@@ -16,8 +18,9 @@ import (
 type TestRunner interface {
 	// Start runs go test with the given args and returns an EventStream
 	Start(args []string) (EventStream, error)
-	// StartSingle runs go test for a specific package and optional test name
-	StartSingle(pkg, testName string) (EventStream, error)
+	// StartSingle runs go test for a specific package and optional test name,
+	// with the flags of the original run's args
+	StartSingle(pkg, testName string, args gotest.Args) (EventStream, error)
 	// CleanCache runs go clean -testcache
 	CleanCache() error
 }
@@ -56,17 +59,19 @@ func (r *RealTestRunner) Start(args []string) (EventStream, error) {
 }
 
 // StartSingle implements TestRunner.StartSingle
-func (r *RealTestRunner) StartSingle(pkg, testName string) (EventStream, error) {
-	var cmdArgs []string
-	if testName == "" {
-		// No specific test - run all tests in package
-		cmdArgs = []string{"test", "-json", pkg}
-	} else {
+func (r *RealTestRunner) StartSingle(pkg, testName string, args gotest.Args) (EventStream, error) {
+	return r.startCommand(singleArgs(pkg, testName, args))
+}
+
+// singleArgs builds the go test command line of StartSingle
+func singleArgs(pkg, testName string, args gotest.Args) []string {
+	// No specific test - run all tests in package
+	runPattern := ""
+	if testName != "" {
 		// Build -run pattern: for "TestFoo/subtest" use "^TestFoo$/^subtest$"
-		runPattern := buildRunPattern(testName)
-		cmdArgs = []string{"test", "-json", pkg, "-run", runPattern}
+		runPattern = buildRunPattern(testName)
 	}
-	return r.startCommand(cmdArgs)
+	return append([]string{"test", "-json"}, args.Rerun(pkg, runPattern)...)
 }
 
 // CleanCache implements TestRunner.CleanCache
@@ -198,10 +203,12 @@ func (s *realEventStream) readStderr() {
 // buildRunPattern creates a -run regex pattern for a test name
 // For "TestFoo" -> "^TestFoo$"
 // For "TestFoo/subtest" -> "^TestFoo$/^subtest$"
+// Regex metacharacters in the names, common in table-driven subtests, are
+// quoted so they match literally.
 func buildRunPattern(testName string) string {
 	parts := strings.Split(testName, "/")
 	for i, part := range parts {
-		parts[i] = "^" + part + "$"
+		parts[i] = "^" + regexp.QuoteMeta(part) + "$"
 	}
 	return strings.Join(parts, "/")
 }
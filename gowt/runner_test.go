@@ -0,0 +1,54 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/rickchristie/govner/gowt/gotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildRunPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		testName string
+		want     string
+	}{
+		{"top level", "TestFoo", "^TestFoo$"},
+		{"subtest", "TestFoo/sub_case", "^TestFoo$/^sub_case$"},
+		{"nested", "TestFoo/a/b", "^TestFoo$/^a$/^b$"},
+		{"metacharacters", "TestFoo/a+b_(c)", `^TestFoo$/^a\+b_\(c\)$`},
+		{"brackets and dots", "TestFoo/[1.5]*2", `^TestFoo$/^\[1\.5\]\*2$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildRunPattern(tt.testName))
+		})
+	}
+}
+
+func TestBuildRunPattern_MatchesOnlyTheTest(t *testing.T) {
+	// Each level of the pattern matches its own name, and not names the
+	// unquoted pattern would have matched too
+	pattern := buildRunPattern("TestFoo/a+b_(c)")
+	levels := strings.Split(pattern, "/")
+	assert.Len(t, levels, 2)
+	assert.Regexp(t, regexp.MustCompile(levels[0]), "TestFoo")
+	assert.Regexp(t, regexp.MustCompile(levels[1]), "a+b_(c)")
+	assert.NotRegexp(t, regexp.MustCompile(levels[1]), "aab_c")
+	assert.NotRegexp(t, regexp.MustCompile(levels[0]), "TestFooBar")
+}
+
+func TestSingleArgs_KeepsRunFlags(t *testing.T) {
+	args := gotest.ParseArgs([]string{"-race", "-tags", "integration", "-count=1", "-timeout", "2m", "-cover", "-run", "TestF", "./..."})
+
+	assert.Equal(t, []string{
+		"test", "-json", "-race", "-tags", "integration", "-count=1", "-timeout", "2m", "-cover",
+		"example.com/m/a", "-run", "^TestFoo$/^sub$",
+	}, singleArgs("example.com/m/a", "TestFoo/sub", args))
+
+	// The whole package
+	assert.Equal(t, []string{"test", "-json", "-race", "example.com/m/a"},
+		singleArgs("example.com/m/a", "", gotest.ParseArgs([]string{"-race", "./..."})))
+}
@@ -0,0 +1,209 @@
+// Package source reads Go source for display: the tokens to highlight on
+// each line and the function a line belongs to.
+package source
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of token a span covers
+type Kind int
+
+const (
+	Keyword Kind = iota
+	String
+	Comment
+	Number
+	Builtin // Predeclared identifiers: types, constants and nil
+)
+
+// Span is a highlighted token on a line. Start and End are byte offsets
+// into the line.
+type Span struct {
+	Start int
+	End   int
+	Kind  Kind
+}
+
+// File is a source file split into lines. Go files also have their tokens
+// and syntax tree; other files are shown as plain text.
+type File struct {
+	Lines []string
+	Spans [][]Span // Spans of each line, nil if the file isn't Go
+
+	fset *token.FileSet
+	ast  *ast.File // nil if the file isn't Go
+}
+
+var builtins = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true,
+	"complex128": true, "error": true, "float32": true, "float64": true, "int": true,
+	"int8": true, "int16": true, "int32": true, "int64": true, "rune": true,
+	"string": true, "uint": true, "uint8": true, "uint16": true, "uint32": true,
+	"uint64": true, "uintptr": true, "true": true, "false": true, "iota": true, "nil": true,
+}
+
+// Parse splits src into lines and, if name is a Go file, finds its tokens
+// and parses it. Files that don't compile are parsed as far as possible.
+func Parse(name string, src []byte) *File {
+	lines := strings.Split(strings.TrimRight(string(src), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	f := &File{Lines: lines}
+	if !strings.HasSuffix(name, ".go") {
+		return f
+	}
+
+	f.fset = token.NewFileSet()
+	f.ast, _ = parser.ParseFile(f.fset, name, src, parser.SkipObjectResolution)
+	f.Spans = highlight(src, len(lines))
+	return f
+}
+
+// highlight scans src and splits the spans of its tokens into lines.
+// Comments and raw strings can span several lines.
+func highlight(src []byte, lines int) [][]Span {
+	spans := make([][]Span, lines)
+	starts := []int{0}
+	for i, b := range src {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, src, nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		var kind Kind
+		switch {
+		case tok.IsKeyword():
+			kind = Keyword
+		case tok == token.STRING || tok == token.CHAR:
+			kind = String
+		case tok == token.COMMENT:
+			kind = Comment
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			kind = Number
+		case tok == token.IDENT && builtins[lit]:
+			kind = Builtin
+		default:
+			continue
+		}
+
+		start := file.Offset(pos)
+		end := start + len(tok.String())
+		if tok.IsLiteral() || tok == token.COMMENT {
+			end = start + len(lit)
+		}
+		end = min(end, len(src))
+
+		for line := file.Line(pos) - 1; line < lines && start < end; line++ {
+			lineEnd := len(src)
+			if line+1 < len(starts) {
+				lineEnd = starts[line+1] - 1
+			}
+			spanEnd := min(end, lineEnd)
+			if spanEnd > start {
+				spans[line] = append(spans[line], Span{Start: start - starts[line], End: spanEnd - starts[line], Kind: kind})
+			}
+			start = lineEnd + 1
+		}
+	}
+	return spans
+}
+
+// Func returns the name of the function line (1-based) is in, e.g.
+// "TestFoo" or "(*Suite).TestFoo". Subtests run with a literal name add it
+// as go test does: "TestFoo/bar_baz". Empty if line isn't in a function.
+func (f *File) Func(line int) string {
+	if f.ast == nil {
+		return ""
+	}
+	contains := func(n ast.Node) bool {
+		return f.fset.Position(n.Pos()).Line <= line && line <= f.fset.Position(n.End()).Line
+	}
+
+	for _, decl := range f.ast.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || !contains(fn) {
+			continue
+		}
+		name := funcName(fn)
+		if fn.Body == nil {
+			return name
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if n == nil || !contains(n) {
+				return false
+			}
+			if sub, ok := subtestName(n); ok {
+				name += "/" + sub
+			}
+			return true
+		})
+		return name
+	}
+	return ""
+}
+
+// funcName returns the name of a function, with its receiver for methods
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	pointer := ""
+	if star, ok := recv.(*ast.StarExpr); ok {
+		pointer = "*"
+		recv = star.X
+	}
+	// Drop type parameters of generic receivers
+	switch t := recv.(type) {
+	case *ast.IndexExpr:
+		recv = t.X
+	case *ast.IndexListExpr:
+		recv = t.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return "(" + pointer + ident.Name + ")." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// subtestName returns the name of a subtest if n is a call like
+// t.Run("name", func(t *testing.T) {...})
+func subtestName(n ast.Node) (string, bool) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok || len(call.Args) != 2 {
+		return "", false
+	}
+	if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "Run" {
+		return "", false
+	}
+	if _, ok := call.Args[1].(*ast.FuncLit); !ok {
+		return "", false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	name, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	// go test replaces spaces in subtest names
+	return strings.ReplaceAll(name, " ", "_"), true
+}
@@ -0,0 +1,69 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSource = `package foo
+
+import "testing"
+
+/* block
+comment */
+func TestFoo(t *testing.T) {
+	x := 42 // answer
+	t.Run("with space", func(t *testing.T) {
+		t.Run("inner", func(t *testing.T) {
+			assert(t, x == nil)
+		})
+	})
+}
+
+type Suite struct{}
+
+func (s *Suite) TestBar() {
+	_ = ` + "`raw\nstring`" + `
+}
+`
+
+func TestParse_Spans(t *testing.T) {
+	f := Parse("foo_test.go", []byte(testSource))
+
+	assert.Len(t, f.Lines, 21)
+	assert.Equal(t, []Span{{0, 7, Keyword}}, f.Spans[0])
+	assert.Equal(t, []Span{{0, 6, Keyword}, {7, 16, String}}, f.Spans[2])
+	assert.Equal(t, []Span{{0, 8, Comment}}, f.Spans[4])
+	assert.Equal(t, []Span{{0, 10, Comment}}, f.Spans[5])
+	assert.Equal(t, []Span{{6, 8, Number}, {9, 18, Comment}}, f.Spans[7])
+	assert.Equal(t, []Span{{7, 19, String}, {21, 25, Keyword}}, f.Spans[8])
+	assert.Equal(t, []Span{{18, 21, Builtin}}, f.Spans[10])
+	assert.Equal(t, []Span{{5, 9, String}}, f.Spans[18])
+	assert.Equal(t, []Span{{0, 7, String}}, f.Spans[19])
+}
+
+func TestParse_NotGo(t *testing.T) {
+	f := Parse("testdata/golden.txt", []byte("func x\r\n\nend\n"))
+	assert.Equal(t, []string{"func x", "", "end"}, f.Lines)
+	assert.Nil(t, f.Spans)
+	assert.Empty(t, f.Func(1))
+}
+
+func TestFile_Func(t *testing.T) {
+	f := Parse("foo_test.go", []byte(testSource))
+
+	assert.Equal(t, "", f.Func(1))
+	assert.Equal(t, "TestFoo", f.Func(7))
+	assert.Equal(t, "TestFoo", f.Func(8))
+	assert.Equal(t, "TestFoo/with_space", f.Func(9))
+	assert.Equal(t, "TestFoo/with_space/inner", f.Func(11))
+	assert.Equal(t, "TestFoo/with_space", f.Func(13))
+	assert.Equal(t, "", f.Func(16))
+	assert.Equal(t, "(*Suite).TestBar", f.Func(19))
+}
+
+func TestFile_FuncBroken(t *testing.T) {
+	f := Parse("foo_test.go", []byte("package foo\n\nfunc TestFoo(t *testing.T) {\n\tx := \n}\n"))
+	assert.Equal(t, "TestFoo", f.Func(4))
+}
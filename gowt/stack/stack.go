@@ -0,0 +1,314 @@
+// Package stack parses the goroutine stacks in test output: panics, the
+// goroutine dump of a test that hits -timeout, and -race reports.
+package stack
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Frame is a function call in a stack
+type Frame struct {
+	Func string // Function without its arguments, e.g. "example.com/foo.(*T).Run"
+	File string
+	Line int
+}
+
+// Package returns the import path of the frame's function
+func (f Frame) Package() string {
+	slash := strings.LastIndexByte(f.Func, '/') + 1
+	if dot := strings.IndexByte(f.Func[slash:], '.'); dot >= 0 {
+		return f.Func[:slash+dot]
+	}
+	return f.Func
+}
+
+// Std reports whether the frame is in the standard library, the runtime or
+// the generated test main: packages whose path has no dot in its first element
+func (f Frame) Std() bool {
+	first, _, _ := strings.Cut(f.Package(), "/")
+	return !strings.Contains(first, ".")
+}
+
+// InModule reports whether the frame is in a package of module
+func (f Frame) InModule(module string) bool {
+	pkg := f.Package()
+	return module != "" && (pkg == module || strings.HasPrefix(pkg, module+"/"))
+}
+
+// key identifies the frame's call site, for grouping identical stacks
+func (f Frame) key() string {
+	return f.Func + " " + f.File + ":" + strconv.Itoa(f.Line)
+}
+
+// Goroutine is a goroutine of a panic or goroutine dump
+type Goroutine struct {
+	ID        int
+	State     string // e.g. "running" or "chan receive, 2 minutes"
+	Frames    []Frame
+	CreatedBy *Frame // nil for the main goroutine
+}
+
+// Stack is a titled stack of a race report, e.g. "Read at 0x00c000018308
+// by goroutine 8" or "Goroutine 8 (running) created at"
+type Stack struct {
+	Title  string
+	Frames []Frame
+}
+
+// Access returns what the stack's title says happened, e.g. "Read" or
+// "Previous write". Empty for stacks of where a goroutine was created.
+func (s Stack) Access() string {
+	access, _, ok := strings.Cut(s.Title, " at ")
+	if !ok {
+		return ""
+	}
+	return access
+}
+
+// Goroutine returns the ID of the goroutine in the stack's title, 0 for the
+// main goroutine or none
+func (s Stack) Goroutine() int {
+	m := stackGoroutinePattern.FindStringSubmatch(s.Title)
+	if m == nil {
+		return 0
+	}
+	id, _ := strconv.Atoi(m[1])
+	return id
+}
+
+// Race is a race detector report: the two conflicting accesses, then where
+// their goroutines were created
+type Race struct {
+	Stacks []Stack
+}
+
+// Sides splits the report into its two conflicting accesses, each followed
+// by where its goroutine was created if the report has it
+func (r Race) Sides() [2][]Stack {
+	var sides [2][]Stack
+	n := 0
+	for _, s := range r.Stacks {
+		if s.Access() != "" && n < len(sides) {
+			sides[n] = append(sides[n], s)
+			n++
+		}
+	}
+	for i, side := range sides[:n] {
+		id := side[0].Goroutine()
+		for _, s := range r.Stacks {
+			if s.Access() == "" && id != 0 && s.Goroutine() == id {
+				sides[i] = append(sides[i], s)
+				break
+			}
+		}
+	}
+	return sides
+}
+
+// Key identifies the race by the code of its accesses: the kind of each and
+// its first frame outside the standard library, or its first frame. The same
+// race reported again, at another address, by other goroutines or from other
+// callers, has the same key. So does the race reported the other way round,
+// e.g. a write then a previous read rather than a read then a previous write.
+func (r Race) Key() string {
+	var keys []string
+	for _, s := range r.Stacks {
+		if s.Access() == "" || len(s.Frames) == 0 {
+			continue
+		}
+		frame := s.Frames[0]
+		for _, f := range s.Frames {
+			if !f.Std() {
+				frame = f
+				break
+			}
+		}
+		access := strings.ToLower(strings.TrimPrefix(s.Access(), "Previous "))
+		keys = append(keys, access+" "+frame.key())
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, "\n")
+}
+
+// Dump is what was found in a test's output
+type Dump struct {
+	Panic      []string // Lines of the panic or fatal error, empty if there was none
+	Goroutines []Goroutine
+	Races      []Race
+}
+
+var (
+	goroutinePattern = regexp.MustCompile(`^goroutine (\d+) \[([^\]]*)\]:$`)
+	createdByPattern = regexp.MustCompile(`^created by (\S+)(?: in goroutine \d+)?$`)
+	locationPattern  = regexp.MustCompile(`^\s+(.+):(\d+)(?: \+0x[0-9a-f]+)?$`)
+
+	// "Read at 0x00c000018308 by goroutine 8", "Goroutine 8 (running) created at"
+	stackGoroutinePattern = regexp.MustCompile(`(?:by goroutine |^Goroutine )(\d+)`)
+)
+
+// The race detector prints each report between separators, starting with a warning
+const (
+	RaceWarning   = "WARNING: DATA RACE"
+	RaceSeparator = "=================="
+)
+
+// Contains reports whether output has something Parse finds. It's a quick
+// check for output that streams in, without parsing.
+func Contains(output string) bool {
+	return strings.Contains(output, RaceWarning) ||
+		(strings.Contains(output, "\ngoroutine ") || strings.HasPrefix(output, "goroutine ")) && strings.Contains(output, "]:\n")
+}
+
+// Parse finds the panic, goroutine stacks and race reports in output.
+// Returns nil if there are none.
+func Parse(output string) *Dump {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	d := &Dump{}
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case len(d.Panic) == 0 && (strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ")):
+			for ; i < len(lines) && lines[i] != "" && !goroutinePattern.MatchString(lines[i]); i++ {
+				d.Panic = append(d.Panic, lines[i])
+			}
+
+		case goroutinePattern.MatchString(line):
+			m := goroutinePattern.FindStringSubmatch(line)
+			id, _ := strconv.Atoi(m[1])
+			g := Goroutine{ID: id, State: m[2]}
+			g.Frames, g.CreatedBy, i = parseFrames(lines, i+1)
+			d.Goroutines = append(d.Goroutines, g)
+
+		case line == RaceWarning:
+			var race Race
+			race, i = parseRace(lines, i+1)
+			d.Races = append(d.Races, race)
+
+		default:
+			i++
+		}
+	}
+
+	if len(d.Goroutines) == 0 && len(d.Races) == 0 {
+		return nil
+	}
+	return d
+}
+
+// parseFrames parses the frames of a goroutine from lines[i], each a
+// function line then a location line, up to the first line that isn't a
+// frame. Returns the index of that line.
+func parseFrames(lines []string, i int) ([]Frame, *Frame, int) {
+	var frames []Frame
+	for i < len(lines) {
+		line := lines[i]
+		// Deep stacks print "...N frames elided..." in between
+		if strings.HasPrefix(line, "...") {
+			i++
+			continue
+		}
+		if i+1 >= len(lines) || line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			break
+		}
+		loc := locationPattern.FindStringSubmatch(lines[i+1])
+		if loc == nil {
+			break
+		}
+		frame := Frame{Func: funcName(line), File: loc[1]}
+		frame.Line, _ = strconv.Atoi(loc[2])
+		i += 2
+
+		if m := createdByPattern.FindStringSubmatch(line); m != nil {
+			frame.Func = m[1]
+			return frames, &frame, i
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil, i
+}
+
+// parseRace parses a race report from lines[i], after its warning, up to
+// its closing separator. Returns the index of the line after it.
+func parseRace(lines []string, i int) (Race, int) {
+	var race Race
+	for ; i < len(lines) && lines[i] != RaceSeparator; i++ {
+		line := lines[i]
+		switch {
+		case strings.HasSuffix(line, ":") && !strings.HasPrefix(line, " "):
+			race.Stacks = append(race.Stacks, Stack{Title: strings.TrimSuffix(line, ":")})
+		case strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "    ") && len(race.Stacks) > 0:
+			frame := Frame{Func: funcName(strings.TrimSpace(line))}
+			if i+1 < len(lines) {
+				if loc := locationPattern.FindStringSubmatch(lines[i+1]); loc != nil {
+					frame.File = loc[1]
+					frame.Line, _ = strconv.Atoi(loc[2])
+					i++
+				}
+			}
+			stack := &race.Stacks[len(race.Stacks)-1]
+			stack.Frames = append(stack.Frames, frame)
+		}
+	}
+	return race, i + 1
+}
+
+// funcName drops the arguments from a function line of a stack, e.g.
+// "testing.(*T).Run(0xc000, {0x55698b?, 0x4})" becomes "testing.(*T).Run"
+func funcName(line string) string {
+	if !strings.HasSuffix(line, ")") {
+		return line
+	}
+	depth := 0
+	for i := len(line) - 1; i >= 0; i-- {
+		switch line[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// Group is goroutines with identical stacks, as a dump often has many
+// goroutines waiting in the same place
+type Group struct {
+	IDs       []int
+	States    []string // Distinct states of the goroutines, in order of appearance
+	Frames    []Frame
+	CreatedBy *Frame
+}
+
+// Groups groups the dump's goroutines by identical stacks, in order of first appearance
+func (d *Dump) Groups() []Group {
+	var groups []Group
+	index := make(map[string]int)
+	for _, g := range d.Goroutines {
+		var key strings.Builder
+		for _, f := range g.Frames {
+			key.WriteString(f.key() + "\n")
+		}
+		if g.CreatedBy != nil {
+			key.WriteString("created by " + g.CreatedBy.key())
+		}
+
+		i, ok := index[key.String()]
+		if !ok {
+			i = len(groups)
+			index[key.String()] = i
+			groups = append(groups, Group{Frames: g.Frames, CreatedBy: g.CreatedBy})
+		}
+		group := &groups[i]
+		group.IDs = append(group.IDs, g.ID)
+		if !slices.Contains(group.States, g.State) {
+			group.States = append(group.States, g.State)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,234 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const panicOutput = `=== RUN   TestPanic
+    dumps_test.go:14: before
+--- FAIL: TestPanic (0.00s)
+panic: runtime error: integer divide by zero [recovered, repanicked]
+
+goroutine 6 [running]:
+testing.tRunner.func1.2({0x6b8ec8, 0x6f1000})
+	/usr/local/go/src/testing/testing.go:2123 +0x232
+panic({0x6b8ec8?, 0x6f1000?})
+	/usr/local/go/src/runtime/panic.go:859 +0x125
+example.com/dumps.helper(...)
+	/tmp/dumps/dumps_test.go:10
+example.com/dumps.TestPanic(0x36636596c248?)
+	/tmp/dumps/dumps_test.go:15 +0x139
+created by testing.(*T).Run in goroutine 1
+	/usr/local/go/src/testing/testing.go:2258 +0x4d4
+FAIL	example.com/dumps	0.004s
+`
+
+const timeoutOutput = `panic: test timed out after 2s
+	running tests:
+		TestTimeout (2s)
+
+goroutine 1 [chan receive]:
+testing.(*T).Run(0x32011c19a008, {0x55698b?, 0x32011c193aa0?}, 0x6d6af8)
+	/usr/local/go/src/testing/testing.go:2266 +0x4f2
+main.main()
+	_testmain.go:50 +0x9b
+
+goroutine 7 [chan receive]:
+example.com/dumps.block(0x0?, 0x0?)
+	/tmp/dumps/dumps_test.go:20 +0x45
+created by example.com/dumps.TestTimeout in goroutine 6
+	/tmp/dumps/dumps_test.go:28 +0x5b
+
+goroutine 8 [chan receive, 2 minutes]:
+example.com/dumps.block(0x0?, 0x0?)
+	/tmp/dumps/dumps_test.go:20 +0x45
+created by example.com/dumps.TestTimeout in goroutine 6
+	/tmp/dumps/dumps_test.go:28 +0x5b
+`
+
+const raceOutput = `=== RUN   TestRace
+==================
+WARNING: DATA RACE
+Read at 0x00c000018308 by goroutine 8:
+  example.com/dumps.TestRace.func1()
+      /tmp/dumps/dumps_test.go:36 +0x33
+
+Previous write at 0x00c000018308 by goroutine 7:
+  example.com/dumps.TestRace()
+      /tmp/dumps/dumps_test.go:37 +0x116
+  testing.tRunner()
+      /usr/local/go/src/testing/testing.go:2193 +0x21c
+
+Goroutine 8 (running) created at:
+  example.com/dumps.TestRace()
+      /tmp/dumps/dumps_test.go:36 +0xf9
+==================
+--- FAIL: TestRace (0.01s)
+    testing.go:1865: race detected during execution of test
+`
+
+func TestParse_Panic(t *testing.T) {
+	d := Parse(panicOutput)
+	require.NotNil(t, d)
+
+	assert.Equal(t, []string{"panic: runtime error: integer divide by zero [recovered, repanicked]"}, d.Panic)
+	assert.Equal(t, []Goroutine{{
+		ID:    6,
+		State: "running",
+		Frames: []Frame{
+			{Func: "testing.tRunner.func1.2", File: "/usr/local/go/src/testing/testing.go", Line: 2123},
+			{Func: "panic", File: "/usr/local/go/src/runtime/panic.go", Line: 859},
+			{Func: "example.com/dumps.helper", File: "/tmp/dumps/dumps_test.go", Line: 10},
+			{Func: "example.com/dumps.TestPanic", File: "/tmp/dumps/dumps_test.go", Line: 15},
+		},
+		CreatedBy: &Frame{Func: "testing.(*T).Run", File: "/usr/local/go/src/testing/testing.go", Line: 2258},
+	}}, d.Goroutines)
+	assert.Empty(t, d.Races)
+}
+
+func TestParse_Timeout(t *testing.T) {
+	d := Parse(timeoutOutput)
+	require.NotNil(t, d)
+
+	assert.Equal(t, []string{"panic: test timed out after 2s", "\trunning tests:", "\t\tTestTimeout (2s)"}, d.Panic)
+	require.Len(t, d.Goroutines, 3)
+	assert.Equal(t, []Frame{
+		{Func: "testing.(*T).Run", File: "/usr/local/go/src/testing/testing.go", Line: 2266},
+		{Func: "main.main", File: "_testmain.go", Line: 50},
+	}, d.Goroutines[0].Frames)
+	assert.Nil(t, d.Goroutines[0].CreatedBy)
+
+	groups := d.Groups()
+	require.Len(t, groups, 2)
+	assert.Equal(t, []int{1}, groups[0].IDs)
+	assert.Equal(t, []int{7, 8}, groups[1].IDs)
+	assert.Equal(t, []string{"chan receive", "chan receive, 2 minutes"}, groups[1].States)
+	assert.Equal(t, "example.com/dumps.TestTimeout", groups[1].CreatedBy.Func)
+}
+
+func TestParse_Race(t *testing.T) {
+	d := Parse(raceOutput)
+	require.NotNil(t, d)
+
+	assert.Empty(t, d.Panic)
+	assert.Empty(t, d.Goroutines)
+	assert.Equal(t, []Race{{Stacks: []Stack{
+		{Title: "Read at 0x00c000018308 by goroutine 8", Frames: []Frame{
+			{Func: "example.com/dumps.TestRace.func1", File: "/tmp/dumps/dumps_test.go", Line: 36},
+		}},
+		{Title: "Previous write at 0x00c000018308 by goroutine 7", Frames: []Frame{
+			{Func: "example.com/dumps.TestRace", File: "/tmp/dumps/dumps_test.go", Line: 37},
+			{Func: "testing.tRunner", File: "/usr/local/go/src/testing/testing.go", Line: 2193},
+		}},
+		{Title: "Goroutine 8 (running) created at", Frames: []Frame{
+			{Func: "example.com/dumps.TestRace", File: "/tmp/dumps/dumps_test.go", Line: 36},
+		}},
+	}}}, d.Races)
+}
+
+func TestParse_None(t *testing.T) {
+	output := "=== RUN   TestFoo\n    foo_test.go:3: goroutine 5 [running]:\n--- PASS: TestFoo (0.00s)\n"
+	assert.Nil(t, Parse(output))
+	assert.False(t, Contains(output))
+}
+
+func TestContains(t *testing.T) {
+	assert.True(t, Contains(panicOutput))
+	assert.True(t, Contains(timeoutOutput))
+	assert.True(t, Contains(raceOutput))
+}
+
+func TestFrame(t *testing.T) {
+	tests := []struct {
+		fn       string
+		pkg      string
+		std      bool
+		inModule bool
+	}{
+		{"testing.(*T).Run", "testing", true, false},
+		{"internal/poll.(*FD).Read", "internal/poll", true, false},
+		{"panic", "panic", true, false},
+		{"main.main", "main", true, false},
+		{"example.com/dumps.TestRace.func1", "example.com/dumps", false, true},
+		{"example.com/dumps/sub.(*T).Do", "example.com/dumps/sub", false, true},
+		{"example.com/dumpsother.F", "example.com/dumpsother", false, false},
+		{"github.com/stretchr/testify/assert.Equal", "github.com/stretchr/testify/assert", false, false},
+	}
+	for _, tt := range tests {
+		f := Frame{Func: tt.fn}
+		assert.Equal(t, tt.pkg, f.Package(), tt.fn)
+		assert.Equal(t, tt.std, f.Std(), tt.fn)
+		assert.Equal(t, tt.inModule, f.InModule("example.com/dumps"), tt.fn)
+	}
+}
+
+func TestRace_Sides(t *testing.T) {
+	race := Parse(raceOutput).Races[0]
+	sides := race.Sides()
+
+	require.Len(t, sides[0], 2)
+	assert.Equal(t, "Read", sides[0][0].Access())
+	assert.Equal(t, 8, sides[0][0].Goroutine())
+	assert.Equal(t, "Goroutine 8 (running) created at", sides[0][1].Title)
+	assert.Equal(t, "", sides[0][1].Access())
+
+	// Goroutine 7 runs the test, the report doesn't say where it was created
+	require.Len(t, sides[1], 1)
+	assert.Equal(t, "Previous write", sides[1][0].Access())
+	assert.Equal(t, 7, sides[1][0].Goroutine())
+}
+
+func TestRace_Key(t *testing.T) {
+	race := Parse(raceOutput).Races[0]
+
+	// Reported again at another address, by other goroutines
+	again := Parse(`WARNING: DATA RACE
+Read at 0x00c0000a2010 by goroutine 12:
+  example.com/dumps.TestRace.func1()
+      /tmp/dumps/dumps_test.go:36 +0x33
+
+Previous write at 0x00c0000a2010 by main goroutine:
+  example.com/dumps.TestRace()
+      /tmp/dumps/dumps_test.go:37 +0x116
+  testing.tRunner()
+      /usr/local/go/src/testing/testing.go:2193 +0x21c
+==================
+`).Races[0]
+	assert.Equal(t, race.Key(), again.Key())
+	assert.Equal(t, 0, again.Stacks[1].Goroutine())
+
+	// Another line is another race
+	other := Parse(strings.Replace(raceOutput, "dumps_test.go:37", "dumps_test.go:38", 1)).Races[0]
+	assert.NotEqual(t, race.Key(), other.Key())
+}
+
+func TestRace_KeySwapped(t *testing.T) {
+	race := Parse(raceOutput).Races[0]
+
+	// The same race, with the write caught first and the read as previous
+	swapped := Parse(`WARNING: DATA RACE
+Write at 0x00c000018308 by goroutine 7:
+  example.com/dumps.TestRace()
+      /tmp/dumps/dumps_test.go:37 +0x116
+  testing.tRunner()
+      /usr/local/go/src/testing/testing.go:2193 +0x21c
+
+Previous read at 0x00c000018308 by goroutine 8:
+  example.com/dumps.TestRace.func1()
+      /tmp/dumps/dumps_test.go:36 +0x33
+
+Goroutine 8 (finished) created at:
+  example.com/dumps.TestRace()
+      /tmp/dumps/dumps_test.go:36 +0xf9
+==================
+`).Races[0]
+	assert.Equal(t, race.Key(), swapped.Key())
+
+	// Writes on both lines are another race
+	writes := Parse(strings.Replace(raceOutput, "Read at", "Write at", 1)).Races[0]
+	assert.NotEqual(t, race.Key(), writes.Key())
+}
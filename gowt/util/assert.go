@@ -0,0 +1,186 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles for assertion failures
+var (
+	// Field labels: dim, the values are what matters
+	assertLabelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	// The error itself: red and bold like a failed test
+	assertErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+	// Test name: bold
+	assertTestStyle = lipgloss.NewStyle().Bold(true)
+	// Diff: red for expected/want, green for actual/got, cyan hunk headers
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	diffHunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
+	diffContextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
+)
+
+var (
+	// testify's labeled output: "\tError Trace:\t/path/foo_test.go:12" after
+	// go test's indent. Values spanning lines continue with the label blanked.
+	testifyFieldPattern    = regexp.MustCompile(`^ *\t(Error Trace|Error|Test|Messages):\s*\t(.*)$`)
+	testifyContinuePattern = regexp.MustCompile(`^ *\t +\t(.*)$`)
+
+	// go-cmp diffs are usually logged after a legend: "mismatch (-want +got):"
+	cmpHeaderPattern = regexp.MustCompile(`\((-\w+ \+\w+|\+\w+ -\w+)\):?\s*$`)
+)
+
+const (
+	assertIndent      = "        " // go test's indent for continued log lines
+	assertLabelWidth  = len("Error Trace:")
+	assertValueIndent = assertIndent + "             " // Label width and a space
+)
+
+type assertBlock int
+
+const (
+	blockNone assertBlock = iota
+	blockTestify
+	blockCmp
+)
+
+// testify sections of the Error field
+type assertSection int
+
+const (
+	sectionMessage assertSection = iota
+	sectionExpected
+	sectionActual
+	sectionDiff
+)
+
+// AssertFormatter formats the failure blocks of testify's assert and require
+// and go-cmp diffs in a test's output: testify's fields are aligned under
+// their labels, and expected/actual values and diffs are colored as a unified
+// diff. Blocks span several lines, so the formatter keeps the state of the
+// block it's in: use one per test.
+type AssertFormatter struct {
+	block   assertBlock
+	field   string        // testify field the block is in
+	section assertSection // Part of testify's Error field the block is in
+}
+
+// Format formats a line of output, without ANSI codes, if it's part of an
+// assertion block. Returns false for other lines, which end the block.
+func (f *AssertFormatter) Format(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+
+	if m := testifyFieldPattern.FindStringSubmatch(line); m != nil {
+		if m[1] == "Error Trace" || f.block == blockTestify {
+			f.block = blockTestify
+			f.field = m[1]
+			f.section = sectionMessage
+			return f.formatField(m[1], m[2]) + "\n", true
+		}
+	}
+
+	switch f.block {
+	case blockTestify:
+		if m := testifyContinuePattern.FindStringSubmatch(line); m != nil {
+			return assertValueIndent + f.formatValue(m[1]) + "\n", true
+		}
+	case blockCmp:
+		if strings.HasPrefix(line, assertIndent) {
+			return assertIndent + formatCmpLine(line[len(assertIndent):]) + "\n", true
+		}
+	}
+	f.block = blockNone
+
+	if m := cmpHeaderPattern.FindStringSubmatchIndex(line); m != nil {
+		f.block = blockCmp
+		return line[:m[2]] + formatLegend(line[m[2]:m[3]]) + line[m[3]:] + "\n", true
+	}
+	return "", false
+}
+
+// formatField formats the first line of a testify field
+func (f *AssertFormatter) formatField(label, value string) string {
+	label = assertLabelStyle.Render(fmt.Sprintf("%-*s", assertLabelWidth, label+":"))
+	return assertIndent + label + " " + f.formatValue(value)
+}
+
+// formatValue formats a line of the current testify field's value
+func (f *AssertFormatter) formatValue(value string) string {
+	switch f.field {
+	case "Error":
+		return f.formatError(value)
+	case "Test":
+		return assertTestStyle.Render(value)
+	}
+	return value
+}
+
+// formatError formats a line of testify's Error field, which holds the
+// expected and actual values and their diff after the message
+func (f *AssertFormatter) formatError(value string) string {
+	trimmed := strings.TrimSpace(value)
+	switch {
+	case strings.HasPrefix(trimmed, "expected:") || strings.HasPrefix(trimmed, "expected "):
+		f.section = sectionExpected
+	case strings.HasPrefix(trimmed, "actual:") || strings.HasPrefix(trimmed, "actual "):
+		f.section = sectionActual
+	case trimmed == "Diff:":
+		f.section = sectionDiff
+		return assertLabelStyle.Render(value)
+	}
+
+	switch f.section {
+	case sectionExpected:
+		return diffRemovedStyle.Render(value)
+	case sectionActual:
+		return diffAddedStyle.Render(value)
+	case sectionDiff:
+		return formatDiffLine(value)
+	}
+	return assertErrorStyle.Render(value)
+}
+
+// formatDiffLine colors a line of a unified diff
+func formatDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+		return assertLabelStyle.Render(line)
+	case strings.HasPrefix(line, "@@"):
+		return diffHunkStyle.Render(line)
+	case strings.HasPrefix(line, "-"):
+		return diffRemovedStyle.Render(line)
+	case strings.HasPrefix(line, "+"):
+		return diffAddedStyle.Render(line)
+	}
+	return diffContextStyle.Render(line)
+}
+
+// formatCmpLine colors a line of a go-cmp diff. go-cmp randomly uses
+// non-breaking spaces after its markers so that its output isn't relied on;
+// they are shown as spaces.
+func formatCmpLine(line string) string {
+	line = strings.ReplaceAll(line, "\u00a0", " ")
+	switch {
+	case strings.HasPrefix(line, "-"):
+		return diffRemovedStyle.Render(line)
+	case strings.HasPrefix(line, "+"):
+		return diffAddedStyle.Render(line)
+	}
+	return diffContextStyle.Render(line)
+}
+
+// formatLegend colors the sides of a diff legend, e.g. "-want +got"
+func formatLegend(legend string) string {
+	parts := strings.Fields(legend)
+	for i, part := range parts {
+		if strings.HasPrefix(part, "-") {
+			parts[i] = diffRemovedStyle.Render(part)
+		} else {
+			parts[i] = diffAddedStyle.Render(part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,114 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// formatLines runs lines through one formatter, returning the formatted
+// output without styles and "-" for lines it left alone
+func formatLines(lines ...string) []string {
+	var f AssertFormatter
+	var out []string
+	for _, line := range lines {
+		formatted, ok := f.Format(line + "\n")
+		if !ok {
+			out = append(out, "-")
+			continue
+		}
+		out = append(out, stripAnsi(formatted))
+	}
+	return out
+}
+
+func TestAssertFormatter_Testify(t *testing.T) {
+	out := formatLines(
+		"    foo_test.go:15: ",
+		"        \tError Trace:\t/src/foo_test.go:15",
+		"        \tError:      \tNot equal: ",
+		"        \t            \texpected: 1",
+		"        \t            \tactual  : 2",
+		"        \t            \t",
+		"        \t            \tDiff:",
+		"        \t            \t--- Expected",
+		"        \t            \t+++ Actual",
+		"        \t            \t@@ -1 +1 @@",
+		"        \t            \t-1",
+		"        \t            \t+2",
+		"        \tTest:       \tTestFoo",
+		"        \tMessages:   \tvalues differ",
+		"--- FAIL: TestFoo (0.00s)",
+	)
+
+	assert.Equal(t, []string{
+		"-",
+		"        Error Trace: /src/foo_test.go:15\n",
+		"        Error:       Not equal: \n",
+		"                     expected: 1\n",
+		"                     actual  : 2\n",
+		"                     \n",
+		"                     Diff:\n",
+		"                     --- Expected\n",
+		"                     +++ Actual\n",
+		"                     @@ -1 +1 @@\n",
+		"                     -1\n",
+		"                     +2\n",
+		"        Test:        TestFoo\n",
+		"        Messages:    values differ\n",
+		"-",
+	}, out)
+}
+
+func TestAssertFormatter_TestifyStyles(t *testing.T) {
+	var f AssertFormatter
+	f.Format("        \tError Trace:\t/src/foo_test.go:15\n")
+	f.Format("        \tError:      \tNot equal: \n")
+
+	out, _ := f.Format("        \t            \texpected: 1\n")
+	assert.Equal(t, assertValueIndent+diffRemovedStyle.Render("expected: 1")+"\n", out)
+	out, _ = f.Format("        \t            \tactual  : 2\n")
+	assert.Equal(t, assertValueIndent+diffAddedStyle.Render("actual  : 2")+"\n", out)
+
+	// A new field starts over
+	out, _ = f.Format("        \tError:      \tShould be true\n")
+	assert.Contains(t, out, assertErrorStyle.Render("Should be true"))
+}
+
+func TestAssertFormatter_FieldOutsideBlock(t *testing.T) {
+	// Only testify's first field starts a block
+	assert.Equal(t, []string{"-", "-"}, formatLines(
+		"        \tError:      \tNot equal: ",
+		"        \t            \texpected: 1",
+	))
+}
+
+func TestAssertFormatter_Cmp(t *testing.T) {
+	out := formatLines(
+		"    foo_test.go:20: Get() mismatch (-want +got):",
+		"          foo.S{",
+		"        - \tA: 1,",
+		"        + \tA: 2,",
+		"          }",
+		"    foo_test.go:21: after",
+	)
+
+	assert.Equal(t, []string{
+		"    foo_test.go:20: Get() mismatch (-want +got):\n",
+		"          foo.S{\n",
+		"        -     A: 1,\n",
+		"        +     A: 2,\n",
+		"          }\n",
+		"-",
+	}, out)
+}
+
+func TestAssertFormatter_CmpLegend(t *testing.T) {
+	var f AssertFormatter
+	out, ok := f.Format("    foo_test.go:20: diff (+got -want)\n")
+	assert.True(t, ok)
+	assert.Equal(t, "    foo_test.go:20: diff ("+diffAddedStyle.Render("+got")+" "+diffRemovedStyle.Render("-want")+")\n", out)
+
+	_, ok = f.Format("    foo_test.go:20: not a diff (see above)\n")
+	assert.False(t, ok)
+}
@@ -0,0 +1,429 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rickchristie/govner/gowt/bench"
+	model "github.com/rickchristie/govner/gowt/model"
+)
+
+// BenchViewRequest represents a request from BenchView to the controller
+type BenchViewRequest interface {
+	isBenchViewRequest()
+}
+
+// CloseBenchRequest is emitted when user wants to go back to tree view
+type CloseBenchRequest struct{}
+
+func (CloseBenchRequest) isBenchViewRequest() {}
+
+// SaveBaselineRequest is emitted when user wants to save the current results
+// as the baseline later runs are compared against
+type SaveBaselineRequest struct{}
+
+func (SaveBaselineRequest) isBenchViewRequest() {}
+
+// BenchSort is the column the benchmark table is sorted by
+type BenchSort int
+
+const (
+	BenchSortName BenchSort = iota
+	BenchSortTime
+	BenchSortBytes
+	BenchSortAllocs
+	BenchSortDelta // Change in time/op against the baseline
+	benchSortCount
+)
+
+func (s BenchSort) String() string {
+	switch s {
+	case BenchSortTime:
+		return "time/op"
+	case BenchSortBytes:
+		return "B/op"
+	case BenchSortAllocs:
+		return "allocs/op"
+	case BenchSortDelta:
+		return "Δ time"
+	default:
+		return "name"
+	}
+}
+
+// benchRow is one benchmark in the table
+type benchRow struct {
+	name    string // Benchmark name, e.g. "BenchmarkFoo/small"
+	pkg     string
+	runs    int
+	metrics [3]benchMetric // time, bytes, allocs
+}
+
+// benchMetric is one standard metric of a benchmark, with its comparison
+// against the baseline if the baseline has it
+type benchMetric struct {
+	ok      bool // Whether the benchmark reports this unit
+	summary bench.Summary
+	cmp     *bench.Comparison
+}
+
+var benchUnits = [3]string{bench.UnitTime, bench.UnitBytes, bench.UnitAllocs}
+
+// BenchView shows benchmark results as a sortable table, compared against a
+// saved baseline
+type BenchView struct {
+	rows         []benchRow
+	baselinePath string
+	baselineSize int // Benchmarks in the baseline, 0 = none
+	cursor       int
+	scrollTop    int
+	sortBy       BenchSort
+	descending   bool
+	status       string // One-off message, e.g. after saving
+
+	width  int
+	height int
+	styles benchStyles
+}
+
+type benchStyles struct {
+	header   lipgloss.Style
+	column   lipgloss.Style
+	hint     lipgloss.Style
+	selected lipgloss.Style
+	better   lipgloss.Style
+	worse    lipgloss.Style
+	same     lipgloss.Style
+}
+
+func defaultBenchStyles() benchStyles {
+	return benchStyles{
+		header: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")),
+		column: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("248")),
+		hint:   lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+		selected: lipgloss.NewStyle().
+			Background(lipgloss.Color("24")).
+			Foreground(lipgloss.Color("231")).
+			Bold(true),
+		better: lipgloss.NewStyle().Foreground(ColorPassed),
+		worse:  lipgloss.NewStyle().Foreground(ColorFailed),
+		same:   lipgloss.NewStyle().Foreground(ColorSkipped),
+	}
+}
+
+// NewBenchView creates a new BenchView
+func NewBenchView() BenchView {
+	return BenchView{styles: defaultBenchStyles()}
+}
+
+// SetData builds the table from the benchmarks in the tree, compared against
+// baseline (nil for none) saved at baselinePath
+func (v BenchView) SetData(tree *model.TestTree, baseline bench.Set, baselinePath string) BenchView {
+	v.baselinePath = baselinePath
+	v.baselineSize = len(baseline)
+	v.rows = nil
+	for _, node := range tree.Benchmarks() {
+		row := benchRow{
+			name: strings.TrimPrefix(node.FullPath, node.Package+"/"),
+			pkg:  node.Package,
+			runs: len(node.Bench),
+		}
+		old := baseline[node.Bench[0].Key()]
+		for i, unit := range benchUnits {
+			values := bench.Values(node.Bench, unit)
+			if len(values) == 0 {
+				continue
+			}
+			m := benchMetric{ok: true, summary: bench.Summarize(values)}
+			if oldValues := bench.Values(old, unit); len(oldValues) > 0 {
+				cmp := bench.Compare(oldValues, values)
+				m.cmp = &cmp
+			}
+			row.metrics[i] = m
+		}
+		v.rows = append(v.rows, row)
+	}
+	v.sortRows()
+	if v.cursor >= len(v.rows) {
+		v.cursor = max(0, len(v.rows)-1)
+	}
+	return v
+}
+
+// SetStatus shows a one-off message in the footer
+func (v BenchView) SetStatus(status string) BenchView {
+	v.status = status
+	return v
+}
+
+// sortRows orders rows by the sort column, benchmarks lacking it last
+func (v *BenchView) sortRows() {
+	sortValue := func(r benchRow) (float64, bool) {
+		switch v.sortBy {
+		case BenchSortTime:
+			return r.metrics[0].summary.Median, r.metrics[0].ok
+		case BenchSortBytes:
+			return r.metrics[1].summary.Median, r.metrics[1].ok
+		case BenchSortAllocs:
+			return r.metrics[2].summary.Median, r.metrics[2].ok
+		case BenchSortDelta:
+			if cmp := r.metrics[0].cmp; cmp != nil {
+				return cmp.Delta, true
+			}
+		}
+		return 0, false
+	}
+
+	sort.SliceStable(v.rows, func(i, j int) bool {
+		a, b := v.rows[i], v.rows[j]
+		if v.sortBy == BenchSortName {
+			if v.descending {
+				return a.pkg+a.name > b.pkg+b.name
+			}
+			return a.pkg+a.name < b.pkg+b.name
+		}
+		av, aok := sortValue(a)
+		bv, bok := sortValue(b)
+		if aok != bok {
+			return aok
+		}
+		if v.descending {
+			return av > bv
+		}
+		return av < bv
+	})
+}
+
+// Init implements tea.Model
+func (v BenchView) Init() tea.Cmd {
+	return nil
+}
+
+type benchKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	Sort     key.Binding
+	Reverse  key.Binding
+	Save     key.Binding
+	Back     key.Binding
+}
+
+var benchKeys = benchKeyMap{
+	Up:       key.NewBinding(key.WithKeys("up", "k", "K")),
+	Down:     key.NewBinding(key.WithKeys("down", "j", "J")),
+	PageUp:   key.NewBinding(key.WithKeys("pgup", "ctrl+u", "ctrl+U")),
+	PageDown: key.NewBinding(key.WithKeys("pgdown", "ctrl+d", "ctrl+D")),
+	Top:      key.NewBinding(key.WithKeys("g")),
+	Bottom:   key.NewBinding(key.WithKeys("G")),
+	Sort:     key.NewBinding(key.WithKeys("s")),
+	Reverse:  key.NewBinding(key.WithKeys("S")),
+	Save:     key.NewBinding(key.WithKeys("w", "W")),
+	Back:     key.NewBinding(key.WithKeys("esc", "backspace", "q", "Q")),
+}
+
+// Update implements tea.Model
+func (v BenchView) Update(msg tea.Msg) (BenchView, tea.Cmd, BenchViewRequest) {
+	var request BenchViewRequest
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width = msg.Width
+		v.height = msg.Height
+
+	case tea.KeyMsg:
+		v.status = ""
+		pageSize := max(1, v.visibleRows())
+		switch {
+		case key.Matches(msg, benchKeys.Back):
+			request = CloseBenchRequest{}
+		case key.Matches(msg, benchKeys.Save):
+			request = SaveBaselineRequest{}
+		case key.Matches(msg, benchKeys.Up):
+			v.cursor = max(0, v.cursor-1)
+		case key.Matches(msg, benchKeys.Down):
+			v.cursor = max(0, min(len(v.rows)-1, v.cursor+1))
+		case key.Matches(msg, benchKeys.PageUp):
+			v.cursor = max(0, v.cursor-pageSize)
+		case key.Matches(msg, benchKeys.PageDown):
+			v.cursor = max(0, min(len(v.rows)-1, v.cursor+pageSize))
+		case key.Matches(msg, benchKeys.Top):
+			v.cursor = 0
+		case key.Matches(msg, benchKeys.Bottom):
+			v.cursor = max(0, len(v.rows)-1)
+		case key.Matches(msg, benchKeys.Sort):
+			v.sortBy = (v.sortBy + 1) % benchSortCount
+			v.sortRows()
+		case key.Matches(msg, benchKeys.Reverse):
+			v.descending = !v.descending
+			v.sortRows()
+		}
+
+		// Keep the cursor on screen
+		if v.cursor < v.scrollTop {
+			v.scrollTop = v.cursor
+		}
+		if v.cursor >= v.scrollTop+pageSize {
+			v.scrollTop = v.cursor - pageSize + 1
+		}
+	}
+
+	return v, cmd, request
+}
+
+// visibleRows is the number of table rows that fit: title, column headers,
+// detail line and help bar take four lines
+func (v BenchView) visibleRows() int {
+	return v.height - 4
+}
+
+// View implements tea.Model
+func (v BenchView) View() string {
+	var sb strings.Builder
+
+	// Title
+	title := v.styles.header.Render("Benchmarks") + "  " + v.styles.hint.Render(fmt.Sprintf("%d results", len(v.rows)))
+	if v.baselineSize > 0 {
+		title += "  " + v.styles.hint.Render(fmt.Sprintf("vs %s (%d benchmarks)", v.baselinePath, v.baselineSize))
+	} else {
+		title += "  " + v.styles.hint.Render("no baseline, press w to save one")
+	}
+	sb.WriteString(title + "\n")
+
+	// Column headers
+	nameWidth := v.nameWidth()
+	sortLabel := v.sortBy.String()
+	if v.descending {
+		sortLabel += " ↓"
+	} else {
+		sortLabel += " ↑"
+	}
+	sb.WriteString(v.styles.column.Render(padRight("Benchmark", nameWidth) + "  " +
+		fmt.Sprintf("%12s %7s  %10s %7s  %9s %7s  %s", "time/op", "Δ", "B/op", "Δ", "allocs/op", "Δ", "runs")))
+	sb.WriteString("  " + v.styles.hint.Render("sorted by "+sortLabel) + "\n")
+
+	// Rows
+	visible := max(1, v.visibleRows())
+	var lines []string
+	for i := v.scrollTop; i < len(v.rows) && i < v.scrollTop+visible; i++ {
+		lines = append(lines, v.renderRow(v.rows[i], nameWidth, i == v.cursor))
+	}
+	if len(v.rows) == 0 {
+		lines = append(lines, v.styles.hint.Render("No benchmark results"))
+	}
+	for len(lines) < visible {
+		lines = append(lines, "")
+	}
+	sb.WriteString(strings.Join(lines, "\n") + "\n")
+
+	// Detail of the selected benchmark
+	sb.WriteString(v.renderDetail() + "\n")
+
+	// Help bar
+	help := "[s Sort]  [S Reverse]  [w Save baseline]  [Arrows Navigate]  [Esc Back]"
+	if v.status != "" {
+		help = v.status
+	}
+	sb.WriteString(v.styles.hint.Render(help))
+	return sb.String()
+}
+
+// nameWidth fits the longest name, within limits
+func (v BenchView) nameWidth() int {
+	width := len("Benchmark")
+	for _, r := range v.rows {
+		width = max(width, lipgloss.Width(r.name))
+	}
+	return min(width, max(20, v.width-75))
+}
+
+func (v BenchView) renderRow(r benchRow, nameWidth int, selected bool) string {
+	name := padRight(truncatePlainText(r.name, nameWidth), nameWidth)
+	if selected {
+		name = v.styles.selected.Render(name)
+	}
+
+	cells := []string{name}
+	widths := [3]int{12, 10, 9}
+	for i, m := range r.metrics {
+		value := ""
+		if m.ok {
+			value = formatBenchValue(benchUnits[i], m.summary.Median)
+			if m.summary.N > 1 {
+				value += fmt.Sprintf(" ±%.0f%%", m.summary.Spread*100)
+			}
+		}
+		cells = append(cells, fmt.Sprintf("%*s", widths[i], value), v.renderDelta(m.cmp))
+	}
+	cells = append(cells, v.styles.hint.Render(fmt.Sprintf("%4d", r.runs)))
+	return cells[0] + "  " + cells[1] + " " + cells[2] + "  " + cells[3] + " " + cells[4] + "  " +
+		cells[5] + " " + cells[6] + "  " + cells[7]
+}
+
+// renderDelta renders a change like benchstat: a percentage if significant,
+// "~" otherwise. Lower is better for all standard units.
+func (v BenchView) renderDelta(cmp *bench.Comparison) string {
+	if cmp == nil {
+		return strings.Repeat(" ", 7)
+	}
+	if !cmp.Significant {
+		return v.styles.same.Render(fmt.Sprintf("%7s", "~"))
+	}
+	text := fmt.Sprintf("%+6.1f%%", cmp.Delta*100)
+	if cmp.Delta > 0 {
+		return v.styles.worse.Render(text)
+	}
+	return v.styles.better.Render(text)
+}
+
+// renderDetail describes the time/op comparison of the selected benchmark
+func (v BenchView) renderDetail() string {
+	if v.cursor >= len(v.rows) {
+		return ""
+	}
+	r := v.rows[v.cursor]
+	detail := r.pkg
+	if m := r.metrics[0]; m.cmp != nil {
+		detail = fmt.Sprintf("time/op %s → %s  (p=%.3f n=%d+%d)  %s",
+			formatBenchValue(bench.UnitTime, m.cmp.Old.Median),
+			formatBenchValue(bench.UnitTime, m.cmp.New.Median),
+			m.cmp.P, m.cmp.Old.N, m.cmp.New.N, r.pkg)
+	}
+	return v.styles.hint.Render(truncatePlainText(detail, max(10, v.width)))
+}
+
+// formatBenchValue formats a metric value with a readable unit
+func formatBenchValue(unit string, value float64) string {
+	switch unit {
+	case bench.UnitTime:
+		switch {
+		case value < 1e3:
+			return fmt.Sprintf("%.4gns", value)
+		case value < 1e6:
+			return fmt.Sprintf("%.4gµs", value/1e3)
+		case value < 1e9:
+			return fmt.Sprintf("%.4gms", value/1e6)
+		default:
+			return fmt.Sprintf("%.4gs", value/1e9)
+		}
+	case bench.UnitBytes:
+		switch {
+		case value < 1<<10:
+			return fmt.Sprintf("%.0fB", value)
+		case value < 1<<20:
+			return fmt.Sprintf("%.1fKiB", value/(1<<10))
+		default:
+			return fmt.Sprintf("%.1fMiB", value/(1<<20))
+		}
+	default:
+		return fmt.Sprintf("%.4g", value)
+	}
+}
@@ -0,0 +1,76 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rickchristie/govner/gowt/history"
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/rickchristie/govner/gowt/util"
+)
+
+// changesPerGroup is how many tests the changes modal lists for each kind of change
+const changesPerGroup = 5
+
+// RenderChangesModal renders the changes since the previous run, grouped by
+// kind, with an OK button
+func RenderChangesModal(content string, diff history.Diff, screenWidth, screenHeight int) string {
+	styles := DefaultModalStyles()
+	groupStyles := map[history.Change]lipgloss.Style{
+		history.ChangeRegressed: lipgloss.NewStyle().Foreground(ColorFailed).Bold(true),
+		history.ChangeFixed:     lipgloss.NewStyle().Foreground(ColorPassed).Bold(true),
+		history.ChangeSkipped:   lipgloss.NewStyle().Foreground(ColorSkipped).Bold(true),
+		history.ChangeAdded:     lipgloss.NewStyle().Foreground(lipgloss.Color("33")).Bold(true),
+		history.ChangeRemoved:   lipgloss.NewStyle().Foreground(ColorSkipped).Bold(true),
+		history.ChangeSlower:    lipgloss.NewStyle().Foreground(ColorCached).Bold(true),
+	}
+	background := lipgloss.Color("235")
+	maxWidth := max(20, screenWidth-16)
+
+	var lines []string
+	if diff.Empty() {
+		lines = append(lines, "No tests changed since the previous run")
+	}
+	for i := 0; i < len(diff.Entries); {
+		change := diff.Entries[i].Change
+		j := i
+		for j < len(diff.Entries) && diff.Entries[j].Change == change {
+			j++
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, groupStyles[change].Background(background).Render(fmt.Sprintf("%d %s", j-i, change)))
+		for k := i; k < j && k < i+changesPerGroup; k++ {
+			e := diff.Entries[k]
+			line := "  " + model.ShortPath(e.Path)
+			if change == history.ChangeSlower {
+				line += fmt.Sprintf("  %s → %s", util.FormatDuration(e.Before), util.FormatDuration(e.After))
+			}
+			lines = append(lines, truncatePlainText(line, maxWidth))
+		}
+		if j-i > changesPerGroup {
+			lines = append(lines, fmt.Sprintf("  … and %d more", j-i-changesPerGroup))
+		}
+		i = j
+	}
+
+	// The message is centered, so pad lines to the same width to keep the list aligned
+	width := 0
+	for _, line := range lines {
+		width = max(width, lipgloss.Width(line))
+	}
+	for i, line := range lines {
+		lines[i] = line + strings.Repeat(" ", width-lipgloss.Width(line))
+	}
+
+	config := ModalConfig{
+		Title:   "Changes since previous run",
+		Message: strings.Join(lines, "\n"),
+		Buttons: []ModalButton{
+			{Label: "OK", Selected: true},
+		},
+	}
+	return RenderModal(content, config, styles, screenWidth, screenHeight)
+}
@@ -0,0 +1,371 @@
+package view
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rickchristie/govner/gowt/coverage"
+)
+
+// CoverageViewRequest represents a request from CoverageView to the controller
+type CoverageViewRequest interface {
+	isCoverageViewRequest()
+}
+
+// CloseCoverageRequest is emitted when user wants to go back to tree view
+type CloseCoverageRequest struct{}
+
+func (CloseCoverageRequest) isCoverageViewRequest() {}
+
+// OpenCoverageFileRequest is emitted when user wants to see a file's source.
+// The controller loads the source and hands it back with SetSource.
+type OpenCoverageFileRequest struct {
+	File string // Profile file name, e.g. "example.com/m/pkg/file.go"
+}
+
+func (OpenCoverageFileRequest) isCoverageViewRequest() {}
+
+// CoverageView shows the coverage of one package: first its files, then the
+// source of a file with covered and uncovered lines highlighted
+type CoverageView struct {
+	pkg     string
+	profile *coverage.Profile
+	files   []string
+	cursor  int
+
+	// Source mode (file != "")
+	file     string
+	lines    []string
+	lineCov  map[int]bool // Line number (1-based) -> covered, only lines with statements
+	loadErr  error
+	viewport viewport.Model
+
+	width  int
+	height int
+	ready  bool
+	styles coverageStyles
+}
+
+type coverageStyles struct {
+	header    lipgloss.Style
+	hint      lipgloss.Style
+	selected  lipgloss.Style
+	lineNum   lipgloss.Style
+	covered   lipgloss.Style
+	uncovered lipgloss.Style
+	neutral   lipgloss.Style
+	high      lipgloss.Style // >= 80%
+	medium    lipgloss.Style // >= 50%
+	low       lipgloss.Style
+}
+
+func defaultCoverageStyles() coverageStyles {
+	return coverageStyles{
+		header: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")),
+		hint:   lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+		selected: lipgloss.NewStyle().
+			Background(lipgloss.Color("24")).
+			Foreground(lipgloss.Color("231")).
+			Bold(true),
+		lineNum:   lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+		covered:   lipgloss.NewStyle().Foreground(ColorPassed),
+		uncovered: lipgloss.NewStyle().Foreground(ColorFailed),
+		neutral:   lipgloss.NewStyle().Foreground(lipgloss.Color("250")),
+		high:      lipgloss.NewStyle().Foreground(ColorPassed),
+		medium:    lipgloss.NewStyle().Foreground(ColorCached),
+		low:       lipgloss.NewStyle().Foreground(ColorFailed),
+	}
+}
+
+// NewCoverageView creates a new CoverageView
+func NewCoverageView() CoverageView {
+	return CoverageView{styles: defaultCoverageStyles()}
+}
+
+// SetPackage shows the file list of a package
+func (v CoverageView) SetPackage(pkg string, profile *coverage.Profile) CoverageView {
+	v.pkg = pkg
+	v.profile = profile
+	v.files = profile.PackageFiles(pkg)
+	v.cursor = 0
+	v.file = ""
+	v.lines = nil
+	return v
+}
+
+// SetSource shows the source of a file. err is shown instead if the source
+// could not be read.
+func (v CoverageView) SetSource(file string, source string, err error) CoverageView {
+	v.file = file
+	v.loadErr = err
+	v.lines = strings.Split(strings.TrimRight(source, "\n"), "\n")
+	v.lineCov = v.profile.LineCoverage(file)
+	if v.ready {
+		v.viewport.SetContent(v.renderSource())
+		v.viewport.GotoTop()
+	}
+	return v
+}
+
+// Init implements tea.Model
+func (v CoverageView) Init() tea.Cmd {
+	return nil
+}
+
+type coverageKeyMap struct {
+	Up            key.Binding
+	Down          key.Binding
+	PageUp        key.Binding
+	PageDown      key.Binding
+	Top           key.Binding
+	Bottom        key.Binding
+	Open          key.Binding
+	NextUncovered key.Binding
+	PrevUncovered key.Binding
+	Back          key.Binding
+}
+
+var coverageKeys = coverageKeyMap{
+	Up:            key.NewBinding(key.WithKeys("up", "k", "K")),
+	Down:          key.NewBinding(key.WithKeys("down", "j", "J")),
+	PageUp:        key.NewBinding(key.WithKeys("pgup", "ctrl+u", "ctrl+U")),
+	PageDown:      key.NewBinding(key.WithKeys("pgdown", "ctrl+d", "ctrl+D")),
+	Top:           key.NewBinding(key.WithKeys("g")),
+	Bottom:        key.NewBinding(key.WithKeys("G")),
+	Open:          key.NewBinding(key.WithKeys("enter")),
+	NextUncovered: key.NewBinding(key.WithKeys("n")),
+	PrevUncovered: key.NewBinding(key.WithKeys("N")),
+	Back:          key.NewBinding(key.WithKeys("esc", "backspace", "q", "Q")),
+}
+
+// Update implements tea.Model
+func (v CoverageView) Update(msg tea.Msg) (CoverageView, tea.Cmd, CoverageViewRequest) {
+	var request CoverageViewRequest
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width = msg.Width
+		v.height = msg.Height
+		headerHeight := 2 // Title + help bar
+		if !v.ready {
+			v.viewport = viewport.New(msg.Width, msg.Height-headerHeight)
+			v.ready = true
+		} else {
+			v.viewport.Width = msg.Width
+			v.viewport.Height = msg.Height - headerHeight
+		}
+		if v.file != "" {
+			v.viewport.SetContent(v.renderSource())
+		}
+
+	case tea.KeyMsg:
+		if v.file != "" {
+			return v.updateSource(msg)
+		}
+		switch {
+		case key.Matches(msg, coverageKeys.Back):
+			request = CloseCoverageRequest{}
+		case key.Matches(msg, coverageKeys.Up):
+			if v.cursor > 0 {
+				v.cursor--
+			}
+		case key.Matches(msg, coverageKeys.Down):
+			if v.cursor < len(v.files)-1 {
+				v.cursor++
+			}
+		case key.Matches(msg, coverageKeys.Top):
+			v.cursor = 0
+		case key.Matches(msg, coverageKeys.Bottom):
+			v.cursor = max(0, len(v.files)-1)
+		case key.Matches(msg, coverageKeys.Open):
+			if v.cursor < len(v.files) {
+				request = OpenCoverageFileRequest{File: v.files[v.cursor]}
+			}
+		}
+	}
+
+	return v, cmd, request
+}
+
+// updateSource handles keys while a file's source is shown
+func (v CoverageView) updateSource(msg tea.KeyMsg) (CoverageView, tea.Cmd, CoverageViewRequest) {
+	switch {
+	case key.Matches(msg, coverageKeys.Back):
+		// Back to the file list
+		v.file = ""
+		v.lines = nil
+	case key.Matches(msg, coverageKeys.Up):
+		v.viewport.LineUp(1)
+	case key.Matches(msg, coverageKeys.Down):
+		v.viewport.LineDown(1)
+	case key.Matches(msg, coverageKeys.PageUp):
+		v.viewport.HalfViewUp()
+	case key.Matches(msg, coverageKeys.PageDown):
+		v.viewport.HalfViewDown()
+	case key.Matches(msg, coverageKeys.Top):
+		v.viewport.GotoTop()
+	case key.Matches(msg, coverageKeys.Bottom):
+		v.viewport.GotoBottom()
+	case key.Matches(msg, coverageKeys.NextUncovered):
+		if line, ok := v.nextUncovered(v.viewport.YOffset+1, 1); ok {
+			v.viewport.SetYOffset(line - 1)
+		}
+	case key.Matches(msg, coverageKeys.PrevUncovered):
+		if line, ok := v.nextUncovered(v.viewport.YOffset-1, -1); ok {
+			v.viewport.SetYOffset(line - 1)
+		}
+	}
+	return v, nil, nil
+}
+
+// nextUncovered finds the first line of the next uncovered run of lines,
+// searching from the 0-based line index from in direction dir. Returns a
+// 1-based line number.
+func (v CoverageView) nextUncovered(from, dir int) (int, bool) {
+	for i := from; i >= 0 && i < len(v.lines); i += dir {
+		line := i + 1
+		if v.isUncovered(line) && !v.isUncovered(line-1) {
+			return line, true
+		}
+	}
+	return 0, false
+}
+
+// isUncovered reports whether a line holds statements that did not run
+func (v CoverageView) isUncovered(line int) bool {
+	covered, tracked := v.lineCov[line]
+	return tracked && !covered
+}
+
+// View implements tea.Model
+func (v CoverageView) View() string {
+	if v.file != "" {
+		return v.viewSource()
+	}
+	return v.viewFiles()
+}
+
+func (v CoverageView) viewFiles() string {
+	var sb strings.Builder
+
+	title := v.styles.header.Render("Coverage") + "  " + v.pkg
+	if stats, ok := v.profile.PackageStats(v.pkg); ok {
+		title += "  " + v.renderPercent(stats.Percent())
+	}
+	sb.WriteString(title)
+	sb.WriteString("\n")
+
+	visibleRows := max(1, v.height-2)
+	top := 0
+	if v.cursor >= visibleRows {
+		top = v.cursor - visibleRows + 1
+	}
+
+	var rows []string
+	for i := top; i < len(v.files) && i < top+visibleRows; i++ {
+		file := v.files[i]
+		stats := v.profile.FileStats(file)
+		name := padRight(path.Base(file), 32)
+		counts := v.styles.hint.Render(fmt.Sprintf("%d/%d stmts", stats.Covered, stats.Total))
+		if i == v.cursor {
+			name = v.styles.selected.Render(name)
+		}
+		rows = append(rows, " "+name+" "+v.renderPercent(stats.Percent())+"  "+counts)
+	}
+	if len(v.files) == 0 {
+		rows = append(rows, v.styles.hint.Render(" No coverage data for this package"))
+	}
+	for len(rows) < visibleRows {
+		rows = append(rows, "")
+	}
+	sb.WriteString(strings.Join(rows, "\n"))
+	sb.WriteString("\n")
+	sb.WriteString(v.styles.hint.Render("[↵ Source]  [Arrows Navigate]  [Esc Back]"))
+	return sb.String()
+}
+
+func (v CoverageView) viewSource() string {
+	var sb strings.Builder
+
+	stats := v.profile.FileStats(v.file)
+	sb.WriteString(v.styles.header.Render("Coverage") + "  " + v.file + "  " + v.renderPercent(stats.Percent()))
+	sb.WriteString("\n")
+	if v.ready {
+		sb.WriteString(v.viewport.View())
+	} else {
+		sb.WriteString(v.renderSource())
+	}
+	sb.WriteString("\n")
+
+	help := "[n/N Next/Prev uncovered]  [Arrows Scroll]  [Esc Back]"
+	scrollInfo := fmt.Sprintf("─ %3.0f%% ─", v.viewport.ScrollPercent()*100)
+	padding := max(1, v.width-lipgloss.Width(help)-lipgloss.Width(scrollInfo))
+	sb.WriteString(v.styles.hint.Render(help) + strings.Repeat(" ", padding) + v.styles.hint.Render(scrollInfo))
+	return sb.String()
+}
+
+// renderSource renders the source with a gutter: line number and a bar
+// colored by coverage
+func (v CoverageView) renderSource() string {
+	if v.loadErr != nil {
+		return v.styles.uncovered.Render(fmt.Sprintf("Cannot read source: %v", v.loadErr))
+	}
+
+	width := len(fmt.Sprint(len(v.lines)))
+	var sb strings.Builder
+	for i, line := range v.lines {
+		num := v.styles.lineNum.Render(fmt.Sprintf("%*d ", width, i+1))
+		line = strings.ReplaceAll(line, "\t", "    ")
+		covered, tracked := v.lineCov[i+1]
+		switch {
+		case !tracked:
+			sb.WriteString(num + "  " + v.styles.neutral.Render(line))
+		case covered:
+			sb.WriteString(num + v.styles.covered.Render("▌ "+line))
+		default:
+			sb.WriteString(num + v.styles.uncovered.Render("▌ "+line))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// renderPercent colors a coverage percentage by how good it is
+func (v CoverageView) renderPercent(percent float64) string {
+	return coverageStyle(v.styles, percent).Render(coverage.FormatPercent(percent))
+}
+
+// coverageStyle picks the style for a coverage percentage
+func coverageStyle(styles coverageStyles, percent float64) lipgloss.Style {
+	switch {
+	case percent >= 80:
+		return styles.high
+	case percent >= 50:
+		return styles.medium
+	default:
+		return styles.low
+	}
+}
+
+// packageCoverage returns the coverage of each package in the profile
+func packageCoverage(profile *coverage.Profile) map[string]float64 {
+	stats := make(map[string]coverage.Stats)
+	for file := range profile.Files {
+		fs := profile.FileStats(file)
+		s := stats[path.Dir(file)]
+		s.Covered += fs.Covered
+		s.Total += fs.Total
+		stats[path.Dir(file)] = s
+	}
+	percents := make(map[string]float64, len(stats))
+	for pkg, s := range stats {
+		percents[pkg] = s.Percent()
+	}
+	return percents
+}
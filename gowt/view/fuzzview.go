@@ -0,0 +1,288 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rickchristie/govner/gowt/fuzz"
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/rickchristie/govner/gowt/util"
+)
+
+// FuzzViewRequest represents a request from FuzzView to the controller
+type FuzzViewRequest interface {
+	isFuzzViewRequest()
+}
+
+// CloseFuzzRequest is emitted when user wants to go back to tree view
+type CloseFuzzRequest struct{}
+
+func (CloseFuzzRequest) isFuzzViewRequest() {}
+
+// RerunCrasherRequest is emitted when user wants to rerun only the failing
+// input a fuzz target wrote to testdata/fuzz
+type RerunCrasherRequest struct {
+	Node *model.TestNode // The fuzz target
+}
+
+func (RerunCrasherRequest) isFuzzViewRequest() {}
+
+// FuzzView shows live progress of the fuzz targets in the tree
+type FuzzView struct {
+	targets   []*model.TestNode
+	cursor    int
+	scrollTop int
+
+	width  int
+	height int
+	styles fuzzStyles
+}
+
+type fuzzStyles struct {
+	header   lipgloss.Style
+	column   lipgloss.Style
+	hint     lipgloss.Style
+	selected lipgloss.Style
+	running  lipgloss.Style
+	passed   lipgloss.Style
+	failed   lipgloss.Style
+}
+
+func defaultFuzzStyles() fuzzStyles {
+	return fuzzStyles{
+		header: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")),
+		column: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("248")),
+		hint:   lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+		selected: lipgloss.NewStyle().
+			Background(lipgloss.Color("24")).
+			Foreground(lipgloss.Color("231")).
+			Bold(true),
+		running: lipgloss.NewStyle().Foreground(SpinnerColors[0]),
+		passed:  lipgloss.NewStyle().Foreground(ColorPassed),
+		failed:  lipgloss.NewStyle().Foreground(ColorFailed),
+	}
+}
+
+// NewFuzzView creates a new FuzzView
+func NewFuzzView() FuzzView {
+	return FuzzView{styles: defaultFuzzStyles()}
+}
+
+// SetData shows the fuzz targets of the tree
+func (v FuzzView) SetData(tree *model.TestTree) FuzzView {
+	v.targets = tree.FuzzTargets()
+	if v.cursor >= len(v.targets) {
+		v.cursor = max(0, len(v.targets)-1)
+	}
+	return v
+}
+
+// Init implements tea.Model
+func (v FuzzView) Init() tea.Cmd {
+	return nil
+}
+
+type fuzzKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Top    key.Binding
+	Bottom key.Binding
+	Rerun  key.Binding
+	Back   key.Binding
+}
+
+var fuzzKeys = fuzzKeyMap{
+	Up:     key.NewBinding(key.WithKeys("up", "k", "K")),
+	Down:   key.NewBinding(key.WithKeys("down", "j", "J")),
+	Top:    key.NewBinding(key.WithKeys("g")),
+	Bottom: key.NewBinding(key.WithKeys("G")),
+	Rerun:  key.NewBinding(key.WithKeys("r", "R")),
+	Back:   key.NewBinding(key.WithKeys("esc", "backspace", "q", "Q")),
+}
+
+// Update implements tea.Model
+func (v FuzzView) Update(msg tea.Msg) (FuzzView, tea.Cmd, FuzzViewRequest) {
+	var request FuzzViewRequest
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width = msg.Width
+		v.height = msg.Height
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, fuzzKeys.Back):
+			request = CloseFuzzRequest{}
+		case key.Matches(msg, fuzzKeys.Rerun):
+			if node := v.selected(); node != nil && node.Fuzz.Crasher != "" {
+				request = RerunCrasherRequest{Node: node}
+			}
+		case key.Matches(msg, fuzzKeys.Up):
+			v.cursor = max(0, v.cursor-1)
+		case key.Matches(msg, fuzzKeys.Down):
+			v.cursor = max(0, min(len(v.targets)-1, v.cursor+1))
+		case key.Matches(msg, fuzzKeys.Top):
+			v.cursor = 0
+		case key.Matches(msg, fuzzKeys.Bottom):
+			v.cursor = max(0, len(v.targets)-1)
+		}
+
+		// Keep the cursor on screen
+		visible := max(1, v.visibleRows())
+		if v.cursor < v.scrollTop {
+			v.scrollTop = v.cursor
+		}
+		if v.cursor >= v.scrollTop+visible {
+			v.scrollTop = v.cursor - visible + 1
+		}
+	}
+
+	return v, cmd, request
+}
+
+// selected returns the fuzz target under the cursor, nil if there is none
+func (v FuzzView) selected() *model.TestNode {
+	if v.cursor < len(v.targets) {
+		return v.targets[v.cursor]
+	}
+	return nil
+}
+
+// visibleRows is the number of table rows that fit: title, column headers,
+// three detail lines and help bar take six lines
+func (v FuzzView) visibleRows() int {
+	return v.height - 6
+}
+
+// View implements tea.Model
+func (v FuzzView) View() string {
+	var sb strings.Builder
+
+	// Title
+	crashers := 0
+	for _, node := range v.targets {
+		if node.Fuzz.Crasher != "" {
+			crashers++
+		}
+	}
+	title := v.styles.header.Render("Fuzzing") + "  " + v.styles.hint.Render(fmt.Sprintf("%d targets", len(v.targets)))
+	if crashers > 0 {
+		title += "  " + v.styles.failed.Render(fmt.Sprintf("%d crashers", crashers))
+	}
+	sb.WriteString(title + "\n")
+
+	// Column headers
+	nameWidth := v.nameWidth()
+	sb.WriteString(v.styles.column.Render(padRight("Target", nameWidth)+"  "+
+		fmt.Sprintf("%-16s %8s %9s %9s %6s %7s %7s", "Status", "Elapsed", "Execs", "Execs/s", "New", "Corpus", "Workers")) + "\n")
+
+	// Rows
+	visible := max(1, v.visibleRows())
+	var lines []string
+	for i := v.scrollTop; i < len(v.targets) && i < v.scrollTop+visible; i++ {
+		lines = append(lines, v.renderRow(v.targets[i], nameWidth, i == v.cursor))
+	}
+	if len(v.targets) == 0 {
+		lines = append(lines, v.styles.hint.Render("No fuzz targets"))
+	}
+	for len(lines) < visible {
+		lines = append(lines, "")
+	}
+	sb.WriteString(strings.Join(lines, "\n") + "\n")
+
+	// Detail of the selected target
+	sb.WriteString(v.renderDetail() + "\n")
+
+	// Help bar
+	help := "[Arrows Navigate]  [Esc Back]"
+	if node := v.selected(); node != nil && node.Fuzz.Crasher != "" {
+		help = "[r Rerun crasher]  " + help
+	}
+	sb.WriteString(v.styles.hint.Render(help))
+	return sb.String()
+}
+
+// nameWidth fits the longest name, within limits
+func (v FuzzView) nameWidth() int {
+	width := len("Target")
+	for _, node := range v.targets {
+		width = max(width, node.NameWidth)
+	}
+	return min(width, max(20, v.width-70))
+}
+
+func (v FuzzView) renderRow(node *model.TestNode, nameWidth int, selected bool) string {
+	name := padRight(truncatePlainText(node.Name, nameWidth), nameWidth)
+	if selected {
+		name = v.styles.selected.Render(name)
+	}
+
+	p := node.Fuzz
+	status, style := v.fuzzStatus(node)
+	cells := fmt.Sprintf("%8s %9s %9s %6d %7d %7s",
+		util.FormatDuration(max(p.Elapsed.Seconds(), node.Elapsed)),
+		fuzz.FormatCount(p.Execs),
+		fuzz.FormatCount(p.ExecsPerSec),
+		p.NewInteresting,
+		p.Corpus,
+		formatWorkers(p.Workers))
+	return name + "  " + style.Render(padRight(status, 16)) + " " + cells
+}
+
+// renderDetail shows where the selected target's crasher was written and how
+// to rerun it, or its package
+func (v FuzzView) renderDetail() string {
+	node := v.selected()
+	if node == nil {
+		return "\n\n"
+	}
+	width := max(10, v.width)
+	lines := []string{v.styles.hint.Render(truncatePlainText(node.Package, width)), "", ""}
+	if crasher := node.Fuzz.Crasher; crasher != "" {
+		lines[1] = v.styles.failed.Render(truncatePlainText("Failing input: "+crasher, width))
+		lines[2] = v.styles.hint.Render(truncatePlainText("go test -run="+node.Fuzz.RerunTest()+" "+node.Package, width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fuzzStatus describes what a fuzz target is doing
+func (v FuzzView) fuzzStatus(node *model.TestNode) (string, lipgloss.Style) {
+	p := node.Fuzz
+	switch {
+	case p.Crasher != "":
+		return "crasher found", v.styles.failed
+	case node.Status == model.StatusFailed:
+		return "failed", v.styles.failed
+	case node.Status == model.StatusPassed:
+		return "done", v.styles.passed
+	case p.Phase == fuzz.PhaseBaseline:
+		return fmt.Sprintf("baseline %d/%d", p.Baseline, p.BaselineTotal), v.styles.running
+	default:
+		return p.Phase.String(), v.styles.running
+	}
+}
+
+// fuzzSummary is the short progress shown next to a fuzz target in the tree
+func fuzzSummary(p *fuzz.Progress) string {
+	switch {
+	case p.Crasher != "":
+		return "crasher found"
+	case p.Phase == fuzz.PhaseBaseline:
+		return fmt.Sprintf("baseline %d/%d", p.Baseline, p.BaselineTotal)
+	case p.Phase == fuzz.PhaseMinimizing:
+		return "minimizing"
+	default:
+		return fmt.Sprintf("%s/s  %d new  %d corpus", fuzz.FormatCount(p.ExecsPerSec), p.NewInteresting, p.Corpus)
+	}
+}
+
+func formatWorkers(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", n)
+}
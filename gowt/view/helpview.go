@@ -196,6 +196,12 @@ func (v HelpView) renderTreeContent() string {
 	sb.WriteString(v.renderKey("Space", "Toggle filter (All/Focus)"))
 	sb.WriteString(v.renderKey("r", "Rerun selected test"))
 	sb.WriteString(v.renderKey("R", "Rerun all failed tests"))
+	sb.WriteString(v.renderKey("c", "Toggle coverage for next runs"))
+	sb.WriteString(v.renderKey("v", "View coverage of package"))
+	sb.WriteString(v.renderKey("b", "View benchmark results"))
+	sb.WriteString(v.renderKey("f", "View fuzzing progress"))
+	sb.WriteString(v.renderKey("d", "Show changes since previous run"))
+	sb.WriteString(v.renderKey("w", "View data races found by -race"))
 
 	// Other
 	sb.WriteString("\n")
@@ -247,6 +253,11 @@ func (v HelpView) renderLogContent() string {
 	sb.WriteString(v.renderKey("Space", "Toggle view mode (Processed/Raw)"))
 	sb.WriteString(v.renderKey("c", "Copy logs to clipboard"+getClipboardHint()))
 	sb.WriteString(v.renderKey("r", "Rerun this test"))
+	sb.WriteString(v.renderKey("Tab", "Select next file:line reference"))
+	sb.WriteString(v.renderKey("Shift+Tab", "Select previous file:line reference"))
+	sb.WriteString(v.renderKey("e", "Open reference in editor"))
+	sb.WriteString(v.renderKey("p", "Toggle source preview"))
+	sb.WriteString(v.renderKey("s", "Browse panic, goroutine and race stacks"))
 
 	// Other
 	sb.WriteString("\n")
@@ -12,6 +12,7 @@ const (
 	IconCharSkipped = "⊘"
 	IconCharPending = "○"
 	IconCharCached  = "↯"
+	IconCharFlaky   = "◐"
 	IconCharGear    = "⚙"
 )
 
@@ -25,6 +26,7 @@ var (
 	ColorSkipped = lipgloss.Color("245") // Gray
 	ColorPending = lipgloss.Color("241") // Dim gray
 	ColorCached  = lipgloss.Color("220") // Yellow/gold
+	ColorFlaky   = lipgloss.Color("208") // Orange
 )
 
 // Spinner gradient colors (cyan -> blue -> magenta -> pink cycle)
@@ -51,6 +53,7 @@ var (
 	IconSkipped string
 	IconPending string
 	IconCached  string
+	IconFlaky   string
 
 	// Status icons - styled with color, NO trailing space (for headers/compact use)
 	IconPassedCompact  string
@@ -58,6 +61,7 @@ var (
 	IconSkippedCompact string
 	IconPendingCompact string
 	IconCachedCompact  string
+	IconFlakyCompact   string
 
 	// Raw icons - no color styling (for use in inverted/selected rows)
 	IconPassedRaw  = IconCharPassed + " "
@@ -65,6 +69,7 @@ var (
 	IconSkippedRaw = IconCharSkipped + " "
 	IconPendingRaw = IconCharPending + " "
 	IconCachedRaw  = IconCharCached + " "
+	IconFlakyRaw   = IconCharFlaky + " "
 
 	// Gear icons for header
 	IconGearPassed string
@@ -91,6 +96,7 @@ func init() {
 	skippedStyle := lipgloss.NewStyle().Foreground(ColorSkipped)
 	pendingStyle := lipgloss.NewStyle().Foreground(ColorPending)
 	cachedStyle := lipgloss.NewStyle().Foreground(ColorCached)
+	flakyStyle := lipgloss.NewStyle().Foreground(ColorFlaky)
 
 	// With trailing space (for tree view rows)
 	IconPassed = passedStyle.Render(IconCharPassed) + " "
@@ -98,6 +104,7 @@ func init() {
 	IconSkipped = skippedStyle.Render(IconCharSkipped) + " "
 	IconPending = pendingStyle.Render(IconCharPending) + " "
 	IconCached = cachedStyle.Render(IconCharCached) + " "
+	IconFlaky = flakyStyle.Render(IconCharFlaky) + " "
 
 	// Without trailing space (for headers/compact use)
 	IconPassedCompact = passedStyle.Render(IconCharPassed)
@@ -105,6 +112,7 @@ func init() {
 	IconSkippedCompact = skippedStyle.Render(IconCharSkipped)
 	IconPendingCompact = pendingStyle.Render(IconCharPending)
 	IconCachedCompact = cachedStyle.Render(IconCharCached)
+	IconFlakyCompact = flakyStyle.Render(IconCharFlaky)
 
 	// Pre-render gear icons for header
 	IconGearPassed = passedStyle.Render(IconCharGear)
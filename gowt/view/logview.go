@@ -8,8 +8,12 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/rickchristie/govner/gowt/editor"
 	"github.com/rickchristie/govner/gowt/meta"
 	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/rickchristie/govner/gowt/source"
+	"github.com/rickchristie/govner/gowt/stack"
 )
 
 // LogViewRequest represents a request from LogView to the controller
@@ -31,12 +35,20 @@ func (CopyLogsRequest) isLogViewRequest() {}
 
 // OpenEditorRequest is emitted when user wants to open file in editor
 type OpenEditorRequest struct {
-	File string
+	File string // As written in the log, resolved against the package directory
 	Line int
+	Col  int // 0 if the reference has no column
 }
 
 func (OpenEditorRequest) isLogViewRequest() {}
 
+// LoadSourceRequest is emitted when the source preview needs a file
+type LoadSourceRequest struct {
+	File string // As written in the log, resolved against the package directory
+}
+
+func (LoadSourceRequest) isLogViewRequest() {}
+
 // LogRerunTestRequest is emitted when user wants to rerun the current test
 type LogRerunTestRequest struct {
 	Node *model.TestNode
@@ -44,6 +56,12 @@ type LogRerunTestRequest struct {
 
 func (LogRerunTestRequest) isLogViewRequest() {}
 
+// ShowStacksRequest is emitted when user wants to see the panic, goroutine
+// dump or race reports in the log
+type ShowStacksRequest struct{}
+
+func (ShowStacksRequest) isLogViewRequest() {}
+
 // ShowLogHelpRequest is emitted when user wants to see log help
 type ShowLogHelpRequest struct{}
 
@@ -91,10 +109,29 @@ type LogView struct {
 	searchActive       bool            // Whether confirmed search is active (after Enter)
 	highlightedContent strings.Builder // Content with search highlights applied
 	highlightedLastEnd int             // Last renderer position we've highlighted up to
+
+	// file:line references in the log, cycled with Tab
+	locations     []editor.Location
+	locationIndex int    // Index into locations (-1 if none selected)
+	locScanned    int    // Length of the log scanned for references, up to a line end
+	locLines      int    // Lines in the scanned part
+	status        string // Shown in the help bar until the next key, e.g. editor errors
+	hasStacks     bool   // Whether the scanned log has goroutine stacks or race reports
+
+	// Source around the selected reference, shown below the log
+	showPreview   bool         // Whether the preview is shown when a reference is selected
+	previewFile   string       // File of the preview as written in the log, "" if none
+	previewSource *source.File // nil while loading or if it could not be read
+	previewErr    error
 }
 
 const scrollOffsetBottom = -1 // Sentinel value meaning "scroll to bottom"
 
+const (
+	logHeaderHeight  = 3 // header + help bar + empty line
+	previewMinHeight = 5 // Title and 4 source lines, also the least the log keeps
+)
+
 type logStyles struct {
 	header          lipgloss.Style
 	helpBar         lipgloss.Style
@@ -107,8 +144,18 @@ type logStyles struct {
 	copyFailed      lipgloss.Style
 	copySheen       lipgloss.Style // Bright highlight for sheen animation
 	searchHighlight lipgloss.Style // Highlight for search matches
+	location        lipgloss.Style // Highlight for the selected file:line reference
+	previewTitle    lipgloss.Style
+	previewFunc     lipgloss.Style
+	lineNum         lipgloss.Style
+	markedLine      lipgloss.Style // Line number of the referenced line
+	sourceText      lipgloss.Style
+	syntax          map[source.Kind]lipgloss.Style
 }
 
+// colorMarkedLine is the background of the referenced line in the preview
+const colorMarkedLine = lipgloss.Color("52")
+
 func defaultLogStyles() logStyles {
 	return logStyles{
 		header:          lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")),
@@ -122,6 +169,19 @@ func defaultLogStyles() logStyles {
 		copyFailed:      lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true),
 		copySheen:       lipgloss.NewStyle().Foreground(lipgloss.Color("255")).Bold(true),                      // Bright white
 		searchHighlight: lipgloss.NewStyle().Background(lipgloss.Color("220")).Foreground(lipgloss.Color("0")), // Yellow bg, black text
+		location:        lipgloss.NewStyle().Background(lipgloss.Color("33")).Foreground(lipgloss.Color("15")).Underline(true),
+		previewTitle:    lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+		previewFunc:     lipgloss.NewStyle().Foreground(lipgloss.Color("81")).Bold(true),
+		lineNum:         lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+		markedLine:      lipgloss.NewStyle().Foreground(ColorFailed).Bold(true),
+		sourceText:      lipgloss.NewStyle().Foreground(lipgloss.Color("252")),
+		syntax: map[source.Kind]lipgloss.Style{
+			source.Keyword: lipgloss.NewStyle().Foreground(lipgloss.Color("204")),
+			source.String:  lipgloss.NewStyle().Foreground(lipgloss.Color("180")),
+			source.Comment: lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Italic(true),
+			source.Number:  lipgloss.NewStyle().Foreground(lipgloss.Color("141")),
+			source.Builtin: lipgloss.NewStyle().Foreground(lipgloss.Color("81")),
+		},
 	}
 }
 
@@ -130,6 +190,8 @@ func NewLogView() LogView {
 		styles:           defaultLogStyles(),
 		processedYOffset: scrollOffsetBottom,
 		rawYOffset:       scrollOffsetBottom,
+		locationIndex:    -1,
+		showPreview:      true,
 	}
 }
 
@@ -176,6 +238,14 @@ func (v LogView) SetData(node *model.TestNode, processedBuffer, rawBuffer *model
 		v.rawRenderer = nil
 	}
 
+	v.status = ""
+	// References of another package resolve against another directory
+	v.previewFile = ""
+	v.previewSource = nil
+	v.previewErr = nil
+	(&v).resetLocations()
+	(&v).findLocations()
+
 	if v.ready {
 		v.viewport.SetContent(v.getContent())
 		v.viewport.GotoBottom()
@@ -212,6 +282,7 @@ func (v LogView) UpdateContent(node *model.TestNode) LogView {
 		if v.searchActive {
 			(&v).appendHighlightedContent()
 		}
+		(&v).findLocations()
 
 		if v.ready {
 			wasAtBottom := v.viewport.AtBottom()
@@ -257,6 +328,8 @@ func (v LogView) getContent() string {
 		}
 	}
 
+	content = v.highlightLocation(content)
+
 	// Add end mark when test is completed
 	if v.node != nil && v.node.Status != model.StatusRunning && v.node.Status != model.StatusPending {
 		endMark := lipgloss.NewStyle().Faint(true).Render("·  end of log  ·")
@@ -387,6 +460,11 @@ type logKeyMap struct {
 	Search     key.Binding
 	NextMatch  key.Binding
 	PrevMatch  key.Binding
+	NextLoc    key.Binding
+	PrevLoc    key.Binding
+	Editor     key.Binding
+	Preview    key.Binding
+	Stacks     key.Binding
 }
 
 var logKeys = logKeyMap{
@@ -404,6 +482,11 @@ var logKeys = logKeyMap{
 	Search:     key.NewBinding(key.WithKeys("/")),
 	NextMatch:  key.NewBinding(key.WithKeys("n")),
 	PrevMatch:  key.NewBinding(key.WithKeys("N")),
+	NextLoc:    key.NewBinding(key.WithKeys("tab")),
+	PrevLoc:    key.NewBinding(key.WithKeys("shift+tab")),
+	Editor:     key.NewBinding(key.WithKeys("e", "E")),
+	Preview:    key.NewBinding(key.WithKeys("p", "P")),
+	Stacks:     key.NewBinding(key.WithKeys("s", "S")),
 }
 
 func (v LogView) Update(msg tea.Msg) (LogView, tea.Cmd, LogViewRequest) {
@@ -415,12 +498,11 @@ func (v LogView) Update(msg tea.Msg) (LogView, tea.Cmd, LogViewRequest) {
 		v.width = msg.Width
 		v.height = msg.Height
 
-		headerHeight := 3 // header + help bar + empty line
-
 		if !v.ready {
-			v.viewport = viewport.New(msg.Width, msg.Height-headerHeight)
+			v.viewport = viewport.New(msg.Width, msg.Height-logHeaderHeight)
 			v.viewport.Style = lipgloss.NewStyle()
 			v.ready = true
+			v.layout()
 			if v.renderer != nil || v.rawRenderer != nil {
 				v.viewport.SetContent(v.getContent())
 				if v.gotoBottom {
@@ -432,7 +514,7 @@ func (v LogView) Update(msg tea.Msg) (LogView, tea.Cmd, LogViewRequest) {
 			// Check if width changed - need to recalculate line wrapping
 			widthChanged := v.viewport.Width != msg.Width
 			v.viewport.Width = msg.Width
-			v.viewport.Height = msg.Height - headerHeight
+			v.layout()
 
 			// Re-set content to recalculate line wrapping for new width
 			if widthChanged {
@@ -490,7 +572,56 @@ func (v LogView) Update(msg tea.Msg) (LogView, tea.Cmd, LogViewRequest) {
 			return v, cmd, request
 		}
 
+		v.status = ""
+
 		switch {
+		case key.Matches(msg, logKeys.NextLoc):
+			if len(v.locations) > 0 {
+				v.locationIndex = (v.locationIndex + 1) % len(v.locations)
+				v.scrollToLocation()
+				request = v.requestSource()
+			}
+			return v, cmd, request
+
+		case key.Matches(msg, logKeys.PrevLoc):
+			if len(v.locations) > 0 {
+				v.locationIndex--
+				if v.locationIndex < 0 {
+					v.locationIndex = len(v.locations) - 1
+				}
+				v.scrollToLocation()
+				request = v.requestSource()
+			}
+			return v, cmd, request
+
+		case key.Matches(msg, logKeys.Editor):
+			// Without a selection, open the first reference: usually the failure.
+			// Its preview loads when the editor is closed.
+			if len(v.locations) > 0 {
+				if v.locationIndex < 0 {
+					v.locationIndex = 0
+					v.scrollToLocation()
+					v.requestSource()
+				}
+				loc := v.locations[v.locationIndex]
+				request = OpenEditorRequest{File: loc.File, Line: loc.Line, Col: loc.Col}
+			}
+			return v, cmd, request
+
+		case key.Matches(msg, logKeys.Preview):
+			v.showPreview = !v.showPreview
+			if v.locationIndex >= 0 {
+				v.scrollToLocation()
+				request = v.requestSource()
+			}
+			return v, cmd, request
+
+		case key.Matches(msg, logKeys.Stacks):
+			if v.hasStacks {
+				request = ShowStacksRequest{}
+			}
+			return v, cmd, request
+
 		case key.Matches(msg, logKeys.Search):
 			// Enter search mode, clear any previous search highlighting
 			v.searchMode = true
@@ -584,6 +715,10 @@ func (v LogView) Update(msg tea.Msg) (LogView, tea.Cmd, LogViewRequest) {
 				}
 			}
 
+			// References are found in the shown text, so they differ between modes
+			(&v).resetLocations()
+			(&v).findLocations()
+
 			// Refresh viewport content with new mode and restore scroll position
 			if v.ready {
 				v.viewport.SetContent(v.getContent())
@@ -783,6 +918,136 @@ func (v *LogView) scrollToCurrentMatch() {
 	v.autoScroll = false
 }
 
+// resetLocations forgets the references found, for a new log or mode
+func (v *LogView) resetLocations() {
+	v.locations = nil
+	v.locationIndex = -1
+	v.locScanned = 0
+	v.locLines = 0
+	v.hasStacks = false
+	v.layout()
+}
+
+// findLocations finds the file:line references, and whether there are
+// goroutine stacks, in the complete lines of the shown log that haven't been
+// scanned yet
+func (v *LogView) findLocations() {
+	var content string
+	if v.viewMode == LogModeRaw {
+		if v.rawRenderer != nil {
+			content = v.rawRenderer.String()
+		}
+	} else if v.renderer != nil {
+		content = v.renderer.String()
+	}
+	if len(content) < v.locScanned {
+		v.resetLocations()
+	}
+
+	end := strings.LastIndexByte(content, '\n') + 1
+	if end <= v.locScanned {
+		return
+	}
+	chunk := content[v.locScanned:end]
+	if v.viewMode == LogModeProcessed {
+		chunk = stripAnsi(chunk)
+	}
+	if !v.hasStacks {
+		v.hasStacks = stack.Contains(chunk)
+	}
+	for _, loc := range editor.Find(chunk) {
+		loc.LogLine += v.locLines
+		v.locations = append(v.locations, loc)
+	}
+	v.locLines += strings.Count(chunk, "\n")
+	v.locScanned = end
+}
+
+// scrollToLocation highlights the selected reference and centers it in the viewport
+func (v *LogView) scrollToLocation() {
+	if !v.ready || v.locationIndex < 0 {
+		return
+	}
+	v.layout()
+	v.viewport.SetContent(v.getContent())
+	v.viewport.SetYOffset(max(0, v.locations[v.locationIndex].LogLine-v.viewport.Height/2))
+	v.autoScroll = false
+}
+
+// highlightLocation highlights the selected reference in the log content
+func (v LogView) highlightLocation(content string) string {
+	if v.locationIndex < 0 || v.locationIndex >= len(v.locations) {
+		return content
+	}
+	loc := v.locations[v.locationIndex]
+	lines := strings.Split(content, "\n")
+	if loc.LogLine >= len(lines) {
+		return content
+	}
+	lines[loc.LogLine] = strings.Replace(lines[loc.LogLine], loc.Text, v.styles.location.Render(loc.Text), 1)
+	return strings.Join(lines, "\n")
+}
+
+// requestSource returns a request for the file of the selected reference if
+// the preview is shown and doesn't have it yet
+func (v *LogView) requestSource() LogViewRequest {
+	if v.previewHeight() == 0 {
+		return nil
+	}
+	file := v.locations[v.locationIndex].File
+	if file == v.previewFile {
+		return nil
+	}
+	v.previewFile = file
+	v.previewSource = nil
+	v.previewErr = nil
+	return LoadSourceRequest{File: file}
+}
+
+// SetSource shows the source of file in the preview. err is shown instead if
+// it could not be read. Sources of files no longer previewed are ignored.
+func (v LogView) SetSource(file string, src *source.File, err error) LogView {
+	if file != v.previewFile {
+		return v
+	}
+	v.previewSource = src
+	v.previewErr = err
+	return v
+}
+
+// PreviewFile returns the file of the source preview as written in the log,
+// "" if there is none
+func (v LogView) PreviewFile() string {
+	return v.previewFile
+}
+
+// previewHeight returns the lines the source preview takes below the log:
+// 0 if no reference is selected or the terminal is too small for both
+func (v LogView) previewHeight() int {
+	if !v.showPreview || v.locationIndex < 0 {
+		return 0
+	}
+	available := v.height - logHeaderHeight
+	height := max(available/3, previewMinHeight)
+	if available-height < previewMinHeight {
+		return 0
+	}
+	return height
+}
+
+// layout sizes the viewport to leave room for the source preview
+func (v *LogView) layout() {
+	if v.ready {
+		v.viewport.Height = v.height - logHeaderHeight - v.previewHeight()
+	}
+}
+
+// SetStatus shows a message in the help bar until the next key press
+func (v LogView) SetStatus(status string) LogView {
+	v.status = status
+	return v
+}
+
 func (v LogView) View() string {
 	if v.node == nil {
 		return "No test selected"
@@ -801,6 +1066,10 @@ func (v LogView) View() string {
 
 	if v.ready {
 		sb.WriteString(v.viewport.View())
+		if height := v.previewHeight(); height > 0 {
+			sb.WriteString("\n")
+			sb.WriteString(v.renderPreview(height))
+		}
 	} else {
 		sb.WriteString(v.getContent())
 	}
@@ -811,6 +1080,91 @@ func (v LogView) View() string {
 	return sb.String()
 }
 
+// renderPreview renders the source preview: a title with the reference and
+// the function it's in, then the lines around it with the referenced line marked
+func (v LogView) renderPreview(height int) string {
+	loc := v.locations[v.locationIndex]
+	title := v.styles.previewTitle.Render("── ") + v.styles.header.Render(loc.String())
+	titleWidth := lipgloss.Width("── " + loc.String())
+
+	var body []string
+	switch {
+	case v.previewErr != nil:
+		body = []string{v.styles.failed.Render(fmt.Sprintf("  Cannot read source: %v", v.previewErr))}
+	case v.previewSource == nil || v.previewFile != loc.File:
+		body = []string{v.styles.pending.Render("  Loading source…")}
+	default:
+		if fn := v.previewSource.Func(loc.Line); fn != "" {
+			title += v.styles.previewTitle.Render(" in ") + v.styles.previewFunc.Render(fn)
+			titleWidth += lipgloss.Width(" in " + fn)
+		}
+		body = v.renderPreviewLines(loc.Line, height-1)
+	}
+	title += v.styles.previewTitle.Render(" " + strings.Repeat("─", max(0, v.width-titleWidth-1)))
+
+	for len(body) < height-1 {
+		body = append(body, "")
+	}
+	return title + "\n" + strings.Join(body, "\n")
+}
+
+// renderPreviewLines renders up to n source lines centered on line (1-based)
+func (v LogView) renderPreviewLines(line, n int) []string {
+	lines := v.previewSource.Lines
+	start := max(0, min(line-1-n/2, len(lines)-n))
+	end := min(len(lines), start+n)
+	numWidth := len(fmt.Sprint(end))
+
+	rendered := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		marked := i == line-1
+		var gutter string
+		if marked {
+			gutter = v.styles.markedLine.Render(fmt.Sprintf("▶ %*d │ ", numWidth, i+1))
+		} else {
+			gutter = v.styles.lineNum.Render(fmt.Sprintf("  %*d │ ", numWidth, i+1))
+		}
+		rendered = append(rendered, gutter+v.renderSourceLine(i, v.width-numWidth-5, marked))
+	}
+	return rendered
+}
+
+// renderSourceLine renders a source line highlighted and cut to width. The
+// marked line gets a background across the whole width.
+func (v LogView) renderSourceLine(i, width int, marked bool) string {
+	line := v.previewSource.Lines[i]
+	var spans []source.Span
+	if v.previewSource.Spans != nil {
+		spans = v.previewSource.Spans[i]
+	}
+
+	var sb strings.Builder
+	remaining := width
+	write := func(text string, style lipgloss.Style) {
+		text = runewidth.Truncate(strings.ReplaceAll(text, "\t", "    "), remaining, "")
+		if text == "" {
+			return
+		}
+		remaining -= runewidth.StringWidth(text)
+		if marked {
+			style = style.Background(colorMarkedLine)
+		}
+		sb.WriteString(style.Render(text))
+	}
+
+	pos := 0
+	for _, span := range spans {
+		write(line[pos:span.Start], v.styles.sourceText)
+		write(line[span.Start:span.End], v.styles.syntax[span.Kind])
+		pos = span.End
+	}
+	write(line[pos:], v.styles.sourceText)
+	if marked && remaining > 0 {
+		write(strings.Repeat(" ", remaining), v.styles.sourceText)
+	}
+	return sb.String()
+}
+
 func (v LogView) renderHeader() string {
 	if v.node == nil {
 		return v.styles.header.Render(IconCharGear+" GOWT") + " " + v.styles.helpBar.Render(meta.Version)
@@ -903,7 +1257,21 @@ func (v LogView) renderHelpBar() string {
 		} else {
 			searchHint = "  [/ Search]"
 		}
-		help := "[Esc Back]  [↑↓ Scroll]  [Space " + modeText + "]  [c Copy]" + searchHint + "  [? Help]"
+		// Show the selected file:line reference, or that there are some
+		var locationHint string
+		if v.locationIndex >= 0 {
+			locationHint = fmt.Sprintf("  [e Open %s (%d/%d)]", v.locations[v.locationIndex], v.locationIndex+1, len(v.locations))
+		} else if len(v.locations) > 0 {
+			locationHint = "  [Tab file:line]"
+		}
+		var stacksHint string
+		if v.hasStacks {
+			stacksHint = "  [s Stacks]"
+		}
+		help := "[Esc Back]  [↑↓ Scroll]  [Space " + modeText + "]  [c Copy]" + searchHint + locationHint + stacksHint + "  [? Help]"
+		if v.status != "" {
+			help = v.status
+		}
 		helpRendered = v.styles.helpBar.Render(help)
 		helpWidth = lipgloss.Width(help)
 	}
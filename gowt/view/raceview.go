@@ -0,0 +1,445 @@
+package view
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/rickchristie/govner/gowt/stack"
+)
+
+// RaceViewRequest represents a request from RaceView to the controller
+type RaceViewRequest interface {
+	isRaceViewRequest()
+}
+
+// CloseRaceRequest is emitted when user wants to go back to tree view
+type CloseRaceRequest struct{}
+
+func (CloseRaceRequest) isRaceViewRequest() {}
+
+// OpenRaceTestRequest is emitted when user wants to see the log of a test
+// that reported a race
+type OpenRaceTestRequest struct {
+	Node *model.TestNode
+}
+
+func (OpenRaceTestRequest) isRaceViewRequest() {}
+
+// RaceView lists the data races found in the run, with the conflicting
+// accesses of the selected one side by side and the tests that reported it
+type RaceView struct {
+	tree      *model.TestTree
+	races     []*model.RaceReport
+	cursor    int // Selected race
+	scrollTop int
+	test      int // Selected test of the selected race
+	stackTop  int // First line of the stacks shown
+
+	width  int
+	height int
+	styles raceStyles
+}
+
+type raceStyles struct {
+	header   lipgloss.Style
+	hint     lipgloss.Style
+	selected lipgloss.Style
+	divider  lipgloss.Style
+	write    lipgloss.Style // Title of a write access
+	read     lipgloss.Style // Title of a read access
+	access   lipgloss.Style // Frame where an access touches the memory
+	frame    lipgloss.Style
+	std      lipgloss.Style
+	location lipgloss.Style
+}
+
+func defaultRaceStyles() raceStyles {
+	return raceStyles{
+		header: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")),
+		hint:   lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+		selected: lipgloss.NewStyle().
+			Background(lipgloss.Color("24")).
+			Foreground(lipgloss.Color("231")).
+			Bold(true),
+		divider:  lipgloss.NewStyle().Foreground(lipgloss.Color("238")),
+		write:    lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Bold(true),
+		read:     lipgloss.NewStyle().Foreground(lipgloss.Color("81")).Bold(true),
+		access:   lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true),
+		frame:    lipgloss.NewStyle().Foreground(lipgloss.Color("252")),
+		std:      lipgloss.NewStyle().Foreground(lipgloss.Color("243")),
+		location: lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+	}
+}
+
+// NewRaceView creates a new RaceView
+func NewRaceView() RaceView {
+	return RaceView{styles: defaultRaceStyles()}
+}
+
+// SetData shows the races of the tree, keeping the selection as races come in
+func (v RaceView) SetData(tree *model.TestTree) RaceView {
+	v.tree = tree
+	v.races = tree.Races
+	if v.cursor >= len(v.races) {
+		v.cursor = max(0, len(v.races)-1)
+		v.test = 0
+		v.stackTop = 0
+	}
+	v.test = max(0, min(v.test, len(v.tests())-1))
+	v.scrollToCursor()
+	return v
+}
+
+// Init implements tea.Model
+func (v RaceView) Init() tea.Cmd {
+	return nil
+}
+
+type raceKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	PrevTest key.Binding
+	NextTest key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Open     key.Binding
+	Back     key.Binding
+}
+
+var raceKeys = raceKeyMap{
+	Up:       key.NewBinding(key.WithKeys("up", "k", "K")),
+	Down:     key.NewBinding(key.WithKeys("down", "j", "J")),
+	Top:      key.NewBinding(key.WithKeys("g")),
+	Bottom:   key.NewBinding(key.WithKeys("G")),
+	PrevTest: key.NewBinding(key.WithKeys("left", "h", "shift+tab")),
+	NextTest: key.NewBinding(key.WithKeys("right", "l", "tab")),
+	PageUp:   key.NewBinding(key.WithKeys("pgup", "ctrl+u", "ctrl+U")),
+	PageDown: key.NewBinding(key.WithKeys("pgdown", "ctrl+d", "ctrl+D")),
+	Open:     key.NewBinding(key.WithKeys("enter")),
+	Back:     key.NewBinding(key.WithKeys("esc", "backspace", "q", "Q")),
+}
+
+// Update implements tea.Model
+func (v RaceView) Update(msg tea.Msg) (RaceView, tea.Cmd, RaceViewRequest) {
+	var request RaceViewRequest
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width = msg.Width
+		v.height = msg.Height
+		v.scrollToCursor()
+
+	case tea.KeyMsg:
+		prev := v.cursor
+		switch {
+		case key.Matches(msg, raceKeys.Back):
+			request = CloseRaceRequest{}
+		case key.Matches(msg, raceKeys.Up):
+			v.cursor = max(0, v.cursor-1)
+		case key.Matches(msg, raceKeys.Down):
+			v.cursor = max(0, min(len(v.races)-1, v.cursor+1))
+		case key.Matches(msg, raceKeys.Top):
+			v.cursor = 0
+		case key.Matches(msg, raceKeys.Bottom):
+			v.cursor = max(0, len(v.races)-1)
+		case key.Matches(msg, raceKeys.PrevTest):
+			v.test = max(0, v.test-1)
+		case key.Matches(msg, raceKeys.NextTest):
+			v.test = max(0, min(len(v.tests())-1, v.test+1))
+		case key.Matches(msg, raceKeys.PageUp):
+			v.stackTop = max(0, v.stackTop-v.stackHeight())
+		case key.Matches(msg, raceKeys.PageDown):
+			v.stackTop = max(0, min(len(v.stackLines())-v.stackHeight(), v.stackTop+v.stackHeight()))
+		case key.Matches(msg, raceKeys.Open):
+			if tests := v.tests(); v.test < len(tests) {
+				request = OpenRaceTestRequest{Node: tests[v.test]}
+			}
+		}
+		// Another race starts at the top of its stacks and its first test
+		if v.cursor != prev {
+			v.test = 0
+			v.stackTop = 0
+		}
+		v.scrollToCursor()
+	}
+
+	return v, cmd, request
+}
+
+// selected returns the race under the cursor, nil if there is none
+func (v RaceView) selected() *model.RaceReport {
+	if v.cursor < len(v.races) {
+		return v.races[v.cursor]
+	}
+	return nil
+}
+
+// tests returns the nodes that reported the selected race and are still in
+// the tree
+func (v RaceView) tests() []*model.TestNode {
+	report := v.selected()
+	if report == nil {
+		return nil
+	}
+	var nodes []*model.TestNode
+	for _, path := range report.Tests {
+		if node := v.tree.GetNode(path); node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// listHeight is the number of races shown, a part of the screen at most
+func (v RaceView) listHeight() int {
+	return max(1, min(len(v.races), v.height/5))
+}
+
+// testsHeight is the number of tests shown
+func (v RaceView) testsHeight() int {
+	return max(1, min(len(v.tests()), 4))
+}
+
+// stackHeight is the number of stack lines that fit: title, two dividers
+// and help bar take four lines besides the races and tests
+func (v RaceView) stackHeight() int {
+	return max(1, v.height-4-v.listHeight()-v.testsHeight())
+}
+
+// scrollToCursor keeps the selected race on screen
+func (v *RaceView) scrollToCursor() {
+	visible := v.listHeight()
+	if v.cursor < v.scrollTop {
+		v.scrollTop = v.cursor
+	}
+	if v.cursor >= v.scrollTop+visible {
+		v.scrollTop = v.cursor - visible + 1
+	}
+	v.scrollTop = max(0, min(v.scrollTop, len(v.races)-visible))
+}
+
+// View implements tea.Model
+func (v RaceView) View() string {
+	var sb strings.Builder
+
+	// Title
+	title := v.styles.header.Render("Data races") + "  " + v.styles.hint.Render(countRaces(len(v.races)))
+	sb.WriteString(title + "\n")
+
+	// Races
+	var lines []string
+	for i := v.scrollTop; i < len(v.races) && i < v.scrollTop+v.listHeight(); i++ {
+		lines = append(lines, v.renderRace(i, i == v.cursor))
+	}
+	if len(v.races) == 0 {
+		lines = append(lines, v.styles.hint.Render("No data races, run with -race to find them"))
+	}
+	sb.WriteString(strings.Join(lines, "\n") + "\n")
+
+	report := v.selected()
+	if report == nil {
+		return sb.String()
+	}
+
+	// Stacks of the two accesses
+	sb.WriteString(v.renderDivider(fmt.Sprintf("Race %d/%d", v.cursor+1, len(v.races))) + "\n")
+	stackLines := v.stackLines()
+	lines = nil
+	for i := v.stackTop; i < len(stackLines) && i < v.stackTop+v.stackHeight(); i++ {
+		lines = append(lines, stackLines[i])
+	}
+	for len(lines) < v.stackHeight() {
+		lines = append(lines, "")
+	}
+	sb.WriteString(strings.Join(lines, "\n") + "\n")
+
+	// Tests that reported it
+	tests := v.tests()
+	sb.WriteString(v.renderDivider("Reported in "+countTests(len(tests))) + "\n")
+	lines = nil
+	top := max(0, v.test-v.testsHeight()+1)
+	for i := top; i < len(tests) && i < top+v.testsHeight(); i++ {
+		lines = append(lines, v.renderTest(tests[i], i == v.test))
+	}
+	for len(lines) < v.testsHeight() {
+		lines = append(lines, "")
+	}
+	sb.WriteString(strings.Join(lines, "\n") + "\n")
+
+	// Help bar
+	help := "[↑↓ Races]  [←→ Tests]  [↵ Logs]  [PgUp/PgDn Scroll stacks]  [Esc Back]"
+	sb.WriteString(v.styles.hint.Render(help))
+	return sb.String()
+}
+
+// renderRace renders a race of the list: where each side accesses the
+// memory and how many tests reported it
+func (v RaceView) renderRace(i int, selected bool) string {
+	report := v.races[i]
+	parts := []stackPart{{fmt.Sprintf("%3d  ", i+1), v.styles.hint}}
+	for j, side := range report.Race.Sides() {
+		if len(side) == 0 {
+			continue
+		}
+		if j > 0 {
+			parts = append(parts, stackPart{"  ↔  ", v.styles.hint})
+		}
+		access := side[0]
+		parts = append(parts, stackPart{access.Access(), v.accessStyle(access)})
+		if f, ok := accessFrame(access); ok {
+			parts = append(parts,
+				stackPart{" " + shortFunc(f.Func), v.styles.frame},
+				stackPart{fmt.Sprintf(" %s:%d", filepath.Base(f.File), f.Line), v.styles.location})
+		}
+	}
+	parts = append(parts, stackPart{"  " + countTests(len(report.Tests)), v.styles.hint})
+
+	styled, plain, remaining := fitParts(parts, v.width)
+	if selected {
+		return v.styles.selected.Render(plain + strings.Repeat(" ", remaining))
+	}
+	return styled
+}
+
+// stackLines renders the two sides of the selected race in columns, each
+// access followed by where its goroutine was created
+func (v RaceView) stackLines() []string {
+	report := v.selected()
+	if report == nil {
+		return nil
+	}
+	const gap = " │ "
+	colWidth := max(10, (v.width-len(gap))/2)
+
+	sides := report.Race.Sides()
+	left := v.renderSide(sides[0])
+	right := v.renderSide(sides[1])
+	var lines []string
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var l, r []stackPart
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		styled, _, remaining := fitParts(l, colWidth)
+		line := styled + strings.Repeat(" ", remaining) + v.styles.divider.Render(gap)
+		styled, _, _ = fitParts(r, colWidth)
+		lines = append(lines, line+styled)
+	}
+	return lines
+}
+
+// renderSide lays out the stacks of a side as lines of parts. The access's
+// first frame outside the standard library, where it touches the memory in
+// the tests' code, is marked.
+func (v RaceView) renderSide(stacks []stack.Stack) [][]stackPart {
+	var lines [][]stackPart
+	for i, s := range stacks {
+		if i > 0 {
+			lines = append(lines, nil)
+		}
+		style := v.styles.hint
+		if s.Access() != "" {
+			style = v.accessStyle(s)
+		}
+		lines = append(lines, []stackPart{{s.Title, style}})
+
+		marked, _ := accessFrame(s)
+		for _, f := range s.Frames {
+			marker, style := "  ", v.styles.frame
+			switch {
+			case s.Access() != "" && f == marked:
+				marker, style = "● ", v.styles.access
+			case f.Std():
+				style = v.styles.std
+			}
+			lines = append(lines,
+				[]stackPart{{marker, v.styles.access}, {f.Func, style}},
+				[]stackPart{{fmt.Sprintf("    %s:%d", filepath.Base(f.File), f.Line), v.styles.location}})
+		}
+	}
+	return lines
+}
+
+func (v RaceView) renderTest(node *model.TestNode, selected bool) string {
+	name := node.FullPath
+	if selected {
+		return v.styles.selected.Render(padRight("▸ "+raceTestIcon(node, true)+name, max(0, v.width)))
+	}
+	return "  " + raceTestIcon(node, false) + name
+}
+
+// renderDivider renders a labeled line across the width
+func (v RaceView) renderDivider(label string) string {
+	line := "── " + label + " "
+	return v.styles.divider.Render(line + strings.Repeat("─", max(0, v.width-lipgloss.Width(line))))
+}
+
+// accessStyle colors the title of an access by whether it's a write or read
+func (v RaceView) accessStyle(s stack.Stack) lipgloss.Style {
+	if strings.Contains(strings.ToLower(s.Access()), "write") {
+		return v.styles.write
+	}
+	return v.styles.read
+}
+
+// accessFrame returns the frame where an access touches the memory: its
+// first frame outside the standard library, or its first frame
+func accessFrame(s stack.Stack) (stack.Frame, bool) {
+	for _, f := range s.Frames {
+		if !f.Std() {
+			return f, true
+		}
+	}
+	if len(s.Frames) > 0 {
+		return s.Frames[0], true
+	}
+	return stack.Frame{}, false
+}
+
+// shortFunc drops the import path of a function but its package name, e.g.
+// "example.com/foo/bar.(*T).Run" becomes "bar.(*T).Run"
+func shortFunc(fn string) string {
+	return fn[strings.LastIndexByte(fn, '/')+1:]
+}
+
+// raceTestIcon returns the status icon of a test, plain for the selected row
+func raceTestIcon(node *model.TestNode, plain bool) string {
+	switch {
+	case node.Status == model.StatusFailed && plain:
+		return IconFailedRaw
+	case node.Status == model.StatusFailed:
+		return IconFailed
+	case node.Status == model.StatusPassed && plain:
+		return IconPassedRaw
+	case node.Status == model.StatusPassed:
+		return IconPassed
+	case plain:
+		return IconPendingRaw
+	}
+	return IconPending
+}
+
+func countRaces(n int) string {
+	if n == 1 {
+		return "1 race"
+	}
+	return fmt.Sprintf("%d races", n)
+}
+
+func countTests(n int) string {
+	if n == 1 {
+		return "1 test"
+	}
+	return fmt.Sprintf("%d tests", n)
+}
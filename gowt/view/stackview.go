@@ -0,0 +1,572 @@
+package view
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	model "github.com/rickchristie/govner/gowt/model"
+	"github.com/rickchristie/govner/gowt/stack"
+)
+
+// StackViewRequest represents a request from StackView to the controller
+type StackViewRequest interface {
+	isStackViewRequest()
+}
+
+// CloseStackRequest is emitted when user wants to go back to the log view
+type CloseStackRequest struct{}
+
+func (CloseStackRequest) isStackViewRequest() {}
+
+// OpenFrameRequest is emitted when user wants to open a frame in the editor
+type OpenFrameRequest struct {
+	File string
+	Line int
+}
+
+func (OpenFrameRequest) isStackViewRequest() {}
+
+// StackView shows the panic, goroutine dump and race reports in a test's
+// output. Goroutines with identical stacks are grouped, standard library
+// frames are folded and frames in the test's module are highlighted.
+type StackView struct {
+	node    *model.TestNode
+	dump    *stack.Dump
+	module  string // Module of the test's package, empty until known
+	blocks  []stackBlock
+	rows    []stackRow
+	showStd bool // Show standard library frames in every block
+
+	cursor    int
+	scrollTop int
+	status    string // Shown in the help bar until the next key, e.g. editor errors
+
+	width  int
+	height int
+	styles stackStyles
+}
+
+// stackBlock is a stack shown as a unit: a group of goroutines, or one of
+// the stacks of a race report
+type stackBlock struct {
+	heading   string // Shown above the block, e.g. "Data race 1/2", empty for none
+	title     string
+	detail    string
+	frames    []stack.Frame
+	createdBy *stack.Frame
+	expanded  bool
+	showStd   bool // Standard library frames unfolded in this block
+}
+
+type stackRowKind int
+
+const (
+	rowPanic   stackRowKind = iota // A line of the panic message
+	rowHeading                     // Heading of a section
+	rowBlock                       // Title of a block, expands and collapses it
+	rowFrame                       // A frame of an expanded block
+	rowFold                        // Standard library frames folded into one row
+)
+
+type stackRow struct {
+	kind    stackRowKind
+	block   int
+	text    string      // Panic line or heading
+	frame   stack.Frame // Frame of a frame row
+	created bool        // Frame is where the goroutine was created
+	folded  int         // Frames in a fold row
+}
+
+type stackStyles struct {
+	header   lipgloss.Style
+	hint     lipgloss.Style
+	selected lipgloss.Style
+	panic    lipgloss.Style
+	heading  lipgloss.Style
+	title    lipgloss.Style
+	module   lipgloss.Style // Frames in the test's module
+	frame    lipgloss.Style // Frames in dependencies
+	std      lipgloss.Style // Standard library frames
+	location lipgloss.Style
+}
+
+func defaultStackStyles() stackStyles {
+	return stackStyles{
+		header: lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15")),
+		hint:   lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+		selected: lipgloss.NewStyle().
+			Background(lipgloss.Color("24")).
+			Foreground(lipgloss.Color("231")).
+			Bold(true),
+		panic:    lipgloss.NewStyle().Foreground(ColorFailed).Bold(true),
+		heading:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("248")),
+		title:    lipgloss.NewStyle().Bold(true),
+		module:   lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true),
+		frame:    lipgloss.NewStyle().Foreground(lipgloss.Color("252")),
+		std:      lipgloss.NewStyle().Foreground(lipgloss.Color("243")),
+		location: lipgloss.NewStyle().Foreground(lipgloss.Color("241")),
+	}
+}
+
+// NewStackView creates a new StackView
+func NewStackView() StackView {
+	return StackView{styles: defaultStackStyles()}
+}
+
+// SetData shows the stacks found in a test's output. Blocks with frames
+// outside the standard library start expanded, as do race reports; the
+// cursor starts on the first such frame.
+func (v StackView) SetData(node *model.TestNode, dump *stack.Dump) StackView {
+	v.node = node
+	v.dump = dump
+	v.module = ""
+	v.status = ""
+	v.blocks = nil
+
+	groups := dump.Groups()
+	for i, g := range groups {
+		title := fmt.Sprintf("goroutine %d [%s]", g.IDs[0], g.States[0])
+		if len(g.IDs) > 1 {
+			title = fmt.Sprintf("%d goroutines [%s]", len(g.IDs), strings.Join(g.States, " | "))
+		}
+		block := stackBlock{
+			title:     title,
+			detail:    goroutineIDs(g.IDs),
+			frames:    g.Frames,
+			createdBy: g.CreatedBy,
+			expanded:  hasOwnFrames(g.Frames),
+		}
+		if i == 0 {
+			block.heading = "Goroutines: " + countGoroutines(len(dump.Goroutines))
+			if len(groups) < len(dump.Goroutines) {
+				block.heading += fmt.Sprintf(" in %d groups", len(groups))
+			}
+		}
+		v.blocks = append(v.blocks, block)
+	}
+	// A panic in the standard library still shows where it happened
+	if len(groups) > 0 && !hasOwnFrames(groups[0].Frames) && len(dump.Panic) > 0 {
+		v.blocks[0].expanded = true
+	}
+	for i, race := range dump.Races {
+		for j, s := range race.Stacks {
+			block := stackBlock{title: s.Title, frames: s.Frames, expanded: true}
+			if j == 0 {
+				block.heading = fmt.Sprintf("Data race %d/%d", i+1, len(dump.Races))
+			}
+			v.blocks = append(v.blocks, block)
+		}
+	}
+
+	v.buildRows()
+	v.cursor = 0
+	v.scrollTop = 0
+	for i, row := range v.rows {
+		if row.kind == rowFrame && !row.frame.Std() {
+			v.cursor = i
+			break
+		}
+	}
+	v.scrollToCursor()
+	return v
+}
+
+// SetModule highlights the frames in module
+func (v StackView) SetModule(module string) StackView {
+	v.module = module
+	return v
+}
+
+// SetStatus shows a message in the help bar until the next key press
+func (v StackView) SetStatus(status string) StackView {
+	v.status = status
+	return v
+}
+
+// GetNode returns the test whose stacks are shown
+func (v StackView) GetNode() *model.TestNode {
+	return v.node
+}
+
+// hasOwnFrames reports whether a stack has frames outside the standard library
+func hasOwnFrames(frames []stack.Frame) bool {
+	for _, f := range frames {
+		if !f.Std() {
+			return true
+		}
+	}
+	return false
+}
+
+func countGoroutines(n int) string {
+	if n == 1 {
+		return "1 goroutine"
+	}
+	return fmt.Sprintf("%d goroutines", n)
+}
+
+// goroutineIDs lists the IDs of a group, shortened for large groups
+func goroutineIDs(ids []int) string {
+	if len(ids) == 1 {
+		return ""
+	}
+	var parts []string
+	for i, id := range ids {
+		if i == 5 {
+			parts = append(parts, fmt.Sprintf("… %d more", len(ids)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("#%d", id))
+	}
+	return strings.Join(parts, " ")
+}
+
+// buildRows lays out the panic and the blocks as rows, folding runs of
+// standard library frames unless they are shown
+func (v *StackView) buildRows() {
+	v.rows = v.rows[:0]
+	if v.dump != nil {
+		for _, line := range v.dump.Panic {
+			v.rows = append(v.rows, stackRow{kind: rowPanic, text: line})
+		}
+	}
+
+	for i, b := range v.blocks {
+		if b.heading != "" {
+			v.rows = append(v.rows, stackRow{kind: rowHeading, block: i, text: b.heading})
+		}
+		v.rows = append(v.rows, stackRow{kind: rowBlock, block: i})
+		if !b.expanded {
+			continue
+		}
+
+		for j := 0; j < len(b.frames); {
+			// A single standard library frame shows as itself rather than a fold
+			run := 0
+			for j+run < len(b.frames) && b.frames[j+run].Std() {
+				run++
+			}
+			if run > 1 && !v.showStd && !b.showStd {
+				v.rows = append(v.rows, stackRow{kind: rowFold, block: i, folded: run})
+				j += run
+				continue
+			}
+			v.rows = append(v.rows, stackRow{kind: rowFrame, block: i, frame: b.frames[j]})
+			j++
+		}
+		if b.createdBy != nil {
+			v.rows = append(v.rows, stackRow{kind: rowFrame, block: i, frame: *b.createdBy, created: true})
+		}
+	}
+	v.cursor = max(0, min(v.cursor, len(v.rows)-1))
+}
+
+// Init implements tea.Model
+func (v StackView) Init() tea.Cmd {
+	return nil
+}
+
+type stackKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	Toggle   key.Binding
+	Collapse key.Binding
+	Expand   key.Binding
+	Std      key.Binding
+	Editor   key.Binding
+	Back     key.Binding
+}
+
+var stackKeys = stackKeyMap{
+	Up:       key.NewBinding(key.WithKeys("up", "k", "K")),
+	Down:     key.NewBinding(key.WithKeys("down", "j", "J")),
+	PageUp:   key.NewBinding(key.WithKeys("pgup", "ctrl+u", "ctrl+U")),
+	PageDown: key.NewBinding(key.WithKeys("pgdown", "ctrl+d", "ctrl+D")),
+	Top:      key.NewBinding(key.WithKeys("g")),
+	Bottom:   key.NewBinding(key.WithKeys("G")),
+	Toggle:   key.NewBinding(key.WithKeys("enter", " ")),
+	Collapse: key.NewBinding(key.WithKeys("left", "h")),
+	Expand:   key.NewBinding(key.WithKeys("right", "l")),
+	Std:      key.NewBinding(key.WithKeys("s", "S")),
+	Editor:   key.NewBinding(key.WithKeys("e", "E")),
+	Back:     key.NewBinding(key.WithKeys("esc", "backspace", "q", "Q")),
+}
+
+// Update implements tea.Model
+func (v StackView) Update(msg tea.Msg) (StackView, tea.Cmd, StackViewRequest) {
+	var request StackViewRequest
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width = msg.Width
+		v.height = msg.Height
+		v.scrollToCursor()
+
+	case tea.KeyMsg:
+		v.status = ""
+		switch {
+		case key.Matches(msg, stackKeys.Back):
+			request = CloseStackRequest{}
+		case key.Matches(msg, stackKeys.Up):
+			v.cursor = max(0, v.cursor-1)
+		case key.Matches(msg, stackKeys.Down):
+			v.cursor = max(0, min(len(v.rows)-1, v.cursor+1))
+		case key.Matches(msg, stackKeys.PageUp):
+			v.cursor = max(0, v.cursor-v.visibleRows())
+		case key.Matches(msg, stackKeys.PageDown):
+			v.cursor = max(0, min(len(v.rows)-1, v.cursor+v.visibleRows()))
+		case key.Matches(msg, stackKeys.Top):
+			v.cursor = 0
+		case key.Matches(msg, stackKeys.Bottom):
+			v.cursor = max(0, len(v.rows)-1)
+
+		case key.Matches(msg, stackKeys.Toggle):
+			row, ok := v.selected()
+			switch {
+			case !ok:
+			case row.kind == rowBlock:
+				v.setExpanded(row.block, !v.blocks[row.block].expanded)
+			case row.kind == rowFold:
+				v.blocks[row.block].showStd = true
+				v.buildRows()
+			case row.kind == rowFrame:
+				request = OpenFrameRequest{File: row.frame.File, Line: row.frame.Line}
+			}
+		case key.Matches(msg, stackKeys.Collapse):
+			if row, ok := v.selected(); ok && row.kind != rowPanic {
+				v.setExpanded(row.block, false)
+			}
+		case key.Matches(msg, stackKeys.Expand):
+			if row, ok := v.selected(); ok && row.kind != rowPanic {
+				v.setExpanded(row.block, true)
+			}
+
+		case key.Matches(msg, stackKeys.Std):
+			v.showStd = !v.showStd
+			// Stay on the block, the rows around the cursor change
+			if row, ok := v.selected(); ok && row.kind != rowPanic {
+				v.buildRows()
+				v.cursor = v.blockRow(row.block)
+			}
+
+		case key.Matches(msg, stackKeys.Editor):
+			if frame, ok := v.selectedFrame(); ok {
+				request = OpenFrameRequest{File: frame.File, Line: frame.Line}
+			}
+		}
+		v.scrollToCursor()
+	}
+
+	return v, cmd, request
+}
+
+// selected returns the row under the cursor
+func (v StackView) selected() (stackRow, bool) {
+	if v.cursor < len(v.rows) {
+		return v.rows[v.cursor], true
+	}
+	return stackRow{}, false
+}
+
+// selectedFrame returns the frame to open for the row under the cursor: the
+// frame itself, or for a block the first frame outside the standard library
+func (v StackView) selectedFrame() (stack.Frame, bool) {
+	row, ok := v.selected()
+	switch {
+	case !ok:
+	case row.kind == rowFrame:
+		return row.frame, true
+	case row.kind == rowBlock || row.kind == rowHeading:
+		for _, f := range v.blocks[row.block].frames {
+			if !f.Std() {
+				return f, true
+			}
+		}
+	}
+	return stack.Frame{}, false
+}
+
+// setExpanded expands or collapses a block, moving the cursor to its title
+func (v *StackView) setExpanded(block int, expanded bool) {
+	v.blocks[block].expanded = expanded
+	v.buildRows()
+	v.cursor = v.blockRow(block)
+}
+
+// blockRow returns the row of a block's title
+func (v StackView) blockRow(block int) int {
+	for i, row := range v.rows {
+		if row.kind == rowBlock && row.block == block {
+			return i
+		}
+	}
+	return v.cursor
+}
+
+// visibleRows is the number of rows that fit: title and help bar take two lines
+func (v StackView) visibleRows() int {
+	return max(1, v.height-2)
+}
+
+// scrollToCursor keeps the cursor on screen
+func (v *StackView) scrollToCursor() {
+	visible := v.visibleRows()
+	if v.cursor < v.scrollTop {
+		v.scrollTop = v.cursor
+	}
+	if v.cursor >= v.scrollTop+visible {
+		v.scrollTop = v.cursor - visible + 1
+	}
+	v.scrollTop = max(0, min(v.scrollTop, len(v.rows)-visible))
+}
+
+// View implements tea.Model
+func (v StackView) View() string {
+	if v.node == nil || v.dump == nil {
+		return "No stacks"
+	}
+	var sb strings.Builder
+
+	// Title
+	var counts []string
+	if len(v.dump.Goroutines) > 0 {
+		counts = append(counts, countGoroutines(len(v.dump.Goroutines)))
+	}
+	switch len(v.dump.Races) {
+	case 0:
+	case 1:
+		counts = append(counts, "1 data race")
+	default:
+		counts = append(counts, fmt.Sprintf("%d data races", len(v.dump.Races)))
+	}
+	summary := strings.Join(counts, ", ")
+	title := v.styles.header.Render("Stacks") + "  " + model.ShortPath(v.node.FullPath) + "  " + v.styles.hint.Render(summary)
+	sb.WriteString(title + "\n")
+
+	// Rows
+	visible := v.visibleRows()
+	var lines []string
+	for i := v.scrollTop; i < len(v.rows) && i < v.scrollTop+visible; i++ {
+		lines = append(lines, v.renderRow(v.rows[i], i == v.cursor))
+	}
+	for len(lines) < visible {
+		lines = append(lines, "")
+	}
+	sb.WriteString(strings.Join(lines, "\n") + "\n")
+
+	// Help bar
+	help := "[↑↓ Navigate]  [Enter Expand/Open]  [e Open in editor]  [s Show stdlib]  [Esc Back]"
+	if v.showStd {
+		help = "[↑↓ Navigate]  [Enter Expand/Open]  [e Open in editor]  [s Fold stdlib]  [Esc Back]"
+	}
+	if v.status != "" {
+		help = v.status
+	}
+	sb.WriteString(v.styles.hint.Render(help))
+	return sb.String()
+}
+
+// stackPart is a piece of a row with its style
+type stackPart struct {
+	text  string
+	style lipgloss.Style
+}
+
+func (v StackView) renderRow(row stackRow, selected bool) string {
+	var parts []stackPart
+	switch row.kind {
+	case rowPanic:
+		parts = []stackPart{{strings.ReplaceAll(row.text, "\t", "    "), v.styles.panic}}
+
+	case rowHeading:
+		parts = []stackPart{{"── " + row.text + " ──", v.styles.heading}}
+
+	case rowBlock:
+		b := v.blocks[row.block]
+		arrow := "▸ "
+		if b.expanded {
+			arrow = "▾ "
+		}
+		parts = []stackPart{{arrow, v.styles.hint}, {b.title, v.styles.title}}
+		if b.detail != "" {
+			parts = append(parts, stackPart{"  " + b.detail, v.styles.hint})
+		}
+		// Collapsed blocks tell where they are by their first own frame
+		if !b.expanded {
+			for _, f := range b.frames {
+				if !f.Std() {
+					parts = append(parts, stackPart{"  in " + f.Func, v.frameStyle(f)})
+					break
+				}
+			}
+		}
+
+	case rowFold:
+		parts = []stackPart{{fmt.Sprintf("      … %d standard library frames", row.folded), v.styles.std.Italic(true)}}
+
+	case rowFrame:
+		marker := "    "
+		if row.frame.InModule(v.module) {
+			marker = "  ● "
+		}
+		parts = []stackPart{{marker, v.styles.module}}
+		if row.created {
+			parts = append(parts, stackPart{"created by ", v.styles.hint})
+		}
+		parts = append(parts,
+			stackPart{row.frame.Func, v.frameStyle(row.frame)},
+			stackPart{fmt.Sprintf("  %s:%d", filepath.Base(row.frame.File), row.frame.Line), v.styles.location})
+	}
+
+	return v.renderParts(parts, selected)
+}
+
+// frameStyle styles a frame by where it is: the test's module, a dependency
+// or the standard library
+func (v StackView) frameStyle(f stack.Frame) lipgloss.Style {
+	switch {
+	case f.InModule(v.module):
+		return v.styles.module
+	case f.Std():
+		return v.styles.std
+	}
+	return v.styles.frame
+}
+
+// renderParts renders a row cut to the width. The selected row is
+// highlighted across the width instead of styled by part.
+func (v StackView) renderParts(parts []stackPart, selected bool) string {
+	styled, plain, remaining := fitParts(parts, v.width)
+	if selected {
+		return v.styles.selected.Render(plain + strings.Repeat(" ", remaining))
+	}
+	return styled
+}
+
+// fitParts cuts parts to a width. Returns them styled and as plain text, and
+// the width left over.
+func fitParts(parts []stackPart, width int) (string, string, int) {
+	var sb strings.Builder
+	var plain strings.Builder
+	remaining := max(0, width)
+	for _, part := range parts {
+		text := runewidth.Truncate(part.text, remaining, "")
+		if text == "" {
+			continue
+		}
+		remaining -= runewidth.StringWidth(text)
+		sb.WriteString(part.style.Render(text))
+		plain.WriteString(text)
+	}
+	return sb.String(), plain.String(), remaining
+}
@@ -12,6 +12,8 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
+	"github.com/rickchristie/govner/gowt/coverage"
+	"github.com/rickchristie/govner/gowt/history"
 	"github.com/rickchristie/govner/gowt/meta"
 	model "github.com/rickchristie/govner/gowt/model"
 	"github.com/rickchristie/govner/gowt/util"
@@ -61,6 +63,40 @@ type StopRequest struct{}
 
 func (StopRequest) isTreeViewRequest() {}
 
+// ToggleCoverageRequest is emitted when user wants to turn coverage on or off
+type ToggleCoverageRequest struct{}
+
+func (ToggleCoverageRequest) isTreeViewRequest() {}
+
+// ShowCoverageRequest is emitted when user wants to see a package's coverage
+type ShowCoverageRequest struct {
+	Package string
+}
+
+func (ShowCoverageRequest) isTreeViewRequest() {}
+
+// ShowBenchRequest is emitted when user wants to see the benchmark table
+type ShowBenchRequest struct{}
+
+func (ShowBenchRequest) isTreeViewRequest() {}
+
+// ShowFuzzRequest is emitted when user wants to see the fuzzing dashboard
+type ShowFuzzRequest struct{}
+
+func (ShowFuzzRequest) isTreeViewRequest() {}
+
+// ShowChangesRequest is emitted when user wants to see what changed since
+// the previous run
+type ShowChangesRequest struct{}
+
+func (ShowChangesRequest) isTreeViewRequest() {}
+
+// ShowRacesRequest is emitted when user wants to see the data races found
+// by the race detector
+type ShowRacesRequest struct{}
+
+func (ShowRacesRequest) isTreeViewRequest() {}
+
 // FilterMode represents the current filter state
 type FilterMode int
 
@@ -91,6 +127,9 @@ type TreeView struct {
 	styles       treeStyles
 	running      bool // Whether tests are still running
 	stopped      bool // Whether tests were stopped by user
+	watching     bool // Whether watch mode reruns tests on file changes
+	skippedPkgs  int  // Packages left out because no changed file affects them
+	coverageOn   bool // Whether runs collect a coverage profile
 	animFrame    int  // Animation frame for spinner
 	selectorAnim int  // Animation frame for selector (0 = no animation)
 	expanded     bool // Track if tree is in expanded state (for toggle)
@@ -103,6 +142,14 @@ type TreeView struct {
 	searchMode    bool           // Whether search input mode is active
 	searchQuery   string         // Current search query (empty = no filtering)
 	searchMatches map[*model.TestNode]int // Match index for each node (-1 = no direct match but has matching descendant)
+
+	// Coverage of each package from the last coverage run (nil = none)
+	coverage       map[string]float64
+	coverageStyles coverageStyles
+
+	// Changes since the previous run of the same arguments (nil = not compared)
+	changes     map[string]history.Entry
+	regressions map[string]int // Newly failing tests per package
 }
 
 type treeStyles struct {
@@ -115,6 +162,7 @@ type treeStyles struct {
 	running     lipgloss.Style
 	pending     lipgloss.Style
 	cached      lipgloss.Style // For cached test indicator
+	flaky       lipgloss.Style // For tests that passed on a rerun
 	packageName lipgloss.Style
 	testName    lipgloss.Style
 	elapsed     lipgloss.Style
@@ -192,6 +240,8 @@ func defaultTreeStyles() treeStyles {
 		pending: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")),
 		cached: cachedStyle,
+		flaky: lipgloss.NewStyle().
+			Foreground(ColorFlaky),
 		packageName: lipgloss.NewStyle().
 			Bold(true),
 		testName: lipgloss.NewStyle(),
@@ -238,6 +288,8 @@ func NewTreeView() TreeView {
 		filter:   FilterAll,
 		styles:   defaultTreeStyles(),
 		expanded: false, // Start collapsed for stable view during test runs
+
+		coverageStyles: defaultCoverageStyles(),
 	}
 }
 
@@ -289,6 +341,60 @@ func (v TreeView) SetStopped(stopped bool) TreeView {
 	return v
 }
 
+// SetWatching sets whether watch mode is active
+func (v TreeView) SetWatching(watching bool) TreeView {
+	v.watching = watching
+	return v
+}
+
+// SetSkippedPackages sets how many packages were left out of the run
+func (v TreeView) SetSkippedPackages(n int) TreeView {
+	v.skippedPkgs = n
+	return v
+}
+
+// SetCoverageEnabled sets whether runs collect coverage, shown in the header
+func (v TreeView) SetCoverageEnabled(enabled bool) TreeView {
+	v.coverageOn = enabled
+	return v
+}
+
+// SetCoverage shows the coverage of each package in the profile next to its
+// node. A nil profile clears it.
+func (v TreeView) SetCoverage(profile *coverage.Profile) TreeView {
+	v.coverage = nil
+	if profile != nil {
+		v.coverage = packageCoverage(profile)
+	}
+	// Package suffixes include the percentage
+	for _, pkg := range v.tree.Packages {
+		pkg.SuffixCacheValid = false
+	}
+	return v
+}
+
+// SetChanges marks tests that changed since the previous run with badges,
+// and packages with how many of their tests newly fail. nil clears them.
+func (v TreeView) SetChanges(diff *history.Diff) TreeView {
+	v.changes = nil
+	v.regressions = nil
+	if diff != nil {
+		v.changes = diff.Changes
+		v.regressions = make(map[string]int)
+		for _, e := range diff.Changes {
+			if e.Change == history.ChangeRegressed {
+				if node := v.tree.GetNode(e.Path); node != nil {
+					v.regressions[node.Package]++
+				}
+			}
+		}
+	}
+	for _, node := range v.tree.NodeIndex {
+		node.SuffixCacheValid = false
+	}
+	return v
+}
+
 // SetElapsed updates the elapsed time without invalidating the visible nodes cache.
 // Use this for tick updates where only the elapsed time changes.
 func (v TreeView) SetElapsed(elapsed float64) TreeView {
@@ -332,6 +438,12 @@ type treeKeyMap struct {
 	PageDown     key.Binding
 	Help         key.Binding
 	Search       key.Binding
+	Coverage     key.Binding
+	ShowCoverage key.Binding
+	Bench        key.Binding
+	Fuzz         key.Binding
+	Changes      key.Binding
+	Races        key.Binding
 }
 
 var treeKeys = treeKeyMap{
@@ -352,6 +464,12 @@ var treeKeys = treeKeyMap{
 	PageDown:     key.NewBinding(key.WithKeys("pgdown", "ctrl+d", "ctrl+D")),
 	Help:         key.NewBinding(key.WithKeys("?")),
 	Search:       key.NewBinding(key.WithKeys("/")),
+	Coverage:     key.NewBinding(key.WithKeys("c", "C"), key.WithHelp("c", "toggle coverage")),
+	ShowCoverage: key.NewBinding(key.WithKeys("v", "V"), key.WithHelp("v", "view coverage")),
+	Bench:        key.NewBinding(key.WithKeys("b", "B"), key.WithHelp("b", "benchmarks")),
+	Fuzz:         key.NewBinding(key.WithKeys("f", "F"), key.WithHelp("f", "fuzzing")),
+	Changes:      key.NewBinding(key.WithKeys("d", "D"), key.WithHelp("d", "changes")),
+	Races:        key.NewBinding(key.WithKeys("w", "W"), key.WithHelp("w", "data races")),
 }
 
 // Update implements tea.Model and returns (model, cmd, request)
@@ -492,6 +610,36 @@ func (v TreeView) Update(msg tea.Msg) (TreeView, tea.Cmd, TreeViewRequest) {
 		case key.Matches(msg, treeKeys.Quit):
 			request = QuitRequest{}
 
+		case key.Matches(msg, treeKeys.Coverage):
+			request = ToggleCoverageRequest{}
+
+		case key.Matches(msg, treeKeys.Bench):
+			if len(v.tree.Benchmarks()) > 0 {
+				request = ShowBenchRequest{}
+			}
+
+		case key.Matches(msg, treeKeys.Changes):
+			if v.changes != nil {
+				request = ShowChangesRequest{}
+			}
+
+		case key.Matches(msg, treeKeys.Fuzz):
+			if len(v.tree.FuzzTargets()) > 0 {
+				request = ShowFuzzRequest{}
+			}
+
+		case key.Matches(msg, treeKeys.Races):
+			if len(v.tree.Races) > 0 {
+				request = ShowRacesRequest{}
+			}
+
+		case key.Matches(msg, treeKeys.ShowCoverage):
+			if v.cursor < len(nodes) {
+				if _, ok := v.coverage[nodes[v.cursor].Package]; ok {
+					request = ShowCoverageRequest{Package: nodes[v.cursor].Package}
+				}
+			}
+
 		case key.Matches(msg, treeKeys.Top):
 			v.cursor = 0
 			v.scrollTop = 0
@@ -911,11 +1059,30 @@ func (v TreeView) renderHeader() string {
 		} else {
 			statusStr = v.styles.passed.Render(fmt.Sprintf("Done (%s)", elapsedFmt))
 		}
+		if v.watching {
+			statusStr += "  " + v.styles.cached.Render("◉ Watching")
+		}
 
 		header = statusIndicator + " " + v.styles.header.Render("GOWT") + " " + v.styles.elapsed.Render(meta.Version) + "  " +
 			passedStr + "  " + failedStr + "  " + skippedStr + "  " + statusStr
 	}
 
+	if v.coverageOn {
+		header += "  " + v.styles.cached.Render("☂ Coverage")
+	}
+
+	// Races found by -race, listed with w
+	if races := len(v.tree.Races); races == 1 {
+		header += "  " + v.styles.boldFailed.Render("⚡ 1 data race")
+	} else if races > 1 {
+		header += "  " + v.styles.boldFailed.Render(fmt.Sprintf("⚡ %d data races", races))
+	}
+
+	// Packages left out by --changed
+	if v.skippedPkgs > 0 {
+		header += "  " + v.styles.elapsed.Render(fmt.Sprintf("(%d unchanged pkgs skipped)", v.skippedPkgs))
+	}
+
 	return header
 }
 
@@ -1157,6 +1324,30 @@ func (v TreeView) getRenderedSuffix(node *model.TestNode) string {
 		suffix += v.styles.elapsed.Render(" " + util.FormatDuration(node.Elapsed))
 	}
 
+	// Fuzzing progress
+	if node.Fuzz != nil {
+		style := v.styles.elapsed
+		if node.Fuzz.Crasher != "" {
+			style = v.styles.failed
+		}
+		suffix += " " + style.Render(fuzzSummary(node.Fuzz))
+	}
+
+	// Reruns of a failed test
+	if badge, style, ok := v.retryBadge(node); ok {
+		suffix += " " + style.Render(badge)
+	}
+
+	// Change since the previous run
+	if badge, style, ok := v.changeBadge(node); ok {
+		suffix += " " + style.Render(badge)
+	}
+
+	// Coverage (packages only)
+	if percent, ok := v.coverage[node.Package]; ok && node.Parent == nil {
+		suffix += " " + coverageStyle(v.coverageStyles, percent).Render(coverage.FormatPercent(percent))
+	}
+
 	// Cache result
 	node.RenderedSuffix = suffix
 	node.SuffixCacheValid = true
@@ -1192,6 +1383,18 @@ func (v TreeView) renderNode(node *model.TestNode, selected bool) string {
 	if node.Elapsed > 0 {
 		suffixWidth += 1 + 9 // " " + elapsed time (e.g., "10h30m0s")
 	}
+	if node.Fuzz != nil {
+		suffixWidth += 1 + runewidth.StringWidth(fuzzSummary(node.Fuzz))
+	}
+	if badge, _, ok := v.retryBadge(node); ok {
+		suffixWidth += 1 + runewidth.StringWidth(badge)
+	}
+	if badge, _, ok := v.changeBadge(node); ok {
+		suffixWidth += 1 + runewidth.StringWidth(badge)
+	}
+	if _, ok := v.coverage[node.Package]; ok && node.Parent == nil {
+		suffixWidth += 1 + 6 // " " + coverage (e.g., "100.0%")
+	}
 
 	// Total width calculation
 	totalWidth := indentWidth + coreFixedWidth + nameWidth + suffixWidth
@@ -1252,6 +1455,54 @@ func (v TreeView) renderNode(node *model.TestNode, selected bool) string {
 	return indent + coreContent + suffix
 }
 
+// retryBadge returns the tally of a failed test's reruns: how many passed
+// if it is flaky, how many failed if not
+func (v TreeView) retryBadge(node *model.TestNode) (string, lipgloss.Style, bool) {
+	r := node.Retries
+	switch {
+	case r == nil:
+		return "", lipgloss.Style{}, false
+	case r.Pending > 0:
+		return fmt.Sprintf("[retrying %d/%d]", r.Runs()+1, r.Runs()+r.Pending), v.styles.running, true
+	case r.Runs() == 0:
+		return "", lipgloss.Style{}, false
+	case r.Flaky():
+		return fmt.Sprintf("[flaky: passed %d/%d]", r.Passed, r.Runs()), v.styles.flaky, true
+	default:
+		return fmt.Sprintf("[failed %d/%d]", r.Failed, r.Runs()), v.styles.failed, true
+	}
+}
+
+// changeBadge returns the badge of a node that changed since the previous run
+func (v TreeView) changeBadge(node *model.TestNode) (string, lipgloss.Style, bool) {
+	if node.Parent == nil {
+		if n := v.regressions[node.Package]; n > 0 {
+			return fmt.Sprintf("[%d newly failing]", n), v.styles.failed, true
+		}
+		return "", lipgloss.Style{}, false
+	}
+	e, ok := v.changes[node.FullPath]
+	if !ok {
+		return "", lipgloss.Style{}, false
+	}
+	switch e.Change {
+	case history.ChangeRegressed:
+		return "[newly failing]", v.styles.failed, true
+	case history.ChangeFixed:
+		return "[fixed]", v.styles.passed, true
+	case history.ChangeSkipped:
+		return "[newly skipped]", v.styles.skipped, true
+	case history.ChangeAdded:
+		return "[new]", v.styles.running, true
+	case history.ChangeSlower:
+		if e.Before == 0 {
+			return "[slower]", v.styles.cached, true
+		}
+		return fmt.Sprintf("[slower %.1f×]", e.After/e.Before), v.styles.cached, true
+	}
+	return "", lipgloss.Style{}, false
+}
+
 // numDigits returns the number of digits in a non-negative integer (fast path for small numbers)
 func numDigits(n int) int {
 	if n < 10 {
@@ -1302,6 +1553,9 @@ func (v TreeView) renderStatusIcon(node *model.TestNode) string {
 		}
 		return IconPassed // Pre-rendered "✓ " with color
 	case model.StatusFailed:
+		if node.Retries != nil && node.Retries.Flaky() {
+			return IconFlaky // Pre-rendered "◐ " with color
+		}
 		return IconFailed // Pre-rendered "✗ " with color
 	case model.StatusSkipped:
 		return IconSkipped // Pre-rendered "⊘ " with color
@@ -1323,6 +1577,9 @@ func (v TreeView) getStatusIconRaw(node *model.TestNode) string {
 		}
 		return IconPassedRaw
 	case model.StatusFailed:
+		if node.Retries != nil && node.Retries.Flaky() {
+			return IconFlakyRaw
+		}
 		return IconFailedRaw
 	case model.StatusSkipped:
 		return IconSkippedRaw
@@ -0,0 +1,224 @@
+// Package watch implements gowt's watch mode: it polls a module for source
+// changes, debounces bursts of saves and works out which packages a change
+// affects, so only those are tested again.
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default timings for NewWatcher
+const (
+	DefaultInterval = 300 * time.Millisecond
+	DefaultDebounce = 200 * time.Millisecond
+)
+
+// maxTestdataFiles caps the files watched in each testdata directory, so a
+// large fuzz corpus or fixture set doesn't slow down every poll
+const maxTestdataFiles = 1000
+
+// fileState is what a poll remembers about a watched file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher polls a directory tree for changes to .go files, go.mod, go.sum
+// and anything under a testdata directory. Polling needs no platform support
+// and is cheap enough for the size of a Go module.
+type Watcher struct {
+	root     string
+	interval time.Duration
+	debounce time.Duration
+	changes  chan []string
+	stop     chan struct{}
+}
+
+// NewWatcher creates a watcher for the tree under root. Call Start to begin polling.
+func NewWatcher(root string, interval, debounce time.Duration) *Watcher {
+	return &Watcher{
+		root:     root,
+		interval: interval,
+		debounce: debounce,
+		changes:  make(chan []string, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Root returns the watched directory
+func (w *Watcher) Root() string {
+	return w.root
+}
+
+// Changes returns the channel that receives the changed files (absolute,
+// sorted) of each burst of saves, once no further change was seen for the
+// debounce duration.
+func (w *Watcher) Changes() <-chan []string {
+	return w.changes
+}
+
+// Start takes the initial snapshot and starts polling in the background
+func (w *Watcher) Start() {
+	snapshot := w.scan()
+	go w.loop(snapshot)
+}
+
+// Stop ends polling
+func (w *Watcher) Stop() {
+	close(w.stop)
+}
+
+func (w *Watcher) loop(snapshot map[string]fileState) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	pending := make(map[string]bool)
+	var lastChange time.Time
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			current := w.scan()
+			for _, path := range diff(snapshot, current) {
+				pending[path] = true
+				lastChange = now
+			}
+			snapshot = current
+
+			if len(pending) == 0 || now.Sub(lastChange) < w.debounce {
+				continue
+			}
+			files := make([]string, 0, len(pending))
+			for path := range pending {
+				files = append(files, path)
+			}
+			sort.Strings(files)
+			select {
+			case w.changes <- files:
+				pending = make(map[string]bool)
+			default:
+				// The previous burst was not picked up yet, keep collecting
+			}
+		}
+	}
+}
+
+// scan records every watched file under the root
+func (w *Watcher) scan() map[string]fileState {
+	files := make(map[string]fileState)
+	filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Deleted while walking, or unreadable
+		}
+		if d.IsDir() {
+			if path == w.root {
+				return nil
+			}
+			if d.Name() == "testdata" {
+				scanTestdata(path, files)
+				return filepath.SkipDir
+			}
+			if skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsWatched(path) {
+			addFile(files, path, d)
+		}
+		return nil
+	})
+	return files
+}
+
+// scanTestdata records the files of a testdata directory, every one of which
+// can change test results, up to maxTestdataFiles
+func scanTestdata(dir string, files map[string]fileState) {
+	n := 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if n == maxTestdataFiles {
+			return filepath.SkipAll
+		}
+		addFile(files, path, d)
+		n++
+		return nil
+	})
+}
+
+// addFile records the state of a file found by a scan
+func addFile(files map[string]fileState, path string, d fs.DirEntry) {
+	info, err := d.Info()
+	if err != nil {
+		return // Deleted while walking
+	}
+	files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+// skipDir reports directories that never hold package sources: hidden
+// directories (.git), vendor and, like the go tool, names starting with _
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor"
+}
+
+// IsWatched reports whether a change to path can change test results
+func IsWatched(path string) bool {
+	base := filepath.Base(path)
+	if base == "go.mod" || base == "go.sum" || strings.HasSuffix(base, ".go") {
+		return true
+	}
+	return inTestdata(path)
+}
+
+// inTestdata reports whether path is inside a testdata directory
+func inTestdata(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if part == "testdata" {
+			return true
+		}
+	}
+	return false
+}
+
+// diff returns the files added, removed or modified between two scans
+func diff(before, after map[string]fileState) []string {
+	var changed []string
+	for path, state := range after {
+		if prev, ok := before[path]; !ok || prev != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// FindModuleRoot returns the nearest directory at or above dir with a go.mod
+func FindModuleRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", os.ErrNotExist
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,127 @@
+package watch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rickchristie/govner/gowt/gotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsWatched(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"/m/pkg/foo.go", true},
+		{"/m/pkg/foo_test.go", true},
+		{"/m/go.mod", true},
+		{"/m/go.sum", true},
+		{"/m/pkg/testdata/input.json", true},
+		{"/m/pkg/testdata/nested/golden.txt", true},
+		{"/m/README.md", false},
+		{"/m/pkg/data.json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsWatched(tt.path))
+		})
+	}
+}
+
+func TestFindModuleRoot(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n"), 0644))
+
+	found, err := FindModuleRoot(nested)
+	require.NoError(t, err)
+	assert.Equal(t, root, found)
+}
+
+func TestWatcher_DebouncesBurst(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n"), 0644))
+	for _, dir := range []string{".git", "vendor"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+
+	w := NewWatcher(root, 10*time.Millisecond, 50*time.Millisecond)
+	w.Start()
+	defer w.Stop()
+
+	// A burst of saves, including files that are not watched
+	a := filepath.Join(root, "a.go")
+	b := filepath.Join(root, "b_test.go")
+	require.NoError(t, os.WriteFile(a, []byte("package m\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes.txt"), []byte("x"), 0644))
+	for _, dir := range []string{".git", "vendor"} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, dir, "x.go"), []byte("x"), 0644))
+	}
+	require.NoError(t, os.WriteFile(b, []byte("package m\n"), 0644))
+
+	select {
+	case files := <-w.Changes():
+		assert.Equal(t, []string{a, b}, files)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no changes reported")
+	}
+
+	select {
+	case files := <-w.Changes():
+		t.Fatalf("burst reported twice: %v", files)
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestWatcher_TestdataChangeRerunsPackage(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	write("go.mod", "module example.com/m\n\ngo 1.21\n")
+	write("app/app_test.go", "package app\n\nimport \"testing\"\n\nfunc TestGolden(t *testing.T) {}\n")
+	write("lib/lib_test.go", "package lib\n\nimport \"testing\"\n\nfunc TestLib(t *testing.T) {}\n")
+	golden := write("app/testdata/golden/out.txt", "before")
+
+	w := NewWatcher(root, 10*time.Millisecond, 50*time.Millisecond)
+	w.Start()
+	defer w.Stop()
+
+	write("app/testdata/golden/out.txt", "after")
+
+	var files []string
+	select {
+	case files = <-w.Changes():
+		assert.Equal(t, []string{golden}, files)
+	case <-time.After(2 * time.Second):
+		t.Fatal("testdata change not reported")
+	}
+
+	g, err := gotest.LoadGraph(root, gotest.ParseArgs([]string{"./..."}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com/m/app"}, g.Affected(files))
+}
+
+func TestWatcher_CapsTestdataFiles(t *testing.T) {
+	root := t.TempDir()
+	corpus := filepath.Join(root, "testdata", "fuzz", "FuzzParse")
+	require.NoError(t, os.MkdirAll(corpus, 0755))
+	for i := 0; i < maxTestdataFiles+10; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(corpus, fmt.Sprintf("%04d", i)), []byte("x"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.go"), []byte("package m\n"), 0644))
+
+	files := NewWatcher(root, DefaultInterval, DefaultDebounce).scan()
+	assert.Len(t, files, maxTestdataFiles+1)
+	assert.Contains(t, files, filepath.Join(root, "a.go"))
+}
@@ -0,0 +1,251 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+)
+
+// Environment variables consulted by [Discover].
+const (
+	// EnvAddr overrides the locker address. Accepts "9191", ":9191", "host:9191"
+	// or "https://host:9191" for a locker serving TLS.
+	EnvAddr = "PGFLOCK_ADDR"
+	// EnvPassword overrides the locker password.
+	EnvPassword = "PGFLOCK_PASSWORD"
+	// EnvCACert is the CA certificate used to verify a locker serving TLS.
+	// Setting it implies TLS.
+	EnvCACert = "PGFLOCK_CA_CERT"
+)
+
+// Locker is a handle to a running locker server whose address and password have
+// already been resolved. Use [Discover] to build one without hard-coding values.
+type Locker struct {
+	Host     string // Locker host, localhost when empty
+	Port     int
+	Password string
+
+	// TLS connects over HTTPS, verifying the locker against the system roots.
+	// CACertFile verifies it against that CA instead (a copy of the pool
+	// machine's .pgflock/ca/pgflock-ca.pem) and implies TLS.
+	TLS        bool
+	CACertFile string
+}
+
+// Discover resolves the locker address and password without any configuration
+// in the calling code.
+//
+// Resolution order, per value:
+//  1. The PGFLOCK_ADDR, PGFLOCK_PASSWORD and PGFLOCK_CA_CERT environment variables.
+//  2. The advertised_host, locker_port, password and locker_tls settings of the
+//     nearest .pgflock/config.yaml, found by walking up from the current working
+//     directory. With locker_tls, the CA certificate is read from ca/pgflock-ca.pem
+//     next to the config file.
+//
+// The config file is only read when PGFLOCK_ADDR or PGFLOCK_PASSWORD is missing,
+// so CI can run without a checked-in .pgflock directory by setting both variables.
+//
+//	func TestSomething(t *testing.T) {
+//	    locker, err := client.Discover()
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    connStr, err := locker.Lock("TestSomething")
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    defer locker.Unlock(connStr)
+//	}
+func Discover() (*Locker, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return discoverFrom(wd, os.Getenv(EnvAddr), os.Getenv(EnvPassword), os.Getenv(EnvCACert))
+}
+
+// discoverFrom implements Discover with explicit inputs for testing.
+func discoverFrom(startDir, envAddr, envPassword, envCACert string) (*Locker, error) {
+	locker := &Locker{Password: envPassword, CACertFile: envCACert}
+
+	if envAddr != "" {
+		host, port, useTLS, err := parseAddr(envAddr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvAddr, err)
+		}
+		locker.Host, locker.Port, locker.TLS = host, port, useTLS
+	}
+
+	if locker.Port != 0 && locker.Password != "" {
+		return locker, nil
+	}
+
+	cfgDir, err := config.FindConfigDir(startDir)
+	if err != nil {
+		return nil, fmt.Errorf("pgflock locker not configured: set %s and %s, or run 'pgflock configure' in the repository: %w",
+			EnvAddr, EnvPassword, err)
+	}
+
+	cfg, err := config.LoadConfig(filepath.Join(cfgDir, "config.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	if locker.Port == 0 {
+		locker.Host = cfg.AdvertisedHost
+		locker.Port = cfg.LockerPort
+		locker.TLS = cfg.LockerTLS
+	}
+	if locker.TLS && locker.CACertFile == "" {
+		if caPath := config.CACertPath(cfgDir); fileExists(caPath) {
+			locker.CACertFile = caPath
+		}
+	}
+	if locker.Password == "" {
+		locker.Password = cfg.Password
+	}
+
+	if locker.Port == 0 {
+		return nil, fmt.Errorf("locker_port missing from %s/config.yaml", cfgDir)
+	}
+	if locker.Password == "" {
+		return nil, fmt.Errorf("password missing from %s/config.yaml", cfgDir)
+	}
+
+	return locker, nil
+}
+
+// parseAddr splits a PGFLOCK_ADDR value into host and port. An https:// prefix
+// selects TLS; a bare port means localhost.
+func parseAddr(addr string) (host string, port int, useTLS bool, err error) {
+	if rest, ok := strings.CutPrefix(addr, "https://"); ok {
+		addr, useTLS = rest, true
+	} else {
+		addr = strings.TrimPrefix(addr, "http://")
+	}
+
+	portStr := addr
+	if strings.Contains(addr, ":") {
+		host, portStr, err = net.SplitHostPort(addr)
+		if err != nil {
+			return "", 0, false, err
+		}
+	}
+
+	port, err = strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, false, fmt.Errorf("invalid port %q", portStr)
+	}
+	return host, port, useTLS, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// endpoint returns how to reach the locker. A Locker without Host or TLS
+// settings uses the same plain-HTTP localhost endpoint as the package-level
+// functions.
+func (l *Locker) endpoint() (endpoint, error) {
+	useTLS := l.TLS || l.CACertFile != ""
+	if l.Host == "" && !useTLS {
+		return localEndpoint(l.Port), nil
+	}
+
+	host := l.Host
+	if host == "" {
+		host = "localhost"
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(l.Port))
+
+	if !useTLS {
+		return endpoint{baseURL: "http://" + addr, lockClient: lockClient, apiClient: http.DefaultClient}, nil
+	}
+	clients, err := tlsClientsFor(l.CACertFile)
+	if err != nil {
+		return endpoint{}, err
+	}
+	return endpoint{baseURL: "https://" + addr, lockClient: clients.lock, apiClient: clients.api}, nil
+}
+
+// Lock acquires a database lock. See the package-level [Lock] for details.
+func (l *Locker) Lock(marker string) (string, error) {
+	e, err := l.endpoint()
+	if err != nil {
+		return "", err
+	}
+	return e.lock(marker, l.Password, "")
+}
+
+// LockTemplate acquires a database lock reset from a promoted template. See
+// the package-level [LockTemplate] for details.
+func (l *Locker) LockTemplate(marker string, template string) (string, error) {
+	e, err := l.endpoint()
+	if err != nil {
+		return "", err
+	}
+	return e.lock(marker, l.Password, template)
+}
+
+// Unlock releases a database lock. See the package-level [Unlock] for details.
+func (l *Locker) Unlock(connString string) error {
+	return Unlock(l.Port, l.Password, connString)
+}
+
+// Replica returns the replica connection string of a locked database. See [Replica].
+func (l *Locker) Replica(connString string) (string, error) {
+	return Replica(connString)
+}
+
+// HealthCheck verifies that the locker is reachable. See [HealthCheck].
+func (l *Locker) HealthCheck() error {
+	e, err := l.endpoint()
+	if err != nil {
+		return err
+	}
+	return e.healthCheck()
+}
+
+// GetStatus returns the full state of the locker. See [GetStatus].
+func (l *Locker) GetStatus() (*Status, error) {
+	e, err := l.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	return e.getStatus()
+}
+
+// Restart triggers a full restart of the database pool. See [Restart].
+func (l *Locker) Restart() error {
+	e, err := l.endpoint()
+	if err != nil {
+		return err
+	}
+	return e.restart(l.Password)
+}
+
+// UnlockAll releases all locked databases. See [UnlockAll].
+func (l *Locker) UnlockAll() (int, error) {
+	e, err := l.endpoint()
+	if err != nil {
+		return 0, err
+	}
+	return e.unlockAll(l.Password)
+}
+
+// PromoteTemplate snapshots a locked database into a named template. See
+// [PromoteTemplate].
+func (l *Locker) PromoteTemplate(connString string, name string, allInstances bool) (*Template, error) {
+	e, err := l.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	return e.promoteTemplate(l.Password, connString, name, allInstances)
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+)
+
+// writeTestConfig creates <dir>/.pgflock/config.yaml with the given port and password.
+func writeTestConfig(t *testing.T, dir string, port int, password string) {
+	t.Helper()
+	cfgDir := filepath.Join(dir, ".pgflock")
+	if err := os.MkdirAll(cfgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := fmt.Sprintf("locker_port: %d\npassword: %s\n", port, password)
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscover_EnvOnly(t *testing.T) {
+	// No config anywhere under the temp dir; env vars alone must be enough.
+	locker, err := discoverFrom(t.TempDir(), "localhost:9292", "envpw", "")
+	if err != nil {
+		t.Fatalf("discoverFrom failed: %v", err)
+	}
+	if locker.Port != 9292 || locker.Password != "envpw" {
+		t.Errorf("Expected 9292/envpw, got %d/%s", locker.Port, locker.Password)
+	}
+}
+
+func TestDiscover_WalksUpToConfig(t *testing.T) {
+	root := t.TempDir()
+	writeTestConfig(t, root, 9393, "filepw")
+
+	nested := filepath.Join(root, "internal", "store", "pg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	locker, err := discoverFrom(nested, "", "", "")
+	if err != nil {
+		t.Fatalf("discoverFrom failed: %v", err)
+	}
+	if locker.Port != 9393 || locker.Password != "filepw" {
+		t.Errorf("Expected 9393/filepw, got %d/%s", locker.Port, locker.Password)
+	}
+}
+
+func TestDiscover_EnvOverridesConfig(t *testing.T) {
+	root := t.TempDir()
+	writeTestConfig(t, root, 9393, "filepw")
+
+	locker, err := discoverFrom(root, "9494", "", "")
+	if err != nil {
+		t.Fatalf("discoverFrom failed: %v", err)
+	}
+	if locker.Port != 9494 {
+		t.Errorf("Expected env port 9494, got %d", locker.Port)
+	}
+	if locker.Password != "filepw" {
+		t.Errorf("Expected password from config, got %s", locker.Password)
+	}
+}
+
+func TestDiscover_NothingConfigured(t *testing.T) {
+	_, err := discoverFrom(t.TempDir(), "", "", "")
+	if err == nil {
+		t.Fatal("Expected error when neither env nor config is available")
+	}
+	if !strings.Contains(err.Error(), EnvAddr) {
+		t.Errorf("Expected error to mention %s, got: %v", EnvAddr, err)
+	}
+}
+
+func TestDiscover_RemoteLockerFromConfig(t *testing.T) {
+	root := t.TempDir()
+	writeTestConfig(t, root, 9393, "filepw")
+	cfgPath := filepath.Join(root, ".pgflock", "config.yaml")
+	f, err := os.OpenFile(cfgPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(f, "advertised_host: db-pool.internal\nlocker_tls: true\n")
+	f.Close()
+	if _, _, err := config.EnsureCA(filepath.Join(root, ".pgflock")); err != nil {
+		t.Fatal(err)
+	}
+
+	locker, err := discoverFrom(root, "", "", "")
+	if err != nil {
+		t.Fatalf("discoverFrom failed: %v", err)
+	}
+	if locker.Host != "db-pool.internal" || locker.Port != 9393 || !locker.TLS {
+		t.Errorf("Expected TLS locker at db-pool.internal:9393, got %+v", locker)
+	}
+	if want := config.CACertPath(filepath.Join(root, ".pgflock")); locker.CACertFile != want {
+		t.Errorf("Expected CA cert %s, got %s", want, locker.CACertFile)
+	}
+}
+
+func TestParseAddr(t *testing.T) {
+	tests := []struct {
+		input   string
+		host    string
+		port    int
+		tls     bool
+		wantErr bool
+	}{
+		{"9191", "", 9191, false, false},
+		{":9191", "", 9191, false, false},
+		{"localhost:9191", "localhost", 9191, false, false},
+		{"127.0.0.1:9191", "127.0.0.1", 9191, false, false},
+		{"[::1]:9191", "::1", 9191, false, false},
+		{"db.example.com:9191", "db.example.com", 9191, false, false},
+		{"http://db.example.com:9191", "db.example.com", 9191, false, false},
+		{"https://db.example.com:9191", "db.example.com", 9191, true, false},
+		{"abc", "", 0, false, true},
+		{"70000", "", 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			host, port, useTLS, err := parseAddr(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAddr(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if host != tt.host || port != tt.port || useTLS != tt.tls {
+				t.Errorf("parseAddr(%q) = %s/%d/%v, want %s/%d/%v", tt.input, host, port, useTLS, tt.host, tt.port, tt.tls)
+			}
+		})
+	}
+}
+
+func TestLocker_LockUnlock(t *testing.T) {
+	fake, _, port := newTestClientServer(t)
+
+	locker := &Locker{Port: port, Password: testClientPassword}
+	connStr, err := locker.Lock("discovered")
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if fake.lockedCount() != 1 {
+		t.Errorf("Expected 1 locked, got %d", fake.lockedCount())
+	}
+
+	if err := locker.Unlock(connStr); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := awaitClient(2*time.Second, func() bool { return fake.lockedCount() == 0 }); err != nil {
+		t.Errorf("Lock not released: %v", err)
+	}
+}
+
+func TestLocker_TLS(t *testing.T) {
+	cfgDir := t.TempDir()
+	cfg := &config.Config{AdvertisedHost: "db-pool.internal"}
+	tlsConfig, err := config.ServerTLSConfig(cfgDir, cfg)
+	if err != nil {
+		t.Fatalf("ServerTLSConfig failed: %v", err)
+	}
+
+	fake := newFakeLocker(testClientPassword, testClientDBCount)
+	srv := httptest.NewUnstartedServer(fake)
+	srv.TLS = tlsConfig
+	srv.StartTLS()
+	t.Cleanup(func() {
+		CloseAll()
+		srv.Close()
+	})
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+
+	locker := &Locker{
+		Host:       "127.0.0.1",
+		Port:       port,
+		Password:   testClientPassword,
+		CACertFile: config.CACertPath(cfgDir),
+	}
+	if err := locker.HealthCheck(); err != nil {
+		t.Fatalf("HealthCheck over TLS failed: %v", err)
+	}
+	connStr, err := locker.Lock("tls")
+	if err != nil {
+		t.Fatalf("Lock over TLS failed: %v", err)
+	}
+	if fake.lockedCount() != 1 {
+		t.Errorf("Expected 1 locked, got %d", fake.lockedCount())
+	}
+	if err := locker.Unlock(connStr); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := awaitClient(2*time.Second, func() bool { return fake.lockedCount() == 0 }); err != nil {
+		t.Errorf("Lock not released: %v", err)
+	}
+
+	// Without the pgflock CA the server certificate must not be trusted
+	untrusted := &Locker{Host: "127.0.0.1", Port: port, Password: testClientPassword, TLS: true}
+	if err := untrusted.HealthCheck(); err == nil {
+		t.Error("Expected HealthCheck to fail without the pgflock CA")
+	}
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// leaseHeader carries the lease token of a lock. A lock whose connection drops
+// is reclaimed with it instead of being lost.
+const leaseHeader = "X-PGFlock-Lease"
+
+// reclaimTimeout is how long a lock whose connection dropped keeps trying to
+// reach the locker again, e.g. while a new 'pgflock up' takes over. Matches
+// how long the locker holds a lock that was not reclaimed yet.
+var reclaimTimeout = 2 * time.Minute
+
+// reclaimRetryInterval is the pause between reclaim attempts.
+var reclaimRetryInterval = 250 * time.Millisecond
+
+// heldLock is the open connection holding a lock. When the locker handed out
+// a lease, a dropped connection is reopened with it, so the lock survives the
+// locker being restarted or upgraded.
+type heldLock struct {
+	e        endpoint
+	marker   string
+	password string
+	connStr  string
+	lease    string
+	replica  string // Same database on the instance's replica, if any
+
+	mu     sync.Mutex
+	body   io.ReadCloser
+	closed bool
+	done   chan struct{} // Closed by Close
+}
+
+func newHeldLock(e endpoint, marker, password, connStr, lease string, body io.ReadCloser) *heldLock {
+	l := &heldLock{
+		e:        e,
+		marker:   marker,
+		password: password,
+		connStr:  connStr,
+		lease:    lease,
+		body:     body,
+		done:     make(chan struct{}),
+	}
+	if lease != "" {
+		go l.keepAlive()
+	}
+	return l
+}
+
+// Close releases the lock by closing its connection.
+func (l *heldLock) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	close(l.done)
+	return l.body.Close()
+}
+
+// keepAlive waits for the lock connection to drop and reclaims the lock with
+// its lease, until Close is called or the locker no longer knows the lease.
+func (l *heldLock) keepAlive() {
+	for {
+		l.mu.Lock()
+		body := l.body
+		l.mu.Unlock()
+
+		// The locker sends nothing after the connection string, so this only
+		// returns once the connection is gone.
+		io.Copy(io.Discard, body)
+
+		select {
+		case <-l.done:
+			return
+		default:
+		}
+
+		newBody, err := l.reclaim()
+		if err != nil {
+			return
+		}
+
+		l.mu.Lock()
+		if l.closed {
+			l.mu.Unlock()
+			newBody.Close()
+			return
+		}
+		l.body = newBody
+		l.mu.Unlock()
+	}
+}
+
+// reclaim reopens the lock connection with the lease, retrying while the
+// locker is unreachable or handing over to a new locker. Any other response
+// than the lock itself means the lock is gone, e.g. it was force-unlocked.
+func (l *heldLock) reclaim() (io.ReadCloser, error) {
+	reqURL := fmt.Sprintf("%s/lock?marker=%s&password=%s&lease=%s",
+		l.e.baseURL, url.QueryEscape(l.marker), url.QueryEscape(l.password), url.QueryEscape(l.lease))
+	deadline := time.Now().Add(reclaimTimeout)
+
+	for {
+		resp, err := l.e.lockClient.Get(reqURL)
+		if err == nil && resp.StatusCode == http.StatusServiceUnavailable {
+			resp.Body.Close()
+			err = fmt.Errorf("locker is handing over")
+		}
+		if err == nil {
+			if resp.StatusCode != http.StatusOK {
+				resp.Body.Close()
+				return nil, fmt.Errorf("reclaim failed with status %d", resp.StatusCode)
+			}
+			connStr, err := bufio.NewReader(resp.Body).ReadString('\n')
+			if err != nil || strings.TrimSpace(connStr) != l.connStr {
+				resp.Body.Close()
+				return nil, fmt.Errorf("locker reclaimed a different database")
+			}
+			return resp.Body, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("locker unreachable: %w", err)
+		}
+		select {
+		case <-l.done:
+			return nil, fmt.Errorf("lock released")
+		case <-time.After(reclaimRetryInterval):
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+)
+
+// tlsClients caches the HTTP clients for each CA file, so a test suite taking
+// many locks reads the certificate once and reuses the API connection pool.
+var (
+	tlsMu      sync.Mutex
+	tlsClients = make(map[string]tlsClientPair) // CA file ("" for system roots) -> clients
+)
+
+type tlsClientPair struct {
+	lock *http.Client
+	api  *http.Client
+}
+
+// tlsClientsFor returns HTTPS clients that verify the locker against the CA in
+// caCertFile, or against the system roots when caCertFile is empty.
+func tlsClientsFor(caCertFile string) (tlsClientPair, error) {
+	tlsMu.Lock()
+	defer tlsMu.Unlock()
+
+	if pair, ok := tlsClients[caCertFile]; ok {
+		return pair, nil
+	}
+
+	var pair tlsClientPair
+	apiTransport := http.DefaultTransport.(*http.Transport).Clone()
+	lockTransport := &http.Transport{DisableKeepAlives: true} // See lockClient
+	if caCertFile != "" {
+		tlsConfig, err := config.ClientTLSConfig(caCertFile)
+		if err != nil {
+			return pair, err
+		}
+		apiTransport.TLSClientConfig = tlsConfig
+		lockTransport.TLSClientConfig = tlsConfig.Clone()
+	}
+	pair.lock = &http.Client{Transport: lockTransport}
+	pair.api = &http.Client{Transport: apiTransport}
+
+	tlsClients[caCertFile] = pair
+	return pair, nil
+}
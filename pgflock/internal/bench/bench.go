@@ -0,0 +1,296 @@
+// Package bench drives a running locker with concurrent synthetic clients and
+// summarises how long they waited for locks, how long resets took and how the
+// locks were spread across instances.
+package bench
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rickchristie/govner/pgflock/internal/locker"
+)
+
+// Options configures a benchmark run.
+type Options struct {
+	BaseURL    string        // Locker base URL, e.g. http://localhost:9776
+	HTTPClient *http.Client  // Client used for /lock requests (default: http.DefaultClient)
+	Password   string        // Locker password
+	Clients    int           // Number of concurrent clients
+	Duration   time.Duration // How long clients keep taking locks
+	Hold       time.Duration // How long each lock is held before it is released
+	Template   string        // Optional template to lock databases from
+}
+
+// Percentiles holds latency percentiles in milliseconds.
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// InstanceLocks counts the locks handed out by a single instance.
+type InstanceLocks struct {
+	Port  int     `json:"port"`
+	Locks int     `json:"locks"`
+	Share float64 `json:"share"` // Fraction of all locks, 0-1
+}
+
+// Result summarises a benchmark run.
+type Result struct {
+	Clients        int             `json:"clients"`
+	DurationSec    float64         `json:"duration_seconds"`
+	HoldMs         float64         `json:"hold_ms"`
+	Template       string          `json:"template,omitempty"`
+	Locks          int             `json:"locks"`
+	Errors         int             `json:"errors"`
+	LocksPerSecond float64         `json:"locks_per_second"`
+	LockWaitMs     Percentiles     `json:"lock_wait_ms"`
+	ResetMs        Percentiles     `json:"reset_ms"`
+	Instances      []InstanceLocks `json:"instances"`
+	FirstError     string          `json:"first_error,omitempty"`
+}
+
+// sample is one completed lock
+type sample struct {
+	wait  time.Duration
+	reset time.Duration
+	port  int
+}
+
+// Run starts opts.Clients clients that lock, hold and release databases in a
+// loop until opts.Duration has passed, then returns the summary. Locks still
+// waiting when the duration ends are abandoned and not counted.
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	if opts.Clients < 1 {
+		return nil, fmt.Errorf("clients must be at least 1")
+	}
+	if opts.Duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	var mu sync.Mutex
+	var samples []sample
+	var errCount int
+	var firstErr error
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Clients; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			marker := fmt.Sprintf("bench-%d", id)
+			for ctx.Err() == nil {
+				s, err := lockOnce(ctx, opts, marker)
+				if err == nil {
+					// A lock whose hold was cut short by the end of the run still counts
+					mu.Lock()
+					samples = append(samples, s)
+					mu.Unlock()
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					mu.Lock()
+					errCount++
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					// Don't spin against a locker that rejects every request
+					select {
+					case <-ctx.Done():
+					case <-time.After(100 * time.Millisecond):
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	result := summarise(samples, time.Since(start))
+	result.Clients = opts.Clients
+	result.HoldMs = ms(opts.Hold)
+	result.Template = opts.Template
+	result.Errors = errCount
+	if firstErr != nil {
+		result.FirstError = firstErr.Error()
+	}
+	if len(samples) == 0 && firstErr != nil {
+		return result, fmt.Errorf("no lock succeeded: %w", firstErr)
+	}
+	return result, nil
+}
+
+// lockOnce takes a streaming lock, holds it for opts.Hold and releases it by
+// closing the connection.
+func lockOnce(ctx context.Context, opts Options, marker string) (sample, error) {
+	params := url.Values{}
+	params.Set("marker", marker)
+	params.Set("password", opts.Password)
+	if opts.Template != "" {
+		params.Set("template", opts.Template)
+	}
+
+	requested := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.BaseURL+"/lock?"+params.Encode(), nil)
+	if err != nil {
+		return sample{}, err
+	}
+	resp, err := opts.HTTPClient.Do(req)
+	if err != nil {
+		return sample{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return sample{}, fmt.Errorf("lock failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	connStr, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		return sample{}, fmt.Errorf("failed to read connection string: %w", err)
+	}
+	wait := time.Since(requested)
+
+	port, err := connStringPort(strings.TrimSpace(connStr))
+	if err != nil {
+		return sample{}, err
+	}
+	var reset time.Duration
+	if header := resp.Header.Get(locker.ResetDurationHeader); header != "" {
+		if reset, err = time.ParseDuration(header); err != nil {
+			return sample{}, fmt.Errorf("invalid %s header %q", locker.ResetDurationHeader, header)
+		}
+	}
+
+	// Holding past the end of the run is fine: the lock was already counted
+	select {
+	case <-time.After(opts.Hold):
+	case <-ctx.Done():
+	}
+	return sample{wait: wait, reset: reset, port: port}, nil
+}
+
+// connStringPort returns the port of a postgresql:// or mysql:// connection string
+func connStringPort(connStr string) (int, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid connection string %q", connStr)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return 0, fmt.Errorf("no port in connection string %q", connStr)
+	}
+	return port, nil
+}
+
+// summarise computes throughput, percentiles and instance balance
+func summarise(samples []sample, elapsed time.Duration) *Result {
+	result := &Result{
+		DurationSec: elapsed.Seconds(),
+		Locks:       len(samples),
+		Instances:   []InstanceLocks{},
+	}
+	if elapsed > 0 {
+		result.LocksPerSecond = float64(len(samples)) / elapsed.Seconds()
+	}
+
+	waits := make([]time.Duration, len(samples))
+	resets := make([]time.Duration, len(samples))
+	perPort := make(map[int]int)
+	for i, s := range samples {
+		waits[i] = s.wait
+		resets[i] = s.reset
+		perPort[s.port]++
+	}
+	result.LockWaitMs = percentiles(waits)
+	result.ResetMs = percentiles(resets)
+
+	for port, n := range perPort {
+		result.Instances = append(result.Instances, InstanceLocks{
+			Port:  port,
+			Locks: n,
+			Share: float64(n) / float64(len(samples)),
+		})
+	}
+	sort.Slice(result.Instances, func(i, j int) bool { return result.Instances[i].Port < result.Instances[j].Port })
+	return result
+}
+
+// percentiles returns nearest-rank percentiles of durations
+func percentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := func(p float64) float64 {
+		idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		idx = max(0, min(idx, len(sorted)-1))
+		return ms(sorted[idx])
+	}
+	return Percentiles{
+		P50: rank(50),
+		P90: rank(90),
+		P99: rank(99),
+		Max: ms(sorted[len(sorted)-1]),
+	}
+}
+
+// ms converts a duration to fractional milliseconds
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// WriteTable writes the result as a human-readable table.
+func (r *Result) WriteTable(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Clients:     %d (hold %s", r.Clients, time.Duration(r.HoldMs*float64(time.Millisecond)))
+	if r.Template != "" {
+		fmt.Fprintf(&b, ", template %s", r.Template)
+	}
+	fmt.Fprintf(&b, ")\n")
+	fmt.Fprintf(&b, "Duration:    %.1fs\n", r.DurationSec)
+	fmt.Fprintf(&b, "Locks:       %d (%d errors)\n", r.Locks, r.Errors)
+	fmt.Fprintf(&b, "Throughput:  %.1f locks/s\n", r.LocksPerSecond)
+	if r.FirstError != "" {
+		fmt.Fprintf(&b, "First error: %s\n", r.FirstError)
+	}
+
+	fmt.Fprintf(&b, "\n%-12s %10s %10s %10s %10s\n", "LATENCY", "P50", "P90", "P99", "MAX")
+	for _, row := range []struct {
+		name string
+		p    Percentiles
+	}{{"lock wait", r.LockWaitMs}, {"reset", r.ResetMs}} {
+		fmt.Fprintf(&b, "%-12s %8.1fms %8.1fms %8.1fms %8.1fms\n", row.name, row.p.P50, row.p.P90, row.p.P99, row.p.Max)
+	}
+
+	fmt.Fprintf(&b, "\n%-12s %10s %10s\n", "INSTANCE", "LOCKS", "SHARE")
+	for _, inst := range r.Instances {
+		fmt.Fprintf(&b, "%-12d %10d %9.1f%%\n", inst.Port, inst.Locks, inst.Share*100)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
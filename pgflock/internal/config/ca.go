@@ -0,0 +1,262 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// caDirName is the subdirectory within the .pgflock directory that holds CA files.
+	caDirName = "ca"
+	// caCertFile is the PEM-encoded CA certificate filename.
+	caCertFile = "pgflock-ca.pem"
+	// caKeyFile is the PEM-encoded CA private key filename.
+	caKeyFile = "pgflock-ca-key.pem"
+	// caKeyBits is the RSA key size for the CA and server keys.
+	caKeyBits = 2048
+	// caValidityYears is how long the CA certificate is valid.
+	caValidityYears = 10
+	// serverCertValidity is how long a locker server certificate is valid. A new
+	// one is issued every time the locker starts, so it only needs to outlive
+	// a single 'pgflock up'.
+	serverCertValidity = 365 * 24 * time.Hour
+)
+
+// CACertPath returns the path of the CA certificate clients use to verify the
+// locker: {configDir}/ca/pgflock-ca.pem.
+func CACertPath(configDir string) string {
+	return filepath.Join(configDir, caDirName, caCertFile)
+}
+
+// EnsureCA generates a CA certificate and key if they don't already exist at
+// {configDir}/ca/pgflock-ca.pem and {configDir}/ca/pgflock-ca-key.pem.
+// Returns the paths to the cert and key files.
+func EnsureCA(configDir string) (certPath string, keyPath string, err error) {
+	certPath = CACertPath(configDir)
+	keyPath = filepath.Join(configDir, caDirName, caKeyFile)
+
+	if CAExists(configDir) {
+		return certPath, keyPath, nil
+	}
+
+	return generateCA(configDir)
+}
+
+// CAExists returns true if both the CA certificate and key files exist.
+func CAExists(configDir string) bool {
+	if _, err := os.Stat(CACertPath(configDir)); err != nil {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(configDir, caDirName, caKeyFile)); err != nil {
+		return false
+	}
+	return true
+}
+
+// ServerTLSConfig returns the TLS configuration for the locker server. It
+// creates the CA on first use and issues a fresh server certificate signed by
+// it, valid for localhost and the configured advertised host and bind address.
+func ServerTLSConfig(configDir string, cfg *Config) (*tls.Config, error) {
+	certPath, keyPath, err := EnsureCA(configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	caCert, caKey, err := loadCA(certPath, keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := issueServerCert(caCert, caKey, certHosts(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ClientTLSConfig returns a TLS configuration that trusts the CA certificate
+// at caCertPath.
+func ClientTLSConfig(caCertPath string) (*tls.Config, error) {
+	data, err := os.ReadFile(caCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", caCertPath)
+	}
+
+	return &tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// certHosts returns the names the locker server certificate is valid for.
+func certHosts(cfg *Config) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if cfg.AdvertisedHost != "" {
+		hosts = append(hosts, cfg.AdvertisedHost)
+	}
+	if ip := net.ParseIP(cfg.LockerBindAddress); ip != nil && !ip.IsUnspecified() {
+		hosts = append(hosts, cfg.LockerBindAddress)
+	}
+	return hosts
+}
+
+// loadCA reads the CA certificate and private key written by generateCA.
+func loadCA(certPath, keyPath string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("invalid CA certificate in %s", certPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA key: %w", err)
+	}
+	block, _ = pem.Decode(keyPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("invalid CA key in %s", keyPath)
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CA key: %w", err)
+	}
+
+	return cert, key, nil
+}
+
+// issueServerCert creates a server certificate for hosts, signed by the CA.
+// The certificate and key are kept in memory only.
+func issueServerCert(caCert *x509.Certificate, caKey *rsa.PrivateKey, hosts []string) (tls.Certificate, error) {
+	key, err := rsa.GenerateKey(rand.Reader, caKeyBits)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate RSA key: %w", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   hosts[0],
+			Organization: []string{"pgflock"},
+		},
+		NotBefore:   now.Add(-time.Minute), // Tolerate small clock skew between machines
+		NotAfter:    now.Add(serverCertValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create server certificate: %w", err)
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{certDER, caCert.Raw},
+		PrivateKey:  key,
+	}, nil
+}
+
+// randomSerial returns a random 128-bit certificate serial number.
+func randomSerial() (*big.Int, error) {
+	serialLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serial, err := rand.Int(rand.Reader, serialLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+// generateCA creates the ca/ directory if needed, generates an RSA 2048-bit key
+// and a self-signed CA certificate, and writes them as PEM files.
+func generateCA(configDir string) (certPath string, keyPath string, err error) {
+	caDir := filepath.Join(configDir, caDirName)
+	if err := os.MkdirAll(caDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create CA directory: %w", err)
+	}
+
+	certPath = filepath.Join(caDir, caCertFile)
+	keyPath = filepath.Join(caDir, caKeyFile)
+
+	key, err := rsa.GenerateKey(rand.Reader, caKeyBits)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate RSA key: %w", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   "pgflock Locker CA",
+			Organization: []string{"pgflock"},
+		},
+		NotBefore:             now,
+		NotAfter:              now.AddDate(caValidityYears, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+
+	// Self-signed: parent is the same as the template.
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+
+	// Cert is shared with client machines (0644); the key never leaves this one (0600).
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write CA certificate: %w", err)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		// Clean up cert file on key write failure.
+		os.Remove(certPath)
+		return "", "", fmt.Errorf("failed to write CA key: %w", err)
+	}
+
+	return certPath, keyPath, nil
+}
@@ -2,15 +2,35 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Supported database engines for Config.Engine.
+const (
+	EnginePostgres = "postgres"
+	EngineMySQL    = "mysql"
+	EngineMariaDB  = "mariadb"
+)
+
+// DefaultTimelineMinutes is the lock history window used when timeline_minutes is unset.
+const DefaultTimelineMinutes = 15
+
 // Config holds all pgflock configuration
 type Config struct {
 	DockerNamePrefix string `yaml:"docker_name_prefix"`
 
+	// Database engine: "postgres" (default when empty), "mysql" or "mariadb"
+	Engine string `yaml:"engine,omitempty"`
+
+	// Container runtime: "docker", "podman", or empty to auto-detect (docker preferred)
+	ContainerRuntime string `yaml:"container_runtime,omitempty"`
+
 	// PostgreSQL instances
 	InstanceCount int `yaml:"instance_count"` // Number of PostgreSQL instances
 	StartingPort  int `yaml:"starting_port"`  // First instance port, subsequent instances get port+1, port+2, etc.
@@ -22,8 +42,24 @@ type Config struct {
 	CPULimit             string `yaml:"cpu_limit,omitempty"` // CPU limit per container (e.g., "2.0"), empty for no limit
 
 	// dblocker settings
-	LockerPort     int `yaml:"locker_port"`
-	AutoUnlockMins int `yaml:"auto_unlock_minutes"`
+	LockerPort      int `yaml:"locker_port"`
+	AutoUnlockMins  int `yaml:"auto_unlock_minutes"`
+	TimelineMinutes int `yaml:"timeline_minutes,omitempty"` // Lock history shown in the TUI timeline, 0 for default
+
+	// Remote access: the locker listens on locker_bind_address (empty for all
+	// interfaces) and hands out connection strings pointing at advertised_host
+	// (empty for localhost). locker_tls serves the locker over HTTPS with a
+	// certificate signed by the CA in .pgflock/ca.
+	LockerBindAddress string `yaml:"locker_bind_address,omitempty"`
+	AdvertisedHost    string `yaml:"advertised_host,omitempty"`
+	LockerTLS         bool   `yaml:"locker_tls,omitempty"`
+
+	// Compare each released database's schema with test_template and report differences
+	SchemaCheck bool `yaml:"schema_check,omitempty"`
+
+	// Run a streaming-replication hot standby next to each instance (postgres
+	// only). Replica ports follow the instance ports: starting_port+instance_count, ...
+	Replicas bool `yaml:"replicas,omitempty"`
 
 	// PostgreSQL settings
 	PGUsername      string   `yaml:"pg_username"`
@@ -35,6 +71,9 @@ type Config struct {
 	LCCollate       string   `yaml:"lc_collate"`
 	LCCtype         string   `yaml:"lc_ctype"`
 	MaxConnections  int      `yaml:"max_connections"`
+
+	// MySQL/MariaDB settings (only used when engine is mysql or mariadb)
+	MySQLVersion string `yaml:"mysql_version,omitempty"` // Image tag, e.g. "8.4" for mysql or "11.4" for mariadb
 }
 
 // InstancePorts returns the list of ports for all instances
@@ -46,6 +85,29 @@ func (c *Config) InstancePorts() []int {
 	return ports
 }
 
+// ReplicaPort returns the port of the replica of the instance on port
+func (c *Config) ReplicaPort(port int) int {
+	return port + c.InstanceCount
+}
+
+// IsReplicaPort returns true if port belongs to a replica rather than an instance
+func (c *Config) IsReplicaPort(port int) bool {
+	return c.Replicas && port >= c.StartingPort+c.InstanceCount && port < c.StartingPort+2*c.InstanceCount
+}
+
+// ContainerPorts returns the ports of all containers: the instance ports,
+// followed by the replica ports when replicas are enabled
+func (c *Config) ContainerPorts() []int {
+	ports := c.InstancePorts()
+	if !c.Replicas {
+		return ports
+	}
+	for _, port := range c.InstancePorts() {
+		ports = append(ports, c.ReplicaPort(port))
+	}
+	return ports
+}
+
 // LoadConfig loads configuration from a YAML file
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -61,6 +123,28 @@ func LoadConfig(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// FindConfigDir walks up from start looking for a .pgflock directory that
+// contains config.yaml, and returns the path of that directory.
+func FindConfigDir(start string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", start, err)
+	}
+
+	for {
+		candidate := filepath.Join(dir, ".pgflock")
+		if _, err := os.Stat(filepath.Join(candidate, "config.yaml")); err == nil {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no .pgflock/config.yaml found in %s or any parent directory", start)
+		}
+		dir = parent
+	}
+}
+
 // SaveConfig saves configuration to a YAML file
 func SaveConfig(path string, cfg *Config) error {
 	data, err := yaml.Marshal(cfg)
@@ -79,6 +163,7 @@ func SaveConfig(path string, cfg *Config) error {
 func DefaultConfig() *Config {
 	return &Config{
 		DockerNamePrefix:     "pgflock",
+		ContainerRuntime:     "", // Empty = auto-detect
 		InstanceCount:        1,
 		StartingPort:         5432,
 		DatabasesPerInstance: 10,
@@ -104,6 +189,16 @@ func (c *Config) Validate() error {
 	if c.DockerNamePrefix == "" {
 		return fmt.Errorf("docker_name_prefix is required")
 	}
+	switch c.Engine {
+	case "", EnginePostgres, EngineMySQL, EngineMariaDB:
+	default:
+		return fmt.Errorf("invalid engine %q (use postgres, mysql or mariadb)", c.Engine)
+	}
+	switch c.ContainerRuntime {
+	case "", "docker", "podman":
+	default:
+		return fmt.Errorf("invalid container_runtime %q (use docker, podman, or leave empty to auto-detect)", c.ContainerRuntime)
+	}
 	if c.InstanceCount <= 0 {
 		return fmt.Errorf("instance_count must be at least 1")
 	}
@@ -115,12 +210,23 @@ func (c *Config) Validate() error {
 	if lastPort > 65535 {
 		return fmt.Errorf("instance ports exceed valid range (last port would be %d)", lastPort)
 	}
+	if c.Replicas {
+		if c.IsMySQLFamily() {
+			return fmt.Errorf("replicas are only supported with the postgres engine")
+		}
+		if lastReplica := c.ReplicaPort(lastPort); lastReplica > 65535 {
+			return fmt.Errorf("replica ports exceed valid range (last port would be %d)", lastReplica)
+		}
+	}
 	if c.DatabasesPerInstance <= 0 {
 		return fmt.Errorf("databases_per_instance must be positive")
 	}
 	if c.LockerPort <= 0 || c.LockerPort > 65535 {
 		return fmt.Errorf("invalid locker_port %d", c.LockerPort)
 	}
+	if c.LockerBindAddress != "" && net.ParseIP(c.LockerBindAddress) == nil {
+		return fmt.Errorf("invalid locker_bind_address %q (use an IP address, or leave empty for all interfaces)", c.LockerBindAddress)
+	}
 	if c.PGUsername == "" {
 		return fmt.Errorf("pg_username is required")
 	}
@@ -138,11 +244,64 @@ func (c *Config) TotalDatabases() int {
 	return c.InstanceCount * c.DatabasesPerInstance
 }
 
+// EngineName returns the configured database engine, defaulting to postgres
+func (c *Config) EngineName() string {
+	if c.Engine == "" {
+		return EnginePostgres
+	}
+	return c.Engine
+}
+
+// TimelineWindow returns how much lock history the locker keeps for the TUI timeline
+func (c *Config) TimelineWindow() time.Duration {
+	if c.TimelineMinutes <= 0 {
+		return DefaultTimelineMinutes * time.Minute
+	}
+	return time.Duration(c.TimelineMinutes) * time.Minute
+}
+
+// IsMySQLFamily returns true if the engine is MySQL or MariaDB
+func (c *Config) IsMySQLFamily() bool {
+	return c.Engine == EngineMySQL || c.Engine == EngineMariaDB
+}
+
 // ImageName returns the Docker image name
 func (c *Config) ImageName() string {
+	if c.IsMySQLFamily() {
+		return c.DockerNamePrefix + "-" + c.Engine + "-image"
+	}
 	return c.DockerNamePrefix + "-pg-image"
 }
 
+// ConnString returns the connection URL for a database on the given instance port.
+// PostgreSQL uses the postgresql:// scheme; MySQL and MariaDB use mysql://.
+func (c *Config) ConnString(port int, dbName string) string {
+	scheme := "postgresql"
+	if c.IsMySQLFamily() {
+		scheme = "mysql"
+	}
+	return fmt.Sprintf("%s://%s:%s@%s/%s", scheme, c.PGUsername, c.Password,
+		net.JoinHostPort(c.Host(), strconv.Itoa(port)), dbName)
+}
+
+// Host returns the host clients use to reach the databases, defaulting to localhost
+func (c *Config) Host() string {
+	if c.AdvertisedHost == "" {
+		return "localhost"
+	}
+	return c.AdvertisedHost
+}
+
+// LockerListenAddr returns the address the locker server binds to
+func (c *Config) LockerListenAddr() string {
+	return net.JoinHostPort(c.LockerBindAddress, strconv.Itoa(c.LockerPort))
+}
+
+// DatabaseName returns the name of the i-th (1-based) test database on an instance
+func (c *Config) DatabaseName(i int) string {
+	return fmt.Sprintf("%s%d", c.DatabasePrefix, i)
+}
+
 // ContainerName returns the Docker container name for a given port
 func (c *Config) ContainerName(port int) string {
 	return fmt.Sprintf("%s-%d", c.DockerNamePrefix, port)
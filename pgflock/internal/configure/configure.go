@@ -39,8 +39,19 @@ func Run(configDir string) (*config.Config, error) {
 	}
 	cfg.DockerNamePrefix = promptString(reader, "Docker name prefix", defaultPrefix)
 
+	// Database engine
+	prevEngine := cfg.EngineName()
+	cfg.Engine = promptString(reader, "Database engine (postgres, mysql, mariadb)", prevEngine)
+	if cfg.IsMySQLFamily() && prevEngine == config.EnginePostgres && cfg.StartingPort == 5432 {
+		// Suggest the conventional MySQL port when switching engines
+		cfg.StartingPort = 3306
+	}
+
+	// Container runtime
+	cfg.ContainerRuntime = promptString(reader, "Container runtime (docker, podman, empty to auto-detect)", cfg.ContainerRuntime)
+
 	// Number of instances
-	cfg.InstanceCount = promptInt(reader, "Number of PostgreSQL instances", cfg.InstanceCount)
+	cfg.InstanceCount = promptInt(reader, "Number of database instances", cfg.InstanceCount)
 
 	// Starting port (subsequent instances get port+1, port+2, etc.)
 	cfg.StartingPort = promptInt(reader, "Starting port (instances get consecutive ports)", cfg.StartingPort)
@@ -60,27 +71,39 @@ func Run(configDir string) (*config.Config, error) {
 	// Locker port
 	cfg.LockerPort = promptInt(reader, "Locker port", cfg.LockerPort)
 
-	// PostgreSQL settings
-	cfg.PGUsername = promptString(reader, "PostgreSQL username", cfg.PGUsername)
+	// Database settings
+	cfg.PGUsername = promptString(reader, "Database username", cfg.PGUsername)
 	cfg.Password = promptString(reader, "Password (shared for all)", cfg.Password)
 	cfg.DatabasePrefix = promptString(reader, "Database name prefix", cfg.DatabasePrefix)
 
-	// Extensions - show existing as default
-	existingExts := strings.Join(cfg.Extensions, ",")
-	extStr := promptString(reader, "Extensions (comma-separated, e.g., postgis,pg_trgm; [] for none)", existingExts)
-	if parsed := parseExtensions(extStr); parsed != nil {
-		cfg.Extensions = parsed
-	}
+	if cfg.IsMySQLFamily() {
+		// MySQL/MariaDB image tag
+		defaultVersion := cfg.MySQLVersion
+		if defaultVersion == "" {
+			defaultVersion = templates.DefaultMySQLVersion
+			if cfg.Engine == config.EngineMariaDB {
+				defaultVersion = templates.DefaultMariaDBVersion
+			}
+		}
+		cfg.MySQLVersion = promptString(reader, cfg.Engine+" version", defaultVersion)
+	} else {
+		// Extensions - show existing as default
+		existingExts := strings.Join(cfg.Extensions, ",")
+		extStr := promptString(reader, "Extensions (comma-separated, e.g., postgis,pg_trgm; [] for none)", existingExts)
+		if parsed := parseExtensions(extStr); parsed != nil {
+			cfg.Extensions = parsed
+		}
 
-	// Postgres version
-	cfg.PostgresVersion = promptString(reader, "PostgreSQL version", cfg.PostgresVersion)
+		// Postgres version
+		cfg.PostgresVersion = promptString(reader, "PostgreSQL version", cfg.PostgresVersion)
 
-	// Encoding
-	cfg.Encoding = promptString(reader, "Database encoding", cfg.Encoding)
+		// Encoding
+		cfg.Encoding = promptString(reader, "Database encoding", cfg.Encoding)
 
-	// Locale
-	cfg.LCCollate = promptString(reader, "LC_COLLATE", cfg.LCCollate)
-	cfg.LCCtype = promptString(reader, "LC_CTYPE", cfg.LCCtype)
+		// Locale
+		cfg.LCCollate = promptString(reader, "LC_COLLATE", cfg.LCCollate)
+		cfg.LCCtype = promptString(reader, "LC_CTYPE", cfg.LCCtype)
+	}
 
 	// Auto-unlock timeout
 	cfg.AutoUnlockMins = promptInt(reader, "Auto-unlock timeout (minutes)", cfg.AutoUnlockMins)
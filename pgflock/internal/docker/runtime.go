@@ -0,0 +1,314 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+	"github.com/rickchristie/govner/pgflock/internal/engine"
+)
+
+// Supported values for config.Config.ContainerRuntime.
+const (
+	RuntimeAuto   = ""
+	RuntimeDocker = "docker"
+	RuntimePodman = "podman"
+)
+
+// Runtime is a container runtime that pgflock drives to build the image and
+// manage instance containers. Docker and Podman share almost the same CLI, so
+// both are implemented by cliRuntime with small per-runtime differences.
+type Runtime interface {
+	// Name returns the runtime name ("docker" or "podman").
+	Name() string
+
+	// BuildImage builds the database image from configDir. When stdout and
+	// stderr are non-nil, build output is streamed to them.
+	BuildImage(cfg *config.Config, configDir string, stdout, stderr io.Writer) error
+
+	// RunContainers starts one container per instance port, plus one per
+	// replica port when replicas are enabled.
+	RunContainers(cfg *config.Config, eng engine.Engine) error
+
+	// StopContainers stops and removes all instance containers.
+	StopContainers(cfg *config.Config) error
+
+	// ContainerStatus returns the status of each instance container.
+	ContainerStatus(cfg *config.Config) ([]ContainerInfo, error)
+
+	// TailLogs follows a container's logs, writing them to w until the command exits.
+	TailLogs(containerName string, w io.Writer) error
+
+	// Logs returns the full logs of a container.
+	Logs(containerName string) (string, error)
+
+	// Exec runs a command inside a running container with the environment
+	// variables in env (NAME=value).
+	Exec(containerName string, env []string, args ...string) error
+
+	// ExecStream runs a command inside a running container like Exec, with
+	// stdin (when non-nil) and stdout attached. Stderr is included in the
+	// returned error.
+	ExecStream(containerName string, env []string, stdin io.Reader, stdout io.Writer, args ...string) error
+
+	// IsRunning reports whether a container is currently running.
+	IsRunning(containerName string) bool
+}
+
+// RuntimeFor returns the runtime selected by cfg.ContainerRuntime, auto-detecting
+// from PATH when it is empty. Docker is preferred when both are installed.
+func RuntimeFor(cfg *config.Config) (Runtime, error) {
+	switch cfg.ContainerRuntime {
+	case RuntimeDocker:
+		return NewDockerRuntime(), nil
+	case RuntimePodman:
+		return NewPodmanRuntime(), nil
+	case RuntimeAuto:
+		if _, err := exec.LookPath("docker"); err == nil {
+			return NewDockerRuntime(), nil
+		}
+		if _, err := exec.LookPath("podman"); err == nil {
+			return NewPodmanRuntime(), nil
+		}
+		return nil, fmt.Errorf("no container runtime found: install docker or podman")
+	default:
+		return nil, fmt.Errorf("unknown container_runtime %q (use docker or podman)", cfg.ContainerRuntime)
+	}
+}
+
+// NewDockerRuntime returns a Runtime backed by the docker CLI.
+func NewDockerRuntime() Runtime {
+	return &cliRuntime{
+		binary:    "docker",
+		pruneArgs: []string{"system", "prune", "-f"},
+	}
+}
+
+// NewPodmanRuntime returns a Runtime backed by the podman CLI.
+// Works with both rootful and rootless Podman.
+func NewPodmanRuntime() Runtime {
+	return &cliRuntime{
+		binary: "podman",
+		// 'podman system prune' also removes pods and unused networks from the
+		// user's store, which is more than pgflock should touch.
+		pruneArgs: []string{"image", "prune", "-f"},
+	}
+}
+
+// cliRuntime drives a docker-compatible CLI.
+type cliRuntime struct {
+	binary    string
+	pruneArgs []string
+}
+
+func (r *cliRuntime) Name() string {
+	return r.binary
+}
+
+func (r *cliRuntime) command(args ...string) *exec.Cmd {
+	return exec.Command(r.binary, args...)
+}
+
+func (r *cliRuntime) prune() {
+	_ = r.command(r.pruneArgs...).Run()
+}
+
+func (r *cliRuntime) BuildImage(cfg *config.Config, configDir string, stdout, stderr io.Writer) error {
+	imageName := cfg.ImageName()
+
+	// Delete existing image first (like testdb's build-docker.sh)
+	if stdout != nil {
+		fmt.Fprintln(stdout, "Removing existing image...")
+	}
+	_ = r.command("rmi", imageName).Run()
+
+	cmd := r.command("build", "--no-cache", "-t", imageName, configDir)
+
+	// Stream stderr live (if requested) while also capturing it for error reporting
+	var outputBuf bytes.Buffer
+	if stdout != nil {
+		cmd.Stdout = stdout
+	} else {
+		cmd.Stdout = &outputBuf
+	}
+	if stderr != nil {
+		cmd.Stderr = io.MultiWriter(stderr, &outputBuf)
+	} else {
+		cmd.Stderr = &outputBuf
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s build failed: %w\n%s", r.binary, err, outputBuf.String())
+	}
+
+	// Clean up dangling images after build
+	if stdout != nil {
+		fmt.Fprintln(stdout, "Cleaning up dangling images...")
+	}
+	r.prune()
+
+	return nil
+}
+
+func (r *cliRuntime) RunContainers(cfg *config.Config, eng engine.Engine) error {
+	for _, port := range cfg.ContainerPorts() {
+		containerName := cfg.ContainerName(port)
+		spec := eng.ContainerSpec(cfg, port)
+		if cfg.IsReplicaPort(port) {
+			replicator, ok := eng.(engine.Replicator)
+			if !ok {
+				return fmt.Errorf("%s does not support replicas", eng.DisplayName())
+			}
+			spec = replicator.ReplicaContainerSpec(cfg, port-cfg.InstanceCount, port)
+		}
+
+		// Remove existing container if any
+		_ = r.command("rm", "-f", containerName).Run()
+
+		output, err := r.command(runArgs(cfg, containerName, spec)...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to start container %s: %w\n%s", containerName, err, string(output))
+		}
+	}
+
+	return nil
+}
+
+// runArgs builds the arguments of the run command that starts a container
+func runArgs(cfg *config.Config, containerName string, spec engine.ContainerSpec) []string {
+	args := []string{
+		"run", "-d",
+		"--name", containerName,
+		"--net=host",
+		"--tmpfs", fmt.Sprintf("%s:rw,noexec,nosuid,size=%s", spec.DataDir, cfg.TmpfsSize),
+		"--shm-size", cfg.ShmSize,
+	}
+
+	// Add CPU limit if configured
+	if cfg.CPULimit != "" {
+		args = append(args, "--cpus", cfg.CPULimit)
+	}
+
+	for _, env := range spec.Env {
+		args = append(args, "-e", env)
+	}
+	if spec.Entrypoint != "" {
+		args = append(args, "--entrypoint", spec.Entrypoint)
+	}
+	args = append(args, cfg.ImageName())
+	return append(args, spec.Args...)
+}
+
+func (r *cliRuntime) StopContainers(cfg *config.Config) error {
+	var errs []string
+
+	for _, port := range cfg.ContainerPorts() {
+		containerName := cfg.ContainerName(port)
+
+		if err := r.command("stop", containerName).Run(); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to stop %s: %v", containerName, err))
+			continue
+		}
+
+		// Remove the container
+		_ = r.command("rm", containerName).Run() // Ignore error on rm
+	}
+
+	// Clean up dangling containers and images (like testdb's stop-docker.sh)
+	r.prune()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("errors stopping containers:\n%s", strings.Join(errs, "\n"))
+	}
+
+	return nil
+}
+
+func (r *cliRuntime) ContainerStatus(cfg *config.Config) ([]ContainerInfo, error) {
+	ports := cfg.ContainerPorts()
+	infos := make([]ContainerInfo, len(ports))
+
+	for i, port := range ports {
+		containerName := cfg.ContainerName(port)
+		infos[i] = ContainerInfo{
+			Name: containerName,
+			Port: port,
+		}
+
+		output, err := r.command("inspect", "--format", "{{.State.Status}}", containerName).Output()
+		if err != nil {
+			infos[i].Status = "not found"
+			infos[i].Running = false
+			continue
+		}
+
+		status := strings.TrimSpace(string(output))
+		infos[i].Status = status
+		infos[i].Running = status == "running"
+	}
+
+	return infos, nil
+}
+
+func (r *cliRuntime) TailLogs(containerName string, w io.Writer) error {
+	cmd := r.command("logs", "--follow", "--tail", "100", containerName)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if w == nil {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+	return cmd.Run()
+}
+
+func (r *cliRuntime) Logs(containerName string) (string, error) {
+	output, err := r.command("logs", containerName).CombinedOutput()
+	return string(output), err
+}
+
+func (r *cliRuntime) Exec(containerName string, env []string, args ...string) error {
+	return r.execCommand(containerName, env, false, args).Run()
+}
+
+func (r *cliRuntime) ExecStream(containerName string, env []string, stdin io.Reader, stdout io.Writer, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := r.execCommand(containerName, env, stdin != nil, args)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w, output: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// execCommand builds the exec command. Variables are passed by name only
+// (-e NAME) and their values through the CLI's own environment, so they do not
+// show up in the process list.
+func (r *cliRuntime) execCommand(containerName string, env []string, interactive bool, args []string) *exec.Cmd {
+	execArgs := []string{"exec"}
+	if interactive {
+		execArgs = append(execArgs, "-i")
+	}
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		execArgs = append(execArgs, "-e", name)
+	}
+	execArgs = append(execArgs, containerName)
+
+	cmd := r.command(append(execArgs, args...)...)
+	cmd.Env = append(cmd.Environ(), env...)
+	return cmd
+}
+
+func (r *cliRuntime) IsRunning(containerName string) bool {
+	output, err := r.command("inspect", "--format", "{{.State.Running}}", containerName).Output()
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(output)) == "true"
+}
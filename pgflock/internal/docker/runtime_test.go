@@ -0,0 +1,132 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+	"github.com/rickchristie/govner/pgflock/internal/engine"
+)
+
+// fakePath points PATH at a directory holding empty executables with the given names
+func fakePath(t *testing.T, binaries ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range binaries {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestRuntimeFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		runtime   string
+		installed []string
+		want      string
+		wantErr   bool
+	}{
+		{"docker", RuntimeDocker, nil, "docker", false},
+		{"podman", RuntimePodman, nil, "podman", false},
+		{"podman with docker installed", RuntimePodman, []string{"docker", "podman"}, "podman", false},
+		{"auto prefers docker", RuntimeAuto, []string{"docker", "podman"}, "docker", false},
+		{"auto falls back to podman", RuntimeAuto, []string{"podman"}, "podman", false},
+		{"auto without runtime", RuntimeAuto, nil, "", true},
+		{"unknown", "containerd", []string{"docker"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakePath(t, tt.installed...)
+			rt, err := RuntimeFor(&config.Config{ContainerRuntime: tt.runtime})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RuntimeFor(%q) error = %v, wantErr %v", tt.runtime, err, tt.wantErr)
+			}
+			if err == nil && rt.Name() != tt.want {
+				t.Errorf("RuntimeFor(%q).Name() = %q, want %q", tt.runtime, rt.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneArgs(t *testing.T) {
+	tests := []struct {
+		rt   Runtime
+		want []string
+	}{
+		{NewDockerRuntime(), []string{"docker", "system", "prune", "-f"}},
+		// Podman only prunes images, leaving the user's pods and networks alone
+		{NewPodmanRuntime(), []string{"podman", "image", "prune", "-f"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rt.Name(), func(t *testing.T) {
+			r := tt.rt.(*cliRuntime)
+			got := r.command(r.pruneArgs...).Args
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prune command = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunArgs(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.CPULimit = "2.0"
+	spec := (&engine.Postgres{}).ContainerSpec(cfg, 5433)
+
+	want := []string{
+		"run", "-d",
+		"--name", "pgflock-5433",
+		"--net=host",
+		"--tmpfs", "/var/lib/postgresql/data:rw,noexec,nosuid,size=1024m",
+		"--shm-size", "1g",
+		"--cpus", "2.0",
+		"-e", "NUM_TEST_DBS=10",
+		"-e", "PGPORT=5433",
+		"pgflock-pg-image",
+		"postgres", "-c", "port=5433", "-c", "config_file=/etc/postgresql/postgresql.conf",
+	}
+	if got := runArgs(cfg, cfg.ContainerName(5433), spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("runArgs = %q, want %q", got, want)
+	}
+
+	// No CPU limit, and an entrypoint override
+	cfg.CPULimit = ""
+	spec = engine.ContainerSpec{DataDir: "/data", Entrypoint: "/replica.sh", Args: []string{"5432"}}
+	want = []string{
+		"run", "-d",
+		"--name", "pgflock-5434",
+		"--net=host",
+		"--tmpfs", "/data:rw,noexec,nosuid,size=1024m",
+		"--shm-size", "1g",
+		"--entrypoint", "/replica.sh",
+		"pgflock-pg-image",
+		"5432",
+	}
+	if got := runArgs(cfg, cfg.ContainerName(5434), spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("runArgs = %q, want %q", got, want)
+	}
+}
+
+func TestExecCommand(t *testing.T) {
+	for _, rt := range []Runtime{NewDockerRuntime(), NewPodmanRuntime()} {
+		t.Run(rt.Name(), func(t *testing.T) {
+			r := rt.(*cliRuntime)
+			cmd := r.execCommand("pgflock-3306", []string{"MYSQL_PWD=secret"}, true, []string{"mysql", "-uroot"})
+
+			want := []string{rt.Name(), "exec", "-i", "-e", "MYSQL_PWD", "pgflock-3306", "mysql", "-uroot"}
+			if !reflect.DeepEqual(cmd.Args, want) {
+				t.Errorf("exec command = %q, want %q", cmd.Args, want)
+			}
+			if !slices.Contains(cmd.Env, "MYSQL_PWD=secret") {
+				t.Errorf("exec environment is missing MYSQL_PWD")
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+// Package engine abstracts the database server that pgflock pools. Each engine
+// knows how to run its instances inside a container, how to tell when an
+// instance is ready, and how to reset a database back to its template.
+// Image build files are generated by the templates package.
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+)
+
+// ContainerSpec describes how an engine's container is started for one instance.
+type ContainerSpec struct {
+	DataDir    string   // Data directory mounted as tmpfs
+	Env        []string // Extra environment variables (KEY=VALUE)
+	Entrypoint string   // Overrides the image entrypoint when set
+	Args       []string // Arguments passed after the image name
+}
+
+// LogMarkers are container log lines used to detect instance readiness.
+// Only log output after InitComplete is considered, because the official
+// images run a temporary server during initialisation that also logs Ready.
+type LogMarkers struct {
+	InitComplete string
+	Ready        string
+	BindErrors   []string
+}
+
+// Engine is a database server that pgflock can pool.
+type Engine interface {
+	// Name returns the config name of the engine (e.g. "postgres").
+	Name() string
+
+	// DisplayName returns the human-readable name (e.g. "PostgreSQL").
+	DisplayName() string
+
+	// ContainerSpec returns how to start the container for an instance port.
+	ContainerSpec(cfg *config.Config, port int) ContainerSpec
+
+	// LogMarkers returns the log lines that indicate init and readiness.
+	LogMarkers() LogMarkers
+
+	// ReadyCommand returns a command run inside the container that succeeds
+	// once the server accepts connections.
+	ReadyCommand(cfg *config.Config, port int) []string
+
+	// ExecEnv returns the environment (NAME=value) of the commands run inside
+	// the container (ReadyCommand, DumpCommand and RestoreCommand), so that
+	// secrets such as the password stay off their command lines.
+	ExecEnv(cfg *config.Config) []string
+
+	// IsReady checks from the host whether the instance on port responds.
+	IsReady(cfg *config.Config, port int) bool
+
+	// ResetDatabase drops the database in connStr and recreates it from the
+	// template database on the same instance (TemplateDatabase or a promoted
+	// template, see TemplateName).
+	ResetDatabase(cfg *config.Config, connStr string, template string) error
+
+	// CreateTemplate replaces the template database on the instance of connStr
+	// with a copy of the database in connStr. Other connections to connStr may
+	// be terminated while it is copied.
+	CreateTemplate(cfg *config.Config, connStr string, template string) error
+
+	// CopyDatabase replaces the database in toConnStr with a dump of the
+	// database in fromConnStr, which may be on another instance.
+	CopyDatabase(cfg *config.Config, fromConnStr, toConnStr string) error
+
+	// CreateDatabase drops the database in connStr if it exists and creates it empty.
+	CreateDatabase(cfg *config.Config, connStr string) error
+
+	// DumpCommand returns a command run inside the container of the instance
+	// on port that writes a dump of dbName to stdout.
+	DumpCommand(cfg *config.Config, port int, dbName string) []string
+
+	// RestoreCommand returns a command run inside the container of the instance
+	// on port that loads a dump written by DumpCommand from stdin into dbName,
+	// which must exist and be empty.
+	RestoreCommand(cfg *config.Config, port int, dbName string) []string
+
+	// ShellCommand returns the interactive client command line for connStr.
+	ShellCommand(connStr string) []string
+
+	// ShellEnv returns the environment (NAME=value) to run ShellCommand with,
+	// keeping the password off its command line where the client allows it.
+	ShellEnv(connStr string) []string
+
+	// Catalog lists the schema objects (tables, columns, indexes and functions)
+	// of the database in connStr, one normalised line per object, sorted.
+	// Lines are comparable between databases on the same instance.
+	Catalog(cfg *config.Config, connStr string) ([]string, error)
+}
+
+// Replicator is implemented by engines that can run a streaming replica next
+// to each instance (config.Config.Replicas).
+type Replicator interface {
+	// ReplicaContainerSpec returns how to start the replica container on
+	// replicaPort that streams from the instance on primaryPort.
+	ReplicaContainerSpec(cfg *config.Config, primaryPort, replicaPort int) ContainerSpec
+
+	// ReplicaLogMarkers returns the log lines that indicate replica init and readiness.
+	ReplicaLogMarkers() LogMarkers
+
+	// WaitForReplica blocks until the replica has replayed everything written
+	// to the primary up to now, or returns an error after timeout.
+	WaitForReplica(cfg *config.Config, primaryConnStr, replicaConnStr string, timeout time.Duration) error
+}
+
+// TemplateDatabase is the database that test databases are reset from.
+const TemplateDatabase = "test_template"
+
+// maxTemplateNameLength keeps promoted template database names well inside
+// the 63 (PostgreSQL) and 64 (MySQL) character identifier limits.
+const maxTemplateNameLength = 40
+
+// TemplateName returns the database name of a promoted template, or
+// TemplateDatabase when name is empty.
+func TemplateName(name string) string {
+	if name == "" {
+		return TemplateDatabase
+	}
+	return TemplateDatabase + "_" + name
+}
+
+// ValidateTemplateName checks that name can be used for a promoted template.
+// Names are lower-case letters, digits and underscores, so they can be used in
+// a database name without quoting.
+func ValidateTemplateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("template name required")
+	}
+	if len(name) > maxTemplateNameLength {
+		return fmt.Errorf("template name %q longer than %d characters", name, maxTemplateNameLength)
+	}
+	for _, r := range name {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')) {
+			return fmt.Errorf("invalid template name %q (use lower-case letters, digits and underscores)", name)
+		}
+	}
+	return nil
+}
+
+// For returns the engine configured in cfg.
+func For(cfg *config.Config) (Engine, error) {
+	switch cfg.EngineName() {
+	case config.EnginePostgres:
+		return &Postgres{}, nil
+	case config.EngineMySQL:
+		return &MySQL{}, nil
+	case config.EngineMariaDB:
+		return &MySQL{MariaDB: true}, nil
+	default:
+		return nil, fmt.Errorf("unknown engine %q (use postgres, mysql or mariadb)", cfg.Engine)
+	}
+}
+
+// ConnInfo holds the parts of a pgflock connection URL.
+type ConnInfo struct {
+	Host     string
+	Port     string
+	DBName   string
+	User     string
+	Password string
+}
+
+// ParseConnString parses a pgflock connection URL (postgresql:// or mysql://).
+func ParseConnString(connStr string, defaultPort string) (ConnInfo, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return ConnInfo{}, fmt.Errorf("invalid connection string: %w", err)
+	}
+
+	info := ConnInfo{
+		Host:   u.Hostname(),
+		Port:   u.Port(),
+		DBName: strings.TrimPrefix(u.Path, "/"),
+		User:   u.User.Username(),
+	}
+	if info.Port == "" {
+		info.Port = defaultPort
+	}
+	info.Password, _ = u.User.Password()
+
+	return info, nil
+}
+
+// TemplateConnString returns connStr pointed at the template database.
+func TemplateConnString(connStr string) (string, error) {
+	return WithDatabase(connStr, TemplateDatabase)
+}
+
+// WithDatabase returns connStr pointed at another database on the same instance.
+func WithDatabase(connStr, dbName string) (string, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid connection string: %w", err)
+	}
+	u.Path = "/" + dbName
+	return u.String(), nil
+}
+
+// LocalConnString returns connStr pointed at localhost. Connection strings
+// carry the advertised host for clients on other machines; the locker runs
+// its admin commands on the pool host itself, where that name may not route
+// back (e.g. a NAT address without hairpinning).
+func LocalConnString(connStr string) (string, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid connection string: %w", err)
+	}
+	u.Host = net.JoinHostPort("localhost", u.Port())
+	return u.String(), nil
+}
+
+// SchemaDiff lists catalog lines that differ between a database and its template.
+// A changed object (e.g. a column whose type was altered) shows up as one
+// removed and one added line.
+type SchemaDiff struct {
+	Added   []string // In the database but not the template
+	Removed []string // In the template but not the database
+}
+
+// Empty returns true if the database matches its template.
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// DiffCatalogs compares a database catalog against its template catalog.
+func DiffCatalogs(template, db []string) *SchemaDiff {
+	inTemplate := make(map[string]bool, len(template))
+	for _, line := range template {
+		inTemplate[line] = true
+	}
+	inDB := make(map[string]bool, len(db))
+	for _, line := range db {
+		inDB[line] = true
+	}
+
+	diff := &SchemaDiff{}
+	for _, line := range db {
+		if !inTemplate[line] {
+			diff.Added = append(diff.Added, line)
+		}
+	}
+	for _, line := range template {
+		if !inDB[line] {
+			diff.Removed = append(diff.Removed, line)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// CompareWithTemplate compares the catalog of the database in connStr with
+// the template it was reset from, found at templateConnStr.
+func CompareWithTemplate(eng Engine, cfg *config.Config, connStr, templateConnStr string) (*SchemaDiff, error) {
+	template, err := eng.Catalog(cfg, templateConnStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template catalog: %w", err)
+	}
+	db, err := eng.Catalog(cfg, connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database catalog: %w", err)
+	}
+	return DiffCatalogs(template, db), nil
+}
+
+// pipe runs from and to with the output of from as the input of to, such as
+// a dump piped into a client. If to exits early, from fails on the broken pipe.
+func pipe(from, to *exec.Cmd) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create pipe: %w", err)
+	}
+	var fromErr, toErr bytes.Buffer
+	from.Stdout, from.Stderr = w, &fromErr
+	to.Stdin, to.Stderr = r, &toErr
+
+	if err := to.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return fmt.Errorf("failed to start %s: %w", to.Args[0], err)
+	}
+	if err := from.Start(); err != nil {
+		r.Close()
+		w.Close()
+		to.Wait()
+		return fmt.Errorf("failed to start %s: %w", from.Args[0], err)
+	}
+	// The children hold their own copies; closing ours lets to see EOF and
+	// from see the broken pipe.
+	r.Close()
+	w.Close()
+
+	fromWaitErr := from.Wait()
+	if err := to.Wait(); err != nil {
+		return fmt.Errorf("%s error: %w, output: %s", to.Args[0], err, toErr.String())
+	}
+	if fromWaitErr != nil {
+		return fmt.Errorf("%s error: %w, output: %s", from.Args[0], fromWaitErr, fromErr.String())
+	}
+	return nil
+}
+
+// splitLines returns the non-empty, trimmed lines of client output.
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
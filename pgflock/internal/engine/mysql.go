@@ -0,0 +1,352 @@
+package engine
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+)
+
+// MySQL runs the official mysql image, or the mariadb image when MariaDB is set.
+type MySQL struct {
+	MariaDB bool
+}
+
+func (m *MySQL) Name() string {
+	if m.MariaDB {
+		return config.EngineMariaDB
+	}
+	return config.EngineMySQL
+}
+
+func (m *MySQL) DisplayName() string {
+	if m.MariaDB {
+		return "MariaDB"
+	}
+	return "MySQL"
+}
+
+// clientBinary returns the name of a client tool. MariaDB 11 images and recent
+// host packages ship only the mariadb-* names, so those are used for MariaDB.
+func (m *MySQL) clientBinary(tool string) string {
+	if !m.MariaDB {
+		return "mysql" + tool
+	}
+	if tool != "" {
+		tool = "-" + tool
+	}
+	return "mariadb" + tool
+}
+
+// hostClientBinary resolves a client tool on the host, falling back to the
+// other family's name so a mysql client can talk to MariaDB and vice versa.
+func (m *MySQL) hostClientBinary(tool string) string {
+	name := m.clientBinary(tool)
+	if _, err := exec.LookPath(name); err == nil {
+		return name
+	}
+	other := &MySQL{MariaDB: !m.MariaDB}
+	return other.clientBinary(tool)
+}
+
+func (m *MySQL) ContainerSpec(cfg *config.Config, port int) ContainerSpec {
+	// Arguments starting with '-' are appended to the image's default
+	// server command by the entrypoint (mysqld or mariadbd).
+	args := []string{fmt.Sprintf("--port=%d", port)}
+	if !m.MariaDB {
+		// MySQL 8 also listens on 33060 for the X Protocol. Instances share
+		// the host network, so every instance after the first would fail to
+		// bind it. MariaDB has no X Plugin.
+		args = append(args, "--mysqlx=OFF")
+	}
+	return ContainerSpec{
+		DataDir: "/var/lib/mysql",
+		Env: []string{
+			fmt.Sprintf("NUM_TEST_DBS=%d", cfg.DatabasesPerInstance),
+		},
+		Args: args,
+	}
+}
+
+func (m *MySQL) LogMarkers() LogMarkers {
+	initComplete := "MySQL init process done"
+	if m.MariaDB {
+		initComplete = "MariaDB init process done"
+	}
+	return LogMarkers{
+		InitComplete: initComplete,
+		Ready:        "ready for connections",
+		BindErrors:   []string{"Address already in use", "Bind on TCP/IP port"},
+	}
+}
+
+func (m *MySQL) ReadyCommand(cfg *config.Config, port int) []string {
+	return []string{
+		m.clientBinary("admin"), "ping",
+		"--protocol=TCP", "-h", "127.0.0.1",
+		"-P", fmt.Sprintf("%d", port),
+		"-u", cfg.PGUsername,
+	}
+}
+
+// ExecEnv passes the password in MYSQL_PWD, like IsReady and run do on the host.
+func (m *MySQL) ExecEnv(cfg *config.Config) []string {
+	return []string{"MYSQL_PWD=" + cfg.Password}
+}
+
+func (m *MySQL) IsReady(cfg *config.Config, port int) bool {
+	cmd := exec.Command(m.hostClientBinary("admin"), "ping",
+		"--protocol=TCP", "-h", "127.0.0.1",
+		"-P", fmt.Sprintf("%d", port),
+		"-u", cfg.PGUsername,
+	)
+	cmd.Env = append(cmd.Environ(), "MYSQL_PWD="+cfg.Password)
+	return cmd.Run() == nil
+}
+
+func (m *MySQL) ShellCommand(connStr string) []string {
+	conn, err := ParseConnString(connStr, "3306")
+	if err != nil {
+		return []string{m.clientBinary("")}
+	}
+	return []string{
+		m.hostClientBinary(""),
+		"--protocol=TCP", "-h", conn.Host, "-P", conn.Port,
+		"-u", conn.User,
+		conn.DBName,
+	}
+}
+
+// ShellEnv passes the password in MYSQL_PWD, so it stays out of the process
+// list and the client doesn't warn about it.
+func (m *MySQL) ShellEnv(connStr string) []string {
+	conn, err := ParseConnString(connStr, "3306")
+	if err != nil {
+		return nil
+	}
+	return []string{"MYSQL_PWD=" + conn.Password}
+}
+
+// ResetDatabase drops the database and recreates it with a copy of the
+// structure and rows of every table in the template, as MySQL has no CREATE
+// DATABASE ... TEMPLATE. Views, routines and triggers are not copied.
+func (m *MySQL) ResetDatabase(cfg *config.Config, connStr string, template string) error {
+	conn, err := ParseConnString(connStr, "3306")
+	if err != nil {
+		return err
+	}
+	dbname := conn.DBName
+
+	log.Debug().Str("dbname", dbname).Str("port", conn.Port).Str("template", template).Str("engine", m.Name()).Msg("Resetting database")
+
+	// Step 1: Kill any existing connections to the database
+	ids, err := m.query(conn, fmt.Sprintf(
+		"SELECT id FROM information_schema.processlist WHERE db = '%s' AND id <> CONNECTION_ID();", dbname))
+	if err != nil {
+		// Log but don't fail - there might be no connections
+		log.Debug().Err(err).Str("dbname", dbname).Msg("Failed to list connections (may be none)")
+	}
+	for _, id := range ids {
+		if err := m.exec(conn, fmt.Sprintf("KILL %s;", id)); err != nil {
+			log.Debug().Err(err).Str("dbname", dbname).Str("id", id).Msg("Failed to kill connection (may be gone)")
+		}
+	}
+
+	// Step 2: Drop and recreate the database
+	createSQL := fmt.Sprintf(
+		"DROP DATABASE IF EXISTS `%s`; CREATE DATABASE `%s` CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;",
+		dbname, dbname)
+	if err := m.exec(conn, createSQL); err != nil {
+		return fmt.Errorf("failed to recreate database: %w", err)
+	}
+
+	// Step 3: Copy tables from the template
+	tables, err := m.query(conn, fmt.Sprintf(
+		"SELECT table_name FROM information_schema.tables WHERE table_schema = '%s' AND table_type = 'BASE TABLE';",
+		template))
+	if err != nil {
+		return fmt.Errorf("failed to list template tables: %w", err)
+	}
+	if len(tables) == 0 {
+		log.Debug().Str("dbname", dbname).Msg("Database reset complete")
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("SET FOREIGN_KEY_CHECKS = 0;")
+	for _, table := range tables {
+		fmt.Fprintf(&b, "CREATE TABLE `%s`.`%s` LIKE `%s`.`%s`;", dbname, table, template, table)
+		fmt.Fprintf(&b, "INSERT INTO `%s`.`%s` SELECT * FROM `%s`.`%s`;", dbname, table, template, table)
+	}
+	b.WriteString("SET FOREIGN_KEY_CHECKS = 1;")
+	if err := m.exec(conn, b.String()); err != nil {
+		return fmt.Errorf("failed to copy template tables: %w", err)
+	}
+
+	log.Debug().Str("dbname", dbname).Msg("Database reset complete")
+	return nil
+}
+
+// CreateTemplate copies the tables of the database into the template the same
+// way a reset copies them out of it. Connections to the source stay open.
+func (m *MySQL) CreateTemplate(cfg *config.Config, connStr string, template string) error {
+	conn, err := ParseConnString(connStr, "3306")
+	if err != nil {
+		return err
+	}
+	templateConnStr, err := WithDatabase(connStr, template)
+	if err != nil {
+		return err
+	}
+	return m.ResetDatabase(cfg, templateConnStr, conn.DBName)
+}
+
+// CopyDatabase recreates the target empty and loads a mysqldump of the source into it.
+func (m *MySQL) CopyDatabase(cfg *config.Config, fromConnStr, toConnStr string) error {
+	from, err := ParseConnString(fromConnStr, "3306")
+	if err != nil {
+		return err
+	}
+	to, err := ParseConnString(toConnStr, "3306")
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Str("from", from.Port+"/"+from.DBName).Str("to", to.Port+"/"+to.DBName).Str("engine", m.Name()).Msg("Copying database")
+
+	if err := m.CreateDatabase(cfg, toConnStr); err != nil {
+		return err
+	}
+
+	dump := exec.Command(m.hostClientBinary("dump"),
+		"--protocol=TCP", "-h", from.Host, "-P", from.Port,
+		"-u", from.User,
+		"--single-transaction", "--skip-triggers",
+		from.DBName,
+	)
+	dump.Env = append(dump.Environ(), "MYSQL_PWD="+from.Password)
+	load := exec.Command(m.hostClientBinary(""),
+		"--protocol=TCP", "-h", to.Host, "-P", to.Port,
+		"-u", to.User,
+		to.DBName,
+	)
+	load.Env = append(load.Environ(), "MYSQL_PWD="+to.Password)
+	if err := pipe(dump, load); err != nil {
+		return fmt.Errorf("failed to copy database: %w", err)
+	}
+	return nil
+}
+
+// CreateDatabase recreates the database with the same character set as the test databases.
+func (m *MySQL) CreateDatabase(cfg *config.Config, connStr string) error {
+	conn, err := ParseConnString(connStr, "3306")
+	if err != nil {
+		return err
+	}
+	createSQL := fmt.Sprintf(
+		"DROP DATABASE IF EXISTS `%s`; CREATE DATABASE `%s` CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;",
+		conn.DBName, conn.DBName)
+	if err := m.exec(conn, createSQL); err != nil {
+		return fmt.Errorf("failed to recreate database: %w", err)
+	}
+	return nil
+}
+
+// DumpCommand runs mysqldump (or mariadb-dump) inside the container. Triggers
+// are skipped because resets do not copy them either.
+func (m *MySQL) DumpCommand(cfg *config.Config, port int, dbName string) []string {
+	return []string{
+		m.clientBinary("dump"),
+		"--protocol=TCP", "-h", "127.0.0.1",
+		"-P", fmt.Sprintf("%d", port),
+		"-u", cfg.PGUsername,
+		"--single-transaction", "--skip-triggers",
+		dbName,
+	}
+}
+
+// RestoreCommand pipes the dump into the client inside the container.
+func (m *MySQL) RestoreCommand(cfg *config.Config, port int, dbName string) []string {
+	return []string{
+		m.clientBinary(""),
+		"--protocol=TCP", "-h", "127.0.0.1",
+		"-P", fmt.Sprintf("%d", port),
+		"-u", cfg.PGUsername,
+		dbName,
+	}
+}
+
+// mysqlCatalogSQL lists the tables, columns and indexes of one schema, each
+// row with the type of its table. Routines are left out because ResetDatabase
+// does not copy them from the template, so every database would differ.
+const mysqlCatalogSQL = `
+SELECT table_type, CONCAT('table ', table_name, ' ', LOWER(table_type))
+FROM information_schema.tables WHERE table_schema = '%[1]s'
+UNION ALL
+SELECT t.table_type, CONCAT('column ', c.table_name, '.', c.column_name, ' ', c.column_type,
+	IF(c.is_nullable = 'NO', ' not null', ''), IFNULL(CONCAT(' default ', c.column_default), ''))
+FROM information_schema.columns c JOIN information_schema.tables t
+	ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+WHERE c.table_schema = '%[1]s'
+UNION ALL
+SELECT 'BASE TABLE', CONCAT('index ', table_name, '.', index_name, IF(non_unique = 0, ' unique', ''),
+	' (', GROUP_CONCAT(column_name ORDER BY seq_in_index), ')')
+FROM information_schema.statistics WHERE table_schema = '%[1]s'
+GROUP BY table_name, index_name, non_unique
+ORDER BY 2;`
+
+// Catalog lists the base tables of the database with their columns and
+// indexes. Views are left out like routines, as ResetDatabase does not copy
+// them either.
+func (m *MySQL) Catalog(cfg *config.Config, connStr string) ([]string, error) {
+	conn, err := ParseConnString(connStr, "3306")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := m.query(conn, fmt.Sprintf(mysqlCatalogSQL, conn.DBName))
+	if err != nil {
+		return nil, err
+	}
+	var catalog []string
+	for _, row := range rows {
+		if tableType, line, ok := strings.Cut(row, "\t"); ok && tableType == "BASE TABLE" {
+			catalog = append(catalog, line)
+		}
+	}
+	return catalog, nil
+}
+
+// exec runs SQL statements via the mysql client
+func (m *MySQL) exec(conn ConnInfo, sql string) error {
+	_, err := m.run(conn, sql)
+	return err
+}
+
+// query runs a single-column query and returns the values, one per row
+func (m *MySQL) query(conn ConnInfo, sql string) ([]string, error) {
+	output, err := m.run(conn, sql)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(output), nil
+}
+
+func (m *MySQL) run(conn ConnInfo, sql string) (string, error) {
+	cmd := exec.Command(m.hostClientBinary(""),
+		"--protocol=TCP", "-h", conn.Host, "-P", conn.Port,
+		"-u", conn.User,
+		"--batch", "--skip-column-names",
+		"-e", sql,
+	)
+	// MYSQL_PWD keeps the password off the process list
+	cmd.Env = append(cmd.Environ(), "MYSQL_PWD="+conn.Password)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s error: %w, output: %s", cmd.Args[0], err, string(output))
+	}
+	return string(output), nil
+}
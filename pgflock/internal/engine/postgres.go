@@ -0,0 +1,334 @@
+package engine
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+)
+
+// Postgres is the default engine, running the official postgres image.
+type Postgres struct{}
+
+func (p *Postgres) Name() string        { return config.EnginePostgres }
+func (p *Postgres) DisplayName() string { return "PostgreSQL" }
+
+func (p *Postgres) ContainerSpec(cfg *config.Config, port int) ContainerSpec {
+	return ContainerSpec{
+		DataDir: "/var/lib/postgresql/data",
+		Env: []string{
+			fmt.Sprintf("NUM_TEST_DBS=%d", cfg.DatabasesPerInstance),
+			fmt.Sprintf("PGPORT=%d", port),
+		},
+		Args: []string{
+			"postgres", "-c", fmt.Sprintf("port=%d", port),
+			"-c", "config_file=/etc/postgresql/postgresql.conf",
+		},
+	}
+}
+
+func (p *Postgres) LogMarkers() LogMarkers {
+	return LogMarkers{
+		InitComplete: "PostgreSQL init process complete",
+		Ready:        "database system is ready to accept connections",
+		BindErrors:   []string{"Address already in use"},
+	}
+}
+
+// ReplicaContainerSpec starts the same image with replica.sh as entrypoint,
+// which clones the primary with pg_basebackup and runs it as hot standby.
+func (p *Postgres) ReplicaContainerSpec(cfg *config.Config, primaryPort, replicaPort int) ContainerSpec {
+	return ContainerSpec{
+		DataDir: "/var/lib/postgresql/data",
+		Env: []string{
+			fmt.Sprintf("PRIMARY_PORT=%d", primaryPort),
+			fmt.Sprintf("PGPORT=%d", replicaPort),
+		},
+		Entrypoint: "/usr/local/bin/pgflock-replica.sh",
+	}
+}
+
+func (p *Postgres) ReplicaLogMarkers() LogMarkers {
+	return LogMarkers{
+		InitComplete: "pgflock replica init complete",
+		Ready:        "database system is ready to accept read-only connections",
+		BindErrors:   []string{"Address already in use"},
+	}
+}
+
+// WaitForReplica reads the primary's current WAL position and polls the
+// replica until it has replayed up to it.
+func (p *Postgres) WaitForReplica(cfg *config.Config, primaryConnStr, replicaConnStr string, timeout time.Duration) error {
+	primary, err := ParseConnString(primaryConnStr, "5432")
+	if err != nil {
+		return err
+	}
+	replica, err := ParseConnString(replicaConnStr, "5432")
+	if err != nil {
+		return err
+	}
+
+	lsn, err := queryPsql(primaryConnStr, "SELECT pg_current_wal_lsn();", primary.Password)
+	if err != nil {
+		return fmt.Errorf("failed to read primary WAL position: %w", err)
+	}
+
+	caughtUpSQL := fmt.Sprintf("SELECT pg_last_wal_replay_lsn() >= '%s'::pg_lsn;", lsn)
+	deadline := time.Now().Add(timeout)
+	for {
+		caughtUp, err := queryPsql(replicaConnStr, caughtUpSQL, replica.Password)
+		if err == nil && caughtUp == "t" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("replica did not catch up to %s within %s: %w", lsn, timeout, err)
+			}
+			return fmt.Errorf("replica did not catch up to %s within %s", lsn, timeout)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func (p *Postgres) ReadyCommand(cfg *config.Config, port int) []string {
+	// pg_isready via Unix socket
+	return []string{
+		"pg_isready",
+		"-h", "/var/run/postgresql",
+		"-p", fmt.Sprintf("%d", port),
+		"-U", cfg.PGUsername,
+	}
+}
+
+// ExecEnv is empty: commands in the container connect over the Unix socket,
+// which trusts local users.
+func (p *Postgres) ExecEnv(cfg *config.Config) []string {
+	return nil
+}
+
+func (p *Postgres) IsReady(cfg *config.Config, port int) bool {
+	cmd := exec.Command("pg_isready",
+		"-h", "localhost",
+		"-p", fmt.Sprintf("%d", port),
+		"-U", cfg.PGUsername,
+	)
+	return cmd.Run() == nil
+}
+
+func (p *Postgres) ShellCommand(connStr string) []string {
+	return []string{"psql", connStr}
+}
+
+// ShellEnv is empty: psql takes the password from the connection string.
+func (p *Postgres) ShellEnv(connStr string) []string {
+	return nil
+}
+
+// ResetDatabase resets a database to pristine condition by dropping and recreating it from the template
+func (p *Postgres) ResetDatabase(cfg *config.Config, connStr string, template string) error {
+	conn, err := ParseConnString(connStr, "5432")
+	if err != nil {
+		return err
+	}
+	host, port, dbname, user := conn.Host, conn.Port, conn.DBName, conn.User
+
+	log.Debug().Str("dbname", dbname).Str("port", port).Str("template", template).Msg("Resetting database")
+
+	// Build environment for psql commands
+	env := []string{
+		fmt.Sprintf("PGPASSWORD=%s", conn.Password),
+	}
+
+	// Connect to 'postgres' database to drop/create the target database
+	postgresConnStr := fmt.Sprintf("postgresql://%s@%s:%s/postgres", user, host, port)
+
+	// Step 1: Terminate any existing connections to the database
+	terminateConnections(postgresConnStr, dbname, env)
+
+	// Step 2: Drop the database if exists
+	dropSQL := fmt.Sprintf("DROP DATABASE IF EXISTS %s;", dbname)
+	if err := runPsql(postgresConnStr, dropSQL, env); err != nil {
+		return fmt.Errorf("failed to drop database: %w", err)
+	}
+
+	// Step 3: Create the database from the template
+	createSQL := fmt.Sprintf(
+		"CREATE DATABASE %s WITH ENCODING '%s' LC_COLLATE='%s' LC_CTYPE='%s' TEMPLATE=%s;",
+		dbname, cfg.Encoding, cfg.LCCollate, cfg.LCCtype, template,
+	)
+	if err := runPsql(postgresConnStr, createSQL, env); err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+
+	// Step 4: Set owner
+	alterOwnerSQL := fmt.Sprintf("ALTER DATABASE %s OWNER TO %s;", dbname, cfg.PGUsername)
+	if err := runPsql(postgresConnStr, alterOwnerSQL, env); err != nil {
+		return fmt.Errorf("failed to set database owner: %w", err)
+	}
+
+	// Step 5: Connect to the new database and set schema owner
+	newDbConnStr := fmt.Sprintf("postgresql://%s@%s:%s/%s", user, host, port, dbname)
+	alterSchemaSQL := fmt.Sprintf("ALTER SCHEMA public OWNER TO %s;", cfg.PGUsername)
+	if err := runPsql(newDbConnStr, alterSchemaSQL, env); err != nil {
+		return fmt.Errorf("failed to set schema owner: %w", err)
+	}
+
+	log.Debug().Str("dbname", dbname).Msg("Database reset complete")
+	return nil
+}
+
+// CreateTemplate copies the database with CREATE DATABASE ... TEMPLATE, which
+// requires that nobody else is connected to it, so the lock holder's
+// connections are terminated first.
+func (p *Postgres) CreateTemplate(cfg *config.Config, connStr string, template string) error {
+	conn, err := ParseConnString(connStr, "5432")
+	if err != nil {
+		return err
+	}
+	env := []string{fmt.Sprintf("PGPASSWORD=%s", conn.Password)}
+	terminateConnections(fmt.Sprintf("postgresql://%s@%s:%s/postgres", conn.User, conn.Host, conn.Port), conn.DBName, env)
+
+	templateConnStr, err := WithDatabase(connStr, template)
+	if err != nil {
+		return err
+	}
+	return p.ResetDatabase(cfg, templateConnStr, conn.DBName)
+}
+
+// CopyDatabase recreates the target from template0 and restores a pg_dump of
+// the source into it.
+func (p *Postgres) CopyDatabase(cfg *config.Config, fromConnStr, toConnStr string) error {
+	from, err := ParseConnString(fromConnStr, "5432")
+	if err != nil {
+		return err
+	}
+	to, err := ParseConnString(toConnStr, "5432")
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Str("from", from.Port+"/"+from.DBName).Str("to", to.Port+"/"+to.DBName).Msg("Copying database")
+
+	if err := p.CreateDatabase(cfg, toConnStr); err != nil {
+		return err
+	}
+
+	dump := exec.Command("pg_dump", "--no-owner", "--no-privileges", fromConnStr)
+	dump.Env = append(dump.Environ(), fmt.Sprintf("PGPASSWORD=%s", from.Password))
+	restore := exec.Command("psql", toConnStr, "--quiet", "-v", "ON_ERROR_STOP=1")
+	restore.Env = append(restore.Environ(), fmt.Sprintf("PGPASSWORD=%s", to.Password))
+	if err := pipe(dump, restore); err != nil {
+		return fmt.Errorf("failed to copy database: %w", err)
+	}
+	return nil
+}
+
+// CreateDatabase recreates the database from template0, which is always empty.
+func (p *Postgres) CreateDatabase(cfg *config.Config, connStr string) error {
+	return p.ResetDatabase(cfg, connStr, "template0")
+}
+
+// DumpCommand runs pg_dump over the container's Unix socket in the custom
+// format, without ownership so the dump restores into any pool.
+func (p *Postgres) DumpCommand(cfg *config.Config, port int, dbName string) []string {
+	return []string{
+		"pg_dump",
+		"-h", "/var/run/postgresql",
+		"-p", fmt.Sprintf("%d", port),
+		"-U", cfg.PGUsername,
+		"--format=custom", "--no-owner", "--no-privileges",
+		dbName,
+	}
+}
+
+// RestoreCommand runs pg_restore over the container's Unix socket.
+func (p *Postgres) RestoreCommand(cfg *config.Config, port int, dbName string) []string {
+	return []string{
+		"pg_restore",
+		"-h", "/var/run/postgresql",
+		"-p", fmt.Sprintf("%d", port),
+		"-U", cfg.PGUsername,
+		"--no-owner", "--no-privileges", "--exit-on-error",
+		"-d", dbName,
+	}
+}
+
+// terminateConnections closes all other connections to dbname. Errors are
+// only logged, there might be no connections.
+func terminateConnections(postgresConnStr, dbname string, env []string) {
+	terminateSQL := fmt.Sprintf(
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = '%s' AND pid <> pg_backend_pid();",
+		dbname,
+	)
+	if err := runPsql(postgresConnStr, terminateSQL, env); err != nil {
+		log.Debug().Err(err).Str("dbname", dbname).Msg("Failed to terminate connections (may be none)")
+	}
+}
+
+// postgresCatalogSQL lists user schema objects. Index definitions and column
+// defaults come from the server so that any change to them is visible.
+const postgresCatalogSQL = `
+SELECT 'table ' || table_schema || '.' || table_name || ' ' || lower(table_type)
+FROM information_schema.tables
+WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+UNION ALL
+SELECT 'column ' || table_schema || '.' || table_name || '.' || column_name || ' ' || data_type
+	|| CASE WHEN is_nullable = 'NO' THEN ' not null' ELSE '' END
+	|| COALESCE(' default ' || column_default, '')
+FROM information_schema.columns
+WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+UNION ALL
+SELECT 'index ' || schemaname || '.' || indexname || ' ' || indexdef
+FROM pg_indexes
+WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+UNION ALL
+SELECT 'function ' || n.nspname || '.' || p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ')'
+FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+ORDER BY 1;`
+
+// Catalog lists tables, views, columns, indexes and functions outside the system schemas
+func (p *Postgres) Catalog(cfg *config.Config, connStr string) ([]string, error) {
+	conn, err := ParseConnString(connStr, "5432")
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("psql", connStr, "--no-align", "--tuples-only", "-c", postgresCatalogSQL)
+	cmd.Env = append(cmd.Environ(), fmt.Sprintf("PGPASSWORD=%s", conn.Password))
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("psql error: %w, output: %s", err, string(output))
+	}
+	return splitLines(string(output)), nil
+}
+
+// queryPsql runs a single-value query via psql and returns its trimmed result
+func queryPsql(connStr, sql, password string) (string, error) {
+	cmd := exec.Command("psql", connStr, "--no-align", "--tuples-only", "-c", sql)
+	cmd.Env = append(cmd.Environ(), fmt.Sprintf("PGPASSWORD=%s", password))
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("psql error: %w, output: %s", err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// runPsql executes a SQL command via psql
+func runPsql(connStr, sql string, env []string) error {
+	cmd := exec.Command("psql", connStr, "-c", sql)
+	cmd.Env = append(cmd.Environ(), env...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("psql error: %w, output: %s", err, string(output))
+	}
+
+	return nil
+}
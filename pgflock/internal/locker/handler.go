@@ -14,10 +14,19 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/rickchristie/govner/pgflock/internal/config"
+	"github.com/rickchristie/govner/pgflock/internal/engine"
 )
 
 const serverVersion = "2"
 
+// ResetDurationHeader reports how long the database of a /lock response took
+// to reset, as a Go duration string. Used by 'pgflock bench'.
+const ResetDurationHeader = "X-PGFlock-Reset-Duration"
+
+// replicaHeader carries the connection string of the same database on the
+// instance's replica, when replicas are enabled.
+const replicaHeader = "X-PGFlock-Replica"
+
 // RestartRequest represents a restart request from HTTP API to TUI
 type RestartRequest struct {
 	ResponseChan chan error // TUI sends completion status here
@@ -36,10 +45,35 @@ type Handler struct {
 	stateUpdateChan       chan<- *State
 	waitingCount          atomic.Int32
 	restartRequestChan    chan RestartRequest
+	history               *history
+	templates             map[string]*Template // Promoted templates by name
+	templatesMu           sync.RWMutex
+	promoteMu             sync.Mutex // Serialises PromoteTemplate and RegisterTemplate
+	stateFile             string     // Where locks and templates are persisted, empty to disable
+	persistMu             sync.Mutex
+	persisted             []byte // Last state written to stateFile, without its SavedAt
+	reclaimGrace          time.Duration
+	handoverRequestChan   chan struct{}
+	handoverRequested     atomic.Bool
+	handingOver           atomic.Bool // Set by BeginHandover: closed connections keep their locks
 
 	// resetDatabase is the function used to reset a database before handing it to a client.
-	// Defaults to ResetDatabase. Overridable in tests to skip actual Postgres operations.
-	resetDatabase func(cfg *config.Config, connStr string) error
+	// Defaults to ResetDatabase. Overridable in tests to skip actual database operations.
+	resetDatabase func(cfg *config.Config, connStr string, template string) error
+
+	// createTemplate and copyDatabase promote a locked database to a template and
+	// copy templates between instances. Default to CreateTemplate and CopyDatabase.
+	// Overridable in tests.
+	createTemplate func(cfg *config.Config, connStr string, template string) error
+	copyDatabase   func(cfg *config.Config, fromConnStr, toConnStr string) error
+
+	// checkSchema compares a released database with its template when schema_check
+	// is enabled. Defaults to CheckSchema. Overridable in tests.
+	checkSchema func(cfg *config.Config, connStr, templateConnStr string) (*engine.SchemaDiff, error)
+
+	// waitForReplica waits for the replica to catch up after a reset when
+	// replicas are enabled. Defaults to WaitForReplica. Overridable in tests.
+	waitForReplica func(cfg *config.Config, connStr, replicaConnStr string) error
 }
 
 // NewHandler creates a new Handler instance
@@ -53,9 +87,7 @@ func NewHandlerWithCleanupInterval(cfg *config.Config, stateUpdateChan chan<- *S
 	testDatabases := make(map[string]bool)
 	for _, port := range cfg.InstancePorts() {
 		for i := 1; i <= cfg.DatabasesPerInstance; i++ {
-			connString := fmt.Sprintf("postgresql://%s:%s@localhost:%d/%s%d",
-				cfg.PGUsername, cfg.Password, port, cfg.DatabasePrefix, i)
-			testDatabases[connString] = true
+			testDatabases[cfg.ConnString(port, cfg.DatabaseName(i))] = true
 		}
 	}
 
@@ -69,6 +101,13 @@ func NewHandlerWithCleanupInterval(cfg *config.Config, stateUpdateChan chan<- *S
 		autoUnlockDuration:    time.Duration(cfg.AutoUnlockMins) * time.Minute,
 		stateUpdateChan:       stateUpdateChan,
 		resetDatabase:         ResetDatabase,
+		createTemplate:        CreateTemplate,
+		copyDatabase:          CopyDatabase,
+		checkSchema:           CheckSchema,
+		waitForReplica:        WaitForReplica,
+		history:               newHistory(cfg.TimelineWindow()),
+		templates:             make(map[string]*Template),
+		reclaimGrace:          defaultReclaimGrace,
 	}
 
 	// Initially all databases are available
@@ -114,6 +153,12 @@ func (h *Handler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 		h.handleRestart(resp, req)
 	case "/unlock-all":
 		h.handleUnlockAll(resp, req)
+	case "/promote":
+		h.handlePromote(resp, req)
+	case "/register-template":
+		h.handleRegisterTemplate(resp, req)
+	case "/handover":
+		h.handleHandover(resp, req)
 	default:
 		http.NotFound(resp, req)
 	}
@@ -141,6 +186,14 @@ func (h *Handler) validateAuth(req *http.Request) (string, bool) {
 // it (explicit unlock or process death), the server's request context is
 // cancelled, waking the handler which then releases the lock immediately.
 //
+// The optional template query parameter resets the database from a promoted
+// template (see PromoteTemplate) instead of test_template. The optional lease
+// query parameter reclaims a held lock instead (see handleReclaim).
+//
+// With replicas enabled, the lock is only handed out once the instance's
+// replica has replayed the reset, and the replica's connection string to the
+// same database is sent in the X-PGFlock-Replica header.
+//
 // WriteTimeout on the HTTP server enforces the auto-unlock duration: if the
 // connection remains open longer than auto_unlock_minutes, the server closes it,
 // cancelling the handler and releasing the lock.
@@ -151,6 +204,25 @@ func (h *Handler) handleLock(resp http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// The server is about to close; the next locker hands out locks
+	if h.handingOver.Load() {
+		http.Error(resp, "Locker is handing over, retry shortly", http.StatusServiceUnavailable)
+		return
+	}
+
+	if lease := req.URL.Query().Get("lease"); lease != "" {
+		h.handleReclaim(resp, req, lease)
+		return
+	}
+
+	template := req.URL.Query().Get("template")
+	if template != "" {
+		if _, exists := h.lookupTemplate(template); !exists {
+			http.Error(resp, fmt.Sprintf("Unknown template %q", template), http.StatusBadRequest)
+			return
+		}
+	}
+
 	h.waitingCount.Add(1)
 	h.sendStateUpdate()
 
@@ -160,77 +232,165 @@ func (h *Handler) handleLock(resp http.ResponseWriter, req *http.Request) {
 		h.waitingCount.Add(-1)
 		h.sendStateUpdate()
 
-		if err := h.resetDatabase(h.cfg, connStr); err != nil {
+		resetStart := time.Now()
+		err := h.prepareDatabase(connStr, template)
+		if replica := h.replicaConnString(connStr); err == nil && replica != "" {
+			// Readers of the replica must see the reset database, not the
+			// previous holder's data
+			err = h.waitForReplica(h.cfg, connStr, replica)
+		}
+		resetDuration := time.Since(resetStart)
+		h.history.observeReset(time.Now(), resetDuration)
+		if err != nil {
 			h.cLockedDbConn <- connStr
 			log.Error().Err(err).Str("connStr", connStr).Msg("Failed to reset database")
 			http.Error(resp, fmt.Sprintf("Failed to reset database: %v", err), http.StatusInternalServerError)
 			return
 		}
 
-		// lockCtx enforces auto-unlock: if the lock is held longer than
-		// autoUnlockDuration the context times out, waking the select below and
-		// releasing the lock. External callers (ForceUnlock, UnlockAll, handleUnlock)
-		// cancel the context early to release the lock on demand.
-		lockCtx, lockCancel := context.WithTimeout(context.Background(), h.autoUnlockDuration)
-
+		lockInfo := &LockInfo{
+			ConnString: connStr,
+			Marker:     marker,
+			LockedAt:   time.Now(),
+			Template:   template,
+			Lease:      newLease(),
+		}
+		lockCtx := h.attachLock(lockInfo)
+		var handingOver bool
 		h.withLocksLock(func() {
-			h.locks[connStr] = &LockInfo{
-				ConnString: connStr,
-				Marker:     marker,
-				LockedAt:   time.Now(),
-				cancel:     lockCancel,
+			// Checked again under locksMu: BeginHandover may have saved the
+			// final state while the database was reset
+			if handingOver = h.handingOver.Load(); !handingOver {
+				h.locks[connStr] = lockInfo
 			}
 		})
-
-		// Send version header and connection string. The connection stays open
-		// after this — the open connection is what holds the lock.
-		resp.Header().Set("X-PGFlock-Version", serverVersion)
-		resp.WriteHeader(http.StatusOK)
-		fmt.Fprintf(resp, "%s\n", connStr)
-		if f, ok := resp.(http.Flusher); ok {
-			f.Flush()
+		if handingOver {
+			lockInfo.cancel()
+			h.cLockedDbConn <- connStr
+			http.Error(resp, "Locker is handing over, retry shortly", http.StatusServiceUnavailable)
+			return
 		}
 
-		// Disable any server-level write deadline for this streaming connection.
-		// Auto-unlock is handled by lockCtx timeout above, not by connection I/O deadlines.
-		rc := http.NewResponseController(resp)
-		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
-			log.Debug().Err(err).Msg("Could not clear write deadline (non-fatal)")
-		}
+		resp.Header().Set(ResetDurationHeader, resetDuration.String())
+		log.Info().Str("connStr", connStr).Str("marker", marker).Str("template", template).Msg("LOCK")
+		h.streamLock(resp, req, lockInfo, lockCtx)
 
-		log.Info().Str("connStr", connStr).Str("marker", marker).Msg("LOCK")
+	case <-req.Context().Done():
+		h.waitingCount.Add(-1)
 		h.sendStateUpdate()
+		http.Error(resp, "Request cancelled or timed out", http.StatusRequestTimeout)
+		log.Warn().Str("marker", marker).Msg("Lock request cancelled or timed out")
+	}
+}
 
-		// Block until either the client disconnects or an external force-unlock
-		// cancels lockCtx.
-		select {
-		case <-req.Context().Done():
-		case <-lockCtx.Done():
+// handleReclaim reattaches a held lock to a new connection, for a client whose
+// lock connection dropped, e.g. because a new locker took over. The lock keeps
+// its marker, lock time and database, which is not reset again. Returns 410 if
+// the lease is unknown: the lock was released in the meantime.
+func (h *Handler) handleReclaim(resp http.ResponseWriter, req *http.Request, lease string) {
+	var replaced, reclaimed *LockInfo
+	var lockCtx context.Context
+	h.withLocksLock(func() {
+		for connStr, lockInfo := range h.locks {
+			if lockInfo.Lease != lease {
+				continue
+			}
+			replaced = lockInfo
+			reclaimed = &LockInfo{
+				ConnString: lockInfo.ConnString,
+				Marker:     lockInfo.Marker,
+				LockedAt:   lockInfo.LockedAt,
+				Template:   lockInfo.Template,
+				Lease:      lockInfo.Lease,
+			}
+			lockCtx = h.attachLock(reclaimed)
+			h.locks[connStr] = reclaimed
+			return
 		}
+	})
+	if reclaimed == nil {
+		http.Error(resp, "Lease expired or unknown", http.StatusGone)
+		return
+	}
 
-		// Release the lock if it hasn't already been released by the external caller
-		// (ForceUnlock/UnlockAll/handleUnlock remove it from the map before cancelling).
-		var released bool
-		h.withLocksLock(func() {
-			if _, exists := h.locks[connStr]; exists {
-				delete(h.locks, connStr)
-				released = true
-			}
-		})
+	// Wake the previous holder of the lock: a stale connection or the reclaim
+	// timer of a resumed lock. It sees the lock was replaced and leaves it alone.
+	if replaced.cancel != nil {
+		replaced.cancel()
+	}
 
-		if released {
-			h.cLockedDbConn <- connStr
-			log.Info().Str("connStr", connStr).Str("marker", marker).Msg("UNLOCK (connection closed)")
-			h.sendStateUpdate()
-		}
+	log.Info().Str("connStr", reclaimed.ConnString).Str("marker", reclaimed.Marker).Msg("RECLAIM")
+	h.streamLock(resp, req, reclaimed, lockCtx)
+}
+
+// attachLock sets the cancel func of a lock about to be streamed and returns
+// the context that ends it. The context enforces auto-unlock: if the lock is
+// held longer than autoUnlockDuration it times out, waking streamLock and
+// releasing the lock. External callers (ForceUnlock, UnlockAll, handleUnlock)
+// cancel it early to release the lock on demand.
+func (h *Handler) attachLock(lockInfo *LockInfo) context.Context {
+	lockCtx, lockCancel := context.WithDeadline(context.Background(), lockInfo.LockedAt.Add(h.autoUnlockDuration))
+	lockInfo.cancel = lockCancel
+	return lockCtx
+}
+
+// streamLock sends the connection string of a lock that is in h.locks and
+// blocks until the client disconnects or lockCtx ends, then releases the lock
+// unless it was released or reclaimed elsewhere in the meantime.
+func (h *Handler) streamLock(resp http.ResponseWriter, req *http.Request, lockInfo *LockInfo, lockCtx context.Context) {
+	connStr := lockInfo.ConnString
+
+	// Send version header and connection string. The connection stays open
+	// after this — the open connection is what holds the lock.
+	resp.Header().Set("X-PGFlock-Version", serverVersion)
+	resp.Header().Set(leaseHeader, lockInfo.Lease)
+	if replica := h.replicaConnString(connStr); replica != "" {
+		resp.Header().Set(replicaHeader, replica)
+	}
+	resp.WriteHeader(http.StatusOK)
+	fmt.Fprintf(resp, "%s\n", connStr)
+	if f, ok := resp.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	// Disable any server-level write deadline for this streaming connection.
+	// Auto-unlock is handled by lockCtx timeout above, not by connection I/O deadlines.
+	rc := http.NewResponseController(resp)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Debug().Err(err).Msg("Could not clear write deadline (non-fatal)")
+	}
 
-		lockCancel() // always clean up the context
+	h.sendStateUpdate()
 
+	// Block until either the client disconnects or an external force-unlock
+	// cancels lockCtx.
+	select {
 	case <-req.Context().Done():
-		h.waitingCount.Add(-1)
+	case <-lockCtx.Done():
+	}
+	defer lockInfo.cancel() // always clean up the context
+
+	// During a handover the server closes every connection; the locks stay
+	// held for their clients to reclaim from the next locker.
+	if h.handingOver.Load() {
+		return
+	}
+
+	// Release the lock if it hasn't already been released by the external caller
+	// (ForceUnlock/UnlockAll/handleUnlock remove it from the map before cancelling)
+	// or reclaimed on another connection.
+	var released bool
+	h.withLocksLock(func() {
+		if h.locks[connStr] == lockInfo {
+			delete(h.locks, connStr)
+			released = true
+		}
+	})
+
+	if released {
+		h.returnToPool(lockInfo)
+		log.Info().Str("connStr", connStr).Str("marker", lockInfo.Marker).Msg("UNLOCK (connection closed)")
 		h.sendStateUpdate()
-		http.Error(resp, "Request cancelled or timed out", http.StatusRequestTimeout)
-		log.Warn().Str("marker", marker).Msg("Lock request cancelled or timed out")
 	}
 }
 
@@ -277,9 +437,9 @@ func (h *Handler) handleUnlock(resp http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// Return to pool before cancelling so the streaming handler sees released=false
+	// Return to pool before cancelling so the streaming handler sees released=nil
 	// and skips its own pool return, avoiding a double-send.
-	h.cLockedDbConn <- connStr
+	h.returnToPool(lockInfo)
 
 	// Wake the streaming handler (if any) so it exits cleanly.
 	if lockInfo.cancel != nil {
@@ -307,6 +467,8 @@ func (h *Handler) handleHealthCheck(resp http.ResponseWriter, req *http.Request)
 				Marker:          lockInfo.Marker,
 				LockedAt:        lockInfo.LockedAt.Format(time.RFC3339),
 				DurationSeconds: int64(now.Sub(lockInfo.LockedAt).Seconds()),
+				Template:        lockInfo.Template,
+				Replica:         h.replicaConnString(lockInfo.ConnString),
 			})
 		}
 	})
@@ -324,6 +486,8 @@ func (h *Handler) handleHealthCheck(resp http.ResponseWriter, req *http.Request)
 		WaitingRequests:   int(h.waitingCount.Load()),
 		AutoUnlockMinutes: h.cfg.AutoUnlockMins,
 		Locks:             locks,
+		SchemaLeaks:       schemaLeaksJSON(h.history.schemaLeaks()),
+		Templates:         templatesJSON(h.listTemplates()),
 	}
 
 	resp.Header().Set("Content-Type", "application/json")
@@ -401,21 +565,21 @@ func (h *Handler) cleanupExpiredLocks() {
 
 	for range ticker.C {
 		now := time.Now()
-		var unlocked []string
+		var unlocked []*LockInfo
 
 		h.withLocksLock(func() {
 			for connStr, lockInfo := range h.locks {
 				if lockInfo.cancel == nil && now.Sub(lockInfo.LockedAt) > h.autoUnlockDuration {
 					delete(h.locks, connStr)
-					unlocked = append(unlocked, connStr)
+					unlocked = append(unlocked, lockInfo)
 					log.Info().Str("connStr", connStr).Str("marker", lockInfo.Marker).
 						Dur("duration", h.autoUnlockDuration).Msg("AUTO-UNLOCK (safety-net)")
 				}
 			}
 		})
 
-		for _, connStr := range unlocked {
-			h.cLockedDbConn <- connStr
+		for _, lockInfo := range unlocked {
+			h.returnToPool(lockInfo)
 		}
 
 		if len(unlocked) > 0 {
@@ -424,14 +588,17 @@ func (h *Handler) cleanupExpiredLocks() {
 	}
 }
 
-// sendStateUpdate sends the current state to the TUI
+// sendStateUpdate records the current counts in the history, persists the
+// state file and sends the current state to the TUI
 func (h *Handler) sendStateUpdate() {
+	state := h.GetState()
+	h.history.observe(time.Now(), state.LockedDatabases, state.WaitingRequests)
+	h.persist()
+
 	if h.stateUpdateChan == nil {
 		return
 	}
 
-	state := h.GetState()
-
 	// Non-blocking send
 	select {
 	case h.stateUpdateChan <- state:
@@ -460,15 +627,51 @@ func (h *Handler) GetState() *State {
 		FreeDatabases:   len(h.testDatabases) - len(locks),
 		WaitingRequests: int(h.waitingCount.Load()),
 		Locks:           locks,
+		SchemaLeaks:     h.history.schemaLeaks(),
+		Templates:       h.listTemplates(),
+	}
+}
+
+// Timeline returns recent lock spans and activity samples for the TUI timeline view
+func (h *Handler) Timeline() *Timeline {
+	return h.history.snapshot(time.Now(), h.GetState().Locks, len(h.testDatabases))
+}
+
+// recordRelease adds a lock that has just been released to the history
+func (h *Handler) recordRelease(lockInfo *LockInfo) LockSpan {
+	span := LockSpan{
+		ConnString: lockInfo.ConnString,
+		Marker:     lockInfo.Marker,
+		Start:      lockInfo.LockedAt,
+		End:        time.Now(),
 	}
+	h.history.recordSpan(span)
+	return span
 }
 
-// cancelAndRelease removes the lock from the map, returns the database to the pool,
-// and cancels the streaming handler. It is the shared implementation for all
-// force-release operations (ForceUnlock, UnlockByMarker, UnlockAll).
+// returnToPool records a released lock in the history and makes its database
+// available again. With schema_check enabled, the database is held back until
+// its schema has been compared with the template, because the next lock would
+// reset it and erase whatever the previous holder left behind.
 // Must NOT be called with locksMu held.
-func (h *Handler) cancelAndRelease(connStr string, lockInfo *LockInfo) {
-	h.cLockedDbConn <- connStr
+func (h *Handler) returnToPool(lockInfo *LockInfo) {
+	span := h.recordRelease(lockInfo)
+	if !h.cfg.SchemaCheck {
+		h.cLockedDbConn <- lockInfo.ConnString
+		return
+	}
+
+	go func() {
+		h.runSchemaCheck(span, lockInfo.Template)
+		h.cLockedDbConn <- lockInfo.ConnString
+	}()
+}
+
+// cancelAndRelease returns the database to the pool and cancels the streaming
+// handler. It is the shared implementation for the force-release operations
+// (ForceUnlock, UnlockByMarker). Must NOT be called with locksMu held.
+func (h *Handler) cancelAndRelease(lockInfo *LockInfo) {
+	h.returnToPool(lockInfo)
 	if lockInfo.cancel != nil {
 		lockInfo.cancel()
 	}
@@ -491,7 +694,7 @@ func (h *Handler) ForceUnlock(connStr string) bool {
 		return false
 	}
 
-	h.cancelAndRelease(connStr, lockInfo)
+	h.cancelAndRelease(lockInfo)
 	log.Info().Str("connStr", connStr).Msg("TUI FORCE-UNLOCK")
 	h.sendStateUpdate()
 	return true
@@ -509,8 +712,8 @@ func (h *Handler) UnlockByMarker(marker string) int {
 		}
 	})
 
-	for connStr, lockInfo := range released {
-		h.cancelAndRelease(connStr, lockInfo)
+	for _, lockInfo := range released {
+		h.cancelAndRelease(lockInfo)
 	}
 
 	if len(released) > 0 {
@@ -531,8 +734,14 @@ func (h *Handler) UnlockAll() int {
 		h.locks = make(map[string]*LockInfo)
 	})
 
+	// Skip the schema check: UnlockAll precedes a restart, which recreates every
+	// database anyway and would stop the containers mid-check.
 	for connStr, lockInfo := range released {
-		h.cancelAndRelease(connStr, lockInfo)
+		h.recordRelease(lockInfo)
+		h.cLockedDbConn <- connStr
+		if lockInfo.cancel != nil {
+			lockInfo.cancel()
+		}
 	}
 
 	if len(released) > 0 {
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/rickchristie/govner/pgflock/internal/config"
+	"github.com/rickchristie/govner/pgflock/internal/engine"
 )
 
 const defaultDatabaseCount = 25
@@ -63,7 +64,16 @@ func newTestHandlerWithCleanupInterval(cleanupInterval time.Duration) *Handler {
 		cleanupTickerInterval: cleanupInterval,
 		autoUnlockDuration:    time.Duration(cfg.AutoUnlockMins) * time.Minute,
 		stateUpdateChan:       nil,
-		resetDatabase:         func(_ *config.Config, _ string) error { return nil },
+		resetDatabase:         func(_ *config.Config, _ string, _ string) error { return nil },
+		createTemplate:        func(_ *config.Config, _ string, _ string) error { return nil },
+		copyDatabase:          func(_ *config.Config, _, _ string) error { return nil },
+		checkSchema: func(_ *config.Config, _, _ string) (*engine.SchemaDiff, error) {
+			return &engine.SchemaDiff{}, nil
+		},
+		waitForReplica: func(_ *config.Config, _, _ string) error { return nil },
+		history:        newHistory(cfg.TimelineWindow()),
+		templates:      make(map[string]*Template),
+		reclaimGrace:   defaultReclaimGrace,
 	}
 
 	for connStr := range testDatabases {
@@ -273,6 +283,80 @@ func TestVersionHeader(t *testing.T) {
 	}
 }
 
+func TestResetDurationHeader(t *testing.T) {
+	_, server := newStreamingTestServer(t)
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get(fmt.Sprintf("%s/lock?marker=bench&password=%s", server.URL, testPassword))
+	if err != nil {
+		t.Fatalf("lock request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	header := resp.Header.Get(ResetDurationHeader)
+	if _, err := time.ParseDuration(header); err != nil {
+		t.Errorf("Expected %s to hold a duration, got %q", ResetDurationHeader, header)
+	}
+}
+
+func TestReplicaHeader_WaitsForReplica(t *testing.T) {
+	h, server := newStreamingTestServer(t)
+	h.cfg.Replicas = true
+
+	var waited []string
+	var waitedMu sync.Mutex
+	h.waitForReplica = func(_ *config.Config, connStr, replicaConnStr string) error {
+		waitedMu.Lock()
+		defer waitedMu.Unlock()
+		waited = append(waited, connStr+" -> "+replicaConnStr)
+		return nil
+	}
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get(fmt.Sprintf("%s/lock?marker=replica&password=%s", server.URL, testPassword))
+	if err != nil {
+		t.Fatalf("lock request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	buf := make([]byte, 256)
+	n, err := resp.Body.Read(buf)
+	if err != nil && err != io.EOF {
+		t.Fatalf("failed to read conn string: %v", err)
+	}
+	connStr := strings.TrimSpace(string(buf[:n]))
+	dbName := connStr[strings.LastIndex(connStr, "/")+1:]
+	wantReplica := fmt.Sprintf("postgresql://tester:%s@localhost:5433/%s", testPassword, dbName)
+	if got := resp.Header.Get(replicaHeader); got != wantReplica {
+		t.Errorf("Expected %s: %s, got %q", replicaHeader, wantReplica, got)
+	}
+
+	waitedMu.Lock()
+	defer waitedMu.Unlock()
+	if len(waited) != 1 || waited[0] != connStr+" -> "+wantReplica {
+		t.Errorf("Expected one wait for %s's replica, got %v", connStr, waited)
+	}
+}
+
+func TestReplicaHeader_ReplicaBehindReturnsDatabase(t *testing.T) {
+	h := newTestHandler()
+	h.cfg.Replicas = true
+	h.waitForReplica = func(_ *config.Config, _, _ string) error {
+		return fmt.Errorf("replica did not catch up")
+	}
+
+	req := httptest.NewRequest("GET", "/lock?marker=replica&password="+testPassword, nil)
+	rr := httptest.NewRecorder()
+	h.handleLock(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", rr.Code)
+	}
+	if len(h.cLockedDbConn) != defaultDatabaseCount {
+		t.Errorf("Expected database back in the pool, %d of %d free", len(h.cLockedDbConn), defaultDatabaseCount)
+	}
+}
+
 func TestAutoUnlockSafetyNet(t *testing.T) {
 	// Safety-net cleanup only triggers for locks with cancel=nil.
 	h := newTestHandlerWithCleanupInterval(100 * time.Millisecond)
@@ -0,0 +1,303 @@
+package locker
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// StateFileName is the file in the .pgflock directory where the locker keeps
+// its held locks and templates, so a new locker can take over from it.
+const StateFileName = "locks.json"
+
+// leaseHeader carries the lease token of a /lock response. A client whose lock
+// connection drops reclaims the lock with /lock?lease=<token>.
+const leaseHeader = "X-PGFlock-Lease"
+
+// defaultReclaimGrace is how long a lock restored by Resume waits for its holder
+// to reconnect before the database is returned to the pool.
+const defaultReclaimGrace = 2 * time.Minute
+
+// SavedState is the locker state written to the state file.
+type SavedState struct {
+	SavedAt   time.Time      `json:"saved_at"`
+	Locks     []SavedLock    `json:"locks"`
+	Templates []TemplateJSON `json:"templates,omitempty"`
+}
+
+// SavedLock is a held lock in the state file.
+type SavedLock struct {
+	ConnString string    `json:"conn_string"`
+	Marker     string    `json:"marker"`
+	LockedAt   time.Time `json:"locked_at"`
+	Template   string    `json:"template,omitempty"`
+	Lease      string    `json:"lease"`
+}
+
+// LoadState reads the state file written by a previous locker. Returns nil
+// without error when there is none.
+func LoadState(path string) (*SavedState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved SavedState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return &saved, nil
+}
+
+// RemoveState deletes the state file, e.g. after a shutdown that stopped the
+// containers and with them every held database.
+func RemoveState(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// PersistTo makes the handler write its locks and templates to path on every
+// change. Call before the server starts.
+func (h *Handler) PersistTo(path string) {
+	h.stateFile = path
+	h.persist()
+}
+
+// persist writes the current state to the state file if the locks or
+// templates changed since it was last written. Stops at BeginHandover, which
+// writes the final state.
+func (h *Handler) persist() {
+	if h.stateFile == "" || h.handingOver.Load() {
+		return
+	}
+
+	// Snapshot under persistMu so an older snapshot never overwrites a newer one
+	h.persistMu.Lock()
+	defer h.persistMu.Unlock()
+
+	var locks []SavedLock
+	h.withLocksRLock(func() {
+		locks = h.savedLocks()
+	})
+	h.writeState(locks)
+}
+
+// savedLocks returns the held locks, oldest first. Call with locksMu held.
+func (h *Handler) savedLocks() []SavedLock {
+	locks := []SavedLock{}
+	for _, lock := range h.locks {
+		locks = append(locks, SavedLock{
+			ConnString: lock.ConnString,
+			Marker:     lock.Marker,
+			LockedAt:   lock.LockedAt,
+			Template:   lock.Template,
+			Lease:      lock.Lease,
+		})
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].LockedAt.Before(locks[j].LockedAt)
+	})
+	return locks
+}
+
+// writeState replaces the state file atomically, unless it already holds
+// these locks and the current templates. Call with persistMu held.
+func (h *Handler) writeState(locks []SavedLock) {
+	saved := SavedState{
+		Locks:     locks,
+		Templates: templatesJSON(h.listTemplates()),
+	}
+
+	// Compared without SavedAt, which differs on every call
+	unchanged, err := json.Marshal(saved)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode locker state")
+		return
+	}
+	if bytes.Equal(unchanged, h.persisted) {
+		return
+	}
+
+	saved.SavedAt = time.Now()
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode locker state")
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(h.stateFile), StateFileName+".*")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to write locker state")
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), h.stateFile)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Error().Err(err).Msg("Failed to write locker state")
+		return
+	}
+	h.persisted = unchanged
+}
+
+// Resume takes over the locks and templates of a previous locker whose
+// containers are still running. Each restored lock is held until its client
+// reclaims it with its lease, or returned to the pool after the reclaim grace
+// period. Call before the server starts.
+func (h *Handler) Resume(saved *SavedState) {
+	for _, tmpl := range saved.Templates {
+		createdAt, _ := time.Parse(time.RFC3339, tmpl.CreatedAt)
+		h.templates[tmpl.Name] = &Template{
+			Name:             tmpl.Name,
+			SourceConnString: tmpl.SourceConnString,
+			Marker:           tmpl.Marker,
+			CreatedAt:        createdAt,
+			Ports:            tmpl.Ports,
+		}
+	}
+
+	restored := make(map[string]bool)
+	for _, saved := range saved.Locks {
+		if !h.testDatabases[saved.ConnString] || saved.Lease == "" {
+			continue
+		}
+		reclaimCtx, reclaimCancel := context.WithTimeout(context.Background(), h.reclaimGrace)
+		lockInfo := &LockInfo{
+			ConnString: saved.ConnString,
+			Marker:     saved.Marker,
+			LockedAt:   saved.LockedAt,
+			Template:   saved.Template,
+			Lease:      saved.Lease,
+			cancel:     reclaimCancel,
+		}
+		h.withLocksLock(func() {
+			h.locks[saved.ConnString] = lockInfo
+		})
+		restored[saved.ConnString] = true
+		go h.awaitReclaim(reclaimCtx, lockInfo)
+	}
+
+	// Take the restored databases out of the pool
+	free := make([]string, 0, len(h.cLockedDbConn))
+	for len(h.cLockedDbConn) > 0 {
+		if connStr := <-h.cLockedDbConn; !restored[connStr] {
+			free = append(free, connStr)
+		}
+	}
+	for _, connStr := range free {
+		h.cLockedDbConn <- connStr
+	}
+
+	log.Info().Int("locks", len(restored)).Int("templates", len(saved.Templates)).Msg("RESUME")
+	h.sendStateUpdate()
+}
+
+// awaitReclaim returns a restored lock to the pool if its holder does not
+// reconnect in time. A reclaim or force-unlock replaces or removes the lock
+// and cancels ctx first.
+func (h *Handler) awaitReclaim(ctx context.Context, lockInfo *LockInfo) {
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return
+	}
+
+	var released bool
+	h.withLocksLock(func() {
+		if h.locks[lockInfo.ConnString] == lockInfo {
+			delete(h.locks, lockInfo.ConnString)
+			released = true
+		}
+	})
+	if released {
+		h.returnToPool(lockInfo)
+		log.Info().Str("connStr", lockInfo.ConnString).Str("marker", lockInfo.Marker).Msg("UNLOCK (lease not reclaimed)")
+		h.sendStateUpdate()
+	}
+}
+
+// BeginHandover stops handing out and releasing locks, so shutting the server
+// down hands every held lock over instead of returning it to the pool, then
+// saves the final state for the next locker.
+func (h *Handler) BeginHandover() {
+	h.handingOver.Store(true)
+
+	// Holding locksMu, no lock is added or released while the final state is
+	// written. Locks added after it are refused by the handingOver check.
+	if h.stateFile != "" {
+		h.persistMu.Lock()
+		h.withLocksLock(func() {
+			h.writeState(h.savedLocks())
+		})
+		h.persistMu.Unlock()
+	}
+	log.Info().Msg("HANDOVER")
+}
+
+// SetHandoverRequestChan sets the channel for sending handover requests to TUI
+func (h *Handler) SetHandoverRequestChan(ch chan struct{}) {
+	h.handoverRequestChan = ch
+}
+
+// handleHandover is called by a new 'pgflock up' to take over this locker.
+// The response is sent before the TUI is told, because the TUI closes the
+// server, then exits without stopping the containers.
+func (h *Handler) handleHandover(resp http.ResponseWriter, req *http.Request) {
+	_, valid := h.validateAuth(req)
+	if !valid {
+		http.Error(resp, "Invalid marker or password", http.StatusUnauthorized)
+		return
+	}
+
+	if req.Method != "POST" {
+		http.Error(resp, "Method not allowed, use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.stateFile == "" || h.handoverRequestChan == nil {
+		http.Error(resp, "Handover not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if !h.handoverRequested.CompareAndSwap(false, true) {
+		http.Error(resp, "Handover already in progress", http.StatusConflict)
+		return
+	}
+
+	log.Info().Msg("HANDOVER requested via HTTP API")
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	fmt.Fprintf(resp, `{"status":"ok","message":"Handing over"}`)
+	if f, ok := resp.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	go func() { h.handoverRequestChan <- struct{}{} }()
+}
+
+// newLease returns a random lease token
+func newLease() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
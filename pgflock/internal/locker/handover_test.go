@@ -0,0 +1,259 @@
+package locker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+)
+
+// lockWithLease acquires a streaming lock and returns its connection string,
+// lease and open body.
+func lockWithLease(t *testing.T, reqURL string) (connStr, lease string, body io.ReadCloser) {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get(reqURL)
+	if err != nil {
+		t.Fatalf("lock request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		t.Fatalf("lock returned status %d: %s", resp.StatusCode, msg)
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		resp.Body.Close()
+		t.Fatalf("failed to read conn string: %v", err)
+	}
+	return strings.TrimSpace(line), resp.Header.Get(leaseHeader), resp.Body
+}
+
+func TestHandover_LocksSurviveNewLocker(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), StateFileName)
+
+	old := newTestHandler()
+	old.PersistTo(stateFile)
+	oldServer := httptest.NewServer(old)
+	defer oldServer.Close()
+
+	connStr, lease, body := lockWithLease(t, fmt.Sprintf("%s/lock?marker=importer&password=%s", oldServer.URL, testPassword))
+	defer body.Close()
+	if lease == "" {
+		t.Fatal("Expected a lease header on the lock response")
+	}
+
+	// The old locker goes away; its connections close without releasing
+	old.BeginHandover()
+	oldServer.CloseClientConnections()
+	time.Sleep(50 * time.Millisecond)
+	if n := old.GetState().LockedDatabases; n != 1 {
+		t.Errorf("Expected old locker to keep its lock during handover, got %d locked", n)
+	}
+
+	saved, err := LoadState(stateFile)
+	if err != nil || saved == nil {
+		t.Fatalf("LoadState = %v, %v", saved, err)
+	}
+	if len(saved.Locks) != 1 || saved.Locks[0].Lease != lease {
+		t.Fatalf("Unexpected saved locks: %+v", saved.Locks)
+	}
+
+	h := newTestHandler()
+	var resets atomic.Int32
+	h.resetDatabase = func(_ *config.Config, _ string, _ string) error {
+		resets.Add(1)
+		return nil
+	}
+	h.Resume(saved)
+	if n := len(h.cLockedDbConn); n != defaultDatabaseCount-1 {
+		t.Errorf("Expected resumed database out of the pool (%d free), got %d", defaultDatabaseCount-1, n)
+	}
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	reclaimed, _, reclaimBody := lockWithLease(t, fmt.Sprintf("%s/lock?marker=importer&password=%s&lease=%s", server.URL, testPassword, lease))
+	if reclaimed != connStr {
+		t.Errorf("Reclaimed %q, want %q", reclaimed, connStr)
+	}
+	if n := resets.Load(); n != 0 {
+		t.Errorf("Expected no reset on reclaim, got %d", n)
+	}
+	locks := h.GetState().Locks
+	if len(locks) != 1 || locks[0].Marker != "importer" {
+		t.Errorf("Expected the lock to stay with importer, got %+v", locks)
+	}
+
+	reclaimBody.Close()
+	if err := Await(2*time.Second, func() bool { return len(h.cLockedDbConn) == defaultDatabaseCount }); err != nil {
+		t.Errorf("Reclaimed lock not released on close: %v", err)
+	}
+}
+
+func TestHandover_RejectsLocks(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), StateFileName)
+	h, server := newStreamingTestServer(t)
+	h.PersistTo(stateFile)
+
+	_, lease, body := lockWithLease(t, fmt.Sprintf("%s/lock?marker=importer&password=%s", server.URL, testPassword))
+	defer body.Close()
+
+	h.BeginHandover()
+	for _, query := range []string{"", "&lease=" + lease} {
+		resp, err := http.Get(fmt.Sprintf("%s/lock?marker=late&password=%s%s", server.URL, testPassword, query))
+		if err != nil {
+			t.Fatalf("Lock request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503 for /lock%s during handover, got %d", query, resp.StatusCode)
+		}
+	}
+	if n := len(h.cLockedDbConn); n != defaultDatabaseCount-1 {
+		t.Errorf("Expected pool untouched (%d free), got %d", defaultDatabaseCount-1, n)
+	}
+
+	saved, err := LoadState(stateFile)
+	if err != nil || saved == nil {
+		t.Fatalf("LoadState = %v, %v", saved, err)
+	}
+	if len(saved.Locks) != 1 || saved.Locks[0].Lease != lease {
+		t.Errorf("Unexpected saved locks: %+v", saved.Locks)
+	}
+}
+
+func TestHandover_RefusesLockResetDuringHandover(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), StateFileName)
+	h, server := newStreamingTestServer(t)
+	h.PersistTo(stateFile)
+
+	resetting := make(chan struct{})
+	proceed := make(chan struct{})
+	h.resetDatabase = func(*config.Config, string, string) error {
+		close(resetting)
+		<-proceed
+		return nil
+	}
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("%s/lock?marker=slow&password=%s", server.URL, testPassword))
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	// The handover saves its final state while the database is reset
+	<-resetting
+	h.BeginHandover()
+	close(proceed)
+
+	if code := <-status; code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for a lock finished during handover, got %d", code)
+	}
+	if n := len(h.cLockedDbConn); n != defaultDatabaseCount {
+		t.Errorf("Expected database returned to the pool (%d free), got %d", defaultDatabaseCount, n)
+	}
+	saved, err := LoadState(stateFile)
+	if err != nil || saved == nil {
+		t.Fatalf("LoadState = %v, %v", saved, err)
+	}
+	if len(saved.Locks) != 0 || h.GetState().LockedDatabases != 0 {
+		t.Errorf("Expected no lock saved or held, got %+v", saved.Locks)
+	}
+}
+
+func TestPersist_OnlyWhenLocksChange(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), StateFileName)
+	h := newTestHandler()
+	h.PersistTo(stateFile)
+
+	savedAt := func() time.Time {
+		saved, err := LoadState(stateFile)
+		if err != nil || saved == nil {
+			t.Fatalf("LoadState = %v, %v", saved, err)
+		}
+		return saved.SavedAt
+	}
+	first := savedAt()
+
+	// Waiting counts and history changes are not saved
+	time.Sleep(10 * time.Millisecond)
+	h.waitingCount.Add(1)
+	h.sendStateUpdate()
+	if !savedAt().Equal(first) {
+		t.Error("Expected state file untouched when locks are unchanged")
+	}
+
+	connStr := h.cfg.ConnString(5432, "tester1")
+	h.withLocksLock(func() {
+		h.locks[connStr] = &LockInfo{ConnString: connStr, Marker: "importer", LockedAt: time.Now(), Lease: "abc"}
+	})
+	h.sendStateUpdate()
+	if savedAt().Equal(first) {
+		t.Error("Expected state file rewritten after a lock")
+	}
+}
+
+func TestResume_UnreclaimedLockReturnsToPool(t *testing.T) {
+	h := newTestHandler()
+	h.reclaimGrace = 50 * time.Millisecond
+
+	connStr := h.cfg.ConnString(5432, "tester3")
+	h.Resume(&SavedState{
+		Locks: []SavedLock{
+			{ConnString: connStr, Marker: "gone", LockedAt: time.Now(), Lease: "abc"},
+			{ConnString: h.cfg.ConnString(9999, "tester1"), Marker: "stale", LockedAt: time.Now(), Lease: "def"},
+		},
+		Templates: []TemplateJSON{{Name: "seeded", SourceConnString: connStr, Ports: []int{5432}}},
+	})
+
+	if n := h.GetState().LockedDatabases; n != 1 {
+		t.Errorf("Expected only the known database restored, got %d locked", n)
+	}
+	if _, exists := h.lookupTemplate("seeded"); !exists {
+		t.Error("Expected template restored")
+	}
+	if err := Await(2*time.Second, func() bool { return len(h.cLockedDbConn) == defaultDatabaseCount }); err != nil {
+		t.Errorf("Unreclaimed lock not returned to the pool: %v", err)
+	}
+}
+
+func TestReclaim_UnknownLease(t *testing.T) {
+	h := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/lock?marker=test&password=%s&lease=nope", testPassword), nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusGone {
+		t.Errorf("Expected 410 for unknown lease, got %d", rec.Code)
+	}
+}
+
+func TestReclaim_ReplacesStaleConnection(t *testing.T) {
+	h, server := newStreamingTestServer(t)
+
+	connStr, lease, stale := lockWithLease(t, fmt.Sprintf("%s/lock?marker=worker&password=%s", server.URL, testPassword))
+	_, _, fresh := lockWithLease(t, fmt.Sprintf("%s/lock?marker=worker&password=%s&lease=%s", server.URL, testPassword, lease))
+	defer fresh.Close()
+
+	// Closing the stale connection must not release the reclaimed lock
+	stale.Close()
+	time.Sleep(50 * time.Millisecond)
+	locks := h.GetState().Locks
+	if len(locks) != 1 || locks[0].ConnString != connStr {
+		t.Errorf("Expected %s still locked, got %+v", connStr, locks)
+	}
+}
@@ -0,0 +1,208 @@
+package locker
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// sampleInterval is the resolution of the utilisation/queue/reset samples.
+const sampleInterval = time.Second
+
+// LockSpan is one lock of a database, from acquisition to release.
+// End is zero while the lock is still held.
+type LockSpan struct {
+	ConnString string
+	Marker     string
+	Start      time.Time
+	End        time.Time
+	SchemaLeak bool // Released with a schema different from the template
+}
+
+// Sample aggregates locker activity over one sampleInterval.
+type Sample struct {
+	At           time.Time     // Start of the interval
+	Locked       int           // Most databases locked at once during the interval
+	Waiting      int           // Most requests waiting at once during the interval
+	Resets       int           // Number of database resets that finished during the interval
+	ResetLatency time.Duration // Total time spent in those resets
+}
+
+// Timeline is a snapshot of recent lock activity for the TUI timeline view.
+type Timeline struct {
+	Start          time.Time
+	End            time.Time
+	TotalDatabases int
+	Spans          []LockSpan // Sorted by Start; includes locks still held
+	Samples        []Sample   // One per sampleInterval, oldest first
+}
+
+// maxSchemaLeaks caps how many schema leaks are kept. Leaks are rare and worth
+// seeing after the fact, so unlike spans they are not pruned by age.
+const maxSchemaLeaks = 100
+
+// history records finished lock spans and per-interval samples, discarding
+// anything older than its window.
+type history struct {
+	mu      sync.Mutex
+	window  time.Duration
+	spans   []LockSpan
+	samples []bucket
+	leaks   []SchemaLeak
+}
+
+// bucket is a Sample plus the counts left standing at the end of it, which
+// become the starting counts of the next interval.
+type bucket struct {
+	Sample
+	carryLocked  int
+	carryWaiting int
+}
+
+func newHistory(window time.Duration) *history {
+	return &history{window: window}
+}
+
+// recordSpan stores a released lock.
+func (hi *history) recordSpan(span LockSpan) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.spans = append(hi.spans, span)
+	hi.prune(span.End)
+}
+
+// recordLeak stores a schema leak and flags the span it was found on.
+func (hi *history) recordLeak(leak SchemaLeak) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.leaks = append(hi.leaks, leak)
+	if len(hi.leaks) > maxSchemaLeaks {
+		hi.leaks = hi.leaks[len(hi.leaks)-maxSchemaLeaks:]
+	}
+	for i := range hi.spans {
+		if hi.spans[i].ConnString == leak.ConnString && hi.spans[i].End.Equal(leak.ReleasedAt) {
+			hi.spans[i].SchemaLeak = true
+		}
+	}
+}
+
+// schemaLeaks returns the recorded schema leaks, most recent first.
+func (hi *history) schemaLeaks() []SchemaLeak {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	leaks := make([]SchemaLeak, len(hi.leaks))
+	for i, leak := range hi.leaks {
+		leaks[len(hi.leaks)-1-i] = leak
+	}
+	return leaks
+}
+
+// observe records the current locked and waiting counts.
+func (hi *history) observe(now time.Time, locked, waiting int) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	s := hi.advance(now)
+	s.Locked = max(s.Locked, locked)
+	s.Waiting = max(s.Waiting, waiting)
+	// Counts after this event carry forward into the following intervals.
+	s.carryLocked, s.carryWaiting = locked, waiting
+}
+
+// observeReset records how long a database reset took.
+func (hi *history) observeReset(now time.Time, d time.Duration) {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	s := hi.advance(now)
+	s.Resets++
+	s.ResetLatency += d
+}
+
+// snapshot returns the history up to now, adding held locks as open spans.
+func (hi *history) snapshot(now time.Time, held []LockInfo, totalDatabases int) *Timeline {
+	hi.mu.Lock()
+	defer hi.mu.Unlock()
+	hi.advance(now)
+	hi.prune(now)
+
+	spans := make([]LockSpan, 0, len(hi.spans)+len(held))
+	spans = append(spans, hi.spans...)
+	for _, lock := range held {
+		spans = append(spans, LockSpan{
+			ConnString: lock.ConnString,
+			Marker:     lock.Marker,
+			Start:      lock.LockedAt,
+		})
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].Start.Before(spans[j].Start)
+	})
+
+	samples := make([]Sample, len(hi.samples))
+	for i, s := range hi.samples {
+		samples[i] = s.Sample
+	}
+
+	return &Timeline{
+		Start:          now.Add(-hi.window),
+		End:            now,
+		TotalDatabases: totalDatabases,
+		Spans:          spans,
+		Samples:        samples,
+	}
+}
+
+// advance returns the bucket for now, first filling any idle intervals since
+// the last event with the counts that were standing at the time.
+// Must be called with mu held.
+func (hi *history) advance(now time.Time) *bucket {
+	at := now.Truncate(sampleInterval)
+
+	var last bucket
+	if n := len(hi.samples); n > 0 {
+		last = hi.samples[n-1]
+		if !at.After(last.At) {
+			return &hi.samples[n-1]
+		}
+	}
+
+	next := at
+	if len(hi.samples) > 0 {
+		next = last.At.Add(sampleInterval)
+		// A long idle gap only needs filling back to the start of the window
+		if oldest := at.Add(-hi.window); next.Before(oldest) {
+			next = oldest
+		}
+	}
+	for t := next; !t.After(at); t = t.Add(sampleInterval) {
+		hi.samples = append(hi.samples, bucket{
+			Sample: Sample{
+				At:      t,
+				Locked:  last.carryLocked,
+				Waiting: last.carryWaiting,
+			},
+			carryLocked:  last.carryLocked,
+			carryWaiting: last.carryWaiting,
+		})
+	}
+	return &hi.samples[len(hi.samples)-1]
+}
+
+// prune drops spans and samples that ended before the window.
+// Must be called with mu held.
+func (hi *history) prune(now time.Time) {
+	cutoff := now.Add(-hi.window)
+
+	kept := hi.spans[:0]
+	for _, span := range hi.spans {
+		if span.End.After(cutoff) {
+			kept = append(kept, span)
+		}
+	}
+	hi.spans = kept
+
+	i := 0
+	for i < len(hi.samples) && hi.samples[i].At.Add(sampleInterval).Before(cutoff) {
+		i++
+	}
+	hi.samples = hi.samples[i:]
+}
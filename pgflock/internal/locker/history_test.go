@@ -0,0 +1,115 @@
+package locker
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory_FillsIdleIntervals(t *testing.T) {
+	hi := newHistory(time.Minute)
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	hi.observe(t0, 3, 1)
+	hi.observe(t0.Add(200*time.Millisecond), 5, 0)
+	hi.observe(t0.Add(400*time.Millisecond), 2, 0)
+
+	timeline := hi.snapshot(t0.Add(5*time.Second), nil, 10)
+	if len(timeline.Samples) != 6 {
+		t.Fatalf("Expected 6 samples, got %d", len(timeline.Samples))
+	}
+
+	first := timeline.Samples[0]
+	if first.Locked != 5 || first.Waiting != 1 {
+		t.Errorf("Expected first sample to hold the peaks 5/1, got %d/%d", first.Locked, first.Waiting)
+	}
+	for i, s := range timeline.Samples[1:] {
+		if s.Locked != 2 || s.Waiting != 0 {
+			t.Errorf("Sample %d: expected carried counts 2/0, got %d/%d", i+1, s.Locked, s.Waiting)
+		}
+		if want := t0.Add(time.Duration(i+1) * time.Second); !s.At.Equal(want) {
+			t.Errorf("Sample %d: expected At %v, got %v", i+1, want, s.At)
+		}
+	}
+}
+
+func TestHistory_PrunesOutsideWindow(t *testing.T) {
+	hi := newHistory(time.Minute)
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	hi.observe(t0, 1, 0)
+	hi.recordSpan(LockSpan{ConnString: "db1", Marker: "old", Start: t0, End: t0.Add(time.Second)})
+	hi.recordSpan(LockSpan{ConnString: "db2", Marker: "recent", Start: t0, End: t0.Add(90 * time.Second)})
+
+	timeline := hi.snapshot(t0.Add(2*time.Minute), nil, 10)
+	if len(timeline.Spans) != 1 || timeline.Spans[0].Marker != "recent" {
+		t.Errorf("Expected only the recent span, got %+v", timeline.Spans)
+	}
+	if n := len(timeline.Samples); n > 61 {
+		t.Errorf("Expected at most 61 samples in a 1m window, got %d", n)
+	}
+	if got := timeline.Samples[0].At; got.Before(timeline.Start.Add(-sampleInterval)) {
+		t.Errorf("Expected samples to start within the window, first at %v, window start %v", got, timeline.Start)
+	}
+}
+
+func TestHistory_ResetLatency(t *testing.T) {
+	hi := newHistory(time.Minute)
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	hi.observeReset(t0, 40*time.Millisecond)
+	hi.observeReset(t0.Add(500*time.Millisecond), 60*time.Millisecond)
+	hi.observeReset(t0.Add(2*time.Second), 10*time.Millisecond)
+
+	timeline := hi.snapshot(t0.Add(2*time.Second), nil, 10)
+	if len(timeline.Samples) != 3 {
+		t.Fatalf("Expected 3 samples, got %d", len(timeline.Samples))
+	}
+	if s := timeline.Samples[0]; s.Resets != 2 || s.ResetLatency != 100*time.Millisecond {
+		t.Errorf("Expected 2 resets totalling 100ms, got %d totalling %v", s.Resets, s.ResetLatency)
+	}
+	if s := timeline.Samples[1]; s.Resets != 0 {
+		t.Errorf("Expected no resets in idle interval, got %d", s.Resets)
+	}
+}
+
+func TestTimeline_HeldAndReleasedLocks(t *testing.T) {
+	h := newTestHandler()
+
+	var connStrs []string
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/lock?marker=suite%d&password=%s", i, testPassword), nil)
+		rr := httptest.NewRecorder()
+		h.handleLockNoReset(rr, req)
+		connStrs = append(connStrs, strings.TrimSpace(rr.Body.String()))
+	}
+
+	if !h.ForceUnlock(connStrs[0]) {
+		t.Fatal("ForceUnlock failed")
+	}
+
+	timeline := h.Timeline()
+	if timeline.TotalDatabases != defaultDatabaseCount {
+		t.Errorf("Expected total %d, got %d", defaultDatabaseCount, timeline.TotalDatabases)
+	}
+	if len(timeline.Spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(timeline.Spans))
+	}
+
+	spans := make(map[string]LockSpan)
+	for _, span := range timeline.Spans {
+		spans[span.ConnString] = span
+	}
+	if released := spans[connStrs[0]]; released.End.IsZero() || released.Marker != "suite0" {
+		t.Errorf("Expected closed span for suite0, got %+v", released)
+	}
+	if held := spans[connStrs[1]]; !held.End.IsZero() || held.Marker != "suite1" {
+		t.Errorf("Expected open span for suite1, got %+v", held)
+	}
+	if len(timeline.Samples) == 0 {
+		t.Error("Expected lock activity to be sampled")
+	}
+}
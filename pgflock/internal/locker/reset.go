@@ -2,103 +2,65 @@ package locker
 
 import (
 	"fmt"
-	"net/url"
-	"os/exec"
-	"strings"
-
-	"github.com/rs/zerolog/log"
+	"strconv"
+	"time"
 
 	"github.com/rickchristie/govner/pgflock/internal/config"
+	"github.com/rickchristie/govner/pgflock/internal/engine"
 )
 
-// parseConnString parses a PostgreSQL connection string and returns host, port, dbname
-func parseConnString(connStr string) (host string, port string, dbname string, user string, password string, err error) {
-	u, err := url.Parse(connStr)
+// ResetDatabase resets a database to pristine condition using the configured engine
+// (for PostgreSQL: drop and recreate it from the template database)
+func ResetDatabase(cfg *config.Config, connStr string, template string) error {
+	eng, err := engine.For(cfg)
 	if err != nil {
-		return "", "", "", "", "", fmt.Errorf("invalid connection string: %w", err)
-	}
-
-	host = u.Hostname()
-	port = u.Port()
-	if port == "" {
-		port = "5432"
+		return err
 	}
-
-	dbname = strings.TrimPrefix(u.Path, "/")
-	user = u.User.Username()
-	password, _ = u.User.Password()
-
-	return host, port, dbname, user, password, nil
-}
-
-// ResetDatabase resets a database to pristine condition by dropping and recreating it from test_template
-func ResetDatabase(cfg *config.Config, connStr string) error {
-	host, port, dbname, user, password, err := parseConnString(connStr)
+	localConnStr, err := engine.LocalConnString(connStr)
 	if err != nil {
 		return err
 	}
+	return eng.ResetDatabase(cfg, localConnStr, template)
+}
 
-	log.Debug().Str("dbname", dbname).Str("port", port).Msg("Resetting database")
+// replicaCatchUpTimeout bounds how long a reset waits for the replica
+const replicaCatchUpTimeout = 30 * time.Second
 
-	// Build environment for psql commands
-	env := []string{
-		fmt.Sprintf("PGPASSWORD=%s", password),
+// WaitForReplica blocks until the replica in replicaConnStr has replayed
+// everything written to the primary in connStr, e.g. a reset
+func WaitForReplica(cfg *config.Config, connStr, replicaConnStr string) error {
+	eng, err := engine.For(cfg)
+	if err != nil {
+		return err
 	}
-
-	// Connect to 'postgres' database to drop/create the target database
-	postgresConnStr := fmt.Sprintf("postgresql://%s@%s:%s/postgres", user, host, port)
-
-	// Step 1: Terminate any existing connections to the database
-	terminateSQL := fmt.Sprintf(
-		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = '%s' AND pid <> pg_backend_pid();",
-		dbname,
-	)
-	if err := runPsql(postgresConnStr, terminateSQL, env); err != nil {
-		// Log but don't fail - there might be no connections
-		log.Debug().Err(err).Str("dbname", dbname).Msg("Failed to terminate connections (may be none)")
+	replicator, ok := eng.(engine.Replicator)
+	if !ok {
+		return fmt.Errorf("%s does not support replicas", eng.DisplayName())
 	}
-
-	// Step 2: Drop the database if exists
-	dropSQL := fmt.Sprintf("DROP DATABASE IF EXISTS %s;", dbname)
-	if err := runPsql(postgresConnStr, dropSQL, env); err != nil {
-		return fmt.Errorf("failed to drop database: %w", err)
+	localConnStr, err := engine.LocalConnString(connStr)
+	if err != nil {
+		return err
 	}
-
-	// Step 3: Create the database from test_template
-	createSQL := fmt.Sprintf(
-		"CREATE DATABASE %s WITH ENCODING '%s' LC_COLLATE='%s' LC_CTYPE='%s' TEMPLATE=test_template;",
-		dbname, cfg.Encoding, cfg.LCCollate, cfg.LCCtype,
-	)
-	if err := runPsql(postgresConnStr, createSQL, env); err != nil {
-		return fmt.Errorf("failed to create database: %w", err)
+	localReplicaConnStr, err := engine.LocalConnString(replicaConnStr)
+	if err != nil {
+		return err
 	}
+	return replicator.WaitForReplica(cfg, localConnStr, localReplicaConnStr, replicaCatchUpTimeout)
+}
 
-	// Step 4: Set owner
-	alterOwnerSQL := fmt.Sprintf("ALTER DATABASE %s OWNER TO %s;", dbname, cfg.PGUsername)
-	if err := runPsql(postgresConnStr, alterOwnerSQL, env); err != nil {
-		return fmt.Errorf("failed to set database owner: %w", err)
+// replicaConnString returns the connection string of the same database on the
+// replica of connStr's instance, or "" when replicas are disabled
+func (h *Handler) replicaConnString(connStr string) string {
+	if !h.cfg.Replicas {
+		return ""
 	}
-
-	// Step 5: Connect to the new database and set schema owner
-	newDbConnStr := fmt.Sprintf("postgresql://%s@%s:%s/%s", user, host, port, dbname)
-	alterSchemaSQL := fmt.Sprintf("ALTER SCHEMA public OWNER TO %s;", cfg.PGUsername)
-	if err := runPsql(newDbConnStr, alterSchemaSQL, env); err != nil {
-		return fmt.Errorf("failed to set schema owner: %w", err)
+	conn, err := engine.ParseConnString(connStr, "")
+	if err != nil {
+		return ""
 	}
-
-	log.Debug().Str("dbname", dbname).Msg("Database reset complete")
-	return nil
-}
-
-// runPsql executes a SQL command via psql
-func runPsql(connStr, sql string, env []string) error {
-	cmd := exec.Command("psql", connStr, "-c", sql)
-	cmd.Env = append(cmd.Environ(), env...)
-
-	output, err := cmd.CombinedOutput()
+	port, err := strconv.Atoi(conn.Port)
 	if err != nil {
-		return fmt.Errorf("psql error: %w, output: %s", err, string(output))
+		return ""
 	}
-
-	return nil
+	return h.cfg.ConnString(h.cfg.ReplicaPort(port), conn.DBName)
 }
@@ -0,0 +1,72 @@
+package locker
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+	"github.com/rickchristie/govner/pgflock/internal/engine"
+)
+
+// CheckSchema compares the catalog of the database in connStr with the template
+// in templateConnStr using the configured engine.
+func CheckSchema(cfg *config.Config, connStr, templateConnStr string) (*engine.SchemaDiff, error) {
+	eng, err := engine.For(cfg)
+	if err != nil {
+		return nil, err
+	}
+	localConnStr, err := engine.LocalConnString(connStr)
+	if err != nil {
+		return nil, err
+	}
+	localTemplateConnStr, err := engine.LocalConnString(templateConnStr)
+	if err != nil {
+		return nil, err
+	}
+	return engine.CompareWithTemplate(eng, cfg, localConnStr, localTemplateConnStr)
+}
+
+// runSchemaCheck compares a just-released database with the template it was
+// reset from and records any difference against the marker that held the lock.
+func (h *Handler) runSchemaCheck(span LockSpan, template string) {
+	templateConnStr, err := h.templateConnString(span.ConnString, template)
+	if err != nil {
+		log.Warn().Err(err).Str("connStr", span.ConnString).Str("template", template).Msg("Schema check skipped")
+		return
+	}
+	diff, err := h.checkSchema(h.cfg, span.ConnString, templateConnStr)
+	if err != nil {
+		log.Warn().Err(err).Str("connStr", span.ConnString).Str("marker", span.Marker).Msg("Schema check failed")
+		return
+	}
+	if diff.Empty() {
+		return
+	}
+
+	h.history.recordLeak(SchemaLeak{
+		ConnString: span.ConnString,
+		Marker:     span.Marker,
+		ReleasedAt: span.End,
+		Added:      diff.Added,
+		Removed:    diff.Removed,
+	})
+	log.Warn().Str("connStr", span.ConnString).Str("marker", span.Marker).
+		Strs("added", diff.Added).Strs("removed", diff.Removed).Msg("SCHEMA LEAK")
+	h.sendStateUpdate()
+}
+
+// schemaLeaksJSON converts schema leaks for API responses
+func schemaLeaksJSON(leaks []SchemaLeak) []SchemaLeakJSON {
+	var out []SchemaLeakJSON
+	for _, leak := range leaks {
+		out = append(out, SchemaLeakJSON{
+			ConnString: leak.ConnString,
+			Marker:     leak.Marker,
+			ReleasedAt: leak.ReleasedAt.Format(time.RFC3339),
+			Added:      leak.Added,
+			Removed:    leak.Removed,
+		})
+	}
+	return out
+}
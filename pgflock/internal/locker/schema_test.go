@@ -0,0 +1,129 @@
+package locker
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+	"github.com/rickchristie/govner/pgflock/internal/engine"
+)
+
+func TestSchemaCheck_DisabledByDefault(t *testing.T) {
+	h, server := newStreamingTestServer(t)
+
+	var called atomic.Bool
+	h.checkSchema = func(_ *config.Config, _, _ string) (*engine.SchemaDiff, error) {
+		called.Store(true)
+		return &engine.SchemaDiff{}, nil
+	}
+
+	_, body := lockStreaming(t, server.URL, "no-check", testPassword)
+	body.Close()
+
+	if err := Await(2*time.Second, func() bool { return len(h.cLockedDbConn) == defaultDatabaseCount }); err != nil {
+		t.Fatalf("Database not returned to pool: %v", err)
+	}
+	if called.Load() {
+		t.Error("Expected schema check to be skipped when schema_check is off")
+	}
+}
+
+func TestSchemaCheck_RecordsLeakAgainstMarker(t *testing.T) {
+	h, server := newStreamingTestServer(t)
+	h.cfg.SchemaCheck = true
+
+	release := make(chan struct{})
+	h.checkSchema = func(_ *config.Config, _, _ string) (*engine.SchemaDiff, error) {
+		<-release
+		return &engine.SchemaDiff{
+			Added:   []string{"table public.scratch base table"},
+			Removed: []string{"column public.users.name text"},
+		}, nil
+	}
+
+	connStr, body := lockStreaming(t, server.URL, "ddl-suite", testPassword)
+	body.Close()
+
+	// The database must stay out of the pool until the check has run, otherwise
+	// the next lock would reset it before its schema is read.
+	if err := Await(2*time.Second, func() bool { return h.GetState().LockedDatabases == 0 }); err != nil {
+		t.Fatalf("Lock not released: %v", err)
+	}
+	if n := len(h.cLockedDbConn); n != defaultDatabaseCount-1 {
+		t.Errorf("Expected database held back during check (%d free), got %d", defaultDatabaseCount-1, n)
+	}
+	close(release)
+
+	if err := Await(2*time.Second, func() bool { return len(h.cLockedDbConn) == defaultDatabaseCount }); err != nil {
+		t.Fatalf("Database not returned to pool after check: %v", err)
+	}
+
+	leaks := h.GetState().SchemaLeaks
+	if len(leaks) != 1 {
+		t.Fatalf("Expected 1 schema leak, got %d", len(leaks))
+	}
+	if leaks[0].Marker != "ddl-suite" || leaks[0].ConnString != connStr {
+		t.Errorf("Expected leak for ddl-suite on %s, got %s on %s", connStr, leaks[0].Marker, leaks[0].ConnString)
+	}
+
+	var flagged bool
+	for _, span := range h.Timeline().Spans {
+		if span.ConnString == connStr && span.SchemaLeak {
+			flagged = true
+		}
+	}
+	if !flagged {
+		t.Error("Expected the released span to be flagged in the timeline")
+	}
+
+	rr := httptest.NewRecorder()
+	h.handleHealthCheck(rr, httptest.NewRequest("GET", "/health-check", nil))
+	var response HealthCheckResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if len(response.SchemaLeaks) != 1 {
+		t.Fatalf("Expected 1 schema leak in health check, got %d", len(response.SchemaLeaks))
+	}
+	if got := response.SchemaLeaks[0]; got.Marker != "ddl-suite" || len(got.Added) != 1 || len(got.Removed) != 1 {
+		t.Errorf("Unexpected schema leak in health check: %+v", got)
+	}
+}
+
+func TestSchemaCheck_CleanDatabaseNotReported(t *testing.T) {
+	h, server := newStreamingTestServer(t)
+	h.cfg.SchemaCheck = true
+
+	var checked atomic.Int32
+	h.checkSchema = func(_ *config.Config, _, _ string) (*engine.SchemaDiff, error) {
+		checked.Add(1)
+		return &engine.SchemaDiff{}, nil
+	}
+
+	connStr, body := lockStreaming(t, server.URL, "clean-suite", testPassword)
+	body.Close()
+	if err := Await(2*time.Second, func() bool { return len(h.cLockedDbConn) == defaultDatabaseCount }); err != nil {
+		t.Fatalf("Database not returned to pool: %v", err)
+	}
+
+	// Force unlock goes through the same check
+	_, body = lockStreaming(t, server.URL, "clean-suite", testPassword)
+	defer body.Close()
+	if err := Await(2*time.Second, func() bool { return h.GetState().LockedDatabases == 1 }); err != nil {
+		t.Fatalf("Lock not acquired: %v", err)
+	}
+	h.ForceUnlock(h.GetState().Locks[0].ConnString)
+	if err := Await(2*time.Second, func() bool { return len(h.cLockedDbConn) == defaultDatabaseCount }); err != nil {
+		t.Fatalf("Database not returned to pool: %v", err)
+	}
+
+	if n := checked.Load(); n != 2 {
+		t.Errorf("Expected 2 schema checks, got %d", n)
+	}
+	if leaks := h.GetState().SchemaLeaks; len(leaks) != 0 {
+		t.Errorf("Expected no schema leaks for %s, got %+v", connStr, leaks)
+	}
+}
@@ -2,6 +2,7 @@ package locker
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -12,18 +13,20 @@ import (
 	"github.com/rickchristie/govner/pgflock/internal/config"
 )
 
-// StartServer starts the HTTP server and returns it along with an error channel.
+// StartServer starts the HTTP server for handler and returns it along with an error channel.
 // The error channel receives any errors that occur during server operation (e.g., if the server dies).
 // The channel is buffered (size 5) to prevent blocking.
-func StartServer(cfg *config.Config, stateUpdateChan chan<- *State) (*http.Server, *Handler, <-chan error, error) {
-	handler := NewHandler(cfg, stateUpdateChan)
-
-	addr := fmt.Sprintf(":%d", cfg.LockerPort)
+// When tlsConfig is non-nil the server only accepts HTTPS connections.
+func StartServer(cfg *config.Config, tlsConfig *tls.Config, handler *Handler) (*http.Server, <-chan error, error) {
+	addr := cfg.LockerListenAddr()
 
 	// Try to bind to the port first to catch "address already in use" errors synchronously
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to bind to port %d: %w", cfg.LockerPort, err)
+		return nil, nil, fmt.Errorf("failed to bind to %s: %w", addr, err)
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
 	}
 
 	server := &http.Server{
@@ -48,7 +51,7 @@ func StartServer(cfg *config.Config, stateUpdateChan chan<- *State) (*http.Serve
 			}
 		}()
 
-		log.Info().Str("addr", addr).Msg("Starting locker server")
+		log.Info().Str("addr", addr).Bool("tls", tlsConfig != nil).Msg("Starting locker server")
 		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			log.Error().Err(err).Msg("Locker server error")
 			select {
@@ -58,7 +61,7 @@ func StartServer(cfg *config.Config, stateUpdateChan chan<- *State) (*http.Serve
 		}
 	}()
 
-	return server, handler, errChan, nil
+	return server, errChan, nil
 }
 
 // StopServer gracefully shuts down the server
@@ -69,3 +72,10 @@ func StopServer(server *http.Server) error {
 	log.Info().Msg("Shutting down locker server")
 	return server.Shutdown(ctx)
 }
+
+// CloseServer closes the server and every open connection immediately. Used for
+// a handover, where StopServer would wait for the streaming lock connections.
+func CloseServer(server *http.Server) error {
+	log.Info().Msg("Closing locker server")
+	return server.Close()
+}
@@ -13,6 +13,8 @@ type State struct {
 	WaitingRequests int
 	Locks           []LockInfo
 	Instances       []InstanceStatus
+	SchemaLeaks     []SchemaLeak // Most recent first
+	Templates       []Template   // Promoted templates, sorted by name
 }
 
 // LockInfo stores information about a locked database
@@ -20,6 +22,8 @@ type LockInfo struct {
 	ConnString string
 	Marker     string
 	LockedAt   time.Time
+	Template   string // Promoted template the database was reset from, empty for test_template
+	Lease      string // Token the holder uses to reclaim the lock after its connection drops
 	// cancel is non-nil for streaming (v2) locks. Calling it signals the streaming
 	// handler to stop blocking and release the lock. Used by ForceUnlock, UnlockAll, etc.
 	cancel context.CancelFunc
@@ -31,17 +35,60 @@ type LockInfoJSON struct {
 	Marker          string `json:"marker"`
 	LockedAt        string `json:"locked_at"`
 	DurationSeconds int64  `json:"duration_seconds"`
+	Template        string `json:"template,omitempty"`
+	Replica         string `json:"replica,omitempty"` // Replica of the database when replicas are enabled
+}
+
+// SchemaLeak records a database that was released with a schema different from
+// its template, i.e. the lock holder ran DDL and did not undo it.
+type SchemaLeak struct {
+	ConnString string
+	Marker     string
+	ReleasedAt time.Time
+	Added      []string // Catalog lines present in the database but not the template
+	Removed    []string // Catalog lines present in the template but not the database
+}
+
+// SchemaLeakJSON is the JSON representation of SchemaLeak for API responses
+type SchemaLeakJSON struct {
+	ConnString string   `json:"conn_string"`
+	Marker     string   `json:"marker"`
+	ReleasedAt string   `json:"released_at"`
+	Added      []string `json:"added,omitempty"`
+	Removed    []string `json:"removed,omitempty"`
+}
+
+// Template is a locked database that was promoted to a named template. Locks
+// that ask for it are reset from the copy on their own instance, or loaded from
+// the source instance when their instance has no copy.
+type Template struct {
+	Name             string
+	SourceConnString string // Database the template was promoted from, or the template itself when restored
+	Marker           string // Holder of the lock at promotion time
+	CreatedAt        time.Time
+	Ports            []int // Instances holding a copy, sorted
+}
+
+// TemplateJSON is the JSON representation of Template for API responses
+type TemplateJSON struct {
+	Name             string `json:"name"`
+	SourceConnString string `json:"source_conn_string"`
+	Marker           string `json:"marker"`
+	CreatedAt        string `json:"created_at"`
+	Ports            []int  `json:"ports"`
 }
 
 // HealthCheckResponse is the JSON response for the health-check endpoint
 type HealthCheckResponse struct {
-	Status            string         `json:"status"`
-	TotalDatabases    int            `json:"total"`
-	LockedDatabases   int            `json:"locked"`
-	FreeDatabases     int            `json:"free"`
-	WaitingRequests   int            `json:"waiting"`
-	AutoUnlockMinutes int            `json:"auto_unlock_minutes"`
-	Locks             []LockInfoJSON `json:"locks"`
+	Status            string           `json:"status"`
+	TotalDatabases    int              `json:"total"`
+	LockedDatabases   int              `json:"locked"`
+	FreeDatabases     int              `json:"free"`
+	WaitingRequests   int              `json:"waiting"`
+	AutoUnlockMinutes int              `json:"auto_unlock_minutes"`
+	Locks             []LockInfoJSON   `json:"locks"`
+	SchemaLeaks       []SchemaLeakJSON `json:"schema_leaks,omitempty"`
+	Templates         []TemplateJSON   `json:"templates,omitempty"`
 }
 
 // InstanceStatus represents the status of a PostgreSQL instance
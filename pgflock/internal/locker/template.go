@@ -0,0 +1,435 @@
+package locker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/rickchristie/govner/pgflock/internal/config"
+	"github.com/rickchristie/govner/pgflock/internal/engine"
+)
+
+var (
+	// ErrNotLocked is returned when promoting a database that nobody holds a lock on.
+	ErrNotLocked = errors.New("database is not currently locked")
+	// ErrNotLeaseHolder is returned when promoting a database with a lease
+	// other than the one of its lock.
+	ErrNotLeaseHolder = errors.New("lease does not match the lock on the database")
+)
+
+// PostgreSQL refuses to copy or drop a database while another session is
+// connected to it, e.g. a dump of a template for a lock on another instance.
+// Such sessions are short, so copies from a busy template are retried.
+const (
+	templateBusyError   = "is being accessed by other users"
+	templateBusyTimeout = 30 * time.Second
+	templateBusyBackoff = 100 * time.Millisecond
+)
+
+// CreateTemplate copies the database in connStr into the template database on
+// the same instance using the configured engine.
+func CreateTemplate(cfg *config.Config, connStr string, template string) error {
+	eng, err := engine.For(cfg)
+	if err != nil {
+		return err
+	}
+	localConnStr, err := engine.LocalConnString(connStr)
+	if err != nil {
+		return err
+	}
+	return eng.CreateTemplate(cfg, localConnStr, template)
+}
+
+// CopyDatabase replaces the database in toConnStr with a dump of the database
+// in fromConnStr using the configured engine.
+func CopyDatabase(cfg *config.Config, fromConnStr, toConnStr string) error {
+	eng, err := engine.For(cfg)
+	if err != nil {
+		return err
+	}
+	localFrom, err := engine.LocalConnString(fromConnStr)
+	if err != nil {
+		return err
+	}
+	localTo, err := engine.LocalConnString(toConnStr)
+	if err != nil {
+		return err
+	}
+	return eng.CopyDatabase(cfg, localFrom, localTo)
+}
+
+// PromoteTemplate snapshots a locked database into the named template on its
+// instance, replacing any template of the same name. With allInstances the
+// template is also copied to every other instance, so locks there are reset
+// from a local copy instead of loading a dump.
+//
+// The database stays locked by its holder, but on PostgreSQL the holder's
+// connections are terminated while it is copied.
+func (h *Handler) PromoteTemplate(connStr, name string, allInstances bool) (*Template, error) {
+	return h.promoteTemplate(connStr, "", name, allInstances)
+}
+
+// promoteTemplate is PromoteTemplate on behalf of the lock's holder, which
+// proves it holds the lock with its lease. An empty lease skips the check,
+// for the TUI which acts for the pool's owner.
+func (h *Handler) promoteTemplate(connStr, lease, name string, allInstances bool) (*Template, error) {
+	if err := engine.ValidateTemplateName(name); err != nil {
+		return nil, err
+	}
+	if !h.testDatabases[connStr] {
+		return nil, fmt.Errorf("database connection does not exist")
+	}
+
+	var marker string
+	var locked, holder bool
+	h.withLocksRLock(func() {
+		if lockInfo, exists := h.locks[connStr]; exists {
+			marker, locked = lockInfo.Marker, true
+			holder = lease == "" || lockInfo.Lease == lease
+		}
+	})
+	if !locked {
+		return nil, ErrNotLocked
+	}
+	if !holder {
+		return nil, ErrNotLeaseHolder
+	}
+
+	sourcePort, err := instancePort(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// One promotion at a time: two promotions of the same name would drop
+	// each other's copies mid-way.
+	h.promoteMu.Lock()
+	defer h.promoteMu.Unlock()
+
+	dbName := engine.TemplateName(name)
+	log.Info().Str("connStr", connStr).Str("template", name).Bool("allInstances", allInstances).Msg("PROMOTE")
+
+	err = retryTemplateBusy(func() error {
+		return h.createTemplate(h.cfg, connStr, dbName)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	return h.publishTemplate(name, connStr, marker, sourcePort, allInstances)
+}
+
+// RegisterTemplate records a template database that was loaded onto the
+// instance on port outside the locker, e.g. by 'pgflock restore'. With
+// allInstances it is copied to every other instance as in PromoteTemplate.
+func (h *Handler) RegisterTemplate(name string, port int, marker string, allInstances bool) (*Template, error) {
+	if err := engine.ValidateTemplateName(name); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(h.cfg.InstancePorts(), port) {
+		return nil, fmt.Errorf("no instance on port %d", port)
+	}
+
+	h.promoteMu.Lock()
+	defer h.promoteMu.Unlock()
+
+	log.Info().Int("port", port).Str("template", name).Bool("allInstances", allInstances).Msg("REGISTER-TEMPLATE")
+	return h.publishTemplate(name, h.cfg.ConnString(port, engine.TemplateName(name)), marker, port, allInstances)
+}
+
+// publishTemplate copies a template that exists on sourcePort to the other
+// instances when allInstances is set, and makes it available to /lock.
+// Must be called with promoteMu held.
+func (h *Handler) publishTemplate(name, sourceConnStr, marker string, sourcePort int, allInstances bool) (*Template, error) {
+	dbName := engine.TemplateName(name)
+	sourceTemplate, err := engine.WithDatabase(sourceConnStr, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := []int{sourcePort}
+	if allInstances {
+		for _, port := range h.cfg.InstancePorts() {
+			if port == sourcePort {
+				continue
+			}
+			err := retryTemplateBusy(func() error {
+				return h.copyDatabase(h.cfg, sourceTemplate, h.cfg.ConnString(port, dbName))
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to copy template to port %d: %w", port, err)
+			}
+			ports = append(ports, port)
+		}
+		sort.Ints(ports)
+	}
+
+	tmpl := &Template{
+		Name:             name,
+		SourceConnString: sourceConnStr,
+		Marker:           marker,
+		CreatedAt:        time.Now(),
+		Ports:            ports,
+	}
+	h.withTemplatesLock(func() {
+		h.templates[name] = tmpl
+	})
+
+	h.sendStateUpdate()
+	return tmpl, nil
+}
+
+// ForgetTemplates drops every promoted template. Called on restart, because the
+// recreated containers start from an empty data directory.
+func (h *Handler) ForgetTemplates() {
+	h.withTemplatesLock(func() {
+		h.templates = make(map[string]*Template)
+	})
+	h.sendStateUpdate()
+}
+
+// withTemplatesLock executes the given function while holding the templates lock
+func (h *Handler) withTemplatesLock(fn func()) {
+	h.templatesMu.Lock()
+	defer h.templatesMu.Unlock()
+	fn()
+}
+
+// lookupTemplate returns a copy of the named template
+func (h *Handler) lookupTemplate(name string) (Template, bool) {
+	h.templatesMu.RLock()
+	defer h.templatesMu.RUnlock()
+	tmpl, exists := h.templates[name]
+	if !exists {
+		return Template{}, false
+	}
+	return *tmpl, true
+}
+
+// listTemplates returns copies of all templates, sorted by name
+func (h *Handler) listTemplates() []Template {
+	h.templatesMu.RLock()
+	defer h.templatesMu.RUnlock()
+	var out []Template
+	for _, tmpl := range h.templates {
+		out = append(out, *tmpl)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// prepareDatabase resets a database before it is handed to a client: from
+// test_template when template is empty, otherwise from the promoted template.
+func (h *Handler) prepareDatabase(connStr, template string) error {
+	return retryTemplateBusy(func() error {
+		return h.resetFromTemplate(connStr, template)
+	})
+}
+
+// retryTemplateBusy runs a copy from a template, retrying for a while as long
+// as it fails because another session is connected to the template
+func retryTemplateBusy(copy func() error) error {
+	deadline := time.Now().Add(templateBusyTimeout)
+	for {
+		err := copy()
+		if err == nil || !strings.Contains(err.Error(), templateBusyError) || time.Now().After(deadline) {
+			return err
+		}
+		log.Debug().Err(err).Msg("Template busy, retrying")
+		time.Sleep(templateBusyBackoff)
+	}
+}
+
+// resetFromTemplate does one attempt of prepareDatabase
+func (h *Handler) resetFromTemplate(connStr, template string) error {
+	if template == "" {
+		return h.resetDatabase(h.cfg, connStr, engine.TemplateDatabase)
+	}
+
+	tmpl, exists := h.lookupTemplate(template)
+	if !exists {
+		return fmt.Errorf("template %q no longer exists", template)
+	}
+	port, err := instancePort(connStr)
+	if err != nil {
+		return err
+	}
+	if tmpl.onInstance(port) {
+		return h.resetDatabase(h.cfg, connStr, engine.TemplateName(template))
+	}
+
+	sourceTemplate, err := engine.WithDatabase(tmpl.SourceConnString, engine.TemplateName(template))
+	if err != nil {
+		return err
+	}
+	return h.copyDatabase(h.cfg, sourceTemplate, connStr)
+}
+
+// templateConnString returns the connection string of the template database
+// that connStr was reset from, for comparing their schemas.
+func (h *Handler) templateConnString(connStr, template string) (string, error) {
+	if template == "" {
+		return engine.TemplateConnString(connStr)
+	}
+
+	tmpl, exists := h.lookupTemplate(template)
+	if !exists {
+		return "", fmt.Errorf("template %q no longer exists", template)
+	}
+	port, err := instancePort(connStr)
+	if err != nil {
+		return "", err
+	}
+	if tmpl.onInstance(port) {
+		return engine.WithDatabase(connStr, engine.TemplateName(template))
+	}
+	return engine.WithDatabase(tmpl.SourceConnString, engine.TemplateName(template))
+}
+
+// onInstance returns true if the instance on port holds a copy of the template
+func (t *Template) onInstance(port int) bool {
+	for _, p := range t.Ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// instancePort returns the instance port of a pool connection string
+func instancePort(connStr string) (int, error) {
+	conn, err := engine.ParseConnString(connStr, "")
+	if err != nil {
+		return 0, err
+	}
+	port, err := strconv.Atoi(conn.Port)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port in connection string %q", connStr)
+	}
+	return port, nil
+}
+
+// handlePromote promotes the locked database in the request body to the
+// template named by the name query parameter. all=true copies it to every
+// instance. The lease query parameter must be the lease of the lock.
+func (h *Handler) handlePromote(resp http.ResponseWriter, req *http.Request) {
+	_, valid := h.validateAuth(req)
+	if !valid {
+		http.Error(resp, "Invalid marker or password", http.StatusUnauthorized)
+		return
+	}
+
+	if req.Method != "POST" {
+		http.Error(resp, "Method not allowed, use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	bodyBytes, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(resp, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	connStr := string(bodyBytes)
+	if connStr == "" {
+		http.Error(resp, "Connection string required in request body", http.StatusBadRequest)
+		return
+	}
+
+	if !h.testDatabases[connStr] {
+		http.Error(resp, "Database connection does not exist", http.StatusBadRequest)
+		return
+	}
+
+	name := req.URL.Query().Get("name")
+	if err := engine.ValidateTemplateName(name); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Only the holder of the lock may promote it
+	lease := req.URL.Query().Get("lease")
+	if lease == "" {
+		http.Error(resp, "lease query parameter required", http.StatusBadRequest)
+		return
+	}
+
+	tmpl, err := h.promoteTemplate(connStr, lease, name, req.URL.Query().Get("all") == "true")
+	if errors.Is(err, ErrNotLocked) {
+		http.Error(resp, "Database is not currently locked", http.StatusConflict)
+		return
+	}
+	if errors.Is(err, ErrNotLeaseHolder) {
+		http.Error(resp, "Database is locked with another lease", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("connStr", connStr).Str("template", name).Msg("Promote failed")
+		http.Error(resp, fmt.Sprintf("Promote failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	json.NewEncoder(resp).Encode(templatesJSON([]Template{*tmpl})[0])
+}
+
+// handleRegisterTemplate records a template database restored onto the
+// instance named by the port query parameter. all=true copies it to every instance.
+func (h *Handler) handleRegisterTemplate(resp http.ResponseWriter, req *http.Request) {
+	marker, valid := h.validateAuth(req)
+	if !valid {
+		http.Error(resp, "Invalid marker or password", http.StatusUnauthorized)
+		return
+	}
+
+	if req.Method != "POST" {
+		http.Error(resp, "Method not allowed, use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	port, err := strconv.Atoi(req.URL.Query().Get("port"))
+	if err != nil {
+		http.Error(resp, "port query parameter required", http.StatusBadRequest)
+		return
+	}
+
+	name := req.URL.Query().Get("name")
+	if err := engine.ValidateTemplateName(name); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tmpl, err := h.RegisterTemplate(name, port, marker, req.URL.Query().Get("all") == "true")
+	if err != nil {
+		log.Error().Err(err).Int("port", port).Str("template", name).Msg("Register template failed")
+		http.Error(resp, fmt.Sprintf("Register template failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	json.NewEncoder(resp).Encode(templatesJSON([]Template{*tmpl})[0])
+}
+
+// templatesJSON converts templates for API responses
+func templatesJSON(templates []Template) []TemplateJSON {
+	var out []TemplateJSON
+	for _, tmpl := range templates {
+		out = append(out, TemplateJSON{
+			Name:             tmpl.Name,
+			SourceConnString: tmpl.SourceConnString,
+			Marker:           tmpl.Marker,
+			CreatedAt:        tmpl.CreatedAt.Format(time.RFC3339),
+			Ports:            tmpl.Ports,
+		})
+	}
+	return out
+}
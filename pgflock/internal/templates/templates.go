@@ -19,6 +19,7 @@ type DockerfileData struct {
 	PostgresVersion string
 	Password        string
 	HasPostGIS      bool
+	Replicas        bool
 }
 
 // InitScriptData holds data for init.sh template
@@ -31,14 +32,40 @@ type InitScriptData struct {
 	Encoding       string
 	LCCollate      string
 	LCCtype        string
+	Replicas       bool
 }
 
 // PostgresConfData holds data for postgresql.conf template
 type PostgresConfData struct {
 	Port           int
 	MaxConnections int
+	Replicas       bool
 }
 
+// ReplicaScriptData holds data for replica.sh template
+type ReplicaScriptData struct {
+	Username string
+	Password string
+}
+
+// MySQLData holds data for the MySQL/MariaDB Dockerfile, init.sh and my.cnf templates
+type MySQLData struct {
+	Image          string // "mysql" or "mariadb", pulled from Docker Hub
+	Version        string
+	IsMariaDB      bool
+	NumDatabases   int
+	Username       string
+	Password       string
+	DatabasePrefix string
+	MaxConnections int
+}
+
+// Default image tags when mysql_version is not set
+const (
+	DefaultMySQLVersion   = "8.4"
+	DefaultMariaDBVersion = "11.4"
+)
+
 // GenerateDockerfile generates Dockerfile content from config
 func GenerateDockerfile(cfg *config.Config) (string, error) {
 	tmpl, err := template.ParseFS(templateFS, "Dockerfile.tmpl")
@@ -50,6 +77,7 @@ func GenerateDockerfile(cfg *config.Config) (string, error) {
 		PostgresVersion: cfg.PostgresVersion,
 		Password:        cfg.Password,
 		HasPostGIS:      hasExtension(cfg.Extensions, "postgis"),
+		Replicas:        cfg.Replicas,
 	}
 
 	var buf strings.Builder
@@ -76,6 +104,7 @@ func GenerateInitScript(cfg *config.Config) (string, error) {
 		Encoding:       cfg.Encoding,
 		LCCollate:      cfg.LCCollate,
 		LCCtype:        cfg.LCCtype,
+		Replicas:       cfg.Replicas,
 	}
 
 	var buf strings.Builder
@@ -96,6 +125,7 @@ func GeneratePostgresConf(cfg *config.Config, port int) (string, error) {
 	data := PostgresConfData{
 		Port:           port,
 		MaxConnections: cfg.MaxConnections,
+		Replicas:       cfg.Replicas,
 	}
 
 	var buf strings.Builder
@@ -106,8 +136,97 @@ func GeneratePostgresConf(cfg *config.Config, port int) (string, error) {
 	return buf.String(), nil
 }
 
+// GenerateReplicaScript generates replica.sh content from config
+func GenerateReplicaScript(cfg *config.Config) (string, error) {
+	tmpl, err := template.ParseFS(templateFS, "replica.sh.tmpl")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse replica.sh template: %w", err)
+	}
+
+	data := ReplicaScriptData{
+		Username: cfg.PGUsername,
+		Password: cfg.Password,
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute replica.sh template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// newMySQLData builds template data for the MySQL family engines
+func newMySQLData(cfg *config.Config) MySQLData {
+	data := MySQLData{
+		Image:          config.EngineMySQL,
+		Version:        cfg.MySQLVersion,
+		NumDatabases:   cfg.DatabasesPerInstance,
+		Username:       cfg.PGUsername,
+		Password:       cfg.Password,
+		DatabasePrefix: cfg.DatabasePrefix,
+		MaxConnections: cfg.MaxConnections,
+	}
+	if cfg.Engine == config.EngineMariaDB {
+		data.Image = config.EngineMariaDB
+		data.IsMariaDB = true
+	}
+	if data.Version == "" {
+		data.Version = DefaultMySQLVersion
+		if data.IsMariaDB {
+			data.Version = DefaultMariaDBVersion
+		}
+	}
+	return data
+}
+
+// GenerateMySQLFile renders one of the MySQL family templates
+// (Dockerfile.mysql.tmpl, init.mysql.sh.tmpl or my.cnf.tmpl) from config
+func GenerateMySQLFile(cfg *config.Config, name string) (string, error) {
+	tmpl, err := template.ParseFS(templateFS, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, newMySQLData(cfg)); err != nil {
+		return "", fmt.Errorf("failed to execute %s template: %w", name, err)
+	}
+
+	return buf.String(), nil
+}
+
+// writeMySQLTemplates writes the Dockerfile, init.sh and my.cnf for MySQL/MariaDB
+func writeMySQLTemplates(cfg *config.Config, outputDir string) error {
+	files := []struct {
+		tmpl string
+		out  string
+		mode os.FileMode
+	}{
+		{"Dockerfile.mysql.tmpl", "Dockerfile", 0644},
+		{"init.mysql.sh.tmpl", "init.sh", 0755},
+		{"my.cnf.tmpl", "my.cnf", 0644},
+	}
+
+	for _, f := range files {
+		content, err := GenerateMySQLFile(cfg, f.tmpl)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(outputDir, f.out), []byte(content), f.mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.out, err)
+		}
+	}
+
+	return nil
+}
+
 // WriteAllTemplates writes all generated files to the output directory
 func WriteAllTemplates(cfg *config.Config, outputDir string) error {
+	if cfg.IsMySQLFamily() {
+		return writeMySQLTemplates(cfg, outputDir)
+	}
+
 	// Generate and write Dockerfile
 	dockerfile, err := GenerateDockerfile(cfg)
 	if err != nil {
@@ -136,6 +255,17 @@ func WriteAllTemplates(cfg *config.Config, outputDir string) error {
 		return fmt.Errorf("failed to write postgresql.conf: %w", err)
 	}
 
+	// Generate and write replica.sh, the entrypoint of replica containers
+	if cfg.Replicas {
+		replicaScript, err := GenerateReplicaScript(cfg)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(outputDir, "replica.sh"), []byte(replicaScript), 0755); err != nil {
+			return fmt.Errorf("failed to write replica.sh: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -3,11 +3,13 @@ package tui
 import (
 	"fmt"
 	"os/exec"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/rickchristie/govner/pgflock/internal/docker"
+	"github.com/rickchristie/govner/pgflock/internal/engine"
 	"github.com/rickchristie/govner/pgflock/internal/locker"
 )
 
@@ -68,6 +70,9 @@ type (
 		responseChan chan error
 	}
 
+	// handoverRequestMsg is sent when a new 'pgflock up' takes over the locker
+	handoverRequestMsg struct{}
+
 	// healthStatusStartMsg signals the start of a health check cycle
 	healthStatusStartMsg struct{}
 
@@ -92,6 +97,12 @@ type (
 
 	// healthStatusClearMsg clears the health status message after a delay
 	healthStatusClearMsg struct{}
+
+	// promoteResultMsg is sent when promoting a database to a template finishes
+	promoteResultMsg struct {
+		name string
+		err  error
+	}
 )
 
 // Init initializes the TUI model
@@ -170,6 +181,19 @@ func (m *Model) waitForRestartRequest() tea.Cmd {
 	}
 }
 
+// waitForHandoverRequest waits for a handover request from HTTP API
+func (m *Model) waitForHandoverRequest() tea.Cmd {
+	return func() tea.Msg {
+		if m.handoverRequestChan == nil {
+			return nil
+		}
+		if _, ok := <-m.handoverRequestChan; !ok {
+			return nil
+		}
+		return handoverRequestMsg{}
+	}
+}
+
 // healthCheckTick sends periodic health check messages
 func (m *Model) healthCheckTick() tea.Cmd {
 	return tea.Tick(HealthCheckInterval, func(t time.Time) tea.Msg {
@@ -194,14 +218,14 @@ func (m *Model) doHealthCheck() tea.Cmd {
 		}
 
 		// Check container health
-		health := make([]ContainerHealth, len(cfg.InstancePorts()))
-		for i, port := range cfg.InstancePorts() {
+		health := make([]ContainerHealth, len(cfg.ContainerPorts()))
+		for i, port := range cfg.ContainerPorts() {
 			health[i] = ContainerHealth{
 				Port:   port,
 				Status: HealthDown, // Default to down
 			}
-			// Check if container is running and PostgreSQL is responding
-			if docker.PostgresStatus(cfg, port) {
+			// Check if container is running and the database server is responding
+			if docker.InstanceReady(cfg, port) {
 				health[i].Status = HealthOK
 			}
 		}
@@ -293,7 +317,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateAllDatabasesLockStatus()
 
 		// Adjust selection and scroll if out of bounds (for locked view)
-		if !m.showAllDatabases && m.state != nil {
+		if !m.listsAllDatabases() && m.state != nil {
 			maxIdx := len(m.state.Locks) - 1
 			if maxIdx < 0 {
 				maxIdx = 0
@@ -311,9 +335,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.handler != nil {
 			m.state = m.handler.GetState()
 			m.updateAllDatabasesLockStatus()
+			m.refreshTimeline()
 
 			// Adjust selection and scroll if out of bounds (for locked view)
-			if !m.showAllDatabases && m.state != nil {
+			if !m.listsAllDatabases() && m.state != nil {
 				maxIdx := len(m.state.Locks) - 1
 				if maxIdx < 0 {
 					maxIdx = 0
@@ -478,6 +503,24 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, m.waitForRestartRequest()
 
+	case handoverRequestMsg:
+		// A new locker is taking over: stop serving, but leave the containers
+		// and the held databases running for it
+		if m.onHandover != nil {
+			m.onHandover()
+		}
+		m.handedOver = true
+		m.quitting = true
+		return m, tea.Quit
+
+	case promoteResultMsg:
+		m.promoting = ""
+		m.err = msg.err
+		if msg.err != nil {
+			m.err = fmt.Errorf("promote %s: %w", msg.name, msg.err)
+		}
+		return m, nil
+
 	case errMsg:
 		m.err = msg.err
 		return m, nil
@@ -531,6 +574,11 @@ func (m *Model) handleLoadingComplete() (tea.Model, tea.Cmd) {
 		cmds = append(cmds, m.waitForRestartRequest())
 	}
 
+	// Start listening for handover requests once; restarts come back here too
+	if m.handoverRequestChan != nil && m.loadingScreen.Mode() == LoadingModeStartup {
+		cmds = append(cmds, m.waitForHandoverRequest())
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
@@ -584,6 +632,10 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case " ":
+		// The timeline always shows every database, so there is nothing to toggle
+		if m.showTimeline {
+			return m, nil
+		}
 		m.showAllDatabases = !m.showAllDatabases
 		m.selectedIdx = 0
 		m.scrollOffset = 0
@@ -591,6 +643,30 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.adjustScrollOffset(m.getCurrentListSize())
 		return m, nil
 
+	case "p":
+		if db := m.selectedDatabase(); db != nil && db.IsLocked && m.promoting == "" {
+			m.promoteConnStr = db.ConnString
+			m.promoteName = ""
+			m.promoteAll = m.instanceCount() > 1
+			m.confirm = ConfirmPromote
+		}
+		return m, nil
+
+	case "l":
+		if len(m.schemaLeaks()) > 0 {
+			m.confirm = ConfirmSchemaLeaks
+		}
+		return m, nil
+
+	case "t":
+		m.showTimeline = !m.showTimeline
+		m.selectedIdx = 0
+		m.scrollOffset = 0
+		m.refreshTimeline()
+		// Ensure scroll offset is valid for the new view
+		m.adjustScrollOffset(m.getCurrentListSize())
+		return m, nil
+
 	case "up", "k":
 		if m.selectedIdx > 0 {
 			m.selectedIdx--
@@ -612,9 +688,18 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// copyToClipboard copies the psql command to clipboard with shimmer animation
+// copyToClipboard copies the client command (e.g. psql) to clipboard with shimmer animation
 func (m *Model) copyToClipboard(connStr string) (tea.Model, tea.Cmd) {
-	psqlCmd := fmt.Sprintf("psql '%s'", connStr)
+	eng, err := engine.For(m.cfg)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	psqlCmd := shellJoin(eng.ShellCommand(connStr))
+	for _, kv := range eng.ShellEnv(connStr) {
+		name, value, _ := strings.Cut(kv, "=")
+		psqlCmd = name + "=" + shellQuote(value) + " " + psqlCmd
+	}
 
 	// Try xclip first (Linux), then xsel, then pbcopy (macOS)
 	var cmd *exec.Cmd
@@ -658,8 +743,37 @@ func (m *Model) copyToClipboard(connStr string) (tea.Model, tea.Cmd) {
 	)
 }
 
+// shellJoin joins a command line for pasting into a shell, single-quoting
+// arguments that contain anything other than plain word characters.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if i > 0 {
+			arg = shellQuote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote single-quotes arg for a POSIX shell when it has characters the
+// shell would interpret
+func shellQuote(arg string) string {
+	if strings.IndexFunc(arg, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r == '=' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	}) >= 0 {
+		return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return arg
+}
+
 // handleConfirmKey handles keys when a confirmation dialog is shown
 func (m *Model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.confirm == ConfirmPromote {
+		return m.handlePromoteKey(msg)
+	}
+
 	switch msg.String() {
 	case "enter", "y":
 		return m.executeConfirmedAction()
@@ -672,6 +786,56 @@ func (m *Model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handlePromoteKey edits the template name in the promote modal. Only characters
+// valid in a template name are accepted; upper-case letters are lowered.
+func (m *Model) handlePromoteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		if engine.ValidateTemplateName(m.promoteName) != nil {
+			return m, nil
+		}
+		m.confirm = ConfirmNone
+		m.promoting = m.promoteName
+		return m, m.doPromote(m.promoteConnStr, m.promoteName, m.promoteAll)
+
+	case tea.KeyEsc:
+		m.confirm = ConfirmNone
+		return m, nil
+
+	case tea.KeyBackspace:
+		if n := len(m.promoteName); n > 0 {
+			m.promoteName = m.promoteName[:n-1]
+		}
+		return m, nil
+
+	case tea.KeyTab:
+		if m.instanceCount() > 1 {
+			m.promoteAll = !m.promoteAll
+		}
+		return m, nil
+
+	case tea.KeyRunes:
+		for _, r := range strings.ToLower(string(msg.Runes)) {
+			if name := m.promoteName + string(r); engine.ValidateTemplateName(name) == nil {
+				m.promoteName = name
+			}
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// doPromote promotes a locked database to a template in the background, since
+// copying it (and to every instance with all) can take a while.
+func (m *Model) doPromote(connStr, name string, all bool) tea.Cmd {
+	handler := m.handler
+	return func() tea.Msg {
+		_, err := handler.PromoteTemplate(connStr, name, all)
+		return promoteResultMsg{name: name, err: err}
+	}
+}
+
 // executeConfirmedAction executes the confirmed action
 func (m *Model) executeConfirmedAction() (tea.Model, tea.Cmd) {
 	action := m.confirm
@@ -716,6 +880,10 @@ func (m *Model) executeConfirmedAction() (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case ConfirmSchemaLeaks:
+		// Informational only, closing the modal is all there is to do
+		return m, nil
+
 	case ConfirmLockerDied:
 		// Locker server died - quit the application
 		m.quitting = true
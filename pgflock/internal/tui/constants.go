@@ -58,6 +58,8 @@ var (
 	ColorAmber  = lipgloss.Color("#fbbf24") // Lantern light, selection
 	ColorCyan   = lipgloss.Color("#22d3ee") // Headers, keys
 	ColorViolet = lipgloss.Color("#a78bfa") // Test identifiers/markers
+	ColorSky    = lipgloss.Color("#60a5fa") // Timeline marker
+	ColorPink   = lipgloss.Color("#f472b6") // Timeline marker
 
 	// LOCKED animation colors - warm pulse
 	ColorCoral  = lipgloss.Color("#f87171") // frame 0, 4 (base)
@@ -98,8 +100,17 @@ const (
 
 	// Navigation hint
 	NavArrows = "↑↓"
+
+	// Timeline view
+	TimelineLocked = "█"
+	TimelineLeaked = "▓" // lock released with schema changes
+	TimelineIdle   = "·"
+	LegendSwatch   = "■"
 )
 
+// SparklineLevels are the bar heights of sparkline charts, lowest first
+var SparklineLevels = []string{"▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+
 // LockedAnimationIcons returns the icon sequence for LOCKED animation
 func LockedAnimationIcons() []string {
 	return []string{
@@ -133,6 +144,20 @@ func CopyShimmerColors() []lipgloss.Color {
 	}
 }
 
+// MarkerColors returns the palette used to tell markers apart on the timeline
+func MarkerColors() []lipgloss.Color {
+	return []lipgloss.Color{
+		ColorViolet,
+		ColorCyan,
+		ColorAmber,
+		ColorLime,
+		ColorPink,
+		ColorSky,
+		ColorOrange,
+		ColorMint,
+	}
+}
+
 // Sheep animation frames for running/checking (dots pulse around stationary sheep)
 // Similar to startup screen animation style
 var SheepRunningFrames = []string{
@@ -16,7 +16,7 @@ const (
 	StepInit LoadingStep = iota
 	StepStoppingContainers
 	StepStartingContainers
-	StepWaitingPostgres
+	StepWaitingInstances
 	StepStartingLocker
 	StepReady
 	StepFailed
@@ -160,7 +160,7 @@ func (s *LoadingScreen) calculateTargetProgress() float64 {
 		return 0.1
 	case StepStartingContainers:
 		return 0.3
-	case StepWaitingPostgres:
+	case StepWaitingInstances:
 		// Progress based on ready instances
 		readyCount := 0
 		for _, inst := range s.instances {
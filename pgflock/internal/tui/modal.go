@@ -4,6 +4,8 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/rickchristie/govner/pgflock/internal/locker"
 )
 
 // Pre-computed modal styles for performance
@@ -94,7 +96,7 @@ func RenderModal(cfg ModalConfig) string {
 // QuitModal returns the quit confirmation modal.
 func QuitModal(lockedCount int) string {
 	body := []string{
-		"This will stop all PostgreSQL containers",
+		"This will stop all database containers",
 	}
 	if lockedCount > 0 {
 		body = append(body, "and release "+pluralize(lockedCount, "database lock", "database locks")+".")
@@ -141,6 +143,32 @@ func UnlockModal(dbName, marker string, duration string) string {
 	})
 }
 
+// PromoteModal returns the modal asking for the name of the template to promote
+// a locked database to. instanceCount > 1 shows the copy-to-all toggle.
+func PromoteModal(dbName, marker, name string, all bool, instanceCount int) string {
+	body := []string{
+		"Snapshot " + dbName + " held by [" + marker + "]",
+		"into a template that locks can start from.",
+		"Its open connections may be closed.",
+		"",
+		"Name: " + name + "▏",
+	}
+	if instanceCount > 1 {
+		copyTo := "this instance only"
+		if all {
+			copyTo = "all " + itoa(instanceCount) + " instances"
+		}
+		body = append(body, "", "Copy to: "+copyTo+"  [Tab]")
+	}
+
+	return RenderModal(ModalConfig{
+		Title:       "Promote to template?",
+		Body:        body,
+		ConfirmText: "Promote",
+		CancelText:  "Cancel",
+	})
+}
+
 // LockerDiedModal returns the locker died modal.
 func LockerDiedModal(err error) string {
 	errMsg := "Unknown error"
@@ -164,6 +192,58 @@ func LockerDiedModal(err error) string {
 	})
 }
 
+// schemaLeaksShown and schemaLeakLinesShown keep the leaks modal within the screen.
+const (
+	schemaLeaksShown     = 3
+	schemaLeakLinesShown = 4
+)
+
+// SchemaLeaksModal returns the modal listing the most recent schema leaks.
+func SchemaLeaksModal(leaks []locker.SchemaLeak) string {
+	var body []string
+	for i, leak := range leaks {
+		if i == schemaLeaksShown {
+			body = append(body, "... and "+itoa(len(leaks)-i)+" more in /health-check")
+			break
+		}
+		if i > 0 {
+			body = append(body, "")
+		}
+		dbName, port := parseConnString(leak.ConnString)
+		body = append(body, "["+leak.Marker+"] "+port+":"+dbName+" at "+leak.ReleasedAt.Format("15:04:05"))
+
+		var changes []string
+		for _, line := range leak.Added {
+			changes = append(changes, "+ "+line)
+		}
+		for _, line := range leak.Removed {
+			changes = append(changes, "- "+line)
+		}
+		for j, change := range changes {
+			if j == schemaLeakLinesShown {
+				body = append(body, "  ... "+pluralize(len(changes)-j, "more change", "more changes"))
+				break
+			}
+			body = append(body, truncateText(change, 44))
+		}
+	}
+
+	return RenderModalSingleButton(ModalConfig{
+		Title:       "Schema leaks",
+		Body:        body,
+		ConfirmText: "Close",
+	})
+}
+
+// truncateText shortens plain text to maxWidth runes, ending with "…" when cut.
+func truncateText(s string, maxWidth int) string {
+	runes := []rune(s)
+	if len(runes) <= maxWidth {
+		return s
+	}
+	return string(runes[:maxWidth-1]) + "…"
+}
+
 // RenderModalSingleButton renders a modal with only a confirm button.
 func RenderModalSingleButton(cfg ModalConfig) string {
 	var b strings.Builder
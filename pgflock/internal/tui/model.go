@@ -1,7 +1,6 @@
 package tui
 
 import (
-	"fmt"
 	"sort"
 
 	"github.com/rickchristie/govner/pgflock/internal/config"
@@ -16,7 +15,9 @@ const (
 	ConfirmQuit
 	ConfirmUnlock
 	ConfirmRestart
-	ConfirmLockerDied // Modal shown when locker server dies
+	ConfirmLockerDied  // Modal shown when locker server dies
+	ConfirmSchemaLeaks // Modal listing recent schema leaks
+	ConfirmPromote     // Modal asking for a template name to promote the selected database to
 )
 
 // HealthStatus represents the health of a component
@@ -28,7 +29,7 @@ const (
 	HealthDown
 )
 
-// ContainerHealth tracks the health of a PostgreSQL container
+// ContainerHealth tracks the health of a database container
 type ContainerHealth struct {
 	Port   int
 	Status HealthStatus
@@ -60,7 +61,15 @@ type Model struct {
 	err              error
 	quitting         bool
 	showAllDatabases bool
+	showTimeline     bool
 	allDatabases     []DatabaseInfo
+	timeline         *locker.Timeline // Lock history, refreshed while the timeline view is shown
+
+	// Promote modal input (see ConfirmPromote)
+	promoteConnStr string // Database being promoted, captured when the modal opens
+	promoteName    string
+	promoteAll     bool   // Copy the template to every instance
+	promoting      string // Name of the template being created, empty when idle
 
 	// Health monitoring
 	lockerHealth     HealthStatus
@@ -96,6 +105,11 @@ type Model struct {
 	// HTTP API restart handling
 	restartRequestChan     <-chan locker.RestartRequest // Channel for restart requests from HTTP API
 	pendingRestartResponse chan error                   // Response channel for current restart request
+
+	// HTTP API handover handling
+	handoverRequestChan <-chan struct{} // Signalled when a new 'pgflock up' takes over
+	onHandover          func()          // Called to stop the locker without stopping the containers
+	handedOver          bool
 }
 
 // NewModel creates a new TUI model for startup mode.
@@ -105,12 +119,11 @@ func NewModel(cfg *config.Config, loadingProgressChan <-chan LoadingProgress) *M
 	var allDbs []DatabaseInfo
 	for _, port := range cfg.InstancePorts() {
 		for i := 1; i <= cfg.DatabasesPerInstance; i++ {
-			connStr := fmt.Sprintf("postgresql://%s:%s@localhost:%d/%s%d",
-				cfg.PGUsername, cfg.Password, port, cfg.DatabasePrefix, i)
+			dbName := cfg.DatabaseName(i)
 			allDbs = append(allDbs, DatabaseInfo{
-				ConnString: connStr,
+				ConnString: cfg.ConnString(port, dbName),
 				Port:       port,
-				DBName:     fmt.Sprintf("%s%d", cfg.DatabasePrefix, i),
+				DBName:     dbName,
 			})
 		}
 	}
@@ -122,8 +135,8 @@ func NewModel(cfg *config.Config, loadingProgressChan <-chan LoadingProgress) *M
 		return allDbs[i].DBName < allDbs[j].DBName
 	})
 
-	// Collect instance ports for startup animation
-	instancePorts := cfg.InstancePorts()
+	// Collect container ports (instances, then replicas) for startup animation
+	instancePorts := cfg.ContainerPorts()
 
 	// Initialize container health tracking
 	containerHealth := make([]ContainerHealth, len(instancePorts))
@@ -193,6 +206,19 @@ func (m *Model) SetRestartRequestChan(ch <-chan locker.RestartRequest) {
 	m.restartRequestChan = ch
 }
 
+// SetHandoverRequestChan sets the channel for handover requests from HTTP API
+// and the callback that stops the locker for the new one.
+func (m *Model) SetHandoverRequestChan(ch <-chan struct{}, onHandover func()) {
+	m.handoverRequestChan = ch
+	m.onHandover = onHandover
+}
+
+// HandedOver returns true if the TUI exited because a new locker took over.
+// The containers must then be left running.
+func (m *Model) HandedOver() bool {
+	return m.handedOver
+}
+
 // SetContainerHealthy marks a container as healthy.
 func (m *Model) SetContainerHealthy(port int) {
 	for i := range m.containerHealth {
@@ -228,14 +254,14 @@ func (m *Model) totalContainerCount() int {
 
 // StartShutdown transitions to the shutdown loading screen.
 func (m *Model) StartShutdown(progressChan <-chan LoadingProgress) {
-	m.loadingScreen = NewLoadingScreen(LoadingModeShutdown, m.cfg.InstancePorts())
+	m.loadingScreen = NewLoadingScreen(LoadingModeShutdown, m.cfg.ContainerPorts())
 	m.loadingProgressChan = progressChan
 	m.showingLoading = true
 }
 
 // StartRestart transitions to the restart loading screen.
 func (m *Model) StartRestart(progressChan <-chan LoadingProgress) {
-	m.loadingScreen = NewLoadingScreen(LoadingModeRestart, m.cfg.InstancePorts())
+	m.loadingScreen = NewLoadingScreen(LoadingModeRestart, m.cfg.ContainerPorts())
 	m.loadingProgressChan = progressChan
 	m.showingLoading = true
 }
@@ -251,9 +277,15 @@ func (m *Model) selectedLock() *locker.LockInfo {
 	return &m.state.Locks[m.selectedIdx]
 }
 
+// listsAllDatabases returns true if the current view has a row for every database
+// (the all-databases list and the timeline) rather than only locked ones.
+func (m *Model) listsAllDatabases() bool {
+	return m.showAllDatabases || m.showTimeline
+}
+
 // selectedDatabase returns the currently selected database info
 func (m *Model) selectedDatabase() *DatabaseInfo {
-	if m.showAllDatabases {
+	if m.listsAllDatabases() {
 		if m.selectedIdx < 0 || m.selectedIdx >= len(m.allDatabases) {
 			return nil
 		}
@@ -298,7 +330,7 @@ func (m *Model) updateAllDatabasesLockStatus() {
 
 // getMaxSelectionIndex returns the max valid selection index based on current view
 func (m *Model) getMaxSelectionIndex() int {
-	if m.showAllDatabases {
+	if m.listsAllDatabases() {
 		return len(m.allDatabases) - 1
 	}
 	if m.state == nil {
@@ -309,7 +341,7 @@ func (m *Model) getMaxSelectionIndex() int {
 
 // getCurrentListSize returns the number of items in the current view
 func (m *Model) getCurrentListSize() int {
-	if m.showAllDatabases {
+	if m.listsAllDatabases() {
 		return len(m.allDatabases)
 	}
 	if m.state == nil {
@@ -327,6 +359,9 @@ func (m *Model) adjustScrollOffset(totalItems int) {
 		height = 24
 	}
 	visibleHeight := height - 4 // header (2) + footer (2)
+	if m.showTimeline {
+		visibleHeight -= timelineHeaderHeight
+	}
 	if visibleHeight < 1 {
 		visibleHeight = 1
 	}
@@ -390,7 +425,23 @@ func (m *Model) waitingCount() int {
 	return m.state.WaitingRequests
 }
 
-// instanceCount returns the number of PostgreSQL instances
+// schemaLeaks returns recent schema leaks, most recent first
+func (m *Model) schemaLeaks() []locker.SchemaLeak {
+	if m.state == nil {
+		return nil
+	}
+	return m.state.SchemaLeaks
+}
+
+// templates returns the promoted templates, sorted by name
+func (m *Model) templates() []locker.Template {
+	if m.state == nil {
+		return nil
+	}
+	return m.state.Templates
+}
+
+// instanceCount returns the number of database instances
 func (m *Model) instanceCount() int {
 	return m.cfg.InstanceCount
 }
@@ -1,6 +1,10 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"hash/fnv"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 // Pre-computed styles for performance.
 // All styles are initialized at package load time to avoid allocations in render loop.
@@ -77,6 +81,29 @@ var (
 	FreeStatusStyle = lipgloss.NewStyle().
 			Foreground(ColorLime)
 
+	// Schema leak count "⚠ 2 schema leaks"
+	SchemaLeakStyle = lipgloss.NewStyle().
+			Foreground(ColorCoral).
+			Bold(true)
+
+	// Promoted templates "2 templates"
+	TemplateStyle = lipgloss.NewStyle().
+			Foreground(ColorSky)
+
+	// === Timeline Styles ===
+
+	// Free time on a database timeline
+	TimelineIdleStyle = lipgloss.NewStyle().
+				Foreground(ColorBorder)
+
+	// Sparkline bars
+	SparklineStyle = lipgloss.NewStyle().
+			Foreground(ColorCyan)
+
+	// Chart labels "Util  62%"
+	ChartLabelStyle = lipgloss.NewStyle().
+			Foreground(ColorTextMuted)
+
 	// === Empty State ===
 
 	EmptyStateStyle = lipgloss.NewStyle().
@@ -126,3 +153,12 @@ func GetLockedCountStyle(animFrame int) lipgloss.Style {
 	colors := LockedAnimationColors()
 	return LockedCountStyle.Copy().Foreground(colors[animFrame%len(colors)])
 }
+
+// GetMarkerStyle returns the timeline style for a marker.
+// The colour is derived from the marker name so it stays stable across renders.
+func GetMarkerStyle(marker string) lipgloss.Style {
+	colors := MarkerColors()
+	h := fnv.New32a()
+	h.Write([]byte(marker))
+	return lipgloss.NewStyle().Foreground(colors[h.Sum32()%uint32(len(colors))])
+}
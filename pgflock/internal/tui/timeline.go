@@ -0,0 +1,334 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rickchristie/govner/pgflock/internal/locker"
+)
+
+// timelineHeaderHeight is the number of fixed lines above the timeline rows:
+// three sparklines, the marker legend and the time axis.
+const timelineHeaderHeight = 5
+
+// chartLabelWidth fits chart labels such as "Reset 120ms".
+const chartLabelWidth = 12
+
+// timelineLayout holds the column positions shared by the charts and the rows,
+// so every sparkline and lock bar lines up on the same time axis.
+type timelineLayout struct {
+	labelWidth int
+	barWidth   int
+}
+
+func (m *Model) timelineLayout(width int) timelineLayout {
+	maxDbPortWidth := 0
+	for _, db := range m.allDatabases {
+		dbName, port := parseConnString(db.ConnString)
+		if w := len(dbName) + 1 + len(port); w > maxDbPortWidth {
+			maxDbPortWidth = w
+		}
+	}
+
+	// "▶ port:dbname" plus two spaces, or a chart label, whichever is wider
+	labelWidth := max(3+maxDbPortWidth+2, 3+chartLabelWidth+2)
+	barWidth := width - labelWidth - 1
+	if barWidth < 10 {
+		barWidth = 10
+	}
+	return timelineLayout{labelWidth: labelWidth, barWidth: barWidth}
+}
+
+// refreshTimeline fetches the latest lock history from the handler
+func (m *Model) refreshTimeline() {
+	if m.handler == nil || !m.showTimeline {
+		return
+	}
+	m.timeline = m.handler.Timeline()
+}
+
+// renderTimelineHeader renders the fixed lines above the timeline rows:
+//
+//	Util   62%  ▁▂▃▅▇█▇▅▃▂
+//	Queue    3  ▁▁▁▂▅▂▁▁▁▁
+//	Reset 42ms  ▁▂▁▁▃▁▁▁▁▁
+//	Markers     ■ api-tests  ■ worker
+//	            -15m                    now
+func (m *Model) renderTimelineHeader(width int) []string {
+	layout := m.timelineLayout(width)
+	cols := m.timelineColumns(layout.barWidth)
+
+	utilisation := make([]float64, len(cols))
+	queue := make([]float64, len(cols))
+	reset := make([]float64, len(cols))
+	var peakQueue, peakReset float64
+	for i, col := range cols {
+		if !col.sampled {
+			utilisation[i], queue[i], reset[i] = -1, -1, -1
+			continue
+		}
+		utilisation[i] = col.utilisation
+		queue[i] = float64(col.waiting)
+		reset[i] = float64(col.resetLatency)
+		peakQueue = max(peakQueue, queue[i])
+		peakReset = max(peakReset, reset[i])
+	}
+
+	utilLabel := fmt.Sprintf("%d%%", m.utilisationPercent())
+	queueLabel := fmt.Sprintf("%d", m.waitingCount())
+	resetLabel := "-"
+	if d := m.averageResetLatency(); d > 0 {
+		resetLabel = formatLatency(d)
+	}
+
+	lines := []string{
+		m.renderChartLine(layout, "Util", utilLabel, renderSparkline(utilisation, 1)),
+		m.renderChartLine(layout, "Queue", queueLabel, renderSparkline(queue, peakQueue)),
+		m.renderChartLine(layout, "Reset", resetLabel, renderSparkline(reset, peakReset)),
+		m.renderChartLine(layout, "Markers", "", m.renderMarkerLegend()),
+		m.renderTimeAxis(layout),
+	}
+	return lines
+}
+
+// renderChartLine renders "   Label  value  chart" aligned with the timeline bars
+func (m *Model) renderChartLine(layout timelineLayout, label, value, chart string) string {
+	text := fmt.Sprintf("%-6s%*s", label, chartLabelWidth-6, value)
+	return "   " + ChartLabelStyle.Render(padRight(text, layout.labelWidth-3)) + chart
+}
+
+// renderTimeAxis renders the window start at the left and "now" at the right
+func (m *Model) renderTimeAxis(layout timelineLayout) string {
+	window := time.Duration(0)
+	if m.timeline != nil {
+		window = m.timeline.End.Sub(m.timeline.Start)
+	}
+	if window <= 0 {
+		window = m.cfg.TimelineWindow()
+	}
+
+	left := fmt.Sprintf("-%dm", int(window.Minutes()))
+	right := "now"
+	gap := layout.barWidth - len(left) - len(right)
+	if gap < 1 {
+		gap = 1
+	}
+	return strings.Repeat(" ", layout.labelWidth) + DimStyle.Render(left+strings.Repeat(" ", gap)+right)
+}
+
+// renderMarkerLegend lists the markers on the timeline, most recent first
+func (m *Model) renderMarkerLegend() string {
+	if m.timeline == nil || len(m.timeline.Spans) == 0 {
+		return DimStyle.Render("(no locks in this window)")
+	}
+
+	// Markers that leaked schema changes get a warning after their name
+	leaked := make(map[string]bool)
+	for _, span := range m.timeline.Spans {
+		if span.SchemaLeak {
+			leaked[span.Marker] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var parts []string
+	for i := len(m.timeline.Spans) - 1; i >= 0; i-- {
+		marker := m.timeline.Spans[i].Marker
+		if seen[marker] {
+			continue
+		}
+		seen[marker] = true
+		part := GetMarkerStyle(marker).Render(LegendSwatch) + " " + MarkerStyle.Render(marker)
+		if leaked[marker] {
+			part += " " + SchemaLeakStyle.Render(IconWarning)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// renderTimelineRows renders one row per database with its lock spans over the window
+func (m *Model) renderTimelineRows(width int) string {
+	if len(m.allDatabases) == 0 {
+		return EmptyStateStyle.Render("(no databases configured)")
+	}
+
+	layout := m.timelineLayout(width)
+
+	spansByDb := make(map[string][]locker.LockSpan)
+	if m.timeline != nil {
+		for _, span := range m.timeline.Spans {
+			spansByDb[span.ConnString] = append(spansByDb[span.ConnString], span)
+		}
+	}
+
+	var b strings.Builder
+	for i, db := range m.allDatabases {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		dbName, port := parseConnString(db.ConnString)
+		portDb := port + ":" + dbName
+		padStr := strings.Repeat(" ", layout.labelWidth-3-len(portDb)-2)
+
+		if i == m.selectedIdx {
+			b.WriteString(RowSelectedStyle.Render(IconSelectionArrow + " " + portDb + padStr))
+			b.WriteString(" ")
+		} else {
+			b.WriteString(RowNormalStyle.Render("   ") + PortStyle.Render(port) + RowNormalStyle.Render(":"+dbName) + padStr + "  ")
+		}
+		b.WriteString(m.renderLockBar(spansByDb[db.ConnString], layout.barWidth))
+	}
+	return b.String()
+}
+
+// renderLockBar renders a database's lock spans as a bar of barWidth columns,
+// each column coloured by the marker that held the lock at that time. Locks
+// released with schema changes are drawn with a shaded block.
+func (m *Model) renderLockBar(spans []locker.LockSpan, barWidth int) string {
+	if m.timeline == nil {
+		return TimelineIdleStyle.Render(strings.Repeat(TimelineIdle, barWidth))
+	}
+
+	start := m.timeline.Start
+	slot := m.timeline.End.Sub(start) / time.Duration(barWidth)
+
+	// Span per column, nil when free
+	cols := make([]*locker.LockSpan, barWidth)
+	for c := range cols {
+		colStart := start.Add(time.Duration(c) * slot)
+		colEnd := colStart.Add(slot)
+		// Spans are sorted by start, so the last overlap is the most recent lock
+		for i := range spans {
+			if spans[i].Start.Before(colEnd) && (spans[i].End.IsZero() || spans[i].End.After(colStart)) {
+				cols[c] = &spans[i]
+			}
+		}
+	}
+
+	// Render runs of the same span with a single style call
+	var b strings.Builder
+	for c := 0; c < barWidth; {
+		run := c + 1
+		for run < barWidth && cols[run] == cols[c] {
+			run++
+		}
+		switch span := cols[c]; {
+		case span == nil:
+			b.WriteString(TimelineIdleStyle.Render(strings.Repeat(TimelineIdle, run-c)))
+		case span.SchemaLeak:
+			b.WriteString(GetMarkerStyle(span.Marker).Render(strings.Repeat(TimelineLeaked, run-c)))
+		default:
+			b.WriteString(GetMarkerStyle(span.Marker).Render(strings.Repeat(TimelineLocked, run-c)))
+		}
+		c = run
+	}
+	return b.String()
+}
+
+// timelineColumn aggregates the samples that fall into one chart column
+type timelineColumn struct {
+	sampled      bool
+	utilisation  float64       // average share of databases locked
+	waiting      int           // most requests waiting at once
+	resetLatency time.Duration // average reset time, 0 if no resets
+}
+
+// timelineColumns buckets the timeline samples into barWidth columns
+func (m *Model) timelineColumns(barWidth int) []timelineColumn {
+	cols := make([]timelineColumn, barWidth)
+	if m.timeline == nil || m.timeline.TotalDatabases == 0 {
+		return cols
+	}
+
+	start := m.timeline.Start
+	slot := m.timeline.End.Sub(start) / time.Duration(barWidth)
+	if slot <= 0 {
+		return cols
+	}
+
+	lockedSum := make([]int, barWidth)
+	counts := make([]int, barWidth)
+	resets := make([]int, barWidth)
+	resetSum := make([]time.Duration, barWidth)
+	for _, s := range m.timeline.Samples {
+		c := int(s.At.Sub(start) / slot)
+		if c < 0 || c >= barWidth {
+			continue
+		}
+		lockedSum[c] += s.Locked
+		counts[c]++
+		cols[c].waiting = max(cols[c].waiting, s.Waiting)
+		resets[c] += s.Resets
+		resetSum[c] += s.ResetLatency
+	}
+
+	for c := range cols {
+		if counts[c] == 0 {
+			continue
+		}
+		cols[c].sampled = true
+		cols[c].utilisation = float64(lockedSum[c]) / float64(counts[c]) / float64(m.timeline.TotalDatabases)
+		if resets[c] > 0 {
+			cols[c].resetLatency = resetSum[c] / time.Duration(resets[c])
+		}
+	}
+	return cols
+}
+
+// utilisationPercent returns the share of databases locked right now
+func (m *Model) utilisationPercent() int {
+	if m.totalCount() == 0 {
+		return 0
+	}
+	return m.lockedCount() * 100 / m.totalCount()
+}
+
+// averageResetLatency returns the mean reset time over the timeline window
+func (m *Model) averageResetLatency() time.Duration {
+	if m.timeline == nil {
+		return 0
+	}
+	var total time.Duration
+	var count int
+	for _, s := range m.timeline.Samples {
+		total += s.ResetLatency
+		count += s.Resets
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / time.Duration(count)
+}
+
+// renderSparkline renders values scaled against peak. Negative values are gaps
+// with no data yet; zero values are drawn as a faint baseline.
+func renderSparkline(values []float64, peak float64) string {
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case v < 0:
+			b.WriteString(" ")
+		case v == 0 || peak <= 0:
+			b.WriteString(TimelineIdleStyle.Render(SparklineLevels[0]))
+		default:
+			level := int(v/peak*float64(len(SparklineLevels)-1) + 0.5)
+			level = min(max(level, 1), len(SparklineLevels)-1)
+			b.WriteString(SparklineStyle.Render(SparklineLevels[level]))
+		}
+	}
+	return b.String()
+}
+
+// formatLatency formats a reset duration compactly: "850µs", "42ms", "1.2s"
+func formatLatency(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	default:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+}
@@ -12,6 +12,9 @@ import (
 // View renders the entire TUI.
 func (m *Model) View() string {
 	if m.quitting {
+		if m.handedOver {
+			return "Handing over to the new locker...\n"
+		}
 		return "Shutting down...\n"
 	}
 
@@ -57,9 +60,20 @@ func (m *Model) renderMainView() []string {
 	// FIXED: Section header (extends to terminal width)
 	lines = append(lines, m.renderSectionHeader(width))
 
+	// FIXED: Timeline charts stay in place while the rows below them scroll
+	if m.showTimeline {
+		lines = append(lines, m.renderTimelineHeader(width)...)
+		contentAreaHeight -= timelineHeaderHeight
+		if contentAreaHeight < 1 {
+			contentAreaHeight = 1
+		}
+	}
+
 	// Get all content lines
 	var contentLines []string
-	if m.showAllDatabases {
+	if m.showTimeline {
+		contentLines = strings.Split(m.renderTimelineRows(width), "\n")
+	} else if m.showAllDatabases {
 		contentLines = strings.Split(m.renderAllDatabases(), "\n")
 	} else {
 		contentLines = strings.Split(m.renderLockedDatabases(), "\n")
@@ -71,7 +85,7 @@ func (m *Model) renderMainView() []string {
 	}
 
 	// Check if we're showing empty state (need to center it)
-	isEmptyState := !m.showAllDatabases && (m.state == nil || len(m.state.Locks) == 0)
+	isEmptyState := !m.listsAllDatabases() && (m.state == nil || len(m.state.Locks) == 0)
 	totalContentLines := len(contentLines)
 
 	if isEmptyState && contentAreaHeight > 0 {
@@ -195,21 +209,38 @@ func (m *Model) renderHeader(width int) string {
 		statusParts = append(statusParts, WaitingCountStyle.Render(waitingText))
 	}
 
+	// Schema leaks (only found when schema_check is enabled)
+	if n := len(m.schemaLeaks()); n > 0 {
+		leakText := fmt.Sprintf("%s %s", IconWarning, pluralize(n, "schema leak", "schema leaks"))
+		statusParts = append(statusParts, SchemaLeakStyle.Render(leakText))
+	}
+
+	// Promoted templates, and the one being created
+	if m.promoting != "" {
+		statusParts = append(statusParts, TemplateStyle.Render("Promoting "+m.promoting+"…"))
+	} else if n := len(m.templates()); n > 0 {
+		statusParts = append(statusParts, TemplateStyle.Render(pluralize(n, "template", "templates")))
+	}
+
 	// Join: brand+prefix (2 spaces) status parts (2 spaces between each)
 	leftContent := brandWithPrefix + "  " + strings.Join(statusParts, "  ")
 	leftWidth := lipglossWidth(leftContent)
 
-	// View toggle at right: "All Databases (10) | Locked Databases"
-	var viewToggle string
-	if m.showAllDatabases {
-		viewToggle = TitleStyle.Render(fmt.Sprintf("All Databases (%d)", m.totalCount())) +
-			DimStyle.Render(" | ") +
-			DimStyle.Render("Locked Databases")
-	} else {
-		viewToggle = DimStyle.Render(fmt.Sprintf("All Databases (%d)", m.totalCount())) +
-			DimStyle.Render(" | ") +
-			TitleStyle.Render("Locked Databases")
+	// View toggle at right: "All Databases (10) | Locked Databases | Timeline"
+	allStyle, lockedStyle, timelineStyle := DimStyle, DimStyle, DimStyle
+	switch {
+	case m.showTimeline:
+		timelineStyle = TitleStyle
+	case m.showAllDatabases:
+		allStyle = TitleStyle
+	default:
+		lockedStyle = TitleStyle
 	}
+	viewToggle := allStyle.Render(fmt.Sprintf("All Databases (%d)", m.totalCount())) +
+		DimStyle.Render(" | ") +
+		lockedStyle.Render("Locked Databases") +
+		DimStyle.Render(" | ") +
+		timelineStyle.Render("Timeline")
 	toggleWidth := lipglossWidth(viewToggle)
 
 	// Calculate padding to push toggle to far right
@@ -346,6 +377,9 @@ func (m *Model) renderDatabaseRow(idx int, connStr string, isLocked bool, lockIn
 			"  " + MarkerStyle.Render(fmt.Sprintf("[%s]", lockInfo.Marker)) +
 			"  " + DurationStyle.Render(formatDuration(elapsed)) +
 			"  " + m.lockTimeoutBar.Render(progress)
+		if lockInfo.Template != "" {
+			statusPart += "  " + DimStyle.Render("from "+lockInfo.Template)
+		}
 	} else {
 		// FREE status
 		statusPart = FreeStatusStyle.Render(IconFree + " FREE")
@@ -377,16 +411,24 @@ func (m *Model) renderHelpBar(width, totalLines, visibleHeight, scrollOffset int
 	parts = append(parts, renderHelpKey("r", "Restart"))
 
 	// Toggle view
-	if m.showAllDatabases {
-		parts = append(parts, renderHelpKey("Space", "Locked Only"))
+	if m.showTimeline {
+		parts = append(parts, renderHelpKey("t", "List"))
 	} else {
-		parts = append(parts, renderHelpKey("Space", "Show All"))
+		if m.showAllDatabases {
+			parts = append(parts, renderHelpKey("Space", "Locked Only"))
+		} else {
+			parts = append(parts, renderHelpKey("Space", "Show All"))
+		}
+		parts = append(parts, renderHelpKey("t", "Timeline"))
 	}
 
 	// Context-sensitive options
 	if db := m.selectedDatabase(); db != nil {
 		if db.IsLocked {
 			parts = append(parts, renderHelpKey("u", "Unlock"))
+			if m.promoting == "" {
+				parts = append(parts, renderHelpKey("p", "Promote"))
+			}
 		}
 
 		// Copy with shimmer animation
@@ -399,6 +441,10 @@ func (m *Model) renderHelpBar(width, totalLines, visibleHeight, scrollOffset int
 		parts = append(parts, renderHelpKey(NavArrows, "Nav"))
 	}
 
+	if len(m.schemaLeaks()) > 0 {
+		parts = append(parts, renderHelpKey("l", "Leaks"))
+	}
+
 	leftContent := strings.Join(parts, "  ")
 	leftWidth := lipglossWidth(leftContent)
 
@@ -715,6 +761,17 @@ func (m *Model) renderModal() string {
 		return UnlockModal("unknown", "unknown", "0s")
 	case ConfirmLockerDied:
 		return LockerDiedModal(m.lockerDiedError)
+	case ConfirmSchemaLeaks:
+		return SchemaLeaksModal(m.schemaLeaks())
+	case ConfirmPromote:
+		dbName, port := parseConnString(m.promoteConnStr)
+		marker := "unknown"
+		for _, lock := range m.state.Locks {
+			if lock.ConnString == m.promoteConnStr {
+				marker = lock.Marker
+			}
+		}
+		return PromoteModal(port+":"+dbName, marker, m.promoteName, m.promoteAll, m.instanceCount())
 	}
 	return ""
 }
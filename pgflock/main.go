@@ -2,22 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	"github.com/rickchristie/govner/pgflock/internal/bench"
 	"github.com/rickchristie/govner/pgflock/internal/config"
 	"github.com/rickchristie/govner/pgflock/internal/configure"
 	"github.com/rickchristie/govner/pgflock/internal/docker"
+	"github.com/rickchristie/govner/pgflock/internal/engine"
 	"github.com/rickchristie/govner/pgflock/internal/locker"
 	"github.com/rickchristie/govner/pgflock/internal/tui"
 	"github.com/rickchristie/govner/pgflock/meta"
@@ -31,9 +39,26 @@ var (
 	upDatabases int
 )
 
+// Flags for 'dump' and 'restore' commands
+var (
+	dumpOutput      string
+	restoreTemplate string
+	restorePort     int
+	restoreAll      bool
+)
+
+// Flags for 'bench' command
+var (
+	benchClients  int
+	benchDuration time.Duration
+	benchHold     time.Duration
+	benchTemplate string
+	benchJSON     bool
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "pgflock",
-	Short: "PostgreSQL test database pool manager",
+	Short: "PostgreSQL (and MySQL/MariaDB) test database pool manager",
 	Long: `pgflock - Shepherd your test databases
 
 Spawn, lock, and control memory-backed PostgreSQL databases for testing.
@@ -82,14 +107,14 @@ var configureCmd = &cobra.Command{
 var buildCmd = &cobra.Command{
 	Use:   "build",
 	Short: "Build the PostgreSQL Docker image",
-	Long:  `Builds the PostgreSQL Docker image using the generated Dockerfile.`,
+	Long:  `Builds the PostgreSQL image using the generated Dockerfile and the configured container runtime (docker or podman).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, cfgDir, err := loadConfig()
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Building Docker image: %s\n", cfg.ImageName())
+		fmt.Printf("Building image: %s\n", cfg.ImageName())
 		return buildImage(cfg, cfgDir)
 	},
 }
@@ -97,7 +122,12 @@ var buildCmd = &cobra.Command{
 var upCmd = &cobra.Command{
 	Use:   "up",
 	Short: "Start the database pool with TUI",
-	Long:  `Starts PostgreSQL containers, the locker server, and opens the TUI.`,
+	Long: `Starts PostgreSQL containers, the locker server, and opens the TUI.
+
+If a locker is already running for this .pgflock directory, it hands over to
+the new one: the old TUI exits, the containers keep running, and held locks are
+reclaimed by their clients. The same happens after the locker crashed, as long
+as the containers are still up.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, _, err := loadConfig()
 		if err != nil {
@@ -121,12 +151,12 @@ var downCmd = &cobra.Command{
 	Short: "Stop all PostgreSQL containers",
 	Long:  `Stops all running PostgreSQL containers managed by pgflock.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, _, err := loadConfig()
+		cfg, dir, err := loadConfig()
 		if err != nil {
 			return err
 		}
 
-		return stopContainers(cfg)
+		return stopContainers(cfg, dir)
 	},
 }
 
@@ -135,19 +165,19 @@ var statusCmd = &cobra.Command{
 	Short: "Show status of containers and locker",
 	Long:  `Shows the current status of PostgreSQL containers and the locker server.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, _, err := loadConfig()
+		cfg, dir, err := loadConfig()
 		if err != nil {
 			return err
 		}
 
-		return showStatus(cfg)
+		return showStatus(cfg, dir)
 	},
 }
 
 var connectCmd = &cobra.Command{
 	Use:   "connect [port] [dbname]",
-	Short: "Connect to a database via psql",
-	Long:  `Opens a psql session to a specified database.`,
+	Short: "Connect to a database via psql (or mysql)",
+	Long:  `Opens an interactive client session (psql, or mysql/mariadb for those engines) to a specified database.`,
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, _, err := loadConfig()
@@ -163,8 +193,8 @@ var connectCmd = &cobra.Command{
 
 var tailCmd = &cobra.Command{
 	Use:   "tail [port]",
-	Short: "Tail Docker container logs",
-	Long:  `Streams logs from a PostgreSQL container. If no port is specified, uses the starting port from config.`,
+	Short: "Tail container logs",
+	Long:  `Streams logs from a PostgreSQL container using the configured runtime (docker or podman). If no port is specified, uses the starting port from config.`,
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, _, err := loadConfig()
@@ -193,13 +223,78 @@ Useful for recovering from stuck tests or when running in automated environments
 where the TUI is not available (e.g., AI agents in Docker containers).
 
 Requires pgflock to be running (started with 'pgflock up').`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, dir, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		return restartViaAPI(cfg, dir)
+	},
+}
+
+var dumpCmd = &cobra.Command{
+	Use:   "dump [port] [dbname]",
+	Short: "Dump a database to a file",
+	Long: `Dumps a database with pg_dump (or mysqldump/mariadb-dump) run inside the
+instance container, so no client tools are needed on the host. Any database
+can be dumped, including locked ones and templates (test_template_<name>).
+
+The dump can be loaded into a template with 'pgflock restore', on this machine
+or on another machine's pool running the same engine.`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, _, err := loadConfig()
 		if err != nil {
 			return err
 		}
 
-		return restartViaAPI(cfg)
+		port, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid port: %s", args[0])
+		}
+		return dumpDatabase(cfg, port, args[1], dumpOutput)
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [file]",
+	Short: "Restore a dump into a named template",
+	Long: `Loads a dump written by 'pgflock dump' into the template database
+test_template_<name> with pg_restore (or mysql/mariadb) run inside the instance
+container, and registers it with the running locker. Tests can then lock a
+database reset from it, e.g. client.LockTemplate(..., "<name>").
+
+Requires pgflock to be running (started with 'pgflock up'). Templates are lost
+when the pool restarts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, dir, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		return restoreTemplateFromDump(cfg, dir, args[0], restoreTemplate, restorePort, restoreAll)
+	},
+}
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Load-test the running locker with synthetic clients",
+	Long: `Starts concurrent synthetic clients that lock a database, hold it and
+release it in a loop for a fixed duration, then reports throughput, lock wait
+and reset time percentiles, and how the locks were spread across instances.
+
+Use it to size instances and databases per instance for a test suite's
+parallelism. Requires pgflock to be running (started with 'pgflock up'); the
+bench clients show up in the TUI as bench-<n>.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, dir, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		return runBench(cfg, dir)
 	},
 }
 
@@ -213,6 +308,29 @@ func init() {
 	upCmd.Flags().IntVarP(&upDatabases, "databases", "d", 0,
 		"Databases per instance (overrides config)")
 
+	// Flags for 'dump' and 'restore' commands
+	dumpCmd.Flags().StringVarP(&dumpOutput, "output", "o", "",
+		"File to write the dump to (default: <dbname>.dump)")
+	restoreCmd.Flags().StringVarP(&restoreTemplate, "template", "t", "",
+		"Name of the template to restore into (required)")
+	restoreCmd.Flags().IntVarP(&restorePort, "port", "p", 0,
+		"Instance to restore into (default: starting_port)")
+	restoreCmd.Flags().BoolVar(&restoreAll, "all", false,
+		"Copy the template to every instance")
+	restoreCmd.MarkFlagRequired("template")
+
+	// Flags for 'bench' command
+	benchCmd.Flags().IntVarP(&benchClients, "clients", "c", 0,
+		"Number of concurrent clients (default: total databases)")
+	benchCmd.Flags().DurationVarP(&benchDuration, "duration", "d", 30*time.Second,
+		"How long to run")
+	benchCmd.Flags().DurationVar(&benchHold, "hold", 100*time.Millisecond,
+		"How long each client holds a lock")
+	benchCmd.Flags().StringVarP(&benchTemplate, "template", "t", "",
+		"Lock databases reset from this template")
+	benchCmd.Flags().BoolVar(&benchJSON, "json", false,
+		"Print the result as JSON")
+
 	rootCmd.AddCommand(configureCmd)
 	rootCmd.AddCommand(buildCmd)
 	rootCmd.AddCommand(upCmd)
@@ -221,6 +339,9 @@ func init() {
 	rootCmd.AddCommand(connectCmd)
 	rootCmd.AddCommand(tailCmd)
 	rootCmd.AddCommand(restartCmd)
+	rootCmd.AddCommand(dumpCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(benchCmd)
 }
 
 func main() {
@@ -260,6 +381,11 @@ func setupLogging(cfgDir string) (*os.File, error) {
 }
 
 func runUp(cfg *config.Config) error {
+	eng, err := engine.For(cfg)
+	if err != nil {
+		return err
+	}
+
 	// Set up logging to file
 	dir := configDir
 	if dir == "" {
@@ -284,6 +410,9 @@ func runUp(cfg *config.Config) error {
 	var lockerErrChan <-chan error
 	var startupErr error
 
+	// Locks and templates are kept here so a new locker can take over
+	stateFile := filepath.Join(dir, locker.StateFileName)
+
 	// Set up quit callback (called only during startup cancel)
 	model.SetOnQuit(func() {
 		// During startup, we need to clean up whatever was started
@@ -291,55 +420,88 @@ func runUp(cfg *config.Config) error {
 			locker.StopServer(server)
 		}
 		docker.StopContainers(cfg)
+		locker.RemoveState(stateFile)
 	})
 
 	// Run startup process in background
 	go func() {
 		defer close(loadingProgressChan)
 
-		// Step 1: Stop any existing containers
+		// Step 1: Take over from a locker still running for this directory
 		loadingProgressChan <- tui.LoadingProgress{
 			Step:    tui.StepStoppingContainers,
-			Message: "Stopping existing containers...",
-		}
-		_ = docker.StopContainers(cfg)
-
-		// Step 2: Start containers
-		loadingProgressChan <- tui.LoadingProgress{
-			Step:    tui.StepStartingContainers,
-			Message: "Starting PostgreSQL containers...",
+			Message: "Checking for a running locker...",
 		}
-		if err := docker.RunContainers(cfg); err != nil {
+		if err := takeOverRunningLocker(cfg, dir); err != nil {
 			loadingProgressChan <- tui.LoadingProgress{
 				Step:  tui.StepFailed,
-				Error: fmt.Errorf("failed to start containers: %w", err),
+				Error: err,
 			}
 			startupErr = err
 			return
 		}
 
-		// Step 3: Wait for PostgreSQL to be ready (per instance)
+		// Reuse the containers, and with them the held databases, when the
+		// previous locker left its state behind and they are still up
+		saved, err := locker.LoadState(stateFile)
+		if err != nil {
+			log.Warn().Err(err).Msg("Ignoring unreadable locker state")
+		}
+		if saved != nil && !instancesReady(cfg) {
+			saved = nil
+		}
+
+		if saved != nil {
+			loadingProgressChan <- tui.LoadingProgress{
+				Step:    tui.StepStartingContainers,
+				Message: fmt.Sprintf("Reusing running containers, %d locks held...", len(saved.Locks)),
+			}
+		} else {
+			// Stop any existing containers
+			loadingProgressChan <- tui.LoadingProgress{
+				Step:    tui.StepStoppingContainers,
+				Message: "Stopping existing containers...",
+			}
+			_ = docker.StopContainers(cfg)
+			locker.RemoveState(stateFile)
+
+			// Step 2: Start containers
+			loadingProgressChan <- tui.LoadingProgress{
+				Step:    tui.StepStartingContainers,
+				Message: fmt.Sprintf("Starting %s containers...", eng.DisplayName()),
+			}
+			if err := docker.RunContainers(cfg); err != nil {
+				loadingProgressChan <- tui.LoadingProgress{
+					Step:  tui.StepFailed,
+					Error: fmt.Errorf("failed to start containers: %w", err),
+				}
+				startupErr = err
+				return
+			}
+		}
+
+		// Step 3: Wait for the database server to be ready (per instance)
 		loadingProgressChan <- tui.LoadingProgress{
-			Step:    tui.StepWaitingPostgres,
-			Message: "Waiting for PostgreSQL...",
+			Step:    tui.StepWaitingInstances,
+			Message: fmt.Sprintf("Waiting for %s...", eng.DisplayName()),
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 		defer cancel()
 
 		// Wait for each instance and report progress
-		for _, port := range cfg.InstancePorts() {
-			if err := docker.WaitForPostgresOnPort(ctx, cfg, port); err != nil {
+		for _, port := range cfg.ContainerPorts() {
+			if err := docker.WaitForInstanceOnPort(ctx, cfg, port); err != nil {
 				loadingProgressChan <- tui.LoadingProgress{
 					Step:  tui.StepFailed,
-					Error: fmt.Errorf("PostgreSQL on port %d not ready: %w", port, err),
+					Error: fmt.Errorf("%s on port %d not ready: %w", eng.DisplayName(), port, err),
 				}
 				startupErr = err
 				return
 			}
 			loadingProgressChan <- tui.LoadingProgress{
-				Step:    tui.StepWaitingPostgres,
-				Message: fmt.Sprintf("PostgreSQL on port %d is ready", port),
+				Step:    tui.StepWaitingInstances,
+				Message: fmt.Sprintf("%s on port %d is ready", eng.DisplayName(), port),
 				Port:    port,
 				Done:    true,
 			}
@@ -352,8 +514,25 @@ func runUp(cfg *config.Config) error {
 		}
 
 		stateUpdateChan = make(chan *locker.State, 10)
-		var err error
-		server, handler, lockerErrChan, err = locker.StartServer(cfg, stateUpdateChan)
+		var tlsConfig *tls.Config
+		if cfg.LockerTLS {
+			var err error
+			tlsConfig, err = config.ServerTLSConfig(dir, cfg)
+			if err != nil {
+				loadingProgressChan <- tui.LoadingProgress{
+					Step:  tui.StepFailed,
+					Error: fmt.Errorf("failed to set up locker TLS: %w", err),
+				}
+				startupErr = err
+				return
+			}
+		}
+		handler = locker.NewHandler(cfg, stateUpdateChan)
+		handler.PersistTo(stateFile)
+		if saved != nil {
+			handler.Resume(saved)
+		}
+		server, lockerErrChan, err = locker.StartServer(cfg, tlsConfig, handler)
 		if err != nil {
 			loadingProgressChan <- tui.LoadingProgress{
 				Step:  tui.StepFailed,
@@ -373,6 +552,14 @@ func runUp(cfg *config.Config) error {
 		handler.SetRestartRequestChan(restartRequestChan)
 		model.SetRestartRequestChan(restartRequestChan)
 
+		// Set up handover: a new 'pgflock up' takes over the containers and locks
+		handoverRequestChan := make(chan struct{})
+		handler.SetHandoverRequestChan(handoverRequestChan)
+		model.SetHandoverRequestChan(handoverRequestChan, func() {
+			handler.BeginHandover()
+			locker.CloseServer(server)
+		})
+
 		// Set up restart callback (now that handler is available)
 		model.SetOnRestart(func() <-chan tui.LoadingProgress {
 			restartChan := make(chan tui.LoadingProgress, 10)
@@ -400,6 +587,7 @@ func runUp(cfg *config.Config) error {
 					Message: "Unlocking all databases...",
 				}
 				handler.UnlockAll()
+				handler.ForgetTemplates()
 
 				// Step 3: Start containers
 				restartChan <- tui.LoadingProgress{
@@ -414,26 +602,26 @@ func runUp(cfg *config.Config) error {
 					return
 				}
 
-				// Step 4: Wait for PostgreSQL (per instance)
+				// Step 4: Wait for the database server (per instance)
 				restartChan <- tui.LoadingProgress{
-					Step:    tui.StepWaitingPostgres,
-					Message: "Waiting for PostgreSQL...",
+					Step:    tui.StepWaitingInstances,
+					Message: fmt.Sprintf("Waiting for %s...", eng.DisplayName()),
 				}
 
 				ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 				defer cancel()
 
-				for _, port := range cfg.InstancePorts() {
-					if err := docker.WaitForPostgresOnPort(ctx, cfg, port); err != nil {
+				for _, port := range cfg.ContainerPorts() {
+					if err := docker.WaitForInstanceOnPort(ctx, cfg, port); err != nil {
 						restartChan <- tui.LoadingProgress{
 							Step:  tui.StepFailed,
-							Error: fmt.Errorf("PostgreSQL on port %d not ready: %w", port, err),
+							Error: fmt.Errorf("%s on port %d not ready: %w", eng.DisplayName(), port, err),
 						}
 						return
 					}
 					restartChan <- tui.LoadingProgress{
-						Step:    tui.StepWaitingPostgres,
-						Message: fmt.Sprintf("PostgreSQL on port %d is ready", port),
+						Step:    tui.StepWaitingInstances,
+						Message: fmt.Sprintf("%s on port %d is ready", eng.DisplayName(), port),
 						Port:    port,
 						Done:    true,
 					}
@@ -471,6 +659,7 @@ func runUp(cfg *config.Config) error {
 					Message: "Stopping containers...",
 				}
 				docker.StopContainers(cfg)
+				locker.RemoveState(stateFile)
 
 				// Step 3: Done
 				shutdownChan <- tui.LoadingProgress{
@@ -495,7 +684,10 @@ func runUp(cfg *config.Config) error {
 		if server != nil {
 			locker.StopServer(server)
 		}
-		docker.StopContainers(cfg)
+		if !model.HandedOver() {
+			docker.StopContainers(cfg)
+			locker.RemoveState(stateFile)
+		}
 		return err
 	}
 
@@ -503,19 +695,86 @@ func runUp(cfg *config.Config) error {
 		return startupErr
 	}
 
+	if model.HandedOver() {
+		fmt.Println("Handed over to a new 'pgflock up'; containers left running")
+	}
+
 	return nil
 }
 
-func stopContainers(cfg *config.Config) error {
+// takeOverRunningLocker asks a locker still running on the configured port to
+// hand over, then waits for it to release the port. Does nothing if no locker
+// is running.
+func takeOverRunningLocker(cfg *config.Config, cfgDir string) error {
+	if _, err := healthCheck(cfg, cfgDir); err != nil {
+		return nil
+	}
+
+	baseURL, httpClient, err := lockerAPI(cfg, cfgDir)
+	if err != nil {
+		return err
+	}
+	reqURL := fmt.Sprintf("%s/handover?marker=up&password=%s", baseURL, url.QueryEscape(cfg.Password))
+
+	resp, err := httpClient.Post(reqURL, "text/plain", nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to running locker: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("a locker is already running on port %d and refused to hand over: %s",
+			cfg.LockerPort, strings.TrimSpace(string(body)))
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := healthCheck(cfg, cfgDir); err != nil {
+			log.Info().Msg("Took over from running locker")
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("running locker on port %d did not stop after handing over", cfg.LockerPort)
+}
+
+// instancesReady returns true if the container of every configured instance
+// and replica is running and accepting connections. A server on one of the
+// ports that is not this pool's container does not count: its databases are
+// not the ones in the saved state.
+func instancesReady(cfg *config.Config) bool {
+	containers, err := docker.ContainerStatus(cfg)
+	if err != nil {
+		return false
+	}
+	for _, container := range containers {
+		if !container.Running || !docker.InstanceReady(cfg, container.Port) {
+			return false
+		}
+	}
+	return len(containers) == len(cfg.ContainerPorts())
+}
+
+func stopContainers(cfg *config.Config, cfgDir string) error {
 	fmt.Println("Stopping containers...")
 	if err := docker.StopContainers(cfg); err != nil {
 		return err
 	}
+	// The held databases are gone with the containers
+	if err := locker.RemoveState(filepath.Join(cfgDir, locker.StateFileName)); err != nil {
+		return err
+	}
 	fmt.Println("All containers stopped")
 	return nil
 }
 
-func showStatus(cfg *config.Config) error {
+func showStatus(cfg *config.Config, cfgDir string) error {
+	eng, err := engine.For(cfg)
+	if err != nil {
+		return err
+	}
+
 	// Container status
 	infos, err := docker.ContainerStatus(cfg)
 	if err != nil {
@@ -525,18 +784,18 @@ func showStatus(cfg *config.Config) error {
 	fmt.Println("Container Status:")
 	fmt.Println("-----------------")
 	for _, info := range infos {
-		pgStatus := "not responding"
-		if info.Running && docker.PostgresStatus(cfg, info.Port) {
-			pgStatus = "ready"
+		dbStatus := "not responding"
+		if info.Running && docker.InstanceReady(cfg, info.Port) {
+			dbStatus = "ready"
 		}
-		fmt.Printf("  %s (port %d): %s, PostgreSQL: %s\n", info.Name, info.Port, info.Status, pgStatus)
+		fmt.Printf("  %s (port %d): %s, %s: %s\n", info.Name, info.Port, info.Status, eng.DisplayName(), dbStatus)
 	}
 
 	// Locker status
 	fmt.Println()
 	fmt.Println("Locker Status:")
 	fmt.Println("--------------")
-	resp, err := healthCheck(cfg.LockerPort)
+	resp, err := healthCheck(cfg, cfgDir)
 	if err != nil {
 		fmt.Printf("  Locker on port %d: not running\n", cfg.LockerPort)
 	} else {
@@ -547,10 +806,20 @@ func showStatus(cfg *config.Config) error {
 }
 
 func connectToDatabase(cfg *config.Config, port, dbname string) error {
-	connStr := fmt.Sprintf("postgresql://%s:%s@localhost:%s/%s",
-		cfg.PGUsername, cfg.Password, port, dbname)
+	eng, err := engine.For(cfg)
+	if err != nil {
+		return err
+	}
+
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("invalid port: %s", port)
+	}
 
-	cmd := exec.Command("psql", connStr)
+	connStr := cfg.ConnString(portNum, dbname)
+	args := eng.ShellCommand(connStr)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(cmd.Environ(), eng.ShellEnv(connStr)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -558,34 +827,65 @@ func connectToDatabase(cfg *config.Config, port, dbname string) error {
 	return cmd.Run()
 }
 
-func healthCheck(port int) (string, error) {
+// lockerAPI returns the base URL and HTTP client for calling the locker running
+// on this machine, trusting the pgflock CA when the locker serves TLS.
+func lockerAPI(cfg *config.Config, cfgDir string) (string, *http.Client, error) {
+	host := "localhost"
+	if ip := net.ParseIP(cfg.LockerBindAddress); ip != nil && !ip.IsUnspecified() {
+		host = cfg.LockerBindAddress
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(cfg.LockerPort))
+
+	if !cfg.LockerTLS {
+		return "http://" + addr, http.DefaultClient, nil
+	}
+	tlsConfig, err := config.ClientTLSConfig(config.CACertPath(cfgDir))
+	if err != nil {
+		return "", nil, err
+	}
+	return "https://" + addr, &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}, nil
+}
+
+func healthCheck(cfg *config.Config, cfgDir string) (string, error) {
+	baseURL, httpClient, err := lockerAPI(cfg, cfgDir)
+	if err != nil {
+		return "", err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	req, err := exec.CommandContext(ctx, "curl", "-s", fmt.Sprintf("http://localhost:%d/health-check", port)).Output()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/health-check", nil)
 	if err != nil {
 		return "", err
 	}
-	return string(req), nil
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
 }
 
 func tailContainerLogs(cfg *config.Config, port int) error {
-	containerName := cfg.ContainerName(port)
-
-	cmd := exec.Command("docker", "logs", "--follow", "--tail", "100", containerName)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
+	return docker.TailContainerLogs(cfg, port)
 }
 
-func restartViaAPI(cfg *config.Config) error {
-	reqURL := fmt.Sprintf("http://localhost:%d/restart?marker=cli&password=%s",
-		cfg.LockerPort, url.QueryEscape(cfg.Password))
+func restartViaAPI(cfg *config.Config, cfgDir string) error {
+	baseURL, httpClient, err := lockerAPI(cfg, cfgDir)
+	if err != nil {
+		return err
+	}
+	reqURL := fmt.Sprintf("%s/restart?marker=cli&password=%s", baseURL, url.QueryEscape(cfg.Password))
 
 	fmt.Println("Restarting database pool...")
 
-	resp, err := http.Post(reqURL, "text/plain", nil)
+	resp, err := httpClient.Post(reqURL, "text/plain", nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to locker server: %w\n\nMake sure 'pgflock up' is running", err)
 	}
@@ -600,3 +900,127 @@ func restartViaAPI(cfg *config.Config) error {
 	fmt.Println("Restart completed successfully")
 	return nil
 }
+
+func dumpDatabase(cfg *config.Config, port int, dbName, output string) error {
+	if output == "" {
+		output = dbName + ".dump"
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", output, err)
+	}
+	if err := docker.DumpDatabase(cfg, port, dbName, f); err != nil {
+		f.Close()
+		os.Remove(output)
+		return fmt.Errorf("dump failed: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	fmt.Printf("Dumped %d:%s to %s\n", port, dbName, output)
+	return nil
+}
+
+func restoreTemplateFromDump(cfg *config.Config, cfgDir, file, name string, port int, all bool) error {
+	eng, err := engine.For(cfg)
+	if err != nil {
+		return err
+	}
+	if err := engine.ValidateTemplateName(name); err != nil {
+		return err
+	}
+	if port == 0 {
+		port = cfg.StartingPort
+	}
+	if !slices.Contains(cfg.InstancePorts(), port) {
+		return fmt.Errorf("no instance on port %d", port)
+	}
+
+	// The template only becomes usable once the locker knows about it, so
+	// make sure it is running before spending time on the restore.
+	if _, err := healthCheck(cfg, cfgDir); err != nil {
+		return fmt.Errorf("locker not running: %w\n\nStart it with 'pgflock up'", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dbName := engine.TemplateName(name)
+	localConnStr, err := engine.LocalConnString(cfg.ConnString(port, dbName))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restoring %s into %d:%s...\n", file, port, dbName)
+	if err := eng.CreateDatabase(cfg, localConnStr); err != nil {
+		return err
+	}
+	if err := docker.RestoreDatabase(cfg, port, dbName, f); err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+
+	baseURL, httpClient, err := lockerAPI(cfg, cfgDir)
+	if err != nil {
+		return err
+	}
+	reqURL := fmt.Sprintf("%s/register-template?marker=%s&password=%s&name=%s&port=%d&all=%t",
+		baseURL, url.QueryEscape("restore "+filepath.Base(file)), url.QueryEscape(cfg.Password),
+		url.QueryEscape(name), port, all)
+
+	resp, err := httpClient.Post(reqURL, "text/plain", nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to locker server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("register template failed: %s", strings.TrimSpace(string(body)))
+	}
+
+	fmt.Printf("Template %q is ready; lock it with template=%s\n", name, name)
+	return nil
+}
+
+func runBench(cfg *config.Config, cfgDir string) error {
+	if _, err := healthCheck(cfg, cfgDir); err != nil {
+		return fmt.Errorf("locker not running: %w\n\nStart it with 'pgflock up'", err)
+	}
+	baseURL, httpClient, err := lockerAPI(cfg, cfgDir)
+	if err != nil {
+		return err
+	}
+
+	clients := benchClients
+	if clients == 0 {
+		clients = cfg.TotalDatabases()
+	}
+	if !benchJSON {
+		fmt.Printf("Running %d clients for %s...\n\n", clients, benchDuration)
+	}
+
+	result, err := bench.Run(context.Background(), bench.Options{
+		BaseURL:    baseURL,
+		HTTPClient: httpClient,
+		Password:   cfg.Password,
+		Clients:    clients,
+		Duration:   benchDuration,
+		Hold:       benchHold,
+		Template:   benchTemplate,
+	})
+	if err != nil {
+		return err
+	}
+
+	if benchJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	return result.WriteTable(os.Stdout)
+}